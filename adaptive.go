@@ -0,0 +1,75 @@
+package bread
+
+import (
+	"math"
+	"math/bits"
+)
+
+const (
+	// adaptiveRecords is the number of records of the average length held by the batches of AdaptiveBuffer
+	adaptiveRecords = 64
+	// adaptiveWindow is the number of batches it takes for the average record length to follow a change
+	adaptiveWindow = 8
+	// adaptiveGrowth is the default upper bound of AdaptiveBuffer, in multiples of BufferSize
+	adaptiveGrowth = 64
+)
+
+// minBufferSize returns the lower bound of AdaptiveBuffer
+func (b Bread) minBufferSize() int {
+	if b.MinBufferSize == 0 {
+		return b.BufferSize
+	}
+
+	return b.MinBufferSize
+}
+
+// maxBufferSize returns the upper bound of AdaptiveBuffer, the default one saturates instead of overflowing
+func (b Bread) maxBufferSize() int {
+	if b.MaxBufferSize == 0 {
+		return min(b.BufferSize, math.MaxInt/adaptiveGrowth) * adaptiveGrowth
+	}
+
+	return b.MaxBufferSize
+}
+
+// adapt resizes a pooled buffer to the size chosen by AdaptiveBuffer, the smaller buffers are replaced
+func (e *eater) adapt(buffer *[]byte) {
+	if !e.AdaptiveBuffer {
+		return
+	}
+
+	size := e.readSize
+
+	if cap(*buffer) < size {
+		*buffer = make([]byte, size, size*2)
+		e.resized++
+
+		return
+	}
+
+	*buffer = (*buffer)[:size]
+}
+
+// observeRecords updates the average record length with a batch, along with the size of the next reads
+func (e *eater) observeRecords(size, records int) {
+	if !e.AdaptiveBuffer || records == 0 {
+		return
+	}
+
+	sample := float64(size) / float64(records)
+
+	if e.recordLength == 0 {
+		e.recordLength = sample
+	} else {
+		e.recordLength += (sample - e.recordLength) / adaptiveWindow
+	}
+
+	target := uint64(e.recordLength * adaptiveRecords)
+
+	// Powers of two, so small variations do not replace the buffers
+	if target > 1 {
+		target = 1 << bits.Len64(target-1)
+	}
+
+	e.readSize = int(min(max(target, uint64(e.minBufferSize())), uint64(e.maxBufferSize())))
+}
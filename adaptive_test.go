@@ -0,0 +1,129 @@
+package bread
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// lines builds n lines of the given length, delimiter included
+func lines(n, length int) []byte {
+	return bytes.Repeat([]byte(strings.Repeat("x", length-1)+"\n"), n)
+}
+
+func TestBread_AdaptiveBuffer(t *testing.T) {
+	cases := [...]struct {
+		bread    Bread
+		data     []byte
+		expected ByteSize
+		err      error
+	}{
+		{
+			// 64 lines of 100 bytes
+			bread:    Bread{BufferSize: 256, AdaptiveBuffer: true},
+			data:     lines(10_000, 100),
+			expected: 8 * KB,
+		},
+		{
+			bread:    Bread{BufferSize: 1 * KB, AdaptiveBuffer: true},
+			data:     lines(1000, 10*KB),
+			expected: 64 * KB,
+		},
+		{
+			bread:    Bread{BufferSize: 1 * KB, AdaptiveBuffer: true, MaxBufferSize: 1 * MB},
+			data:     lines(1000, 10*KB),
+			expected: 1 * MB,
+		},
+		{
+			// The reads shrink down to MinBufferSize
+			bread:    Bread{BufferSize: 64 * KB, AdaptiveBuffer: true, MinBufferSize: 1 * KB},
+			data:     lines(10_000, 10),
+			expected: 1 * KB,
+		},
+		{
+			bread:    Bread{BufferSize: 256},
+			data:     lines(10_000, 100),
+			expected: 256,
+		},
+		{
+			bread: Bread{BufferSize: 1 * KB, AdaptiveBuffer: true, MinBufferSize: 2 * KB, MaxBufferSize: 1 * KB},
+			err:   ErrBufferSizeBounds,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			read, records := atomic.Int64{}, atomic.Int64{}
+
+			c.bread.Workers = 4
+			c.bread.WorkerFunc = func(_ context.Context, buffer *[]byte) {
+				read.Add(int64(len(*buffer)))
+				records.Add(int64(bytes.Count(*buffer, []byte{'\n'})))
+			}
+
+			stats, err := c.bread.EatStats(context.TODO(), bytes.NewReader(c.data))
+			if !errors.Is(err, c.err) {
+				t.Fatalf("expected error %v, got %v", c.err, err)
+			}
+
+			if c.err != nil {
+				t.Logf("Success! %v", err)
+				return
+			}
+
+			if stats.BufferSize != c.expected {
+				t.Fatalf("expected buffer size %v, got %v", c.expected, stats.BufferSize)
+			}
+
+			if read.Load() != int64(len(c.data)) || records.Load() != int64(bytes.Count(c.data, []byte{'\n'})) {
+				t.Fatalf("%d bytes and %d records were processed", read.Load(), records.Load())
+			}
+
+			t.Logf("Success! %d batches, %d allocations", stats.Batches, stats.Allocations)
+		})
+	}
+}
+
+// BenchmarkBread_AdaptiveBuffer compares the adaptive size with a small and a hand-tuned BufferSize,
+// over short and long lines
+func BenchmarkBread_AdaptiveBuffer(b *testing.B) {
+	corpora := [...]struct {
+		name  string
+		data  []byte
+		tuned int
+	}{
+		{name: "short", data: lines(100_000, 100), tuned: 8 * KB},
+		{name: "long", data: lines(500, 20*KB), tuned: 1 * MB},
+	}
+
+	for _, corpus := range corpora {
+		breads := [...]struct {
+			name  string
+			bread Bread
+		}{
+			{name: "small", bread: Bread{BufferSize: 1 * KB}},
+			{name: "tuned", bread: Bread{BufferSize: corpus.tuned}},
+			{name: "adaptive", bread: Bread{BufferSize: 1 * KB, AdaptiveBuffer: true, MaxBufferSize: 1 * MB}},
+		}
+
+		for _, c := range breads {
+			b.Run(corpus.name+"/"+c.name, func(b *testing.B) {
+				c.bread.Workers = 4
+
+				b.SetBytes(int64(len(corpus.data)))
+				b.ReportAllocs()
+
+				for n := 0; n < b.N; n++ {
+					err := c.bread.EatBatches(context.TODO(), bytes.NewReader(corpus.data), func(context.Context, Batch) {})
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
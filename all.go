@@ -0,0 +1,70 @@
+package bread
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+)
+
+// errFailFast is the cause of the cancellation of the readers stopped by FailFast
+var errFailFast = errors.New("another reader failed")
+
+// EatAll eats every reader concurrently, each one on its own goroutine, sharing the buffer pool and the worker
+// budget defined by Workers, so at most Workers batches are processed at a time across all the readers.
+// The reader of each batch is identified by BatchMeta.Source.
+//
+// The error of each reader is wrapped in a *ReaderError and the errors are joined, see FailFast.
+func (b Bread) EatAll(ctx context.Context, readers ...io.Reader) error {
+	if err := b.Validate(); err != nil {
+		return err
+	}
+
+	if b.Workers == 0 {
+		b.Workers = DefaultWorkers
+	}
+
+	if b.slots == nil {
+		b.slots = make(chan struct{}, b.Workers)
+	}
+
+	if b.Pool == nil {
+		b.Pool = b.sharedPool()
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	var (
+		wg   sync.WaitGroup
+		errs = make([]error, len(readers))
+	)
+
+	for i, reader := range readers {
+		config := b
+		config.sourceIndex = i
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			err := config.Eat(ctx, reader)
+
+			// The readers stopped by FailFast do not report the cancellation
+			if err == nil || errors.Is(err, context.Canceled) && context.Cause(ctx) == errFailFast {
+				return
+			}
+
+			errs[config.sourceIndex] = &ReaderError{Index: config.sourceIndex, Err: err}
+
+			if b.FailFast {
+				cancel(errFailFast)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
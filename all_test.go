@@ -0,0 +1,127 @@
+package bread
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBread_EatAll(t *testing.T) {
+	errRead := errors.New("read failure")
+
+	cases := [...]struct {
+		failFast bool
+		// failed is the reader that fails early, if any
+		failed int
+		err    error
+	}{
+		{
+			failed: -1,
+		},
+		{
+			failed: 2,
+			err:    errRead,
+		},
+		{
+			failFast: true,
+			failed:   2,
+			err:      errRead,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			goroutines := runtime.NumGoroutine()
+
+			inputs := make([][]byte, 8)
+			readers := make([]io.Reader, len(inputs))
+
+			for j := range inputs {
+				inputs[j] = memoryData(256*KB + j*KB)
+				readers[j] = bytes.NewReader(inputs[j])
+
+				if j == c.failed {
+					readers[j] = io.MultiReader(bytes.NewReader(inputs[j][:KB]), failedReader{err: c.err})
+				}
+			}
+
+			mu := sync.Mutex{}
+			got := make([][]byte, len(inputs))
+
+			running, peak := atomic.Int32{}, atomic.Int32{}
+
+			bread := Bread{
+				Workers:    4,
+				BufferSize: 4 * KB,
+				FailFast:   c.failFast,
+				WorkerBatchFunc: func(_ context.Context, batch Batch) {
+					peak.Store(max(peak.Load(), running.Add(1)))
+					defer running.Add(-1)
+
+					time.Sleep(10 * time.Microsecond)
+
+					mu.Lock()
+					defer mu.Unlock()
+
+					data := got[batch.Source]
+					if int64(len(data)) < batch.Offset+int64(batch.Size) {
+						data = append(data, make([]byte, batch.Offset+int64(batch.Size)-int64(len(data)))...)
+					}
+
+					copy(data[batch.Offset:], batch.Data)
+					got[batch.Source] = data
+				},
+			}
+
+			err := bread.EatAll(context.TODO(), readers...)
+			if !errors.Is(err, c.err) {
+				t.Fatalf("expected error %v, got %v", c.err, err)
+			}
+
+			// The worker budget is shared by all the readers
+			if peak.Load() > int32(bread.Workers) {
+				t.Fatalf("expected at most %d concurrent workers, got %d", bread.Workers, peak.Load())
+			}
+
+			if c.err != nil {
+				var readerErr *ReaderError
+				if !errors.As(err, &readerErr) || readerErr.Index != c.failed {
+					t.Fatalf("expected the reader %d to fail, got %v", c.failed, err)
+				}
+
+				// Only the failed reader is reported
+				if c.failFast && errors.Is(err, context.Canceled) {
+					t.Fatalf("unexpected cancellation in %v", err)
+				}
+			}
+
+			for j := range inputs {
+				if j == c.failed || c.failFast {
+					continue
+				}
+
+				if !bytes.Equal(got[j], inputs[j]) {
+					t.Fatalf("the batches of reader %d do not reproduce the input", j)
+				}
+			}
+
+			// Nothing keeps running after EatAll returns
+			for deadline := time.Now().Add(time.Second); runtime.NumGoroutine() > goroutines; {
+				if time.Now().After(deadline) {
+					t.Fatalf("expected %d goroutines, got %d", goroutines, runtime.NumGoroutine())
+				}
+
+				time.Sleep(time.Millisecond)
+			}
+
+			t.Logf("Success! %v", err)
+		})
+	}
+}
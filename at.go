@@ -0,0 +1,235 @@
+package bread
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// EatAt works like Eat over the first size bytes of r, but the input is split in Workers ranges that are read
+// concurrently, each one by its own worker with its own buffers, so the reads of a local file are not funneled
+// through a single reader.
+//
+// The ranges are aligned to the delimiters, so no record is split across them: every range but the first one
+// starts right after the first delimiter found from its nominal start, and the previous range reads up to that
+// point. The batches of a range are processed in order by the worker that read them, so the batch indexes and
+// BatchMeta.FirstRecord do not follow the stream order across ranges, unlike the offsets. The StartOffset counts
+// from the start of r, and the ranges split the input from there, see AlignToDelimiter.
+//
+// NOTE: only FramingDelimiter with AlignForward is supported, so RecordSize, SplitFunc and CSVMode fail with
+// ErrRecordSize and ErrSplitFunc. MaxRecords, MaxBatches, LimitBytes, MaxBatchSize, Epochs, ShuffleBuffer,
+// QueueDepth, Ordered, OnDone, Output and SkipLines are ignored by EatAt
+func (b Bread) EatAt(ctx context.Context, r io.ReaderAt, size int64) error {
+	return b.eatAt(ctx, r, size, b.worker())
+}
+
+// eatAt processes the aligned ranges of r with worker, see EatAt
+func (b Bread) eatAt(ctx context.Context, r io.ReaderAt, size int64, worker func(context.Context, Batch) error) error {
+	if r == nil {
+		return ErrNilReader
+	}
+
+	if b.RecordSize > 0 {
+		return ErrRecordSize
+	}
+
+	if b.SplitFunc != nil || b.CSVMode {
+		return ErrSplitFunc
+	}
+
+	// The ranges are processed concurrently, so there is no order to deliver the batches in
+	b.Ordered, b.OnDone, b.Output = false, nil, nil
+
+	return b.feed(ctx, worker, func(ctx context.Context, e *eater) error {
+		return e.readAt(ctx, r, size)
+	})
+}
+
+// EatFile works like EatAt over the file found at path
+func (b Bread) EatFile(ctx context.Context, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	return b.EatAt(ctx, f, info.Size())
+}
+
+// readAt reads the aligned ranges of r concurrently
+func (e *eater) readAt(ctx context.Context, r io.ReaderAt, size int64) error {
+	begin, err := e.startAt(r, size)
+	if err != nil {
+		return err
+	}
+
+	e.startOffset = begin
+
+	// Ranges smaller than the buffer are not worth a worker
+	length := size - begin
+	ranges := max(min(int64(e.Workers), length/int64(e.BufferSize)), 1)
+
+	bounds := make([]int64, 0, ranges+1)
+	bounds = append(bounds, begin)
+
+	for i := int64(1); i < ranges; i++ {
+		start, err := e.alignAt(r, max(begin+i*(length/ranges), bounds[len(bounds)-1]), size)
+		if err != nil {
+			return err
+		}
+
+		bounds = append(bounds, start)
+	}
+
+	bounds = append(bounds, size)
+
+	var (
+		wg   sync.WaitGroup
+		errs = make([]error, len(bounds)-1)
+	)
+
+	for i := range errs {
+		start, end := bounds[i], bounds[i+1]
+		if start == end {
+			continue
+		}
+
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = e.readRange(ctx, bufio.NewReader(io.NewSectionReader(r, start, end-start)), start)
+		}(i)
+	}
+
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// startAt returns the position where the reading of r starts, see StartOffset and AlignToDelimiter
+func (e *eater) startAt(r io.ReaderAt, size int64) (int64, error) {
+	begin := min(e.StartOffset, size)
+
+	if e.AlignToDelimiter && begin > 0 {
+		// A delimiter right before StartOffset is found, so a record starting at StartOffset is kept
+		return e.alignAt(r, max(begin-int64(len(e.separator)), 0), size)
+	}
+
+	return begin, nil
+}
+
+// alignAt returns the position right after the first delimiter found from position, or size if there is none
+func (e *eater) alignAt(r io.ReaderAt, position, size int64) (int64, error) {
+	buffer := make([]byte, max(e.BufferSize, 2*len(e.separator)))
+
+	for position < size {
+		n, err := r.ReadAt(buffer[:min(int64(len(buffer)), size-position)], position)
+
+		if i := bytes.Index(buffer[:n], e.separator); i >= 0 {
+			return position + int64(i+len(e.separator)), nil
+		}
+
+		if err != nil && err != io.EOF {
+			return 0, &ReadError{Position: position + int64(n), Err: err}
+		}
+
+		if err == io.EOF || n < len(e.separator) {
+			break
+		}
+
+		// A delimiter of several bytes may cross the end of the buffer
+		position += int64(n - len(e.separator) + 1)
+	}
+
+	return size, nil
+}
+
+// readRange works like readForward over a range of the input starting at offset, processing its batches on the
+// calling goroutine
+func (e *eater) readRange(ctx context.Context, r *bufio.Reader, offset int64) error {
+	for !e.failed.Load() {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+
+		buffer := e.pool.Get()
+
+		n, err := r.Read(*buffer)
+		if err != nil {
+			e.pool.Put(buffer)
+
+			if err == io.EOF {
+				return nil
+			}
+
+			return &ReadError{Position: offset, Err: err}
+		}
+
+		*buffer = (*buffer)[:n]
+
+		complement, records, err := e.completeRecord(r, buffer)
+		if err != nil && err != io.EOF {
+			e.pool.Put(buffer)
+
+			// The position of the record is relative to the batch
+			var tooLarge *RecordTooLargeError
+			if errors.As(err, &tooLarge) {
+				tooLarge.Position += offset
+				return err
+			}
+
+			return &ReadError{Position: offset + int64(n+complement), Err: err}
+		}
+
+		if complement > 0 || !bytes.HasSuffix(*buffer, e.separator) {
+			records++
+		}
+
+		e.counters.complements.Add(int64(complement))
+		e.debugComplement(ctx, complement)
+
+		// The worker may replace the slice of the buffer
+		size := len(*buffer)
+
+		e.emitAt(ctx, buffer, offset, records)
+
+		offset += int64(size)
+	}
+
+	return nil
+}
+
+// emitAt processes the buffer read at offset on the calling goroutine, see readRange
+func (e *eater) emitAt(ctx context.Context, buffer *[]byte, offset int64, records int) {
+	e.counters.bytesRead.Add(int64(len(*buffer)))
+
+	batch := Batch{
+		BatchMeta: BatchMeta{
+			Index:       e.counters.batches.Add(1) - 1,
+			Offset:      offset,
+			Size:        len(*buffer),
+			Records:     uint32(records),
+			FirstRecord: e.counters.records.Add(uint64(records)) - uint64(records),
+			Source:      e.sourceIndex,
+		},
+		Data:       *buffer,
+		buffer:     buffer,
+		dispatched: time.Now(),
+	}
+
+	e.workInline(ctx, batch)
+}
@@ -0,0 +1,150 @@
+package bread
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestBread_EatAt(t *testing.T) {
+	// Records of random lengths, so the nominal range starts fall in the middle of them
+	generated := make([]byte, 0)
+
+	for i := 0; len(generated) < MB; i++ {
+		generated = append(generated, strings.Repeat("x", i*7919%500)...)
+		generated = append(generated, '\n')
+	}
+
+	cases := [...]struct {
+		bread Bread
+		data  []byte
+		err   error
+	}{
+		{
+			bread: Bread{Workers: 8, BufferSize: KB},
+			data:  generated,
+		},
+		{
+			bread: Bread{Workers: 3, BufferSize: 16, DelimiterBytes: []byte("\r\n")},
+			data:  bytes.ReplaceAll(generated[:10*KB], []byte("\n"), []byte("\r\n")),
+		},
+		// More workers than buffers
+		{
+			bread: Bread{Workers: 16, BufferSize: 64},
+			data:  []byte("a\nbb\nccc\n"),
+		},
+		// The last record does not end with the delimiter
+		{
+			bread: Bread{Workers: 4, BufferSize: 4},
+			data:  []byte("one\ntwo\nthree\nfour\nfive"),
+		},
+		{
+			bread: Bread{Workers: 4, BufferSize: 4, MaxRecordSize: 8},
+			data:  []byte("one\ntwo\nthree\nfour\nfive is too long\nsix\n"),
+			err:   ErrRecordTooLarge,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			separator := c.bread.delimiters().separator()
+
+			mu := sync.Mutex{}
+			records := make([]string, 0)
+
+			c.bread.WorkerBatchFunc = func(_ context.Context, batch Batch) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				if !bytes.Equal(batch.Data, c.data[batch.Offset:batch.Offset+int64(batch.Size)]) {
+					t.Errorf("batch %d is not the input at offset %d", batch.Index, batch.Offset)
+				}
+
+				for _, record := range bytes.SplitAfter(batch.Data, separator) {
+					if len(record) > 0 {
+						records = append(records, string(record))
+					}
+				}
+			}
+
+			err := c.bread.EatAt(context.TODO(), bytes.NewReader(c.data), int64(len(c.data)))
+			if c.err != nil {
+				var tooLarge *RecordTooLargeError
+				if !errors.As(err, &tooLarge) || tooLarge.Position != int64(bytes.Index(c.data, []byte("five"))) {
+					t.Fatalf("expected a *RecordTooLargeError at the record, got %v", err)
+				}
+
+				t.Logf("Success! %v", err)
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// The same records as a single-threaded scan
+			expected := make([]string, 0)
+
+			for _, record := range bytes.SplitAfter(c.data, separator) {
+				if len(record) > 0 {
+					expected = append(expected, string(record))
+				}
+			}
+
+			slices.Sort(records)
+			slices.Sort(expected)
+
+			if !slices.Equal(records, expected) {
+				t.Fatalf("expected %d records, got %d", len(expected), len(records))
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+func TestBread_EatFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "records.txt")
+
+	data := memoryData(MB)
+
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	mu := sync.Mutex{}
+	got := make([]byte, len(data))
+
+	bread := Bread{
+		Workers:    4,
+		BufferSize: 4 * KB,
+		WorkerBatchFunc: func(_ context.Context, batch Batch) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			copy(got[batch.Offset:], batch.Data)
+		},
+	}
+
+	err := bread.EatFile(context.TODO(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, data) {
+		t.Fatal("the batches do not reproduce the file")
+	}
+
+	if err = bread.EatFile(context.TODO(), filepath.Join(t.TempDir(), "missing")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected %v, got %v", os.ErrNotExist, err)
+	}
+
+	t.Log("Success!")
+}
@@ -0,0 +1,97 @@
+package bread
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// BatchMeta describes the position and size of a batch in the stream
+type BatchMeta struct {
+	// Index is the position of the batch in the stream, starting from zero
+	Index uint64
+	// Offset is the position in the stream of the first byte of the batch, relative to the start of the epoch
+	Offset int64
+	// Size is the number of bytes in the batch
+	Size int
+	// Records is the number of records in the batch, that is the number of delimiters it contains
+	// plus the last record when it is not terminated by a delimiter (only possible at the end of the stream).
+	// The delimiters are counted by the reading goroutine while it completes the batch, in the pass that looks for
+	// the last delimiter when it needs one, see BenchmarkBatchMeta_Records.
+	Records uint32
+	// FirstRecord is the number of records in the previous batches, so the records of the batch are numbered
+	// starting from FirstRecord+1
+	FirstRecord uint64
+	// Epoch is the pass over the reader that read the batch, starting from zero. See Bread.Epochs
+	Epoch uint32
+	// ForceSplit indicates that the batch ends in the middle of a record, because no delimiter was found
+	// within MaxBatchSize. The record continues in the next batch, and it is counted in the batch where it ends.
+	ForceSplit bool
+	// Source is the position of the reader in the readers given to EatAll, or of the entry in the archive given
+	// to EatTar, zero for the other Eat calls
+	Source int
+}
+
+// Batch is a portion of the data read from the io.Reader, delimited according to the Bread settings
+//
+// Data is only valid until the worker function returns, after that the underlying buffer is reused by
+// later batches. Call Detach or Retain to keep it beyond that point.
+type Batch struct {
+	BatchMeta
+	// Data contains the bytes of the batch
+	Data []byte
+
+	buffer *[]byte
+	// pool receives the buffer on Release, only set for the batches owned by the consumer, see Bread.EatChan
+	pool bufferPool
+	// retention holds the buffer once it is retained, only set for pooled buffers, see Retain
+	retention *retention
+	// separator is the delimiter picked by DetectDelimiter, see batchRecords
+	separator string
+	// partitions receives the records of RecordFunc, see Bread.PartitionFunc
+	partitions *partitioner
+	// delivered counts the records passed to the worker, only set with sampling, see Bread.SampleEvery
+	delivered *atomic.Uint64
+	// state is the state of the worker processing the batch, see Bread.WorkerInit
+	state any
+	// dispatched is the time the batch was handed to dispatch, see Observer.OnBatchStart
+	dispatched time.Time
+	// weight is the memory acquired for the batch, see Bread.MaxMemory
+	weight int64
+	// abandoned reports that the worker call was still running when the batch failed, see Bread.FailOnBatchTimeout
+	abandoned bool
+}
+
+// Detach transfers the ownership of the batch data to the caller, so it stays valid after the worker returns.
+//
+// NOTE: Detach must be called from the worker function that received the batch
+func (b Batch) Detach() []byte {
+	if b.buffer != nil {
+		*b.buffer = nil
+	}
+
+	return b.Data
+}
+
+// Retain keeps the buffer of the batch out of the pool once the worker returns, so the data stays valid until
+// Release is called, from any goroutine. Unlike Detach the buffer goes back to the pool, and it keeps counting
+// against MaxMemory until then, so the reading waits when the retained batches take the whole budget.
+// The buffers still retained when Eat returns are reported by Stats.Retained.
+//
+// NOTE: Retain must be called from the worker function that received the batch
+func (b Batch) Retain() {
+	if b.retention != nil && b.buffer != nil {
+		b.retention.retain(b.buffer, b.weight)
+	}
+}
+
+// Release returns the buffer of a retained batch, or of a batch received from EatChan, to the pool. The batch data
+// must not be used afterwards. It must be called at most once, the other batches received by a worker function
+// are released for it.
+func (b Batch) Release() {
+	switch {
+	case b.pool != nil:
+		b.pool.Put(b.buffer)
+	case b.retention != nil:
+		b.retention.release(b.buffer)
+	}
+}
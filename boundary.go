@@ -0,0 +1,147 @@
+package bread
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// readSplit assembles batches of about BufferSize bytes out of the records found by SplitFunc, so batches only end
+// on record boundaries. The bytes of the record still incomplete when a batch is full are carried to the next one.
+func (e *eater) readSplit(ctx context.Context, r *bufio.Reader) error {
+	carry := e.scratch(e.BufferSize)
+	eof := false
+
+	for !e.failed.Load() && !e.stopped {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+
+		buffer := e.pool.Get()
+		*buffer = append((*buffer)[:0], carry...)
+		carry = carry[:0]
+
+		// end is the position right after the last record of the batch
+		end, records := 0, 0
+
+		for end < e.BufferSize && (end < len(*buffer) || !eof) {
+			if e.MaxRecords > 0 && e.counters.records.Load()+uint64(records) >= e.MaxRecords {
+				e.stopped = true
+				break
+			}
+
+			advance := 0
+
+			if end < len(*buffer) {
+				n, _, err := e.SplitFunc((*buffer)[end:], eof)
+
+				switch {
+				case n < 0 || n > len(*buffer)-end:
+					e.pool.Put(buffer)
+					return &FramingError{Position: e.offset + int64(end), Reason: fmt.Sprintf("split function advanced %d bytes", n)}
+				case err == bufio.ErrFinalToken:
+					// The rest of the stream is ignored
+					e.stopped = true
+				case err != nil:
+					e.pool.Put(buffer)
+					return &FramingError{Position: e.offset + int64(end), Reason: "split function failed", Err: err}
+				}
+
+				advance = n
+			}
+
+			if advance > 0 {
+				end += advance
+				records++
+
+				if e.stopped {
+					break
+				}
+
+				continue
+			}
+
+			if e.stopped {
+				break
+			}
+
+			// The remainder of the stream is flushed as it is
+			if eof {
+				end = len(*buffer)
+				records++
+				break
+			}
+
+			// The incomplete record starts the next batch
+			if records > 0 && len(*buffer) >= e.BufferSize {
+				break
+			}
+
+			if e.MaxRecordSize > 0 && len(*buffer)-end > e.MaxRecordSize {
+				err := &RecordTooLargeError{Position: e.offset + int64(end), Limit: e.MaxRecordSize, Preview: e.preview((*buffer)[end:])}
+				e.pool.Put(buffer)
+
+				return err
+			}
+
+			// The records of the batch go once the reader is idle, see FlushInterval
+			e.flusher.arm(records > 0)
+
+			err := fill(r, buffer)
+			e.flusher.arm(false)
+
+			if err == errIdle {
+				break
+			}
+
+			if err == io.EOF {
+				eof = true
+				continue
+			}
+
+			if err != nil {
+				e.pool.Put(buffer)
+				return &ReadError{Position: e.offset + int64(len(*buffer)), Err: err}
+			}
+		}
+
+		var err error
+		if carry, err = e.carry(carry, (*buffer)[end:], e.offset+int64(end)); err != nil {
+			e.pool.Put(buffer)
+			return err
+		}
+
+		if records == 0 {
+			e.pool.Put(buffer)
+			return nil
+		}
+
+		// The bytes past the last record are not delivered, see MaxRecords and bufio.ErrFinalToken
+		if e.stopped && e.FinalFunc != nil {
+			e.leftover = bytes.Clone(carry)
+		}
+
+		*buffer = (*buffer)[:end]
+
+		e.emit(ctx, buffer, records)
+	}
+
+	return nil
+}
+
+// fill appends to the buffer the bytes of the next read, growing it when it is full
+func fill(r *bufio.Reader, buffer *[]byte) error {
+	if len(*buffer) == cap(*buffer) {
+		*buffer = slices.Grow(*buffer, max(len(*buffer), 512))
+	}
+
+	n, err := r.Read((*buffer)[len(*buffer):cap(*buffer)])
+	*buffer = (*buffer)[:len(*buffer)+n]
+
+	return err
+}
@@ -0,0 +1,170 @@
+package bread_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/yael-castro/bread"
+	"github.com/yael-castro/bread/breadtest"
+)
+
+// entryPrefix starts the first line of each log entry
+var entryPrefix = []byte("2024-")
+
+// splitEntries splits the log before the lines starting with entryPrefix, so the stack traces stay with their entry
+func splitEntries(data []byte, atEOF bool) (int, []byte, error) {
+	for i := 0; ; {
+		j := bytes.IndexByte(data[i:], '\n')
+		if j < 0 {
+			break
+		}
+
+		i += j + 1
+
+		// The next line is too short to tell whether it starts an entry
+		if len(data)-i < len(entryPrefix) && !atEOF {
+			return 0, nil, nil
+		}
+
+		if bytes.HasPrefix(data[i:], entryPrefix) {
+			return i, data[:i], nil
+		}
+	}
+
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+
+	return 0, nil, nil
+}
+
+func TestBread_SplitFunc(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+
+	entries := make([]string, 200)
+	for i := range entries {
+		entries[i] = fmt.Sprintf("2024-01-01 ERROR entry %d\n", i)
+
+		for j := range random.Intn(8) {
+			entries[i] += fmt.Sprintf("\tat frame %d %s\n", j, strings.Repeat("x", random.Intn(40)))
+		}
+	}
+
+	log := strings.Join(entries, "")
+
+	cases := [...]struct {
+		bread  bread.Bread
+		data   string
+		reader func(io.Reader) io.Reader
+		// expected is the number of records delivered
+		expected int
+		err      error
+	}{
+		{
+			bread:    bread.Bread{BufferSize: 64, Workers: 4, SplitFunc: splitEntries},
+			data:     log,
+			expected: len(entries),
+		},
+		{
+			bread:    bread.Bread{BufferSize: 64, Workers: 4, SplitFunc: splitEntries},
+			data:     log,
+			reader:   iotest.OneByteReader,
+			expected: len(entries),
+		},
+		{
+			bread:    bread.Bread{BufferSize: 1024, Workers: 4, SplitFunc: splitEntries},
+			data:     log,
+			reader:   iotest.HalfReader,
+			expected: len(entries),
+		},
+		{
+			// The last entry is not terminated
+			bread:    bread.Bread{BufferSize: 64, SplitFunc: splitEntries},
+			data:     strings.TrimSuffix(log, "\n"),
+			expected: len(entries),
+		},
+		{
+			// The remainder is flushed as it is
+			bread:    bread.Bread{BufferSize: 64, SplitFunc: bufio.ScanLines},
+			data:     "a\nb\nc",
+			expected: 3,
+		},
+		{
+			bread:    bread.Bread{BufferSize: 64, SplitFunc: splitEntries, MaxRecords: 10},
+			data:     log,
+			expected: 10,
+		},
+		{
+			bread: bread.Bread{BufferSize: 64, SplitFunc: splitEntries, MaxRecordSize: 100},
+			data:  entries[0] + "\tat " + strings.Repeat("x", 200) + "\n" + entries[1],
+			err:   bread.ErrRecordTooLarge,
+		},
+		{
+			bread: bread.Bread{BufferSize: 64, SplitFunc: func([]byte, bool) (int, []byte, error) {
+				return 0, nil, io.ErrUnexpectedEOF
+			}},
+			data: log,
+			err:  io.ErrUnexpectedEOF,
+		},
+		{
+			bread: bread.Bread{BufferSize: 64, SplitFunc: splitEntries, Align: bread.AlignBackward},
+			data:  log,
+			err:   bread.ErrSplitFunc,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			var reader io.Reader = strings.NewReader(c.data)
+			if c.reader != nil {
+				reader = c.reader(reader)
+			}
+
+			recorder := &breadtest.Recorder{}
+
+			err := c.bread.EatBatches(context.TODO(), reader, recorder.WorkerBatchFunc)
+			if c.err != nil {
+				if !errors.Is(err, c.err) {
+					t.Fatalf("expected error %v, got %v", c.err, err)
+				}
+
+				t.Logf("Success! %v", err)
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// No batch starts in the middle of an entry
+			for _, batch := range recorder.Batches() {
+				if strings.HasPrefix(c.data, "2024-") && !bytes.HasPrefix(batch.Data, entryPrefix) {
+					t.Fatalf("batch %d starts in the middle of an entry: %q", batch.Index, batch.Data)
+				}
+			}
+
+			if records := recorder.Records(); records != uint64(c.expected) {
+				t.Fatalf("expected %d records, got %d", c.expected, records)
+			}
+
+			// MaxRecords delivers a prefix of the data
+			expected := []byte(c.data)
+			if c.bread.MaxRecords > 0 {
+				expected = expected[:min(len(recorder.Data()), len(expected))]
+			}
+
+			breadtest.AssertData(t, recorder, expected)
+
+			t.Log("Success!")
+		})
+	}
+}
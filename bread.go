@@ -1,132 +1,1858 @@
 package bread
 
 import (
+	"archive/tar"
 	"bufio"
 	"context"
+	"crypto"
 	"errors"
+	"fmt"
+	"hash"
 	"io"
+	"io/fs"
+	"log/slog"
+	"math"
+	"runtime"
 	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/yael-castro/bread/internal/pool"
+	"github.com/yael-castro/bread/internal/semaphore"
 )
 
+// DelimiterNUL is the DelimiterBytes that selects the zero byte as the Delimiter
+var DelimiterNUL = []byte{0}
+
 // Default Bread parameters
 const (
 	DefaultDelimiter byte = '\n'
 	DefaultWorkers        = 1
+	// DefaultReaderBufferSize is the smallest default ReaderBufferSize, the size of bufio.NewReader
+	DefaultReaderBufferSize = 4 * KB
+	// DefaultErrorContextBytes is the default length of the preview attached to the batch errors
+	DefaultErrorContextBytes = 64
+	// MaxErrorContextBytes bounds ErrorContextBytes, so the errors cannot blow up the logs
+	MaxErrorContextBytes = 1024
+	// MaxWorkers bounds Workers, the slots of the workers are allocated up front
+	MaxWorkers = 1 << 16
+	// MaxSeedPerBuffer bounds BufferSeed to this many buffers for each one that can be in use at once,
+	// that is one per worker, one per queued batch and the one being read
+	MaxSeedPerBuffer = 16
+	// maxBufferGrowth is how many times the pooled buffers can grow past their size, see MaxBufferCap
+	maxBufferGrowth = 4
+	// sizeLimit bounds BufferSize and the sizes of AdaptiveBuffer, so their buffers can grow without overflowing
+	sizeLimit = math.MaxInt / maxBufferGrowth
 )
 
 var (
 	ErrNilReader         = errors.New("nil io reader")
 	ErrMissingWorkerFunc = errors.New("missing worker function")
 	ErrMissingBufferSize = errors.New("missing buffer size")
+	ErrPoolSizeMismatch  = errors.New("pool buffer size does not match buffer size")
+	ErrMissingBoundary   = errors.New("missing multipart boundary")
+	ErrMaxBatchSize      = errors.New("max batch size smaller than buffer size")
+	ErrNotSeekable       = errors.New("io reader is not seekable")
+	ErrScratchTooSmall   = errors.New("scratch too small")
+	ErrDigestMismatch    = errors.New("digest mismatch")
+	ErrDigestUnavailable = errors.New("digest algorithm unavailable")
+	ErrDelimiterBytes    = errors.New("multi-byte delimiter not supported with these settings")
+	ErrOrderedPriority   = errors.New("ordered batches cannot be prioritized")
+	ErrRecordSize        = errors.New("record size not supported with these settings")
+	ErrSplitFunc         = errors.New("split function not supported with these settings")
+	ErrLimitReached      = errors.New("limit reached")
+	ErrAbandonedState    = errors.New("worker states cannot be abandoned")
+	ErrMaxMemory         = errors.New("max memory smaller than buffer size")
+	ErrBufferSizeBounds  = errors.New("min buffer size greater than max buffer size")
+	ErrCheckpoint        = errors.New("checkpoints not supported with these settings")
+	ErrTooManyWorkers    = errors.New("too many workers")
+	ErrBufferSeed        = errors.New("buffer seed too large for the workers")
+	ErrSampling          = errors.New("invalid sampling settings")
+	ErrFlushInterval     = errors.New("flush interval not supported with these settings")
+	ErrFollowEpochs      = errors.New("followed readers cannot be read again")
+	ErrContinueOnError   = errors.New("continue on error not supported with these settings")
+	ErrDetectDelimiter   = errors.New("delimiter detection not supported with these settings")
+	ErrPartitionFunc     = errors.New("partitions not supported with these settings")
+	ErrNegativeSize      = errors.New("negative size")
+	ErrSizeOverflow      = errors.New("size overflows int")
+	ErrStartOffset       = errors.New("start offset not supported with these settings")
+	ErrNoRecords         = errors.New("records not supported with FramingNone")
+)
+
+// Align selects how the end of each batch is aligned to a delimiter
+type Align uint8
+
+// Supported values for Align
+const (
+	// AlignForward completes each batch reading past BufferSize up to the next delimiter,
+	// so batches may be larger than BufferSize
+	AlignForward Align = iota
+	// AlignBackward cuts each batch after the last delimiter within BufferSize and carries the remaining bytes
+	// to the next batch, so batches never exceed BufferSize. Records larger than BufferSize fail
+	// with a *RecordTooLargeError
+	AlignBackward
 )
 
 // Bread provides a way to read data line by line an io.Reader
 type Bread struct {
 	// WorkerFunc is used to process each data batch from the io.Reader
 	//
-	// This member is required.
+	// This member is required, unless Sink or another worker function is set.
 	WorkerFunc func(context.Context, *[]byte)
+	// WorkerErrFunc works like WorkerFunc, but the batch can fail. The first error stops the reading and cancels
+	// the context of the workers still running, Eat returns once every worker finished with the errors joined.
+	//
+	// This member is optional. When it is set WorkerFunc, WorkerBatchFunc and RecordFunc are ignored
+	WorkerErrFunc func(context.Context, *[]byte) error
+	// WorkerBatchFunc works like WorkerFunc, but it receives the batch along with its position in the stream,
+	// the Offset of each batch plus the length of its Data is the Offset of the next one. See EatBatches.
+	//
+	// This member is optional. When it is set WorkerFunc is ignored
+	WorkerBatchFunc func(context.Context, Batch)
+	// RecordFunc works like WorkerFunc, but it receives the records of the batch split on the Delimiter, which is
+	// excluded from them like with bytes.Split. The records found by SplitFunc or CSVMode keep all their bytes,
+	// those of RecordSize are cut by size and the other framings deliver a single record. The record slices alias
+	// the pooled buffer without copying it, so like the buffer they are only valid until RecordFunc returns.
+	//
+	// NOTE: the record cut by a ForceSplit batch is delivered in two parts, see MaxBatchSize
+	//
+	// This member is optional. When it is set WorkerFunc and WorkerBatchFunc are ignored
+	RecordFunc func(ctx context.Context, records [][]byte)
+	// PartitionFunc returns the key of each record passed to RecordFunc. The records with the same key go to the
+	// same one of Workers partitions in stream order, while the partitions run concurrently, so RecordFunc can keep
+	// the state of each key without sharing it. RecordFunc receives the records of a batch that belong to the same
+	// partition, and the batch keeps its buffer until all of them were processed. Each partition queues the records
+	// of a single batch, so a partition behind the others, like the one of a hot key, holds back the reading
+	// instead of buffering more records. OnPanic receives a nil buffer for the panics of RecordFunc.
+	//
+	// This member is optional. It only applies to RecordFunc, without ShuffleBuffer nor PriorityFunc
+	PartitionFunc func(record []byte) uint64
+	// WorkerStateFunc works like WorkerErrFunc, but it also receives the state returned by WorkerInit for the
+	// worker processing the batch. A state is only used by one batch at a time.
+	//
+	// This member is optional. When it is set WorkerFunc, WorkerBatchFunc, RecordFunc and WorkerErrFunc are ignored
+	WorkerStateFunc func(ctx context.Context, state any, buffer *[]byte) error
+	// WorkerInit builds the state of each worker, like a connection or a prepared statement, see WorkerStateFunc.
+	// It is called once for each worker identified from 0 to Workers-1, before reading. If any call fails, Eat
+	// returns its error without reading.
+	//
+	// This member is optional.
+	WorkerInit func(ctx context.Context, workerID int) (any, error)
+	// WorkerClose releases the state of each worker once Eat finishes, even if the worker did not get any batch or
+	// the context was cancelled, so its context is never done. Its errors are returned by Eat.
+	//
+	// This member is optional.
+	WorkerClose func(ctx context.Context, workerID int, state any) error
+	// OnPanic is called with the value recovered from a worker that panicked and the buffer of its batch, the batch
+	// is then released like any other and the processing goes on. The buffer must not be retained.
+	//
+	// This member is optional. By default the panic crashes the program
+	OnPanic func(ctx context.Context, recovered any, buffer *[]byte)
+	// Sink receives the batches instead of WorkerFunc, its Flush is called once after the last Write.
+	// A failed Write stops the reading like any other batch error.
+	//
+	// This member is optional. When it is set WorkerFunc is ignored
+	Sink Sink
 	// Workers number of concurrent workers
 	//
-	// This member is optional. Default value DefaultWorkers
-	Workers uint32
+	// This member is optional. It must not be negative nor exceed MaxWorkers. Default value DefaultWorkers, or
+	// GOMAXPROCS when MaxMemory or AutoWorkers are set, or ScaledWorkersPerCPU times GOMAXPROCS when ScaleWorkers is set
+	Workers int
+	// AutoWorkers sizes the workers to the machine, GOMAXPROCS, when Workers is not set
+	//
+	// This member is optional. Default value false
+	AutoWorkers bool
+	// ScaleWorkers adjusts the number of active workers while eating, from GOMAXPROCS and between 1 and Workers.
+	// A worker is activated after a ScaleWindow in which the batches waited for a free worker, and deactivated
+	// again when the throughput of the next window did not grow, like with CPU-bound workers. The next attempt
+	// to grow waits longer each time. A worker is also deactivated after several windows with idle workers.
+	// The number of active workers is reported by Progress and Stats. It does not apply when Workers is shared by
+	// several inputs, like with EatFS.
+	//
+	// This member is optional. Default value false
+	ScaleWorkers bool
+	// ScaleWindow is the time between the decisions of ScaleWorkers
+	//
+	// This member is optional. Default value DefaultScaleWindow
+	ScaleWindow time.Duration
+	// MaxMemory bounds the combined size of the batches dispatched and not yet processed, so a few batches grown
+	// by large records cannot exceed it no matter the number of Workers, which becomes an upper bound. The reading
+	// waits until the workers release enough memory. A batch larger than MaxMemory waits for the whole budget.
+	// The buffers held by ShuffleBuffer, and the batches handed to the consumer of EatChan, are not counted.
+	//
+	// This member is optional. It must not be smaller than BufferSize. Default value 0 (no limit)
+	MaxMemory int64
 	// BufferSeed indicates the initial reservation of available buffer instances in the object pool
 	//
-	// This member is optional. Default value 0
-	BufferSeed uint32
-	// BufferSize indicates how big will be the buffers instanced
+	// This member is optional. It must not be negative nor exceed MaxSeedPerBuffer buffers for each worker, each
+	// queued batch and the batch being read. Default value 0
+	BufferSeed int
+	// BufferSize indicates how big will be the buffers instanced, sizes like 4*GB are fine on 64-bit platforms
+	//
+	// This member is required. It must be positive, and small enough for the sizes derived from it to fit an int,
+	// see MaxBufferCap and MaxBufferSize
+	BufferSize int
+	// ReaderBufferSize is the size of the bufio.Reader that buffers the reader. The records are completed through it,
+	// and the next batch starts with the bytes it holds, so a small one cuts the large batches in pieces of its size.
+	// A *bufio.Reader at least this large is read directly, without another buffer in between.
 	//
-	// This member is required.
-	BufferSize uint32
+	// This member is optional. It must not be negative. Default value BufferSize, at least DefaultReaderBufferSize
+	ReaderBufferSize int
 	// Delimiter delimits the end of a line/record, in order to avoid sending half-batches of information.
+	// The zero byte means unset, use DelimiterNUL to split NUL-delimited streams like the output of find -print0.
 	//
 	// This member is optional. Default value DefaultDelimiter
 	Delimiter byte
+	// DelimiterBytes delimits the end of a record with a sequence of bytes, like "\r\n", it takes precedence over
+	// Delimiter when it is not empty. A sequence of more than one byte only applies to FramingDelimiter with
+	// AlignForward, without MaxBatchSize, TruncateRecordsAt nor ShuffleBuffer.
+	//
+	// This member is optional.
+	DelimiterBytes []byte
+	// DetectDelimiter picks the delimiter out of the first DelimiterSample bytes of the stream, instead of
+	// Delimiter and DelimiterBytes, so the streams of several sources can be read with the same settings. The line
+	// feed, the CRLF line ending and the DelimiterCandidates are counted, and the one found most often wins, the
+	// longest one on a tie. The line feed is used when none of them is found, and the choice is reported by
+	// Stats.Delimiter. The sequences of more than one byte are only picked when DelimiterBytes supports them.
+	//
+	// This member is optional. It only applies to FramingDelimiter, without RecordSize, SplitFunc nor CSVMode.
+	// Default value false
+	DetectDelimiter bool
+	// DelimiterCandidates are the delimiters considered by DetectDelimiter along with the line endings,
+	// like []byte("|") or DelimiterNUL
+	//
+	// This member is optional.
+	DelimiterCandidates [][]byte
+	// Decompressor wraps the reader given to Eat, like SniffCompression, and it is closed once the reading ends.
+	// Its errors are wrapped in a *DecompressError, unlike the errors of the reader. ExpectedDigest applies to
+	// the bytes of the reader before the decompression.
+	//
+	// This member is optional. It cannot be combined with Epochs, and RereadOnRetry is ignored
+	Decompressor func(io.Reader) (io.ReadCloser, error)
+	// WrapReader wraps the reader given to Eat after the Decompressor, like a charset decoder such as SniffBOM
+	// or DecodeLatin1, so the records are split on the decoded bytes and the offsets refer to the decoded stream.
+	// Its reads are filled up to the buffer size, so the batches match the ones of the decoded data.
+	// ExpectedDigest applies to the bytes of the reader before the wrapper.
+	//
+	// This member is optional. It cannot be combined with Epochs, and RereadOnRetry is ignored
+	WrapReader func(io.Reader) (io.Reader, error)
+	// WorkerDeadline returns the time a worker has to process a batch, the deadline is applied to the context
+	// passed to the worker. A batch that misses its deadline fails with a *DeadlineError.
+	//
+	// Non-positive durations disable the deadline for the batch.
+	//
+	// This member is optional. See ScaleDeadline
+	WorkerDeadline func(meta BatchMeta) time.Duration
+	// BatchContext derives the context passed to the worker of each batch, like one carrying a trace ID or
+	// a deadline of its own. Its CancelFunc runs once the worker returned, even when it panicked or missed its
+	// deadline, and it may be nil when the context is returned unchanged.
+	//
+	// This member is optional.
+	BatchContext func(ctx context.Context, meta BatchMeta) (context.Context, context.CancelFunc)
+	// BatchTimeout is the time a worker has to process each batch, like a WorkerDeadline returning the same
+	// duration for every batch. By default the worker is expected to honor its context.
+	//
+	// This member is optional. When WorkerDeadline is set BatchTimeout is ignored. Default value 0 (no timeout)
+	BatchTimeout time.Duration
+	// FailOnBatchTimeout stops waiting for the worker calls that miss their deadline, so a call that ignores its
+	// context cannot stall Eat. The batch fails right away with a *DeadlineError flagged as Abandoned, and its worker
+	// slot takes the next batches while the call goes on in the background. The buffer of an abandoned batch is left
+	// to the call instead of going back to the pool, so Eat may return before the call ends.
+	//
+	// NOTE: the batches of EatBytes are slices of the data given to it, an abandoned call may still read them
+	//
+	// This member is optional. It cannot be combined with WorkerInit, whose states serve a batch at a time
+	FailOnBatchTimeout bool
+	// MaxBatchSize bounds the batches completed up to the next delimiter. A batch without any delimiter within
+	// MaxBatchSize ends there, and it is flagged as BatchMeta.ForceSplit. It must not be smaller than BufferSize,
+	// and it only applies to FramingDelimiter with AlignForward.
+	//
+	// This member is optional. It must not be negative. Default value 0 (no limit)
+	MaxBatchSize int
+	// SyncThreshold is the size up to which inputs are processed on the calling goroutine, with the same batches
+	// but without dispatching them to concurrent workers. It only applies to FramingDelimiter with AlignForward.
+	//
+	// This member is optional. It must not be negative. Default value 0 (disabled)
+	SyncThreshold int
+	// BatchRetries is the number of times a failed batch is retried before its error is reported
+	//
+	// This member is optional. Default value 0
+	BatchRetries uint32
+	// RetryBackoff is the wait before the first retry of a batch, it doubles on each retry
+	//
+	// This member is optional. Default value 0
+	RetryBackoff time.Duration
+	// RereadOnRetry makes the retries read the batch again from the source instead of keeping its bytes in memory
+	// during the backoff. It requires an io.ReaderAt source, like *os.File, otherwise the bytes are kept in memory.
+	//
+	// This member is optional. Default value false
+	RereadOnRetry bool
+	// RereadChecksum makes RereadOnRetry verify that the bytes read again match the first read, a batch whose
+	// bytes changed fails with a *SourceChangedError. Without it only the length of the batch is verified.
+	//
+	// This member is optional. Default value false
+	RereadChecksum bool
+	// ExpectedDigest is the digest of the whole input, the bytes are hashed while they are read and the digest is
+	// compared at the end of the stream. A different digest fails with a *DigestMismatchError, even when every
+	// batch succeeded. With Epochs only the first pass is hashed. The comparison is skipped when the reading ends
+	// early, see Stats.DigestSkipped. EatBytes and EatFunc ignore it.
+	//
+	// This member is optional.
+	ExpectedDigest []byte
+	// DigestAlgo is the hash function of ExpectedDigest, its package must be linked into the binary
+	//
+	// This member is optional. Default value crypto.SHA256
+	DigestAlgo crypto.Hash
+	// Digest receives every byte read from the reader, on the reading goroutine and in stream order, so its Sum is
+	// the digest of the input once Eat returns. It hashes the same bytes as ExpectedDigest: the skipped lines and
+	// the complement bytes are included, and with Epochs only the first pass is hashed. When the reading ends early
+	// it also holds the bytes read ahead by the internal buffering. EatBytes and EatFunc ignore it.
+	//
+	// NOTE: the hash is written by every Eat call of the Bread, it must not be shared by concurrent calls
+	//
+	// This member is optional.
+	Digest hash.Hash
+	// ErrorContextBytes is the number of leading bytes of the failing data copied to the *WorkerError,
+	// *RecordTooLargeError and *FramingError errors, non-printable bytes are hex-escaped.
+	// It is bounded by MaxErrorContextBytes, and a negative value omits the preview for sensitive data.
+	//
+	// This member is optional. Default value DefaultErrorContextBytes
+	ErrorContextBytes int
+	// Scratch is the staging area for the bytes carried from one batch to the next by AlignBackward and
+	// MaxBatchSize, so the Eat call does not allocate one. Along with a shared Pool, the reading does not allocate
+	// once the pool is warm. A carry that does not fit in its capacity fails with a *ScratchTooSmallError.
+	// Its capacity must not be smaller than MaxRecordSize. It must not be shared by concurrent Eat calls.
+	//
+	// This member is optional. By default each Eat call allocates its own
+	Scratch []byte
+	// SizeHint is the expected size of the input, it lets Progress report the fraction read and an ETA.
+	// Reading more than SizeHint is not an error, see Progress.SizeExceeded
+	//
+	// This member is optional. Default value 0 (unknown)
+	SizeHint int64
+	// DetectSize fills SizeHint from the reader when it is not set: the remaining bytes of the readers with a Len
+	// method like *bytes.Reader, of the regular files, and of the io.Seeker readers by seeking to the end and back
+	//
+	// This member is optional. Default value false
+	DetectSize bool
+	// Epochs is the number of times the reader is eaten, seeking back to its initial position at the end of each
+	// pass. It requires an io.Seeker reader. The epoch of each batch is reported by BatchMeta.Epoch and the
+	// counts of each pass are passed to OnEpoch. MaxRecords limits the records of all the epochs together.
+	//
+	// This member is optional. Default value 1
+	Epochs uint32
+	// ShuffleBuffer is the number of records held in a reservoir to dispatch them in random order, each record
+	// as its own batch. Whenever the reservoir is full a random record is dispatched to make room for the next one,
+	// and the reservoir is drained in random order at the end of the stream, like the shuffle buffer of TensorFlow.
+	// Records are only fully shuffled when ShuffleBuffer is not smaller than the number of records.
+	//
+	// The batches are numbered in dispatch order, BatchMeta.Offset and BatchMeta.FirstRecord keep the position
+	// of the record in the stream.
+	//
+	// This member is optional. Default value 0 (disabled)
+	ShuffleBuffer uint32
+	// ShuffleMaxBytes bounds the bytes held by the reservoir of ShuffleBuffer, a record that does not fit
+	// makes room by dispatching random records first
+	//
+	// This member is optional. Default value 0 (no limit)
+	ShuffleMaxBytes uint64
+	// ShuffleSeed seeds the random generator of ShuffleBuffer, the same seed produces the same order
+	// for the same stream
+	//
+	// This member is optional. Default value 0
+	ShuffleSeed int64
+	// QueueDepth indicates how many batches can be read in advance while waiting for a free worker, so the reading
+	// overlaps the work of slow workers. Once the context is done, the queued batches go back to the pool without
+	// reaching any worker.
+	//
+	// This member is optional. Default value 0 (the reading waits for a free worker)
+	QueueDepth uint32
+	// PriorityFunc decides which of the queued batches goes next to a worker, higher values go first.
+	// Batches with the same priority are dispatched in reading order.
+	//
+	// The head slice holds the batch data and must not be retained.
+	//
+	// This member is optional, it is only used when QueueDepth is set.
+	PriorityFunc func(meta BatchMeta, head []byte) int
+	// Ordered delivers the processed batches to OnDone in stream order, while they are still processed concurrently.
+	// A batch processed before the previous ones is held along with its buffer until they are delivered, and it
+	// keeps its worker, so at most Workers batches are held. It cannot be combined with PriorityFunc.
+	//
+	// This member is optional. Default value false
+	Ordered bool
+	// Output receives the processed batches in stream order, like OnDone, so the batches changed by the worker are
+	// written back in the order they were read. A worker that replaces the slice of the buffer, like TransformFunc,
+	// writes the new slice. It implies Ordered, and a failed write stops the reading like a failed batch.
+	//
+	// This member is optional.
+	Output io.Writer
+	// TransformFunc replaces each batch with the slice it returns, see Output.
+	//
+	// This member is optional. When it is set the other worker functions are ignored
+	TransformFunc func(ctx context.Context, buffer *[]byte) ([]byte, error)
+	// OnDone receives each processed batch in stream order, see Ordered. The calls are serialized, and they stop
+	// once a batch failed. The batch data is only valid until OnDone returns.
+	//
+	// This member is optional, it is only used when Ordered is set.
+	OnDone func(ctx context.Context, batch Batch)
+	// ConfigFor returns the settings used to eat each file found by EatFS, EatDir and EatTar, so files of different
+	// formats can be processed in a single call. Settings without any worker function inherit the worker functions
+	// of the base settings, along with WorkerInit and WorkerClose.
+	//
+	// This member is optional. By default every file is eaten with the base settings
+	ConfigFor func(path string, info fs.FileInfo) (Bread, error)
+	// EntryFilter selects the regular files of the archive eaten by EatTar, the entries it returns false for are
+	// skipped without being read
+	//
+	// This member is optional. By default every regular file is eaten
+	EntryFilter func(header *tar.Header) bool
+	// FailFast stops every reader of EatAll once one of them failed, by default the other readers go on
+	// and the errors of every reader are joined.
+	//
+	// This member is optional. Default value false
+	FailFast bool
+	// CancelMode selects what happens to the batches being processed when ctx is done. In every mode the reading
+	// stops, the queued batches are dropped and Eat waits for the running workers before returning ctx.Err().
+	// With CancelAbort their context is cancelled, with CancelDrain it is not, so they run to completion.
+	//
+	// This member is optional. Default value CancelAbort
+	CancelMode CancelMode
+	// FinalFunc is called exactly once after every worker finished and the Sink was flushed, before Eat returns,
+	// even when some batches failed. It is not called when the settings are invalid. leftover holds the bytes
+	// read but not delivered to any worker, that is the end of the last batch when MaxRecords is reached.
+	// Its error is joined to the error returned by Eat.
+	//
+	// This member is optional.
+	FinalFunc func(ctx context.Context, leftover []byte, stats Stats) error
+	// CheckpointFunc receives the offset up to which every batch was processed without errors, whenever it advances.
+	// The batches complete out of order, so the offset only moves past a batch once the previous ones completed too.
+	// The calls are coalesced and made from a single goroutine, a last call is made once every worker finished if
+	// the offset advanced since the previous one. Its error stops the reading like a failed batch, and Eat returns it.
+	//
+	// The offsets are the ones of BatchMeta.Offset, so a reading resumed from a checkpoint gets offsets relative to it,
+	// unless it is resumed with StartOffset.
+	//
+	// This member is optional. It cannot be combined with Epochs nor ShuffleBuffer
+	CheckpointFunc func(ctx context.Context, offset int64) error
+	// StartOffset is the offset of the stream where the reading starts, like the last checkpoint of an interrupted
+	// reading. Like BatchMeta.Offset, it counts from the position of the reader when the reading starts, so a reader
+	// that implements io.Seeker is moved forward by StartOffset, unless it goes through the Decompressor or
+	// WrapReader, otherwise the bytes before it are read and discarded. BatchMeta.Offset, the checkpoints and the
+	// positions of the errors refer to the whole stream, while Stats.BytesRead does not count the bytes skipped.
+	// ExpectedDigest and Digest only cover the bytes from StartOffset, or from the delimiter before it with
+	// AlignToDelimiter, unless the reader goes through the Decompressor or WrapReader and the whole source is read.
+	//
+	// This member is optional. It must not be negative, and it cannot be combined with Epochs nor SkipLines.
+	// Default value 0
+	StartOffset int64
+	// AlignToDelimiter makes the reading resumed at StartOffset start after the next delimiter, unless StartOffset
+	// is already at the start of a record, so a StartOffset in the middle of a record does not deliver the end of
+	// it. The offset of the first batch is reported by Stats.StartOffset.
+	//
+	// This member is optional. It only applies to FramingDelimiter, without RecordSize, SplitFunc, CSVMode nor
+	// DetectDelimiter. Default value false
+	AlignToDelimiter bool
+	// Observer receives the events of the Eat call, along with OnStart, OnEpoch, OnBatchEnd and OnComplete
+	//
+	// This member is optional.
+	Observer Observer
+	// OnStart is called exactly once before the first read, after the validation and the defaults were applied.
+	// It receives the effective settings. With DetectDelimiter it is called once the delimiter was picked out of
+	// the leading bytes, before the first batch is dispatched.
+	//
+	// This member is optional.
+	OnStart func(ctx context.Context, effective Config)
+	// OnComplete is called exactly once when the processing ends, no matter how it ended, after every worker finished.
+	// It receives the final statistics and the error that Eat is going to return.
+	//
+	// This member is optional.
+	OnComplete func(ctx context.Context, stats Stats, err error)
+	// OnEpoch is called at the end of each pass over the reader with its counts, see Epochs.
+	// It is called from the reading goroutine, while the workers may still process the last batches of the epoch.
+	//
+	// This member is optional.
+	OnEpoch func(ctx context.Context, epoch uint32, stats EpochStats)
+	// OnBatchStart is called exactly once before a worker processes a batch, after Observer.OnBatchStart.
+	// The context it returns is passed to the worker and to OnBatchEnd, like the context of a tracing span.
+	// It is called from the worker goroutines.
+	//
+	// This member is optional.
+	OnBatchStart func(ctx context.Context, meta BatchMeta) context.Context
+	// OnBatchEnd is called exactly once after the last attempt to process a batch, with the error it failed with.
+	// It is called from the worker goroutines.
+	//
+	// This member is optional.
+	OnBatchEnd func(ctx context.Context, meta BatchMeta, err error)
+	// Framing selects how the stream is cut into records
+	//
+	// This member is optional. Default value FramingDelimiter
+	Framing Framing
+	// DocumentSeparator is the separator line used by FramingDocuments
+	//
+	// This member is optional. Default value DefaultDocumentSeparator
+	DocumentSeparator string
+	// SyslogNonTransparent makes FramingSyslog accept messages terminated by a line feed (non-transparent framing)
+	// when they do not start with a digit, the line feed is excluded from the message
+	//
+	// This member is optional. Default value false
+	SyslogNonTransparent bool
+	// MaxRecordSize is the maximum size of a record. With FramingDelimiter and AlignForward it bounds the record
+	// completed past BufferSize at the end of each batch, delimiter excluded, so a missing delimiter fails with
+	// a *RecordTooLargeError instead of reading the rest of the stream into memory.
+	//
+	// This member is optional. It must not be negative. For FramingSyslog the default value is
+	// DefaultMaxSyslogLength, otherwise the default value is 0 (no limit)
+	MaxRecordSize int
+	// CoalesceMessages makes EatFunc join the messages in batches of about BufferSize bytes, each message followed
+	// by the Delimiter, instead of dispatching one message per batch
+	//
+	// This member is optional. Default value false
+	CoalesceMessages bool
+	// MergeUnique makes Merge write a single record out of each group of records that compare equal
+	//
+	// This member is optional. Default value false
+	MergeUnique bool
+	// TruncateRecordsAt is the maximum length of a record, delimiter excluded. Longer records are cut at that
+	// length and the rest of the record is discarded up to the next delimiter, a chunk at a time so it is never held
+	// in memory. The truncated records keep their delimiter, and they are reported to OnTruncate.
+	// It only applies to FramingDelimiter, the batches are completed up to the next delimiter like AlignForward.
+	//
+	// This member is optional. It must not be negative. Default value 0 (no limit)
+	TruncateRecordsAt int
+	// OnTruncate is called from the reading goroutine for each record cut by TruncateRecordsAt, with the stream
+	// offset of the record and its original length, delimiter included
+	//
+	// This member is optional.
+	OnTruncate func(offset int64, originalLen int64)
+	// SplitFunc finds the records of the stream instead of the Delimiter, like the split function of a
+	// bufio.Scanner: each call with a positive advance ends a record, the token is ignored and the records keep
+	// all their bytes. Batches are made of whole records up to about BufferSize bytes, a record longer than the
+	// buffer is read whole up to MaxRecordSize. The bytes the function does not claim at the end of the stream are
+	// delivered as the last record, and bufio.ErrFinalToken stops the reading after its record. Other errors fail
+	// with a *FramingError.
+	//
+	// It only applies to FramingDelimiter with AlignForward, without MaxBatchSize, TruncateRecordsAt, RecordSize
+	// nor ShuffleBuffer.
+	//
+	// This member is optional.
+	SplitFunc bufio.SplitFunc
+	// CSVMode ends the records at the line feeds outside the quoted fields of a CSV stream, following RFC 4180,
+	// so the fields with embedded line feeds are never split across batches. A quoted field longer than
+	// BufferSize is read whole like any record, up to MaxRecordSize. A stream that ends inside a quoted field fails
+	// with a *FramingError that wraps ErrUnterminatedQuote.
+	//
+	// This member is optional. When it is set SplitFunc is ignored, it has the same restrictions
+	CSVMode bool
+	// SkipLines is the number of lines at the start of the stream, like the header of a CSV file, that are not
+	// passed to the workers. They are consumed before the first batch, at the start of each epoch, and the offsets of
+	// the batches count them. A stream with fewer lines is not an error. The lines are bounded by MaxRecordSize.
+	//
+	// This member is optional. Default value 0
+	SkipLines uint32
+	// HeaderFunc receives each line skipped by SkipLines without its line ending, before any worker runs.
+	// Its error stops the Eat call before reading the batches. The line is only valid until HeaderFunc returns.
+	//
+	// This member is optional.
+	HeaderFunc func(ctx context.Context, line []byte) error
+	// TrimDelimiter excludes the delimiter that ends each batch from the data passed to the workers, so the last
+	// record of the batch does not end with it. The last record of a stream without a final delimiter is passed as
+	// it is. The BatchMeta still describes the bytes read, delimiter included. It only applies to FramingDelimiter,
+	// without SplitFunc, CSVMode nor RecordSize.
+	//
+	// This member is optional. Default value false
+	TrimDelimiter bool
+	// NormalizeCRLF replaces the "\r\n" line endings of the records with "\n" before the batches reach the workers,
+	// so the records of Windows and Unix producers look the same. Along with TrimDelimiter the whole line ending is
+	// excluded from the end of the batch. The carriage returns that are not followed by a line feed are kept.
+	// It only applies when the Delimiter is the line feed, with the same restrictions as TrimDelimiter, and the
+	// batches are read into pooled buffers so the input of EatBytes is not modified.
+	//
+	// This member is optional. Default value false
+	NormalizeCRLF bool
+	// OnDecodeError receives the records that EatTyped failed to decode, with their stream offset, so they are
+	// skipped instead of failing their batch. The record is only valid until OnDecodeError returns, and the calls are
+	// concurrent.
+	//
+	// This member is optional, it is only used by EatTyped.
+	OnDecodeError func(offset int64, record []byte, err error)
+	// RecordSize is the size of the records of a binary stream without delimiters, every batch holds a whole
+	// number of records. The buffers are filled up to BufferSize rounded down to a multiple of RecordSize, no matter
+	// how short the reads of the reader are. A partial record at the end of the stream fails with
+	// a *TrailingRecordError once the previous records were dispatched, see AllowTrailing.
+	//
+	// It must not be larger than BufferSize, and it only applies to FramingDelimiter with AlignForward, without
+	// MaxBatchSize, TruncateRecordsAt nor ShuffleBuffer. The Delimiter is ignored.
+	//
+	// This member is optional. It must not be negative. Default value 0 (delimited records)
+	RecordSize int
+	// AllowTrailing delivers the partial record at the end of a RecordSize stream as the end of the last batch,
+	// instead of failing
+	//
+	// This member is optional. Default value false
+	AllowTrailing bool
+	// MaxRecords is the number of records to process before stopping, the batch that reaches the limit
+	// is truncated right after the last allowed record.
+	//
+	// This member is optional. Default value 0 (no limit)
+	MaxRecords uint64
+	// SampleEvery delivers only one of every SampleEvery records to the workers, the ones numbered SampleEvery,
+	// 2*SampleEvery and so on. The records are sampled one by one with RecordFunc, otherwise whole batches are
+	// sampled by their position in the stream. The records left out are still read, so the offsets and checkpoints
+	// stay the same, and the skipped batches still reach OnDone and Output unchanged.
+	// EatChan, EatBytes and the iterators are not sampled.
+	//
+	// This member is optional. Default value 0 (every record)
+	SampleEvery uint64
+	// SampleRate is the fraction of records, or batches, delivered to the workers like SampleEvery, but chosen at
+	// random. The choice only depends on SampleSeed and the record number, so the runs are reproducible.
+	// It must be between 0 and 1, and it cannot be combined with SampleEvery.
+	//
+	// This member is optional. Default value 0 (every record)
+	SampleRate float64
+	// SampleSeed is the seed of the random choice of SampleRate
+	//
+	// This member is optional. Default value 0
+	SampleSeed int64
+	// BytesPerSecond bounds the rate at which the reader is read, so a large input does not starve the other
+	// consumers of a shared resource fed by the workers. The batches are dispatched as they are read, so the workers
+	// get them at the same rate. The bytes of each read are paid before the next one, and the waits end as soon as
+	// the context is done. EatBytes and EatFunc ignore it.
+	//
+	// This member is optional. Default value 0 (no limit)
+	BytesPerSecond int64
+	// InterruptibleRead lets the cancellation of the context interrupt a read that is blocked, like the read of
+	// a network connection or a pipe without data, so Eat returns the context error right away. The readers with a
+	// SetReadDeadline(time.Time) error method, like net.Conn and *os.File, get a deadline in the past on cancellation.
+	// The reads of any other reader run on a helper goroutine, into a buffer of its own, and the read still pending
+	// on cancellation is abandoned: its goroutine ends when the reader returns, like when it is closed.
+	// EatBytes and EatFunc ignore it.
+	//
+	// This member is optional.
+	InterruptibleRead bool
+	// FlushInterval bounds the time the records already read wait for the rest of their batch, for the readers that
+	// trickle data like pipes and sockets. Once FlushInterval elapsed since the last batch was dispatched, the batch
+	// being read is dispatched as soon as it holds a whole record, the bytes of the next record start the next batch.
+	// With RecordSize every whole record goes, and with AlignBackward, or any Framing other than FramingDelimiter,
+	// the batches are dispatched as soon as they are read anyway. The reads run on their own goroutine, a read
+	// still blocked when Eat returns keeps it until the read ends, see InterruptibleRead.
+	// It does not apply to MaxBatchSize nor TruncateRecordsAt, and SyncThreshold is ignored.
+	//
+	// This member is optional. Default value 0 (wait for BufferSize)
+	FlushInterval time.Duration
+	// MinFill keeps reading into the buffer of a batch until it holds MinFill bytes, or the stream ends, before the
+	// batch is aligned to the delimiter and dispatched, so the short reads of network readers do not end up in tiny
+	// batches. The reads are accumulated in the pooled buffer, no matter how few bytes each one returns. The context
+	// is checked between the reads, and with FlushInterval the batch goes once it holds a whole record and the
+	// reader is idle. It applies to FramingDelimiter; RecordSize, SplitFunc and CSVMode fill the buffers anyway.
+	//
+	// This member is optional. It must not be negative. Values above BufferSize fill the whole buffer.
+	// Default value 0 (a single read)
+	MinFill int
+	// Follow keeps reading the reader past its end, like tail -f, so the records appended to a file while it is
+	// eaten are delivered too. The end of the stream is read again every PollInterval, and the reading only ends
+	// when the context is done, then Eat returns the context error. The whole records read are dispatched at each
+	// poll, see FlushInterval, and the record still incomplete when the context is done is dropped unless
+	// AllowPartialOnCancel is set. Along with CheckpointFunc the offsets tell where to resume the file.
+	//
+	// This member is optional. Default value false
+	Follow bool
+	// PollInterval is the time Follow waits at the end of the stream before reading it again
+	//
+	// This member is optional. Default value DefaultPollInterval
+	PollInterval time.Duration
+	// AllowPartialOnCancel makes the cancellation of the context end a followed stream like its end, so the record
+	// still incomplete is dispatched as the last record. The workers get it with a cancelled context unless
+	// CancelMode is CancelDrain.
+	//
+	// This member is optional. Default value false
+	AllowPartialOnCancel bool
+	// ContinueOnError skips the corrupt segments of the stream instead of failing, so one bad record does not stop
+	// a long reading. The errors of the reader, of the Decompressor and the records larger than MaxRecordSize are
+	// passed to OnError, then the record being read is dropped and the reading goes on from the next delimiter.
+	// The Decompressor is started again on the rest of the source, skipping a byte each time it fails, so a stream
+	// of gzip members resumes at the next member. Once the reading ends Eat returns a *SkipError that summarizes
+	// the skipped segments, unless it failed. The records are only passed on once their delimiter is read, and the
+	// offsets of the batches do not count the skipped bytes.
+	//
+	// This member is optional. It only applies to FramingDelimiter, without RecordSize, SplitFunc, CSVMode,
+	// Epochs nor CheckpointFunc. Default value false
+	ContinueOnError bool
+	// OnError receives each corrupt segment skipped by ContinueOnError, with its stream offset, the bytes of the
+	// record read so far and the error. The bytes are only valid until OnError returns. An error stops the reading
+	// and Eat returns it.
+	//
+	// This member is optional. Without it every corrupt segment is skipped
+	OnError func(ctx context.Context, offset int64, data []byte, err error) error
+	// CloseReader makes Eat close the reader once it returns, when the reader is an io.Closer like *os.File or
+	// http.Response.Body, whether the stream was read to the end, failed or was cancelled. The error of Close is
+	// joined with the error of Eat, and the reader is closed before FinalFunc and OnComplete, so they see it too.
+	// The ownership of the reader is transferred to Eat, so the caller must not close it again. Count, Batches,
+	// EatBytes and EatFunc ignore it.
+	//
+	// This member is optional. Default value false
+	CloseReader bool
+	// RetryRead decides whether a failed read is retried after the given backoff, attempt counts the consecutive
+	// failures of the same read from 1. The read is retried on the same reader, so it continues from the point where
+	// the failed read left it, and the bytes read before the failure are kept. The waits end as soon as the context
+	// is done. io.EOF, io.ErrUnexpectedEOF and the context errors are never retried. EatBytes and EatFunc ignore it.
+	//
+	// This member is optional. See RetryReads
+	RetryRead func(err error, attempt int) (retry bool, backoff time.Duration)
+	// MaxBatches is the number of batches to dispatch before stopping, the reading stops right after the batch that
+	// reaches the limit and the batches already dispatched are processed. Like MaxRecords, it limits the batches of
+	// all the epochs together and Eat returns nil.
+	//
+	// This member is optional. Default value 0 (no limit)
+	MaxBatches uint64
+	// LimitBytes is the number of bytes to read before stopping, like MaxRecords. The limit is checked once each batch
+	// is aligned to the records, so the last batch still ends on a record boundary and slightly more than LimitBytes
+	// may be read, about a batch more. The reader itself may be read further ahead by the internal buffering.
+	// With Epochs it limits the bytes of all the epochs together.
+	//
+	// This member is optional. Default value 0 (no limit)
+	LimitBytes int64
+	// FailOnLimit makes Eat return ErrLimitReached when LimitBytes stopped the reading, by default it returns nil
+	//
+	// This member is optional. Default value false
+	FailOnLimit bool
+	// Align selects how batches are aligned to the delimiter
+	//
+	// This member is optional. Default value AlignForward
+	Align Align
+	// Pool provides the buffers, like a pool shared with other Bread instances and Eat calls, see NewBufferPool.
+	// Every buffer taken with Get is returned with Put, except the ones still held by the worker calls abandoned
+	// by FailOnBatchTimeout. The pools that report their buffer size with a Size method must match BufferSize.
+	// Each Eat call seeds it with BufferSeed buffers, and PoolStrategy and MaxBufferCap are ignored.
+	//
+	// This member is optional. By default each Eat call uses its own pool
+	Pool BufferPool
+	// PoolStrategy selects the implementation of the buffer pool
+	//
+	// This member is optional. Default value PoolSync
+	PoolStrategy PoolStrategy
+	// FreelistSize is the number of buffers held by the PoolFreelist strategy
+	//
+	// This member is optional. Default value Workers + QueueDepth + 1
+	FreelistSize uint32
+	// FreelistAllocate makes the PoolFreelist strategy allocate a buffer when all of them are in use,
+	// instead of blocking the reading until a worker returns one
+	//
+	// This member is optional. Default value false
+	FreelistAllocate bool
+	// MaxBufferCap is the maximum capacity of the buffers returned to the pool. The complement of a large record
+	// grows the buffer of its batch, so bigger buffers are released instead of being reused, otherwise the memory
+	// held by the pool would only grow. It is ignored when Pool is set.
+	//
+	// This member is optional. It must not be negative. Default value 4 * BufferSize, or 4 * MaxBufferSize with
+	// AdaptiveBuffer
+	MaxBufferCap int
+	// AdaptiveBuffer adjusts the size of the reads to the average length of the records, so a batch holds about
+	// adaptiveRecords of them and the complement of the last record stays small. BufferSize is the size of the first
+	// reads. The pooled buffers smaller than the chosen size are replaced as they come back, see Stats.BufferSize.
+	//
+	// It only applies to the batches read from an io.Reader with FramingDelimiter and AlignForward, without
+	// MaxBatchSize nor TruncateRecordsAt, and not to the inputs processed inline, see SyncThreshold.
+	//
+	// This member is optional.
+	AdaptiveBuffer bool
+	// MinBufferSize is the lower bound of the reads of AdaptiveBuffer
+	//
+	// This member is optional. It must not be negative. Default value BufferSize
+	MinBufferSize int
+	// MaxBufferSize is the upper bound of the reads of AdaptiveBuffer, it must not be smaller than MinBufferSize
+	//
+	// This member is optional. It must not be negative. Default value 64 * BufferSize
+	MaxBufferSize int
+	// Tracker exposes the progress of the Eat call, see Tracker.Progress
+	//
+	// This member is optional.
+	Tracker *Tracker
+	// OnProgress receives a snapshot of the progress after the batches complete, or every ProgressInterval when it
+	// is set, and once more with the final counters right before Eat returns. It is called from a single goroutine,
+	// a slow OnProgress skips the snapshots of the batches completed meanwhile instead of delaying the workers.
+	//
+	// This member is optional.
+	OnProgress func(p Progress)
+	// ProgressInterval is the time between the calls to OnProgress
+	//
+	// This member is optional. Default value 0 (after the batches complete)
+	ProgressInterval time.Duration
+	// Logger receives the warnings about misconfigurations detected at runtime, see Heuristics. At the debug level
+	// it also receives the events of the reading: the reader wrappers, each read, the complements, the buffers taken
+	// from the pool, the dispatched and processed batches and the reason the Eat call ended. Those events do not
+	// allocate when the debug level is disabled.
+	//
+	// This member is optional.
+	Logger *slog.Logger
+	// Heuristics tunes the detection of misconfigurations, it is only used when Logger is set
+	//
+	// This member is optional.
+	Heuristics Heuristics
+
+	// slots is a worker budget shared with other Eat calls, see EatFS
+	slots chan struct{}
+	// memory is a MaxMemory budget shared with other Eat calls, see EatFS
+	memory *semaphore.Weighted
+	// sourceIndex is the position of the reader in the readers given to EatAll, see BatchMeta.Source
+	sourceIndex int
+	// closer closes the reader of the Eat call, see CloseReader
+	closer *readerCloser
+}
+
+// readerCloser closes the reader of an Eat call once, see CloseReader
+type readerCloser struct {
+	io.Closer
+	closed bool
+}
+
+// close closes the reader unless it was already closed
+func (c *readerCloser) close() error {
+	if c == nil || c.closed {
+		return nil
+	}
+
+	c.closed = true
+
+	return c.Closer.Close()
+}
+
+// ScaleDeadline builds a WorkerDeadline that grants perKB for each kilobyte of the batch,
+// bounded by floor and ceiling
+func ScaleDeadline(perKB, floor, ceiling time.Duration) func(BatchMeta) time.Duration {
+	return func(meta BatchMeta) time.Duration {
+		deadline := time.Duration(meta.Size) * perKB / (1 << 10)
+
+		return min(max(deadline, floor), ceiling)
+	}
+}
+
+// New applies the options to the settings, in order, validates them and returns a Bread that keeps its buffer pool
+// across the Eat calls, so the buffers and the BufferSeed reservation of a call are reused by the next ones.
+// It is safe for concurrent Eat calls.
+//
+// When Pool is set it is kept as it is, otherwise PoolStrategy is ignored and the pool is built like a PoolSync one.
+// A Bread built without New still works, with a pool of its own for each Eat call.
+func New(cfg Bread, opts ...Option) (*Bread, error) {
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	if cfg.Pool == nil {
+		buffers := pool.NewBuffers(cfg.BufferSize, cfg.BufferSize*2)
+		buffers.MaxCap = cfg.maxBufferCap()
+		buffers.Seed(cfg.BufferSeed)
+
+		cfg.Pool = &SharedPool{buffers: buffers, size: cfg.BufferSize}
+	}
+
+	return &cfg, nil
 }
 
 // Eat
 //
+// When the context is done the reading stops, the batches not handed to a worker yet are dropped and Eat returns
+// the context error once the running workers finished.
+//
 // NOTE: if the file does not have an end line the file will read completely
-func (b Bread) Eat(ctx context.Context, reader io.Reader) (err error) {
+func (b Bread) Eat(ctx context.Context, reader io.Reader) error {
+	return b.eat(ctx, reader, b.worker())
+}
+
+// worker returns the worker that passes the batches to the Sink or to the first worker function set,
+// sampled by batch unless the records are sampled by RecordFunc
+func (b Bread) worker() func(context.Context, Batch) error {
 	switch {
-	case reader == nil:
+	case b.Sink != nil:
+		return b.sampleBatches(func(ctx context.Context, batch Batch) error {
+			return b.Sink.Write(ctx, batch.BatchMeta, batch.Data)
+		})
+	case b.TransformFunc != nil:
+		return b.sampleBatches(func(ctx context.Context, batch Batch) error {
+			data, err := b.TransformFunc(ctx, batch.buffer)
+			if err != nil {
+				return err
+			}
+
+			*batch.buffer = data
+			return nil
+		})
+	case b.WorkerStateFunc != nil:
+		return b.sampleBatches(func(ctx context.Context, batch Batch) error {
+			return b.WorkerStateFunc(ctx, batch.state, batch.buffer)
+		})
+	case b.WorkerErrFunc != nil:
+		return b.sampleBatches(func(ctx context.Context, batch Batch) error {
+			return b.WorkerErrFunc(ctx, batch.buffer)
+		})
+	case b.RecordFunc != nil:
+		return b.recordWorker()
+	case b.WorkerBatchFunc != nil:
+		return b.sampleBatches(batchWorker(b.WorkerBatchFunc))
+	case b.WorkerFunc != nil:
+		// Adapter in charge of passing the pooled buffer to the v1 worker
+		return b.sampleBatches(func(ctx context.Context, batch Batch) error {
+			b.WorkerFunc(ctx, batch.buffer)
+			return nil
+		})
+	}
+
+	return nil
+}
+
+// EatBatches works like Eat, but each batch is passed by value to the worker along with its position in the stream.
+//
+// The batch data is only valid until the worker returns, see Batch.Detach.
+//
+// NOTE: WorkerFunc and Sink are ignored by EatBatches
+func (b Bread) EatBatches(ctx context.Context, reader io.Reader, worker func(context.Context, Batch)) error {
+	b.Sink = nil
+
+	return b.eat(ctx, reader, b.sampleBatches(batchWorker(worker)))
+}
+
+// batchWorker adapts a worker that does not fail
+func batchWorker(worker func(context.Context, Batch)) func(context.Context, Batch) error {
+	if worker == nil {
+		return nil
+	}
+
+	return func(ctx context.Context, batch Batch) error {
+		worker(ctx, batch)
+		return nil
+	}
+}
+
+func (b Bread) eat(ctx context.Context, reader io.Reader, worker func(context.Context, Batch) error) (err error) {
+	if reader == nil {
 		return ErrNilReader
-	case b.WorkerFunc == nil:
+	}
+
+	if closer, ok := reader.(io.Closer); ok && b.CloseReader {
+		b.closer = &readerCloser{Closer: closer}
+
+		// The reader is closed before the completion hooks run, unless Eat failed before
+		defer func() {
+			if closeErr := b.closer.close(); closeErr != nil {
+				err = errors.Join(err, closeErr)
+			}
+		}()
+	}
+
+	// The decompressed or decoded data cannot be read again
+	seeker, seekable := reader.(io.Seeker)
+	if b.Epochs > 1 && (!seekable || b.Decompressor != nil || b.WrapReader != nil) {
+		return ErrNotSeekable
+	}
+
+	if b.DetectSize && b.SizeHint == 0 {
+		b.SizeHint = detectSize(reader)
+	}
+
+	return b.feed(ctx, worker, func(ctx context.Context, e *eater) (err error) {
+		if e.StartOffset > 0 {
+			if err := e.seekStart(reader); err != nil {
+				return err
+			}
+		}
+
+		// The offsets of the decompressed or decoded data do not match the source
+		if e.RereadOnRetry && e.Decompressor == nil && e.WrapReader == nil {
+			e.source, e.base = rereadSource(reader)
+
+			// The offsets refer to the whole stream, see StartOffset
+			e.base -= e.offset
+		}
+
+		if e.InterruptibleRead {
+			interruptible := e.interruptible(ctx, reader)
+			defer interruptible.stop()
+
+			reader = interruptible
+			e.debugWrapped(ctx, "interruptible")
+		}
+
+		if e.RetryRead != nil {
+			reader = &retryReader{ctx: ctx, r: reader, retry: e.RetryRead}
+			e.debugWrapped(ctx, "retry")
+		}
+
+		if e.BytesPerSecond > 0 {
+			reader = e.throttle(ctx, reader)
+			e.debugWrapped(ctx, "throttle")
+		}
+
+		// The bytes before StartOffset are not hashed, unlike the source of the decompressed or decoded data
+		if e.Decompressor == nil && e.WrapReader == nil {
+			if err := e.skipBytes(reader); err != nil {
+				return err
+			}
+		}
+
+		digest := e.digest(reader)
+		if digest != nil {
+			reader = digest
+			e.debugWrapped(ctx, "digest")
+		}
+
+		if e.Decompressor != nil {
+			decompressor, err := e.decompress(reader)
+			if err != nil {
+				return err
+			}
+
+			defer func() {
+				err = errors.Join(err, decompressor.Close())
+			}()
+
+			reader = decompressor
+			e.debugWrapped(ctx, "decompressor")
+		}
+
+		if e.WrapReader != nil {
+			wrapped, err := e.WrapReader(reader)
+			if err != nil {
+				return err
+			}
+
+			// The batches do not depend on the size of the decoded reads
+			reader = fullReader{wrapped}
+			e.debugWrapped(ctx, "WrapReader")
+		}
+
+		reader = e.debugReader(ctx, reader)
+
+		if e.Epochs > 1 {
+			err = e.readEpochs(ctx, reader, seeker)
+		} else {
+			err = e.read(ctx, reader)
+		}
+
+		if digest == nil || err != nil {
+			e.digestSkipped = digest != nil && digest.hash != nil
+			return err
+		}
+
+		return e.verify(ctx, digest)
+	})
+}
+
+// feed runs the workers over the batches dispatched by read
+func (b Bread) feed(ctx context.Context, worker func(context.Context, Batch) error, read func(context.Context, *eater) error) (err error) {
+	if worker == nil {
 		return ErrMissingWorkerFunc
-	case b.BufferSize == 0:
-		return ErrMissingBufferSize
 	}
 
-	if b.Delimiter == 0 {
-		b.Delimiter = DefaultDelimiter
+	if err = b.Validate(); err != nil {
+		return
+	}
+
+	b = b.delimiters()
+
+	// Each Eat call keeps the state of its own rows
+	if b.CSVMode {
+		b.SplitFunc = new(csvSplitter).split
 	}
 
 	if b.Workers == 0 {
 		b.Workers = DefaultWorkers
+
+		switch {
+		case b.ScaleWorkers:
+			b.Workers = ScaledWorkersPerCPU * runtime.GOMAXPROCS(0)
+		case b.MaxMemory > 0 || b.AutoWorkers:
+			b.Workers = runtime.GOMAXPROCS(0)
+		}
 	}
 
-	// Worker settings
-	wg := sync.WaitGroup{}
-	workerCh := make(chan struct{}, b.Workers)
+	e := &eater{
+		Bread:     b,
+		worker:    worker,
+		workerCh:  b.slots,
+		batches:   make(chan Batch),
+		observer:  b.observer(),
+		counters:  b.Tracker,
+		separator: b.separator(),
+	}
 
-	// Object pool in charge of handling buffers
-	pool := sync.Pool{
-		New: func() any {
-			buffer := make([]byte, b.BufferSize, b.BufferSize*2)
-			return &buffer
-		},
+	if e.workerCh == nil {
+		e.workerCh = make(chan struct{}, b.Workers)
+	}
+
+	if e.counters == nil {
+		e.counters = new(Tracker)
+	}
+
+	if b.ShuffleBuffer > 0 {
+		e.shuffle = newShuffler(b.ShuffleSeed)
+	}
+
+	e.memory = b.memory
+	if e.memory == nil && b.MaxMemory > 0 {
+		e.memory = semaphore.NewWeighted(b.MaxMemory)
+	}
+
+	e.readSize = b.BufferSize
+	if b.AdaptiveBuffer {
+		e.readSize = min(max(b.BufferSize, b.minBufferSize()), b.maxBufferSize())
+	}
+
+	if b.Ordered || b.Output != nil {
+		e.order = newOrderer()
 	}
 
+	// Object pool in charge of handling buffers
+	e.pool = countedPool{bufferPool: b.newPool(), gets: &e.gets, ctx: ctx, logger: b.Logger}
+
+	// Allocations made before this call by a shared pool
+	e.allocations = e.pool.Allocations()
+
 	// Initial reservation of available buffer instances in the object pool
-	for seed := b.BufferSeed; seed > 0; seed-- {
-		pool.Put(pool.New())
+	e.pool.Seed(b.BufferSeed)
+
+	e.retention = &retention{pool: e.pool, memory: e.memory}
+
+	return e.run(ctx, read)
+}
+
+// delimiters applies the default Delimiter, a DelimiterBytes of a single byte is used as the Delimiter.
+// The default only applies when both are unset, so DelimiterBytes selects the zero byte.
+func (b Bread) delimiters() Bread {
+	if len(b.DelimiterBytes) == 1 {
+		b.Delimiter, b.DelimiterBytes = b.DelimiterBytes[0], nil
+		return b
+	}
+
+	if b.Delimiter == 0 && len(b.DelimiterBytes) == 0 {
+		b.Delimiter = DefaultDelimiter
+	}
+
+	return b
+}
+
+// separator returns the sequence that ends the records, see DelimiterBytes
+func (b Bread) separator() []byte {
+	if len(b.DelimiterBytes) > 0 {
+		return b.DelimiterBytes
+	}
+
+	return []byte{b.Delimiter}
+}
+
+// run dispatches the batches read by read and waits until every worker finished
+func (e *eater) run(ctx context.Context, read func(context.Context, *eater) error) error {
+	// The first failed batch cancels the workers still running, see fail
+	readCtx, workCtx, cancel := e.contexts(ctx)
+	e.cancel = cancel
+	e.workCtx = workCtx
+
+	e.counters.reset()
+	e.counters.size.Store(e.SizeHint * int64(max(e.Epochs, 1)))
+
+	if err := e.startWorkers(ctx, workCtx); err != nil {
+		cancel()
+		return e.finish(ctx, err)
+	}
+
+	e.startPartitions()
+
+	e.startReporter()
+	e.startCheckpoints(ctx)
+	e.startScaler()
+
+	// The delimiter is reported once it is detected, see DetectDelimiter
+	if !e.DetectDelimiter {
+		e.notifyStart(ctx)
+	}
+
+	e.startDispatcher(readCtx)
+
+	err := read(readCtx, e)
+
+	// The reading stopped early, see dispatch
+	if err == nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
+
+	if e.shuffle != nil && err == nil && !e.failed.Load() && readCtx.Err() == nil {
+		e.drain(readCtx)
+	}
+
+	if err == nil && e.limited && e.FailOnLimit {
+		err = ErrLimitReached
+	}
+
+	// The queued batches may need the inactive workers
+	e.stopScaler()
+	e.stopDispatcher()
+	close(e.batches)
+	e.wg.Wait()
+	e.stopPartitions()
+	cancel()
+
+	// The running workers saw the cancellation, even when the reading had already ended
+	if err == nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
+
+	if e.order != nil {
+		e.discard()
+	}
+
+	e.stopCheckpoints(ctx)
+
+	e.mu.Lock()
+	err = errors.Join(append([]error{err}, e.errs...)...)
+	e.mu.Unlock()
+
+	err = e.finish(ctx, err)
+
+	e.stopReporter()
+
+	return err
+}
+
+// notifyStart calls OnStart with the effective settings, once
+func (e *eater) notifyStart(ctx context.Context) {
+	if e.started {
+		return
+	}
+
+	e.started = true
+	e.observer.OnStart(ctx, e.config())
+}
+
+// finish flushes the Sink and calls FinalFunc and OnComplete once the Eat call ended, it returns the final error
+func (e *eater) finish(ctx context.Context, err error) error {
+	// The reading failed before the delimiter was detected
+	e.notifyStart(ctx)
+
+	if closeErr := e.closer.close(); closeErr != nil {
+		err = errors.Join(err, closeErr)
+	}
+
+	if e.Sink != nil {
+		err = errors.Join(err, e.flush(ctx, err))
+	}
+
+	if e.FinalFunc != nil {
+		err = errors.Join(err, e.FinalFunc(ctx, e.leftover, e.stats()))
 	}
 
-	r := bufio.NewReader(reader)
-	n, complement := 0, make([]byte, 0)
+	e.observer.OnComplete(ctx, e.stats(), err)
+	e.debugEnded(ctx, err)
+
+	return err
+}
+
+// eater holds the state of a single Eat call
+type eater struct {
+	Bread
+	worker func(context.Context, Batch) error
+
+	// Object pool in charge of handling buffers
+	pool bufferPool
+
+	// Worker settings
+	wg       sync.WaitGroup
+	workerCh chan struct{}
+	// batches passes the batches to the worker goroutines, it is closed when the processing ends
+	batches chan Batch
+	// workers is the number of worker goroutines started, owned by the dispatching goroutine
+	workers int
+	// states holds the state of each worker, see WorkerInit
+	states []any
+
+	// Batches waiting for a worker, only used when QueueDepth is set
+	queue      *batchQueue
+	dispatched chan struct{}
+
+	// Errors reported by the batches, the first one stops the reading
+	mu     sync.Mutex
+	errs   []error
+	failed atomic.Bool
+
+	// cancel cancels the context of the workers, see fail
+	cancel context.CancelFunc
+	// workCtx is the context of the workers, it outlives the reading with CancelDrain
+	workCtx context.Context
+
+	// observer receives the events, see Bread.Observer
+	observer Observer
+
+	// Counters reported through Stats and Progress
+	counters    *Tracker
+	allocations uint64
+	// gets is the number of buffers taken from the pool, see Stats.Reuses
+	gets atomic.Uint64
+	// reporter calls OnProgress, see startReporter
+	reporter *reporter
+	// flusher stops the reads of the batches that wait for more bytes, see FlushInterval
+	flusher *flushReader
+	// scaler adjusts the active workers, see ScaleWorkers
+	scaler *scaler
+	// waits counts the batches that waited for a free worker, see ScaleWorkers
+	waits atomic.Uint64
+	// checkpoints tracks the processed batches for CheckpointFunc, see startCheckpoints
+	checkpoints *checkpointer
+
+	// Position of the next batch, owned by the reading goroutine
+	index  uint64
+	offset int64
+	// startOffset is the offset of the first batch and skip the bytes to discard before it, see StartOffset
+	startOffset int64
+	skip        int64
+	// positioned indicates that seekStart already ran, see read
+	positioned bool
+	// stopped indicates that a limit was reached, owned by the reading goroutine
+	stopped bool
+	// limited indicates that LimitBytes stopped the reading, owned by the reading goroutine
+	limited bool
+	// leftover are the bytes cut from the last batch when a limit was reached, see FinalFunc
+	leftover []byte
+	// forceSplit marks the next batch as force-split, owned by the reading goroutine
+	forceSplit bool
+
+	// Size of the next reads and average record length, see AdaptiveBuffer. Owned by the reading goroutine
+	readSize     int
+	recordLength float64
+	// resized is the number of buffers replaced for the adaptive size, owned by the reading goroutine
+	resized uint64
+
+	// separator ends the records, see DelimiterBytes
+	separator []byte
+
+	// Source used to re-read the failed batches, see RereadOnRetry
+	source io.ReaderAt
+	base   int64
+	// inline indicates that the workers run on the reading goroutine, see SyncThreshold
+	inline bool
+	// sliced indicates that the batches are slices of the input instead of pooled buffers, see readSlices
+	sliced bool
+	// owned indicates that the consumer releases the batches, see EatChan
+	owned bool
+	// epoch is the current pass over the reader, and epochBase the counts of the previous passes, see Epochs.
+	// They are owned by the reading goroutine
+	epoch     uint32
+	epochBase EpochStats
+	// shuffle holds the records waiting to be dispatched, see ShuffleBuffer
+	shuffle *shuffler
+	// order holds the processed batches waiting for the previous ones, see Ordered
+	order *orderer
+	// memory holds the size of the batches in flight, see MaxMemory
+	memory *semaphore.Weighted
+	// retention holds the buffers retained by the workers, see Batch.Retain
+	retention *retention
+	// partitions passes the records to RecordFunc by key, see PartitionFunc
+	partitions *partitioner
+	// detected is the delimiter picked by DetectDelimiter, see Stats.Delimiter
+	detected string
+	// started indicates that OnStart was called, see notifyStart
+	started bool
+	// digestSkipped indicates that ExpectedDigest was not compared, see Stats.DigestSkipped
+	digestSkipped bool
+}
+
+// dispatch hands the batch to a worker, or to the queue of pending batches if there is one
+func (e *eater) dispatch(ctx context.Context, batch Batch) {
+	batch.dispatched = time.Now()
+
+	if e.inline {
+		e.workInline(ctx, batch)
+		return
+	}
+
+	if !e.acquire(ctx, &batch) {
+		e.drop(batch)
+		return
+	}
+
+	if e.queue != nil {
+		e.queue.push(batch)
+		return
+	}
+
+	select {
+	case e.workerCh <- struct{}{}:
+	default:
+		// Every worker is busy
+		waiting := time.Now()
+		e.waits.Add(1)
 
-	for {
 		select {
+		case e.workerCh <- struct{}{}:
 		case <-ctx.Done():
+			e.drop(batch)
 			return
-		default:
 		}
 
-		buffer := pool.Get().(*[]byte)
+		e.observer.OnBackpressure(ctx, batch.BatchMeta, time.Since(waiting))
+	}
+
+	e.start(batch)
+}
+
+// drop releases a batch that is not handed to any worker because the context is done
+func (e *eater) drop(batch Batch) {
+	e.counters.batches.Add(^uint64(0))
+	e.counters.records.Add(-uint64(batch.Records))
+
+	e.release(batch.buffer)
+	e.free(batch)
+}
+
+// start hands the batch to a worker goroutine, the caller must have acquired a slot of workerCh.
+// The batch runs with the context of the workers, see CancelMode.
+//
+// The worker goroutines are started on demand, up to Workers, and wait for the next batch instead of exiting,
+// so the steady state does not allocate. Only the dispatching goroutine calls start.
+func (e *eater) start(batch Batch) {
+	if e.workers < e.Workers {
+		e.workers++
+		e.wg.Add(1)
+
+		go e.serve(e.workCtx, e.workers-1)
+	}
+
+	e.batches <- batch
+}
+
+// serve processes the batches until the processing ends, then it closes the state of the worker
+func (e *eater) serve(ctx context.Context, id int) {
+	defer e.wg.Done()
+
+	for batch := range e.batches {
+		if e.states != nil {
+			batch.state = e.states[id]
+		}
+
+		e.complete(ctx, batch)
+	}
+
+	if e.WorkerClose == nil {
+		return
+	}
+
+	// The state is released even if the processing was cancelled
+	err := e.WorkerClose(context.WithoutCancel(ctx), id, e.states[id])
+	if err != nil {
+		e.mu.Lock()
+		e.errs = append(e.errs, err)
+		e.mu.Unlock()
+	}
+}
+
+// startWorkers starts every worker goroutine before reading when the workers hold a state, see WorkerInit.
+// If any WorkerInit fails, the states already built are closed and no worker is started.
+func (e *eater) startWorkers(ctx, workCtx context.Context) error {
+	if e.WorkerInit == nil && e.WorkerClose == nil {
+		return nil
+	}
+
+	e.states = make([]any, e.Workers)
+	errs := make([]error, e.Workers)
+
+	if e.WorkerInit != nil {
+		wg := sync.WaitGroup{}
+
+		for id := range e.states {
+			wg.Add(1)
+
+			go func(id int) {
+				defer wg.Done()
+				e.states[id], errs[id] = e.WorkerInit(ctx, id)
+			}(id)
+		}
+
+		wg.Wait()
+	}
 
-		n, err = r.Read(*buffer)
-		if err != nil {
-			if err == io.EOF {
-				break
+	if err := errors.Join(errs...); err != nil {
+		for id, state := range e.states {
+			if errs[id] == nil && e.WorkerClose != nil {
+				err = errors.Join(err, e.WorkerClose(ctx, id, state))
 			}
+		}
 
-			return
+		return err
+	}
+
+	for id := range e.states {
+		e.wg.Add(1)
+
+		go e.serve(workCtx, id)
+	}
+
+	e.workers = len(e.states)
+
+	return nil
+}
+
+// pickUp records the queue age of a batch that is about to be processed, it returns the context of the batch
+func (e *eater) pickUp(ctx context.Context, batch Batch) context.Context {
+	age := time.Since(batch.dispatched)
+
+	e.counters.queueAges.observe(age)
+	e.observer.OnBatchStart(ctx, batch.BatchMeta, age)
+
+	if e.OnBatchStart != nil {
+		return e.OnBatchStart(ctx, batch.BatchMeta)
+	}
+
+	return ctx
+}
+
+// complete runs the worker over the batch and releases its resources
+func (e *eater) complete(ctx context.Context, batch Batch) {
+	batchCtx := e.pickUp(ctx, batch)
+
+	err := e.process(batchCtx, &batch)
+	if err != nil {
+		e.fail(err)
+	}
+
+	e.observer.OnBatchEnd(batchCtx, batch.BatchMeta, err)
+	e.debugProcessed(ctx, batch, err)
+
+	e.counters.completed.Add(1)
+	e.reporter.notify()
+
+	if err == nil {
+		e.checkpoints.complete(batch)
+	}
+
+	if e.order != nil {
+		e.done(ctx, batch)
+		return
+	}
+
+	// Detached buffers are owned by the worker, the pool drops them
+	e.recycle(batch)
+
+	<-e.workerCh
+}
+
+// release returns the buffer of a processed batch to the pool, unless it does not come from the pool
+func (e *eater) release(buffer *[]byte) {
+	// Abandoned buffers belong to their worker call
+	if !e.pooled() || buffer == nil {
+		return
+	}
+
+	e.pool.Put(buffer)
+}
+
+// pooled tells whether the buffers of the batches come from the pool. The sliced batches are not pooled, nor the
+// shuffled records, see shuffler, and the consumer of EatChan releases its batches.
+func (e *eater) pooled() bool {
+	return !e.sliced && e.shuffle == nil && !e.owned
+}
+
+// fail records a batch error
+func (e *eater) fail(err error) {
+	e.mu.Lock()
+	e.errs = append(e.errs, err)
+	e.mu.Unlock()
+
+	e.counters.failures.Add(1)
+	e.failed.Store(true)
+	e.cancel()
+}
+
+// startDispatcher starts the goroutine in charge of moving the queued batches to the workers
+func (e *eater) startDispatcher(ctx context.Context) {
+	if e.QueueDepth == 0 {
+		return
+	}
+
+	e.queue = newBatchQueue(int(e.QueueDepth), e.PriorityFunc)
+	e.dispatched = make(chan struct{})
+
+	go func() {
+		defer close(e.dispatched)
+
+		for {
+			// The queued batches are dropped once the context is done, so the reading is not blocked
+			if ctx.Err() != nil {
+				batch, ok := e.queue.pop()
+				if !ok {
+					return
+				}
+
+				e.drop(batch)
+				continue
+			}
+
+			select {
+			case e.workerCh <- struct{}{}:
+			default:
+				// Every worker is busy
+				e.waits.Add(1)
+
+				select {
+				case e.workerCh <- struct{}{}:
+				case <-ctx.Done():
+					continue
+				}
+			}
+
+			batch, ok := e.queue.pop()
+			if !ok {
+				<-e.workerCh
+				return
+			}
+
+			e.start(batch)
 		}
+	}()
+}
 
-		*buffer = (*buffer)[:n]
+// stopDispatcher waits until every queued batch was handed to a worker
+func (e *eater) stopDispatcher() {
+	if e.queue == nil {
+		return
+	}
 
-		complement, err = r.ReadBytes(b.Delimiter)
-		if err != nil && err != io.EOF {
-			return err
+	e.queue.close()
+	<-e.dispatched
+}
+
+// Validate checks the settings required to eat any io.Reader, it returns the same errors Eat returns for them.
+// The worker functions are not checked, since some methods like EatBatches take their own worker.
+func (b Bread) Validate() error {
+	if b.BufferSize == 0 {
+		return ErrMissingBufferSize
+	}
+
+	if err := b.validateSizes(); err != nil {
+		return err
+	}
+
+	switch {
+	case b.StartOffset < 0:
+		return fmt.Errorf("%w: StartOffset is %d", ErrNegativeSize, b.StartOffset)
+	case b.AdaptiveBuffer && b.maxBufferSize() > sizeLimit:
+		return fmt.Errorf("%w: the default MaxBufferSize of BufferSize %d is above %d", ErrSizeOverflow, b.BufferSize, sizeLimit)
+	case b.Workers > MaxWorkers:
+		return ErrTooManyWorkers
+	case b.BufferSeed > MaxSeedPerBuffer*(max(b.Workers, DefaultWorkers)+int(b.QueueDepth)+1):
+		return ErrBufferSeed
+	case b.poolSize() != b.BufferSize:
+		return ErrPoolSizeMismatch
+	case b.MaxBatchSize > 0 && b.MaxBatchSize < b.BufferSize:
+		return ErrMaxBatchSize
+	case b.MaxMemory > 0 && b.MaxMemory < int64(b.BufferSize):
+		return ErrMaxMemory
+	case b.AdaptiveBuffer && b.minBufferSize() > b.maxBufferSize():
+		return ErrBufferSizeBounds
+	case b.CheckpointFunc != nil && (b.Epochs > 1 || b.ShuffleBuffer > 0):
+		return ErrCheckpoint
+	case b.Scratch != nil && cap(b.Scratch) < b.MaxRecordSize:
+		return ErrScratchTooSmall
+	case b.ExpectedDigest != nil && !b.digestAlgo().Available():
+		return ErrDigestUnavailable
+	case len(b.DelimiterBytes) > 1 && (!b.sliceable() || b.ShuffleBuffer > 0):
+		return ErrDelimiterBytes
+	case (b.Ordered || b.Output != nil) && b.PriorityFunc != nil:
+		return ErrOrderedPriority
+	case b.RecordSize > 0 && (b.RecordSize > b.BufferSize || b.Framing != FramingDelimiter || b.Align != AlignForward):
+		return ErrRecordSize
+	case b.RecordSize > 0 && (b.MaxBatchSize > 0 || b.TruncateRecordsAt > 0 || b.ShuffleBuffer > 0):
+		return ErrRecordSize
+	case (b.SplitFunc != nil || b.CSVMode) && (b.Framing != FramingDelimiter || b.Align != AlignForward):
+		return ErrSplitFunc
+	case (b.SplitFunc != nil || b.CSVMode) && (b.MaxBatchSize > 0 || b.TruncateRecordsAt > 0):
+		return ErrSplitFunc
+	case (b.SplitFunc != nil || b.CSVMode) && (b.RecordSize > 0 || b.ShuffleBuffer > 0):
+		return ErrSplitFunc
+	case b.FailOnBatchTimeout && b.WorkerInit != nil:
+		return ErrAbandonedState
+	case !b.validSampling():
+		return ErrSampling
+	case b.FlushInterval > 0 && (b.MaxBatchSize > 0 || b.TruncateRecordsAt > 0):
+		return ErrFlushInterval
+	case b.Follow && b.Epochs > 1:
+		return ErrFollowEpochs
+	case b.PartitionFunc != nil && (b.RecordFunc == nil || b.ShuffleBuffer > 0 || b.PriorityFunc != nil):
+		return ErrPartitionFunc
+	case b.DetectDelimiter && (b.Framing != FramingDelimiter || b.RecordSize > 0 || b.SplitFunc != nil || b.CSVMode):
+		return ErrDetectDelimiter
+	case b.ContinueOnError && (b.Framing != FramingDelimiter || b.RecordSize > 0 || b.SplitFunc != nil || b.CSVMode || b.Epochs > 1 || b.CheckpointFunc != nil):
+		return ErrContinueOnError
+	case b.StartOffset > 0 && (b.Epochs > 1 || b.SkipLines > 0):
+		return ErrStartOffset
+	case b.AlignToDelimiter && (b.Framing != FramingDelimiter || b.RecordSize > 0 || b.SplitFunc != nil || b.CSVMode || b.DetectDelimiter):
+		return ErrStartOffset
+	case b.Framing == FramingNone && (b.RecordFunc != nil || b.MaxRecords > 0):
+		return ErrNoRecords
+	}
+
+	return nil
+}
+
+// validateSizes rejects the negative sizes and counts, along with the sizes too big for the sizes derived from them
+// to fit an int
+func (b Bread) validateSizes() error {
+	sizes := [...]struct {
+		name  string
+		value int
+		limit int
+	}{
+		{name: "BufferSize", value: b.BufferSize, limit: sizeLimit},
+		{name: "Workers", value: b.Workers, limit: math.MaxInt},
+		{name: "BufferSeed", value: b.BufferSeed, limit: math.MaxInt},
+		{name: "ReaderBufferSize", value: b.ReaderBufferSize, limit: sizeLimit},
+		{name: "MaxBatchSize", value: b.MaxBatchSize, limit: sizeLimit},
+		{name: "SyncThreshold", value: b.SyncThreshold, limit: sizeLimit},
+		{name: "MaxRecordSize", value: b.MaxRecordSize, limit: sizeLimit},
+		{name: "TruncateRecordsAt", value: b.TruncateRecordsAt, limit: sizeLimit},
+		{name: "RecordSize", value: b.RecordSize, limit: sizeLimit},
+		{name: "MinFill", value: b.MinFill, limit: sizeLimit},
+		{name: "MaxBufferCap", value: b.MaxBufferCap, limit: math.MaxInt},
+		{name: "MinBufferSize", value: b.MinBufferSize, limit: sizeLimit},
+		{name: "MaxBufferSize", value: b.MaxBufferSize, limit: sizeLimit},
+	}
+
+	for _, size := range sizes {
+		switch {
+		case size.value < 0:
+			return fmt.Errorf("%w: %s is %d", ErrNegativeSize, size.name, size.value)
+		case size.value > size.limit:
+			return fmt.Errorf("%w: %s %d is above %d", ErrSizeOverflow, size.name, size.value, size.limit)
 		}
+	}
 
-		*buffer = append(*buffer, complement...)
+	return nil
+}
 
-		workerCh <- struct{}{}
-		wg.Add(1)
+// work processes a batch applying the per-batch settings
+func (b Bread) work(ctx context.Context, worker func(context.Context, Batch) error, batch Batch) (err error) {
+	// The call abandoned by FailOnBatchTimeout cancels the context once it returns, see abandon
+	release := func() {}
+	abandoned := false
 
-		go func() {
-			defer wg.Done()
+	if b.BatchContext != nil {
+		batchCtx, cancel := b.BatchContext(ctx, batch.BatchMeta)
 
-			b.WorkerFunc(ctx, buffer)
+		if batchCtx != nil {
+			ctx = batchCtx
+		}
 
-			pool.Put(buffer)
-			<-workerCh
+		if cancel != nil {
+			release = cancel
+		}
+
+		defer func() {
+			if !abandoned {
+				release()
+			}
 		}()
 	}
 
-	close(workerCh)
-	wg.Wait()
+	deadline := b.BatchTimeout
+	if b.WorkerDeadline != nil {
+		deadline = b.WorkerDeadline(batch.BatchMeta)
+	}
 
-	return nil
+	if deadline <= 0 {
+		return worker(ctx, batch)
+	}
+
+	batchCtx, cancel := context.WithTimeout(ctx, deadline)
+	defer cancel()
+
+	start := time.Now()
+
+	if b.FailOnBatchTimeout {
+		abandoned, err = b.abandon(ctx, batchCtx, worker, batch, release)
+	} else {
+		err = worker(batchCtx, batch)
+	}
+
+	// Expirations inherited from the parent context are not attributed to the batch
+	if !abandoned && (ctx.Err() != nil || !errors.Is(batchCtx.Err(), context.DeadlineExceeded)) {
+		return err
+	}
+
+	deadlineErr := &DeadlineError{
+		Meta:      batch.BatchMeta,
+		Deadline:  deadline,
+		Elapsed:   time.Since(start),
+		Abandoned: abandoned,
+	}
+
+	if err != nil {
+		return errors.Join(deadlineErr, err)
+	}
+
+	return deadlineErr
+}
+
+// abandon runs the worker on its own goroutine and stops waiting for it once the batch context expires,
+// see FailOnBatchTimeout. The cancellation of the parent context is still waited for, like any other worker.
+// The abandoned call runs release once it returns.
+func (b Bread) abandon(ctx, batchCtx context.Context, worker func(context.Context, Batch) error, batch Batch, release func()) (bool, error) {
+	type result struct {
+		err       error
+		recovered any
+	}
+
+	results := make(chan result)
+	// gone is closed when the call is abandoned, nobody receives its result afterwards
+	gone := make(chan struct{})
+
+	go func() {
+		r := result{}
+
+		defer func() {
+			r.recovered = recover()
+
+			select {
+			case results <- r:
+				return
+			case <-gone:
+			}
+
+			release()
+
+			// The panics of an abandoned call cannot reach the worker that ran it
+			if r.recovered == nil {
+				return
+			}
+
+			if b.OnPanic == nil {
+				panic(r.recovered)
+			}
+
+			b.OnPanic(ctx, r.recovered, batch.buffer)
+		}()
+
+		r.err = worker(batchCtx, batch)
+	}()
+
+	select {
+	case r := <-results:
+		if r.recovered != nil {
+			panic(r.recovered)
+		}
+
+		return false, r.err
+	case <-batchCtx.Done():
+	}
+
+	if ctx.Err() != nil {
+		r := <-results
+		if r.recovered != nil {
+			panic(r.recovered)
+		}
+
+		return false, r.err
+	}
+
+	close(gone)
+
+	return true, nil
 }
@@ -1,45 +1,225 @@
 package bread
 
 import (
+	"bufio"
 	"bytes"
 	"context"
+	"errors"
 	"io"
+	"math"
+	"runtime"
+	"slices"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
-const MB = 1 << 20
-
 func TestBread_Eat(t *testing.T) {
 	cases := [...]struct {
 		ctx    context.Context
 		bread  Bread
+		worker func(context.Context, Batch)
 		reader io.Reader
 	}{
 		{
 			ctx: context.TODO(),
 			bread: Bread{
 				Workers:    16,
-				WorkerFunc: func(ctx context.Context, buffer *[]byte) {},
 				BufferSize: 1 * MB,
 			},
+			worker: func(context.Context, Batch) {},
 			reader: memoryReader(300 * MB),
 		},
 	}
 
 	for i, v := range cases {
 		t.Run(strconv.Itoa(i), func(t *testing.T) {
-			err := v.bread.Eat(v.ctx, v.reader)
+			err := v.bread.EatBatches(v.ctx, v.reader, v.worker)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+func TestBread_EatBatches(t *testing.T) {
+	cases := [...]struct {
+		ctx   context.Context
+		bread Bread
+		data  []byte
+	}{
+		{
+			ctx: context.TODO(),
+			bread: Bread{
+				Workers:    16,
+				BufferSize: 1 << 10,
+			},
+			data: memoryData(10 * MB),
+		},
+		{
+			ctx: context.TODO(),
+			bread: Bread{
+				Workers:    4,
+				BufferSize: 7,
+				Delimiter:  ',',
+			},
+			data: []byte("a,bb,ccc,dddd,eeeee,ffffff,ggggggg,hhhhhhhh"),
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			mu := sync.Mutex{}
+			batches := make([]Batch, 0)
+
+			err := c.bread.EatBatches(c.ctx, bytes.NewReader(c.data), func(_ context.Context, batch Batch) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				batches = append(batches, Batch{
+					BatchMeta: batch.BatchMeta,
+					Data:      bytes.Clone(batch.Data),
+				})
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			sort.Slice(batches, func(i, j int) bool {
+				return batches[i].Index < batches[j].Index
+			})
+
+			got, offset := make([]byte, 0, len(c.data)), int64(0)
+
+			for index, batch := range batches {
+				if batch.Index != uint64(index) {
+					t.Fatalf("unexpected index %d, expected %d", batch.Index, index)
+				}
+
+				if batch.Offset != offset {
+					t.Fatalf("unexpected offset %d in batch %d, expected %d", batch.Offset, index, offset)
+				}
+
+				offset += int64(len(batch.Data))
+				got = append(got, batch.Data...)
+			}
+
+			if !bytes.Equal(got, c.data) {
+				t.Fatal("the batches do not reproduce the input")
+			}
+
+			// Parity between v1 and v2 workers
+			v1 := make([][]byte, 0, len(batches))
+
+			c.bread.WorkerFunc = func(_ context.Context, buffer *[]byte) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				v1 = append(v1, bytes.Clone(*buffer))
+			}
+
+			err = c.bread.Eat(c.ctx, bytes.NewReader(c.data))
 			if err != nil {
 				t.Fatal(err)
 			}
 
+			sort.Slice(v1, func(i, j int) bool {
+				return bytes.Compare(v1[i], v1[j]) < 0
+			})
+
+			sort.Slice(batches, func(i, j int) bool {
+				return bytes.Compare(batches[i].Data, batches[j].Data) < 0
+			})
+
+			if len(v1) != len(batches) {
+				t.Fatalf("v1 delivered %d batches, v2 delivered %d", len(v1), len(batches))
+			}
+
+			for j := range v1 {
+				if !bytes.Equal(v1[j], batches[j].Data) {
+					t.Fatalf("v1 and v2 batches differ at %d", j)
+				}
+			}
+
 			t.Log("Success!")
 		})
 	}
 }
 
+func TestBatch_Detach(t *testing.T) {
+	mu := sync.Mutex{}
+	detached := make([][]byte, 0)
+
+	bread := Bread{
+		Workers:    4,
+		BufferSize: 1 << 10,
+	}
+
+	data := memoryData(1 * MB)
+
+	err := bread.EatBatches(context.TODO(), bytes.NewReader(data), func(_ context.Context, batch Batch) {
+		if batch.Index%2 != 0 {
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		detached = append(detached, batch.Detach())
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Detached data must not be overwritten by later batches
+	for _, d := range detached {
+		if !bytes.Contains(data, d) || len(d) == 0 {
+			t.Fatal("detached data was corrupted")
+		}
+	}
+
+	t.Log("Success!")
+}
+
 func BenchmarkBread_Eat(b *testing.B) {
+	cases := [...]struct {
+		ctx    context.Context
+		bread  Bread
+		reader io.Reader
+	}{
+		{
+			ctx: context.TODO(),
+			bread: Bread{
+				Workers:    16,
+				BufferSize: MB,
+				BufferSeed: 0,
+			},
+			reader: memoryReader(5_000 * MB),
+		},
+	}
+
+	for i, c := range cases {
+		b.Run(strconv.Itoa(i), func(b *testing.B) {
+			b.ReportAllocs()
+
+			for n := 0; n < b.N; n++ {
+				err := c.bread.EatBatches(c.ctx, c.reader, func(context.Context, Batch) {})
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkBread_WorkerFunc is the baseline of BenchmarkBread_Eat, through the adapter of the v1 workers
+func BenchmarkBread_WorkerFunc(b *testing.B) {
 	cases := [...]struct {
 		ctx    context.Context
 		bread  Bread
@@ -59,6 +239,8 @@ func BenchmarkBread_Eat(b *testing.B) {
 
 	for i, c := range cases {
 		b.Run(strconv.Itoa(i), func(b *testing.B) {
+			b.ReportAllocs()
+
 			for n := 0; n < b.N; n++ {
 				err := c.bread.Eat(c.ctx, c.reader)
 				if err != nil {
@@ -70,6 +252,10 @@ func BenchmarkBread_Eat(b *testing.B) {
 }
 
 func memoryReader(size int) io.Reader {
+	return bytes.NewBuffer(memoryData(size))
+}
+
+func memoryData(size int) []byte {
 	data := make([]byte, size)
 
 	for i := 0; i < len(data); i++ {
@@ -81,5 +267,1769 @@ func memoryReader(size int) io.Reader {
 		data[i] = 'O'
 	}
 
-	return bytes.NewBuffer(data)
+	return data
+}
+
+func TestBread_WorkerDeadline(t *testing.T) {
+	cases := [...]struct {
+		bread  Bread
+		worker func(context.Context, Batch)
+		data   []byte
+		err    bool
+		// noDeadline tells the workers receive contexts without deadline
+		noDeadline bool
+	}{
+		{
+			bread: Bread{
+				Workers:        4,
+				BufferSize:     1 << 10,
+				WorkerDeadline: ScaleDeadline(time.Second, time.Second, time.Minute),
+			},
+			worker: func(context.Context, Batch) {},
+			data:   memoryData(1 * MB),
+		},
+		{
+			bread: Bread{
+				Workers:        2,
+				BufferSize:     10,
+				WorkerDeadline: ScaleDeadline(0, time.Millisecond, time.Millisecond),
+			},
+			worker: func(ctx context.Context, _ Batch) {
+				<-ctx.Done()
+			},
+			data: []byte("aaaaaaaaaaaaaaaaaaaaaaaa\nbbbbbbbbbbb\n"),
+			err:  true,
+		},
+		{
+			bread: Bread{
+				Workers:    2,
+				BufferSize: 10,
+				WorkerDeadline: func(BatchMeta) time.Duration {
+					return 0
+				},
+			},
+			worker:     func(context.Context, Batch) {},
+			data:       []byte("aaaaaaaaaaaaaaaaaaaaaaaa\nbbbbbbbbbbb\n"),
+			noDeadline: true,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			mu := sync.Mutex{}
+			contexts := make([]context.Context, 0)
+
+			err := c.bread.EatBatches(context.TODO(), bytes.NewReader(c.data), func(ctx context.Context, batch Batch) {
+				mu.Lock()
+				contexts = append(contexts, ctx)
+				mu.Unlock()
+
+				c.worker(ctx, batch)
+			})
+
+			if c.noDeadline {
+				for _, ctx := range contexts {
+					if _, ok := ctx.Deadline(); ok {
+						t.Fatal("unexpected deadline")
+					}
+				}
+			}
+
+			// Derived contexts must be released as soon as the worker returns
+			if c.bread.WorkerDeadline(BatchMeta{Size: 1}) > 0 {
+				for _, ctx := range contexts {
+					if ctx.Err() == nil {
+						t.Fatal("batch context was not cancelled")
+					}
+				}
+			}
+
+			if !c.err {
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				t.Log("Success!")
+				return
+			}
+
+			var deadlineErr *DeadlineError
+
+			if !errors.As(err, &deadlineErr) {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatal("deadline errors must match context.DeadlineExceeded")
+			}
+
+			if deadlineErr.Meta.Size == 0 || deadlineErr.Elapsed < deadlineErr.Deadline {
+				t.Fatalf("unexpected error details %+v", deadlineErr)
+			}
+
+			t.Logf("Success! %v", err)
+		})
+	}
+}
+
+func TestBread_BatchTimeout(t *testing.T) {
+	cases := [...]struct {
+		bread Bread
+		// stuck is the index of the batch whose worker does not finish in time
+		stuck uint64
+		err   error
+		// abandoned reports whether the stuck batch is expected to be abandoned
+		abandoned bool
+	}{
+		{
+			// Soft mode, the worker honors its context
+			bread: Bread{Workers: 4, BufferSize: 16, BatchTimeout: 10 * time.Millisecond},
+			stuck: 3,
+			err:   context.DeadlineExceeded,
+		},
+		{
+			bread:     Bread{Workers: 4, BufferSize: 16, BatchTimeout: 10 * time.Millisecond, FailOnBatchTimeout: true},
+			stuck:     3,
+			err:       context.DeadlineExceeded,
+			abandoned: true,
+		},
+		{
+			// Abandoned batches are not retried
+			bread:     Bread{Workers: 1, BufferSize: 16, BatchTimeout: 10 * time.Millisecond, FailOnBatchTimeout: true, BatchRetries: 3},
+			stuck:     0,
+			err:       context.DeadlineExceeded,
+			abandoned: true,
+		},
+		{
+			bread: Bread{
+				Workers:            4,
+				BufferSize:         16,
+				BatchTimeout:       10 * time.Millisecond,
+				FailOnBatchTimeout: true,
+				Ordered:            true,
+			},
+			stuck:     5,
+			err:       context.DeadlineExceeded,
+			abandoned: true,
+		},
+		{
+			bread: Bread{
+				Workers:            4,
+				BufferSize:         16,
+				BatchTimeout:       10 * time.Millisecond,
+				FailOnBatchTimeout: true,
+				WorkerInit:         func(context.Context, int) (any, error) { return nil, nil },
+			},
+			stuck: math.MaxUint64,
+			err:   ErrAbandonedState,
+		},
+	}
+
+	data := bytes.Repeat([]byte("0123456789abcde\n"), 100)
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			c.bread.Pool = NewBufferPool(16, 32)
+
+			unblock := make(chan struct{})
+			finished := make(chan struct{})
+
+			start := time.Now()
+
+			err := c.bread.EatBatches(context.TODO(), bytes.NewReader(data), func(ctx context.Context, batch Batch) {
+				if batch.Index != c.stuck {
+					return
+				}
+
+				if !c.bread.FailOnBatchTimeout {
+					<-ctx.Done()
+					return
+				}
+
+				defer close(finished)
+
+				// The buffer still belongs to the abandoned call, the race detector reports any reuse
+				for {
+					select {
+					case <-unblock:
+						return
+					case <-time.After(time.Millisecond):
+						batch.Data[0] = 'x'
+					}
+				}
+			})
+
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Fatalf("eat took %s", elapsed)
+			}
+
+			if c.abandoned {
+				// Another call takes the buffers of the shared pool while the abandoned call is running
+				reuse := Bread{Workers: 4, BufferSize: 16, Pool: c.bread.Pool, WorkerFunc: func(context.Context, *[]byte) {}}
+				if err := reuse.Eat(context.TODO(), bytes.NewReader(data)); err != nil {
+					t.Fatal(err)
+				}
+
+				close(unblock)
+				<-finished
+			}
+
+			if !errors.Is(err, c.err) {
+				t.Fatalf("expected error %v, got %v", c.err, err)
+			}
+
+			var deadlineErr *DeadlineError
+
+			if c.err == context.DeadlineExceeded && (!errors.As(err, &deadlineErr) || deadlineErr.Meta.Index != c.stuck) {
+				t.Fatalf("expected a deadline error for batch %d, got %v", c.stuck, err)
+			}
+
+			if deadlineErr != nil && deadlineErr.Abandoned != c.abandoned {
+				t.Fatalf("expected abandoned %v, got %v", c.abandoned, deadlineErr.Abandoned)
+			}
+
+			t.Logf("Success! %v", err)
+		})
+	}
+}
+
+func TestBread_BatchContext(t *testing.T) {
+	type indexKey struct{}
+
+	cases := [...]struct {
+		bread Bread
+		// unchanged returns the parent context without a CancelFunc
+		unchanged bool
+		// stuck is the index of the batch whose worker panics or misses its deadline
+		stuck  uint64
+		panics bool
+		err    error
+	}{
+		{
+			bread: Bread{Workers: 4, BufferSize: 16},
+			stuck: math.MaxUint64,
+		},
+		{
+			bread:     Bread{Workers: 4, BufferSize: 16},
+			stuck:     math.MaxUint64,
+			unchanged: true,
+		},
+		{
+			bread:  Bread{Workers: 4, BufferSize: 16, OnPanic: func(context.Context, any, *[]byte) {}},
+			stuck:  3,
+			panics: true,
+		},
+		{
+			bread: Bread{Workers: 4, BufferSize: 16, BatchTimeout: 10 * time.Millisecond},
+			stuck: 3,
+			err:   context.DeadlineExceeded,
+		},
+		{
+			bread: Bread{Workers: 4, BufferSize: 16, BatchTimeout: 10 * time.Millisecond, FailOnBatchTimeout: true},
+			stuck: 3,
+			err:   context.DeadlineExceeded,
+		},
+	}
+
+	data := bytes.Repeat([]byte("0123456789abcde\n"), 100)
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			var (
+				mu       sync.Mutex
+				cancels  = make(map[uint64]int)
+				returned = make(map[uint64]bool)
+			)
+
+			c.bread.BatchContext = func(ctx context.Context, meta BatchMeta) (context.Context, context.CancelFunc) {
+				if c.unchanged {
+					return ctx, nil
+				}
+
+				return context.WithValue(ctx, indexKey{}, meta.Index), func() {
+					mu.Lock()
+					defer mu.Unlock()
+
+					if !returned[meta.Index] {
+						t.Errorf("batch %d cancelled before its worker returned", meta.Index)
+					}
+
+					cancels[meta.Index]++
+				}
+			}
+
+			err := c.bread.EatBatches(context.TODO(), bytes.NewReader(data), func(ctx context.Context, batch Batch) {
+				defer func() {
+					mu.Lock()
+					defer mu.Unlock()
+
+					returned[batch.Index] = true
+				}()
+
+				if index, ok := ctx.Value(indexKey{}).(uint64); !c.unchanged && (!ok || index != batch.Index) {
+					t.Errorf("expected the context of batch %d, got %v", batch.Index, ctx.Value(indexKey{}))
+				}
+
+				if batch.Index != c.stuck {
+					return
+				}
+
+				if c.panics {
+					panic("stuck")
+				}
+
+				<-ctx.Done()
+			})
+
+			if !errors.Is(err, c.err) {
+				t.Fatalf("expected error %v, got %v", c.err, err)
+			}
+
+			// The abandoned call cancels its context once it returns
+			for deadline := time.Now().Add(time.Second); ; time.Sleep(time.Millisecond) {
+				mu.Lock()
+				done := len(cancels) == len(returned)
+				mu.Unlock()
+
+				if done || c.unchanged {
+					break
+				}
+
+				if time.Now().After(deadline) {
+					t.Fatalf("expected %d cancellations, got %d", len(returned), len(cancels))
+				}
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			for index, n := range cancels {
+				if n != 1 {
+					t.Fatalf("expected one cancellation of batch %d, got %d", index, n)
+				}
+			}
+
+			// Each batch is completed with the next line
+			if c.err == nil && len(returned) != 50 {
+				t.Fatalf("expected 50 batches, got %d", len(returned))
+			}
+
+			t.Logf("Success! %v", err)
+		})
+	}
+}
+
+func TestScaleDeadline(t *testing.T) {
+	cases := [...]struct {
+		size     int
+		expected time.Duration
+	}{
+		{size: 0, expected: 10 * time.Millisecond},
+		{size: 20 << 10, expected: 20 * time.Millisecond},
+		{size: 512 << 10, expected: 100 * time.Millisecond},
+	}
+
+	deadline := ScaleDeadline(time.Millisecond, 10*time.Millisecond, 100*time.Millisecond)
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			got := deadline(BatchMeta{Size: c.size})
+			if got != c.expected {
+				t.Fatalf("expected %s, got %s", c.expected, got)
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+func TestBread_PriorityFunc(t *testing.T) {
+	data := make([]byte, 0)
+
+	for i := 0; i < 20; i++ {
+		if i%2 == 0 {
+			data = append(data, "low-"+strconv.Itoa(i)+"\n"...)
+			continue
+		}
+
+		data = append(data, "high-"+strconv.Itoa(i)+"\n"...)
+	}
+
+	eof := make(chan struct{})
+	release := make(chan struct{})
+
+	go func() {
+		<-eof
+		close(release)
+	}()
+
+	order := make([]string, 0)
+
+	bread := Bread{
+		Workers:    1,
+		BufferSize: 1,
+		QueueDepth: 32,
+		PriorityFunc: func(_ BatchMeta, head []byte) int {
+			if bytes.HasPrefix(head, []byte("high")) {
+				return 1
+			}
+
+			return 0
+		},
+	}
+
+	err := bread.EatBatches(context.TODO(), eofReader{Reader: bytes.NewReader(data), eof: eof}, func(_ context.Context, batch Batch) {
+		// The pool stays saturated until every batch is queued
+		<-release
+
+		order = append(order, string(bytes.TrimSpace(batch.Data)))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(order) != 20 {
+		t.Fatalf("expected 20 batches, got %d", len(order))
+	}
+
+	// The first batch may be dispatched before the others are queued
+	lows, highs := make([]string, 0), make([]string, 0)
+
+	for i, line := range order {
+		if strings.HasPrefix(line, "high") {
+			highs = append(highs, line)
+			continue
+		}
+
+		if i > 0 && len(highs) < 10 {
+			t.Fatalf("low priority batch %s overtook high priority batches: %v", line, order)
+		}
+
+		lows = append(lows, line)
+	}
+
+	// FIFO order among equal priorities
+	for i := range highs {
+		if highs[i] != "high-"+strconv.Itoa(i*2+1) || lows[i] != "low-"+strconv.Itoa(i*2) {
+			t.Fatalf("batches with equal priority are out of order: %v", order)
+		}
+	}
+
+	t.Log("Success!")
+}
+
+// eofReader closes eof once the wrapped reader is exhausted
+type eofReader struct {
+	io.Reader
+	eof chan struct{}
+}
+
+func (r eofReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if err == io.EOF {
+		select {
+		case <-r.eof:
+		default:
+			close(r.eof)
+		}
+	}
+
+	return n, err
+}
+
+func TestBread_OnComplete(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.TODO())
+	cancel()
+
+	cases := [...]struct {
+		ctx      context.Context
+		bread    Bread
+		data     []byte
+		expected Stats
+		err      bool
+	}{
+		{
+			ctx: context.TODO(),
+			bread: Bread{
+				Workers:    4,
+				BufferSize: 4,
+			},
+			data: []byte("aaaa\nbbbb\ncccc\ndddd\n"),
+			expected: Stats{
+				BytesRead:        20,
+				Batches:          4,
+				Records:          4,
+				DeliveredRecords: 4,
+				ComplementBytes:  4,
+				Workers:          4,
+				BatchSize:        BatchSizeStats{Min: 5, Mean: 5, Max: 5},
+				BufferSize:       4,
+			},
+		},
+		{
+			ctx: context.TODO(),
+			bread: Bread{
+				Workers:    2,
+				BufferSize: 4,
+				WorkerDeadline: func(BatchMeta) time.Duration {
+					return time.Nanosecond
+				},
+			},
+			data: []byte("aaaa\n"),
+			expected: Stats{
+				BytesRead:        5,
+				Batches:          1,
+				Records:          1,
+				DeliveredRecords: 1,
+				Failures:         1,
+				ComplementBytes:  1,
+				Workers:          2,
+				BatchSize:        BatchSizeStats{Min: 5, Mean: 5, Max: 5},
+				BufferSize:       4,
+			},
+			err: true,
+		},
+		{
+			ctx: cancelled,
+			bread: Bread{
+				BufferSize: 4,
+			},
+			data:     []byte("aaaa\nbbbb\n"),
+			expected: Stats{Workers: 1, BufferSize: 4},
+			err:      true,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			calls, processed := 0, atomic.Uint64{}
+
+			var (
+				stats    Stats
+				complete error
+			)
+
+			c.bread.OnComplete = func(_ context.Context, s Stats, err error) {
+				calls++
+				stats, complete = s, err
+
+				// Every worker must be finished at this point
+				if processed.Load() != s.Batches {
+					t.Errorf("%d batches processed before completion, expected %d", processed.Load(), s.Batches)
+				}
+			}
+
+			err := c.bread.EatBatches(c.ctx, bytes.NewReader(c.data), func(ctx context.Context, _ Batch) {
+				defer processed.Add(1)
+
+				if c.err {
+					<-ctx.Done()
+				}
+			})
+			if (err != nil) != c.err {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			if calls != 1 {
+				t.Fatalf("OnComplete was called %d times", calls)
+			}
+
+			if complete != err {
+				t.Fatalf("OnComplete received %v, but Eat returned %v", complete, err)
+			}
+
+			// Pool reuse and queue ages depend on the scheduling
+			stats.Duration, stats.Allocations, stats.Reuses, stats.QueueAge = 0, 0, 0, QueueAgeStats{}
+
+			if stats != c.expected {
+				t.Fatalf("expected %+v, got %+v", c.expected, stats)
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+func TestBread_OnStart(t *testing.T) {
+	cases := [...]struct {
+		bread    Bread
+		expected Config
+	}{
+		{
+			bread: Bread{
+				BufferSize: 8,
+			},
+			expected: Config{
+				Workers:          DefaultWorkers,
+				BufferSize:       8,
+				Delimiter:        "\n",
+				ReaderBufferSize: 4 * KB,
+				MaxBufferCap:     32,
+			},
+		},
+		{
+			bread: Bread{
+				Workers:    4,
+				BufferSeed: 2,
+				BufferSize: 16,
+				Delimiter:  ';',
+				QueueDepth: 3,
+			},
+			expected: Config{
+				Workers:          4,
+				BufferSeed:       2,
+				BufferSize:       16,
+				Delimiter:        ";",
+				ReaderBufferSize: 4 * KB,
+				QueueDepth:       3,
+				MaxBufferCap:     64,
+			},
+		},
+		{
+			bread: Bread{
+				BufferSize:     16,
+				DelimiterBytes: []byte("\r\n"),
+			},
+			expected: Config{
+				Workers:          DefaultWorkers,
+				BufferSize:       16,
+				Delimiter:        "\r\n",
+				ReaderBufferSize: 4 * KB,
+				MaxBufferCap:     64,
+			},
+		},
+		{
+			bread: Bread{
+				BufferSize: 16,
+				Align:      AlignBackward,
+			},
+			expected: Config{
+				Workers:          DefaultWorkers,
+				BufferSize:       16,
+				Delimiter:        "\n",
+				ReaderBufferSize: 4 * KB,
+				Align:            AlignBackward,
+				MaxBufferCap:     64,
+			},
+		},
+		{
+			bread: Bread{
+				Workers:      2,
+				BufferSize:   16,
+				QueueDepth:   3,
+				PoolStrategy: PoolFreelist,
+				MaxBufferCap: 100,
+			},
+			expected: Config{
+				Workers:          2,
+				BufferSize:       16,
+				Delimiter:        "\n",
+				ReaderBufferSize: 4 * KB,
+				QueueDepth:       3,
+				PoolStrategy:     PoolFreelist,
+				FreelistSize:     6,
+				MaxBufferCap:     100,
+			},
+		},
+		{
+			bread: Bread{
+				BufferSize:     16,
+				AdaptiveBuffer: true,
+				MinBufferSize:  8,
+			},
+			expected: Config{
+				Workers:          DefaultWorkers,
+				BufferSize:       16,
+				Delimiter:        "\n",
+				ReaderBufferSize: 4 * KB,
+				MaxBufferCap:     4 * 64 * 16,
+				AdaptiveBuffer:   true,
+				MinBufferSize:    8,
+				MaxBufferSize:    64 * 16,
+			},
+		},
+		{
+			bread: Bread{
+				BufferSize:       16,
+				ReaderBufferSize: 64,
+			},
+			expected: Config{
+				Workers:          DefaultWorkers,
+				BufferSize:       16,
+				Delimiter:        "\n",
+				MaxBufferCap:     64,
+				ReaderBufferSize: 64,
+			},
+		},
+		{
+			// The pool keeps its own buffers
+			bread: Bread{
+				BufferSize: 16,
+				Pool:       NewBufferPool(16, 16),
+			},
+			expected: Config{
+				Workers:          DefaultWorkers,
+				BufferSize:       16,
+				Delimiter:        "\n",
+				ReaderBufferSize: 4 * KB,
+			},
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			calls, read := 0, false
+
+			var effective Config
+
+			c.bread.OnStart = func(_ context.Context, config Config) {
+				calls++
+				effective = config
+
+				if read {
+					t.Error("OnStart was called after the first read")
+				}
+			}
+
+			reader := readFunc(func(p []byte) (int, error) {
+				read = true
+				return 0, io.EOF
+			})
+
+			err := c.bread.EatBatches(context.TODO(), reader, func(context.Context, Batch) {})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if calls != 1 {
+				t.Fatalf("OnStart was called %d times", calls)
+			}
+
+			if effective != c.expected {
+				t.Fatalf("expected %+v, got %+v", c.expected, effective)
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+// readFunc implements io.Reader with a function
+type readFunc func([]byte) (int, error)
+
+func (f readFunc) Read(p []byte) (int, error) {
+	return f(p)
+}
+
+func TestBatchMeta_Records(t *testing.T) {
+	cases := [...]struct {
+		bread Bread
+		data  []byte
+	}{
+		{
+			bread: Bread{BufferSize: 1},
+			data:  []byte("a\nbb\nccc\ndddd\n"),
+		},
+		{
+			bread: Bread{BufferSize: 8, Workers: 4},
+			data:  []byte("a\nbb\nccc\ndddd\neeeee\nffffff\nunterminated"),
+		},
+		{
+			bread: Bread{BufferSize: 3, Delimiter: ';'},
+			data:  []byte(";;;a;;b;"),
+		},
+		{
+			bread: Bread{BufferSize: 1 << 10, Workers: 8},
+			data:  memoryData(1 * MB),
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			delimiter := c.bread.Delimiter
+			if delimiter == 0 {
+				delimiter = DefaultDelimiter
+			}
+
+			records := atomic.Uint64{}
+
+			err := c.bread.EatBatches(context.TODO(), bytes.NewReader(c.data), func(_ context.Context, batch Batch) {
+				// Reference split of the batch
+				expected := len(bytes.SplitAfter(batch.Data, []byte{delimiter}))
+				if bytes.HasSuffix(batch.Data, []byte{delimiter}) {
+					expected--
+				}
+
+				if batch.Records != uint32(expected) {
+					t.Errorf("batch %d %q has %d records, got %d", batch.Index, batch.Data, expected, batch.Records)
+				}
+
+				records.Add(uint64(batch.Records))
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expected := len(bytes.SplitAfter(c.data, []byte{delimiter}))
+			if bytes.HasSuffix(c.data, []byte{delimiter}) {
+				expected--
+			}
+
+			if records.Load() != uint64(expected) {
+				t.Fatalf("expected %d records, got %d", expected, records.Load())
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+// BenchmarkBatchMeta_Records measures the count of the records of each buffer against the whole reading of in-memory
+// data with workers doing nothing, the worst case for the count: it reads several times faster than Eat. MaxRecordSize
+// and AlignBackward count the records in the pass that finds the last delimiter.
+func BenchmarkBatchMeta_Records(b *testing.B) {
+	const bufferSize = 64 * KB
+
+	data := memoryData(64 * MB)
+
+	b.Run("Eat", func(b *testing.B) {
+		bread := Bread{Workers: 4, BufferSize: bufferSize}
+
+		b.SetBytes(int64(len(data)))
+
+		for n := 0; n < b.N; n++ {
+			// The reader hides the bytes.Reader, so the buffers are read and scanned like any stream
+			err := bread.EatBatches(context.TODO(), readFunc(bytes.NewReader(data).Read), func(context.Context, Batch) {})
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("MaxRecordSize", func(b *testing.B) {
+		bread := Bread{Workers: 4, BufferSize: bufferSize, MaxRecordSize: bufferSize}
+
+		b.SetBytes(int64(len(data)))
+
+		for n := 0; n < b.N; n++ {
+			err := bread.EatBatches(context.TODO(), readFunc(bytes.NewReader(data).Read), func(context.Context, Batch) {})
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("Count", func(b *testing.B) {
+		b.SetBytes(int64(len(data)))
+
+		for n := 0; n < b.N; n++ {
+			for chunk := range slices.Chunk(data, bufferSize) {
+				_ = bytes.Count(chunk, []byte{DefaultDelimiter})
+			}
+		}
+	})
+}
+
+func TestComplete(t *testing.T) {
+	cases := [...]struct {
+		data     []byte
+		expected []byte
+		err      error
+	}{
+		{
+			data:     []byte("abc\ndef\n"),
+			expected: []byte("abc\n"),
+		},
+		{
+			data:     []byte("\n"),
+			expected: []byte("\n"),
+		},
+		{
+			// The delimiter is beyond the internal buffer of the bufio.Reader
+			data:     append(bytes.Repeat([]byte("O"), 10_000), "\nnext"...),
+			expected: append(bytes.Repeat([]byte("O"), 10_000), '\n'),
+		},
+		{
+			data:     []byte("unterminated"),
+			expected: []byte("unterminated"),
+			err:      io.EOF,
+		},
+		{
+			data: []byte{},
+			err:  io.EOF,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			buffer := []byte("prefix")
+
+			n, err := complete(bufio.NewReader(bytes.NewReader(c.data)), &buffer, DefaultDelimiter)
+			if err != c.err {
+				t.Fatalf("expected error %v, got %v", c.err, err)
+			}
+
+			if n != len(c.expected) || !bytes.Equal(buffer, append([]byte("prefix"), c.expected...)) {
+				t.Fatalf("unexpected complement %q (%d bytes)", buffer, n)
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+func TestScanDelimiters(t *testing.T) {
+	cases := [...]struct {
+		data      string
+		delimiter string
+		count     int
+		end       int
+	}{
+		{data: "a\nbb\nccc", delimiter: "\n", count: 2, end: 5},
+		{data: "a\nbb\n", delimiter: "\n", count: 2, end: 5},
+		{data: "abc", delimiter: "\n"},
+		{},
+		{data: "a\r\nb\r\r\n", delimiter: "\r\n", count: 2, end: 7},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			if c.delimiter == "" {
+				c.delimiter = "\n"
+			}
+
+			count, end := scanDelimiters([]byte(c.data), []byte(c.delimiter))
+			if count != c.count || end != c.end {
+				t.Fatalf("expected %d delimiters ending at %d, got %d ending at %d", c.count, c.end, count, end)
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+func TestComplete_Allocs(t *testing.T) {
+	data := bytes.Repeat([]byte(strings.Repeat("O", 99)+"\n"), 1_000)
+
+	reader := bytes.NewReader(data)
+	r := bufio.NewReader(reader)
+	buffer := make([]byte, 0, 1<<10)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		buffer = buffer[:0]
+
+		if _, err := complete(r, &buffer, DefaultDelimiter); err != nil {
+			reader.Reset(data)
+			r.Reset(reader)
+		}
+	})
+
+	if allocs != 0 {
+		t.Fatalf("expected zero allocations per complement, got %v", allocs)
+	}
+
+	t.Log("Success!")
+}
+
+// BenchmarkComplement compares complete, that appends the slices of the bufio.Reader straight into the buffer,
+// with the ReadBytes baseline that allocates each complement, below and beyond the size of the bufio.Reader
+func BenchmarkComplement(b *testing.B) {
+	for _, size := range [...]int{100, 10 * KB} {
+		data := bytes.Repeat(append(bytes.Repeat([]byte("O"), size-1), '\n'), 100)
+
+		name := ByteSize(size).String()
+
+		b.Run(name+"/ReadSlice", func(b *testing.B) {
+			reader := bytes.NewReader(data)
+			r := bufio.NewReader(reader)
+			buffer := make([]byte, 0, 2*size)
+
+			b.SetBytes(int64(size))
+			b.ReportAllocs()
+
+			for n := 0; n < b.N; n++ {
+				buffer = buffer[:0]
+
+				if _, err := complete(r, &buffer, DefaultDelimiter); err != nil {
+					reader.Reset(data)
+					r.Reset(reader)
+				}
+			}
+		})
+
+		b.Run(name+"/ReadBytes", func(b *testing.B) {
+			reader := bytes.NewReader(data)
+			r := bufio.NewReader(reader)
+			buffer := make([]byte, 0, 2*size)
+
+			b.SetBytes(int64(size))
+			b.ReportAllocs()
+
+			for n := 0; n < b.N; n++ {
+				complement, err := r.ReadBytes(DefaultDelimiter)
+				buffer = append(buffer[:0], complement...)
+
+				if err != nil {
+					reader.Reset(data)
+					r.Reset(reader)
+				}
+			}
+		})
+	}
+}
+
+func TestBread_PoolStrategy(t *testing.T) {
+	cases := [...]struct {
+		bread    Bread
+		maxAlloc uint64
+	}{
+		{
+			bread: Bread{
+				Workers:      4,
+				BufferSize:   1 << 10,
+				PoolStrategy: PoolFreelist,
+			},
+			maxAlloc: 5,
+		},
+		{
+			bread: Bread{
+				Workers:      4,
+				BufferSize:   1 << 10,
+				QueueDepth:   4,
+				PoolStrategy: PoolFreelist,
+				FreelistSize: 2,
+			},
+			maxAlloc: 2,
+		},
+		{
+			bread: Bread{
+				Workers:          4,
+				BufferSize:       1 << 10,
+				PoolStrategy:     PoolFreelist,
+				FreelistSize:     1,
+				FreelistAllocate: true,
+			},
+		},
+	}
+
+	data := memoryData(4 * MB)
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			mu := sync.Mutex{}
+			got := make([]byte, len(data))
+
+			var stats Stats
+
+			c.bread.OnComplete = func(_ context.Context, s Stats, _ error) {
+				stats = s
+			}
+
+			err := c.bread.EatBatches(context.TODO(), bytes.NewReader(data), func(_ context.Context, batch Batch) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				copy(got[batch.Offset:], batch.Data)
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(got, data) {
+				t.Fatal("the batches do not reproduce the input")
+			}
+
+			if c.maxAlloc > 0 && stats.Allocations > c.maxAlloc {
+				t.Fatalf("the freelist allocated %d buffers, the limit is %d", stats.Allocations, c.maxAlloc)
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+// BenchmarkBread_MaxBufferCap reports the pooled memory held by the workers over Workers*BufferSize, when 1% of the
+// records are 50 times larger than BufferSize. The freelist keeps its buffers across the garbage collections,
+// so the buffers grown by the large records stay in use unless they are released.
+func BenchmarkBread_MaxBufferCap(b *testing.B) {
+	const bufferSize = 4 << 10
+
+	cases := [...]struct {
+		name  string
+		bread Bread
+	}{
+		{
+			name:  "default",
+			bread: Bread{Workers: 8, BufferSize: bufferSize, PoolStrategy: PoolFreelist},
+		},
+		{
+			name:  "unlimited",
+			bread: Bread{Workers: 8, BufferSize: bufferSize, PoolStrategy: PoolFreelist, MaxBufferCap: math.MaxUint32},
+		},
+	}
+
+	data := make([]byte, 0)
+
+	for i := 0; i < 10_000; i++ {
+		length := 100
+		if i%100 == 0 {
+			length = 50 * bufferSize
+		}
+
+		data = append(data, bytes.Repeat([]byte{'x'}, length)...)
+		data = append(data, DefaultDelimiter)
+	}
+
+	for _, c := range cases {
+		b.Run(c.name, func(b *testing.B) {
+			held, batches := atomic.Uint64{}, atomic.Uint64{}
+
+			for n := 0; n < b.N; n++ {
+				err := c.bread.EatBatches(context.TODO(), bytes.NewReader(data), func(_ context.Context, batch Batch) {
+					// Each worker holds one buffer, so the heap held by the workers is Workers times the average capacity
+					held.Add(uint64(cap(batch.Data)))
+					batches.Add(1)
+				})
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+
+			average := float64(held.Load()) / float64(max(batches.Load(), 1))
+
+			b.ReportMetric(average/float64(c.bread.BufferSize), "heap/budget")
+		})
+	}
+}
+
+func BenchmarkBread_PoolStrategy(b *testing.B) {
+	cases := [...]struct {
+		name  string
+		bread Bread
+	}{
+		{
+			name: "sync",
+			bread: Bread{
+				Workers:    16,
+				BufferSize: 64 << 10,
+			},
+		},
+		{
+			name: "freelist",
+			bread: Bread{
+				Workers:      16,
+				BufferSize:   64 << 10,
+				PoolStrategy: PoolFreelist,
+			},
+		},
+	}
+
+	data := memoryData(256 * MB)
+
+	for _, c := range cases {
+		b.Run(c.name, func(b *testing.B) {
+			b.ReportAllocs()
+
+			for n := 0; n < b.N; n++ {
+				// Garbage collections in the middle of the run release the buffers of sync.Pool
+				err := c.bread.EatBatches(context.TODO(), bytes.NewReader(data), func(_ context.Context, batch Batch) {
+					if batch.Index%64 == 0 {
+						runtime.GC()
+					}
+				})
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestBread_FinalFunc(t *testing.T) {
+	errSink := errors.New("sink failure")
+	errFinal := errors.New("final failure")
+
+	cases := [...]struct {
+		bread    Bread
+		sink     error
+		final    error
+		calls    int
+		leftover string
+		batches  uint64
+		errs     []error
+	}{
+		{
+			bread:   Bread{BufferSize: 2},
+			calls:   1,
+			batches: 2,
+		},
+		{
+			// Failed batches do not prevent the call
+			bread:   Bread{BufferSize: 8},
+			sink:    errSink,
+			calls:   1,
+			batches: 1,
+			errs:    []error{errSink},
+		},
+		{
+			bread:   Bread{BufferSize: 2},
+			final:   errFinal,
+			calls:   1,
+			batches: 2,
+			errs:    []error{errFinal},
+		},
+		{
+			bread:    Bread{BufferSize: 8, MaxRecords: 2},
+			calls:    1,
+			leftover: "c\n",
+			batches:  1,
+		},
+		{
+			bread: Bread{},
+			errs:  []error{ErrMissingBufferSize},
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			calls := 0
+
+			c.bread.Sink = sinkFunc(func(context.Context, BatchMeta, []byte) error {
+				return c.sink
+			})
+			c.bread.FinalFunc = func(_ context.Context, leftover []byte, stats Stats) error {
+				calls++
+
+				if string(leftover) != c.leftover || stats.Batches != c.batches {
+					t.Errorf("expected leftover %q after %d batches, got %q after %d", c.leftover, c.batches, leftover, stats.Batches)
+				}
+
+				return c.final
+			}
+
+			err := c.bread.Eat(context.TODO(), strings.NewReader("a\nb\nc\n"))
+
+			for _, expected := range c.errs {
+				if !errors.Is(err, expected) {
+					t.Fatalf("expected error %v, got %v", expected, err)
+				}
+			}
+
+			if len(c.errs) == 0 && err != nil {
+				t.Fatal(err)
+			}
+
+			if calls != c.calls {
+				t.Fatalf("expected %d calls, got %d", c.calls, calls)
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+func TestBread_WorkerErrFunc(t *testing.T) {
+	errBad := errors.New("bad record")
+
+	cases := [...]struct {
+		data string
+		err  error
+	}{
+		{
+			data: strings.Repeat("ok\n", 100),
+		},
+		{
+			data: "ok\nbad\n" + strings.Repeat("ok\n", 100),
+			err:  errBad,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			goroutines := runtime.NumGoroutine()
+			records := atomic.Int64{}
+
+			bread := Bread{
+				Workers:    4,
+				BufferSize: 1,
+				WorkerErrFunc: func(ctx context.Context, buffer *[]byte) error {
+					if bytes.Equal(*buffer, []byte("bad\n")) {
+						return errBad
+					}
+
+					// The batches still running are cancelled by the failed one
+					if c.err != nil {
+						<-ctx.Done()
+						return ctx.Err()
+					}
+
+					records.Add(1)
+					return nil
+				},
+			}
+
+			err := bread.Eat(context.TODO(), strings.NewReader(c.data))
+			if !errors.Is(err, c.err) {
+				t.Fatalf("expected error %v, got %v", c.err, err)
+			}
+
+			if c.err == nil && records.Load() != int64(strings.Count(c.data, "\n")) {
+				t.Fatalf("expected %d records, got %d", strings.Count(c.data, "\n"), records.Load())
+			}
+
+			// The idle workers exit right after Eat returns
+			for deadline := time.Now().Add(time.Second); runtime.NumGoroutine() > goroutines; {
+				if time.Now().After(deadline) {
+					t.Fatalf("expected %d goroutines, got %d", goroutines, runtime.NumGoroutine())
+				}
+
+				time.Sleep(time.Millisecond)
+			}
+
+			t.Logf("Success! %v", err)
+		})
+	}
+}
+
+func TestBread_OnPanic(t *testing.T) {
+	cases := [...]struct {
+		bread Bread
+		data  string
+	}{
+		{
+			bread: Bread{Workers: 2, BufferSize: 1},
+			data:  strings.Repeat("ok\npanic\n", 50),
+		},
+		{
+			bread: Bread{Workers: 4, BufferSize: 1, QueueDepth: 2},
+			data:  strings.Repeat("panic\n", 100),
+		},
+		{
+			bread: Bread{BufferSize: 1, SyncThreshold: 1 * KB},
+			data:  strings.Repeat("ok\npanic\n", 10),
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			goroutines := runtime.NumGoroutine()
+			panics := atomic.Int64{}
+
+			c.bread.WorkerFunc = func(_ context.Context, buffer *[]byte) {
+				if bytes.Equal(*buffer, []byte("panic\n")) {
+					panic("bad record")
+				}
+			}
+
+			c.bread.OnPanic = func(_ context.Context, recovered any, buffer *[]byte) {
+				if recovered != "bad record" || !bytes.Equal(*buffer, []byte("panic\n")) {
+					t.Errorf("unexpected panic %v with %q", recovered, *buffer)
+				}
+
+				panics.Add(1)
+			}
+
+			done := make(chan error)
+
+			go func() {
+				done <- c.bread.Eat(context.TODO(), strings.NewReader(c.data))
+			}()
+
+			select {
+			case err := <-done:
+				if err != nil {
+					t.Fatal(err)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("Eat did not return")
+			}
+
+			if expected := int64(strings.Count(c.data, "panic")); panics.Load() != expected {
+				t.Fatalf("expected %d panics, got %d", expected, panics.Load())
+			}
+
+			for deadline := time.Now().Add(time.Second); runtime.NumGoroutine() > goroutines; {
+				if time.Now().After(deadline) {
+					t.Fatalf("expected %d goroutines, got %d", goroutines, runtime.NumGoroutine())
+				}
+
+				time.Sleep(time.Millisecond)
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+func TestBread_Eat_Cancel(t *testing.T) {
+	cases := [...]struct {
+		bread Bread
+	}{
+		{
+			bread: Bread{Workers: 2, BufferSize: KB},
+		},
+		{
+			bread: Bread{Workers: 2, BufferSize: KB, QueueDepth: 8},
+		},
+		{
+			bread: Bread{Workers: 2, BufferSize: KB, QueueDepth: 8, PriorityFunc: func(BatchMeta, []byte) int { return 0 }},
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.TODO(), 50*time.Millisecond)
+			defer cancel()
+
+			processed := atomic.Uint64{}
+
+			// The worker ignores the context
+			c.bread.WorkerFunc = func(context.Context, *[]byte) {
+				time.Sleep(20 * time.Millisecond)
+				processed.Add(1)
+			}
+
+			var stats Stats
+
+			c.bread.OnComplete = func(_ context.Context, s Stats, _ error) {
+				stats = s
+			}
+
+			start := time.Now()
+
+			// An endless stream of records
+			err := c.bread.Eat(ctx, repeatReader('\n'))
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("expected %v, got %v", context.DeadlineExceeded, err)
+			}
+
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Fatalf("Eat returned after %s", elapsed)
+			}
+
+			// The batches dropped by the cancellation are not counted
+			if stats.Batches != processed.Load() {
+				t.Fatalf("%d batches processed, expected %d", processed.Load(), stats.Batches)
+			}
+
+			t.Logf("Success! %d batches", stats.Batches)
+		})
+	}
+}
+
+// BenchmarkBread_Workers eats 1 GB with small buffers, where the cost of handing each batch to a worker dominates
+func BenchmarkBread_Workers(b *testing.B) {
+	data := memoryData(16 * MB)
+
+	bread := Bread{
+		Workers:    16,
+		BufferSize: 4 * KB,
+	}
+
+	b.SetBytes(GB)
+	b.ReportAllocs()
+
+	for n := 0; n < b.N; n++ {
+		readers := make([]io.Reader, GB/len(data))
+		for i := range readers {
+			readers[i] = bytes.NewReader(data)
+		}
+
+		err := bread.EatBatches(context.TODO(), io.MultiReader(readers...), func(context.Context, Batch) {})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestBread_Workers(t *testing.T) {
+	cases := [...]Bread{
+		{Workers: 1, BufferSize: 16},
+		{Workers: 4, BufferSize: 16},
+		{Workers: 4, BufferSize: 16, QueueDepth: 8},
+	}
+
+	for i, bread := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			goroutines := runtime.NumGoroutine()
+
+			// The dispatcher of the queue runs on its own goroutine
+			limit := goroutines + bread.Workers
+			if bread.QueueDepth > 0 {
+				limit++
+			}
+
+			peak := atomic.Int64{}
+
+			err := bread.EatBatches(context.TODO(), bytes.NewReader(memoryData(MB)), func(context.Context, Batch) {
+				peak.Store(max(peak.Load(), int64(runtime.NumGoroutine())))
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if peak.Load() > int64(limit) {
+				t.Fatalf("expected at most %d goroutines, got %d", limit, peak.Load())
+			}
+
+			// The worker goroutines exit right after Eat returns
+			for deadline := time.Now().Add(time.Second); runtime.NumGoroutine() > goroutines; {
+				if time.Now().After(deadline) {
+					t.Fatalf("expected %d goroutines, got %d", goroutines, runtime.NumGoroutine())
+				}
+
+				time.Sleep(time.Millisecond)
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+// TestBread_LargeBufferSize checks the sizes derived from a BufferSize that does not fit in 31 bits. The buffers
+// are never allocated, only the sizes the pool and the reader would be built with are computed.
+func TestBread_LargeBufferSize(t *testing.T) {
+	if math.MaxInt == math.MaxInt32 {
+		t.Skip("BufferSize above 2GB requires a 64-bit platform")
+	}
+
+	size := 2*GB + 1
+
+	cases := [...]struct {
+		bread Bread
+		// maxCap is the capacity the pooled buffers can grow to
+		maxCap int
+		// readerSize is the size of the bufio.Reader
+		readerSize int
+	}{
+		{
+			bread:      Bread{BufferSize: size},
+			maxCap:     4 * size,
+			readerSize: size,
+		},
+		{
+			bread:      Bread{BufferSize: size, MaxBatchSize: 2 * size, ReaderBufferSize: KB},
+			maxCap:     4 * size,
+			readerSize: KB,
+		},
+		{
+			bread:      Bread{BufferSize: size, AdaptiveBuffer: true},
+			maxCap:     4 * 64 * size,
+			readerSize: size,
+		},
+		{
+			bread:      Bread{BufferSize: size, MaxBufferCap: 3 * size, PoolStrategy: PoolFreelist},
+			maxCap:     3 * size,
+			readerSize: size,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			if err := c.bread.Validate(); err != nil {
+				t.Fatal(err)
+			}
+
+			if maxCap := c.bread.maxBufferCap(); maxCap != c.maxCap {
+				t.Fatalf("expected max capacity %d, got %d", c.maxCap, maxCap)
+			}
+
+			if readerSize := c.bread.readerBufferSize(); readerSize != c.readerSize {
+				t.Fatalf("expected reader size %d, got %d", c.readerSize, readerSize)
+			}
+
+			// The pool is built with the same sizes, without taking any buffer from it
+			shared := c.bread.sharedPool()
+			if shared.Size() != size {
+				t.Fatalf("expected pool size %d, got %d", size, shared.Size())
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+func TestBread_WorkerInit(t *testing.T) {
+	errInit := errors.New("init failure")
+	errClose := errors.New("close failure")
+
+	cases := [...]struct {
+		data      string
+		initErr   int
+		closeErr  int
+		cancelled bool
+		closes    int
+		err       error
+	}{
+		{
+			data:   strings.Repeat("ok\n", 100),
+			closes: 4,
+		},
+		// The workers without any batch are closed too
+		{
+			data:   "ok\n",
+			closes: 4,
+		},
+		{
+			data:    strings.Repeat("ok\n", 100),
+			initErr: 2,
+			closes:  3,
+			err:     errInit,
+		},
+		{
+			data:     strings.Repeat("ok\n", 100),
+			closeErr: 1,
+			closes:   4,
+			err:      errClose,
+		},
+		{
+			data:      strings.Repeat("ok\n", 100),
+			cancelled: true,
+			closes:    4,
+			err:       context.Canceled,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			if c.cancelled {
+				cancel()
+			}
+
+			records, closes := atomic.Int64{}, atomic.Int64{}
+			finals, completions := atomic.Int64{}, atomic.Int64{}
+
+			var completeErr error
+
+			bread := Bread{
+				Workers:    4,
+				BufferSize: 1,
+				FinalFunc: func(context.Context, []byte, Stats) error {
+					finals.Add(1)
+					return nil
+				},
+				OnComplete: func(_ context.Context, _ Stats, err error) {
+					completions.Add(1)
+					completeErr = err
+				},
+				WorkerInit: func(_ context.Context, id int) (any, error) {
+					if c.initErr > 0 && id == c.initErr {
+						return nil, errInit
+					}
+
+					return new(int), nil
+				},
+				WorkerStateFunc: func(_ context.Context, state any, _ *[]byte) error {
+					// A state is only used by one batch at a time
+					*state.(*int)++
+					records.Add(1)
+
+					return nil
+				},
+				WorkerClose: func(ctx context.Context, id int, state any) error {
+					if ctx.Err() != nil {
+						t.Errorf("worker %d closed with a done context", id)
+					}
+
+					closes.Add(1)
+					records.Add(-int64(*state.(*int)))
+
+					if c.closeErr > 0 && id == c.closeErr {
+						return errClose
+					}
+
+					return nil
+				},
+			}
+
+			err := bread.Eat(ctx, strings.NewReader(c.data))
+			if !errors.Is(err, c.err) {
+				t.Fatalf("expected error %v, got %v", c.err, err)
+			}
+
+			if closes.Load() != int64(c.closes) {
+				t.Fatalf("expected %d closes, got %d", c.closes, closes.Load())
+			}
+
+			// Every record was counted by the state of its worker
+			if records.Load() != 0 {
+				t.Fatalf("expected the states to hold every record, %d left", records.Load())
+			}
+
+			// The completion hooks run even when the workers could not start
+			if finals.Load() != 1 || completions.Load() != 1 {
+				t.Fatalf("expected one call to FinalFunc and OnComplete, got %d and %d", finals.Load(), completions.Load())
+			}
+
+			if completeErr != err {
+				t.Fatalf("expected OnComplete to receive %v, got %v", err, completeErr)
+			}
+
+			t.Logf("Success! %v", err)
+		})
+	}
+}
+
+func TestBread_WorkerBatchFunc(t *testing.T) {
+	cases := [...]struct {
+		bread Bread
+		data  []byte
+	}{
+		{
+			bread: Bread{Workers: 8, BufferSize: KB},
+			data:  memoryData(MB),
+		},
+		{
+			bread: Bread{Workers: 4, BufferSize: 7, Delimiter: ','},
+			data:  []byte("a,bb,ccc,dddd,eeeee,ffffff,ggggggg,hhhhhhhh"),
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			mu := sync.Mutex{}
+			batches := make(map[uint64]Batch)
+
+			c.bread.WorkerFunc = func(context.Context, *[]byte) {
+				t.Error("WorkerFunc must be ignored")
+			}
+
+			c.bread.WorkerBatchFunc = func(_ context.Context, batch Batch) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				batches[batch.Index] = Batch{BatchMeta: batch.BatchMeta, Data: bytes.Clone(batch.Data)}
+			}
+
+			err := c.bread.Eat(context.TODO(), bytes.NewReader(c.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// The metadata alone puts the batches back in order
+			got := make([]byte, 0, len(c.data))
+
+			for index := uint64(0); index < uint64(len(batches)); index++ {
+				batch, ok := batches[index]
+				if !ok {
+					t.Fatalf("missing batch %d", index)
+				}
+
+				if batch.Offset != int64(len(got)) {
+					t.Fatalf("batch %d starts at %d, expected %d", index, batch.Offset, len(got))
+				}
+
+				got = append(got, batch.Data...)
+			}
+
+			if !bytes.Equal(got, c.data) {
+				t.Fatal("the batches do not reproduce the input")
+			}
+
+			t.Log("Success!")
+		})
+	}
 }
@@ -0,0 +1,81 @@
+// Package breadhttp streams HTTP request bodies through bread
+package breadhttp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/yael-castro/bread"
+)
+
+// Handler returns an http.Handler that streams each request body through b.Eat, with the request context.
+//
+// Bodies larger than maxBody fail with an *http.MaxBytesError, a non-positive maxBody disables the limit.
+// When the client disconnects the body is closed, so a read blocked on it returns and the processing stops promptly.
+//
+// onResult writes the response from the statistics and the error returned by Eat, when it is nil
+// the response is written by WriteResult.
+func Handler(b bread.Bread, maxBody int64, onResult func(w http.ResponseWriter, stats bread.Stats, err error)) http.Handler {
+	if onResult == nil {
+		onResult = WriteResult
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := r.Body
+		if maxBody > 0 {
+			body = http.MaxBytesReader(w, body, maxBody)
+		}
+
+		// Unblocks the reading when the client goes away
+		stop := context.AfterFunc(r.Context(), func() {
+			_ = body.Close()
+		})
+		defer stop()
+
+		stats := bread.Stats{}
+
+		config := b
+		config.OnComplete = func(ctx context.Context, s bread.Stats, err error) {
+			stats = s
+
+			if b.OnComplete != nil {
+				b.OnComplete(ctx, s, err)
+			}
+		}
+
+		err := config.Eat(r.Context(), body)
+		if err == nil {
+			err = r.Context().Err()
+		}
+
+		onResult(w, stats, err)
+	})
+}
+
+// WriteResult maps the result of Eat to a status code:
+//   - 200 OK when the body was processed
+//   - 413 Request Entity Too Large when the body exceeded the limit
+//   - 400 Bad Request when the body could not be read or framed
+//   - 500 Internal Server Error for any other error
+//
+// Nothing is written when the client went away.
+func WriteResult(w http.ResponseWriter, _ bread.Stats, err error) {
+	var (
+		maxBytesErr *http.MaxBytesError
+		readErr     *bread.ReadError
+		framingErr  *bread.FramingError
+	)
+
+	switch {
+	case err == nil:
+		w.WriteHeader(http.StatusOK)
+	case errors.Is(err, context.Canceled):
+	case errors.As(err, &maxBytesErr):
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+	case errors.As(err, &readErr), errors.As(err, &framingErr):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,133 @@
+package breadhttp
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/yael-castro/bread"
+)
+
+func TestHandler(t *testing.T) {
+	const records = 1_000
+
+	body := strings.Repeat("{\"key\":\"value\"}\n", records)
+
+	cases := [...]struct {
+		maxBody int64
+		status  int
+		records uint64
+	}{
+		{
+			status:  http.StatusOK,
+			records: records,
+		},
+		{
+			maxBody: int64(len(body)),
+			status:  http.StatusOK,
+			records: records,
+		},
+		{
+			maxBody: int64(len(body)) - 1,
+			status:  http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			var count atomic.Uint64
+
+			b := bread.Bread{
+				Workers:    4,
+				BufferSize: 64,
+				WorkerFunc: func(_ context.Context, data *[]byte) {
+					count.Add(uint64(bytes.Count(*data, []byte("\n"))))
+				},
+			}
+
+			var stats bread.Stats
+
+			server := httptest.NewServer(Handler(b, c.maxBody, func(w http.ResponseWriter, s bread.Stats, err error) {
+				stats = s
+				WriteResult(w, s, err)
+			}))
+			defer server.Close()
+
+			response, err := http.Post(server.URL, "application/x-ndjson", strings.NewReader(body))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_ = response.Body.Close()
+
+			if response.StatusCode != c.status {
+				t.Fatalf("expected status %d, got %d", c.status, response.StatusCode)
+			}
+
+			if c.status != http.StatusOK {
+				t.Log("Success!")
+				return
+			}
+
+			if count.Load() != c.records || stats.Records != c.records {
+				t.Fatalf("expected %d records, got %d (stats %d)", c.records, count.Load(), stats.Records)
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+func TestHandler_ClientDisconnect(t *testing.T) {
+	results := make(chan error, 1)
+
+	b := bread.Bread{
+		BufferSize: 64,
+		WorkerFunc: func(context.Context, *[]byte) {},
+	}
+
+	server := httptest.NewServer(Handler(b, 0, func(_ http.ResponseWriter, _ bread.Stats, err error) {
+		results <- err
+	}))
+	defer server.Close()
+
+	// Slow client that sends a single record and then stalls
+	reader, writer := io.Pipe()
+	defer writer.Close()
+
+	go func() {
+		_, _ = io.WriteString(writer, "first record\n")
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	request, _ := http.NewRequestWithContext(ctx, http.MethodPost, server.URL, reader)
+
+	go func() {
+		response, err := http.DefaultClient.Do(request)
+		if err == nil {
+			_ = response.Body.Close()
+		}
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-results:
+		if err == nil {
+			t.Fatalf("expected the processing to be canceled, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the processing was not stopped after the client went away")
+	}
+
+	t.Log("Success!")
+}
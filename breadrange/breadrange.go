@@ -0,0 +1,341 @@
+// Package breadrange eats files served over HTTP with concurrent Range requests
+package breadrange
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/yael-castro/bread"
+)
+
+// Default Source parameters
+const (
+	DefaultRetries = 3
+	DefaultBackoff = 100 * time.Millisecond
+)
+
+// ErrUnexpectedStatus indicates that the server answered a request with an unexpected status code
+var ErrUnexpectedStatus = errors.New("unexpected status code")
+
+// Source is a file served over HTTP
+type Source struct {
+	// URL of the file
+	//
+	// This member is required.
+	URL string
+	// Client performs the requests
+	//
+	// This member is optional. Default value http.DefaultClient
+	Client *http.Client
+	// Retries is the number of times a failed request is retried, resuming from the last byte received
+	//
+	// This member is optional. Default value DefaultRetries
+	Retries int
+	// Backoff is the wait before the first retry, it doubles on each retry
+	//
+	// This member is optional. Default value DefaultBackoff
+	Backoff time.Duration
+}
+
+// Eat eats the file with b using Range requests, see bread.Bread.EatAt. The file is split in up to Workers ranges
+// aligned to the delimiters, each one streamed and processed by its own worker, so the batch offsets count from the
+// start of the file, the batch indexes are unique and at most Workers batches are processed at a time.
+//
+// The file is streamed with a single GET when it is empty, the server does not report its size or ignores Range
+// requests, and when RecordSize, SplitFunc or CSVMode are set, since EatAt does not support them.
+func (s Source) Eat(ctx context.Context, b bread.Bread) error {
+	s = s.withDefaults()
+
+	size, err := s.size(ctx)
+	if err != nil {
+		return err
+	}
+
+	// An empty file has no byte to probe the Range requests with
+	if size <= 0 || b.RecordSize > 0 || b.SplitFunc != nil || b.CSVMode {
+		return s.stream(ctx, b)
+	}
+
+	ranged, err := s.ranged(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !ranged {
+		return s.stream(ctx, b)
+	}
+
+	reader := &rangeReaderAt{source: s, ctx: ctx, size: size, readers: make(map[int64]*rangeReader)}
+	defer reader.Close()
+
+	return b.EatAt(ctx, reader, size)
+}
+
+func (s Source) withDefaults() Source {
+	if s.Client == nil {
+		s.Client = http.DefaultClient
+	}
+
+	if s.Retries < 0 {
+		s.Retries = 0
+	} else if s.Retries == 0 {
+		s.Retries = DefaultRetries
+	}
+
+	if s.Backoff <= 0 {
+		s.Backoff = DefaultBackoff
+	}
+
+	return s
+}
+
+// size returns the size of the file reported by a HEAD request, or -1 if it is unknown
+func (s Source) size(ctx context.Context) (size int64, err error) {
+	err = s.retry(ctx, func() error {
+		request, err := http.NewRequestWithContext(ctx, http.MethodHead, s.URL, nil)
+		if err != nil {
+			return err
+		}
+
+		response, err := s.Client.Do(request)
+		if err != nil {
+			return err
+		}
+
+		_ = response.Body.Close()
+
+		if response.StatusCode != http.StatusOK {
+			return fmt.Errorf("%w %d for HEAD", ErrUnexpectedStatus, response.StatusCode)
+		}
+
+		size = response.ContentLength
+		return nil
+	})
+
+	return
+}
+
+// stream eats the whole file with a single GET request
+func (s Source) stream(ctx context.Context, b bread.Bread) error {
+	reader := &rangeReader{source: s, ctx: ctx, end: -1}
+	defer reader.Close()
+
+	return b.Eat(ctx, reader)
+}
+
+// ranged reports whether the server honors the Range requests
+func (s Source) ranged(ctx context.Context) (ranged bool, err error) {
+	err = s.retry(ctx, func() error {
+		response, err := s.get(ctx, 0, 1)
+		if err != nil {
+			return err
+		}
+
+		_ = response.Body.Close()
+
+		ranged = response.StatusCode == http.StatusPartialContent
+		return nil
+	})
+
+	return
+}
+
+// get requests the bytes in [start, end), or from start to the end of the file when end is negative
+func (s Source) get(ctx context.Context, start, end int64) (*http.Response, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if end >= 0 {
+		request.Header.Set("Range", "bytes="+strconv.FormatInt(start, 10)+"-"+strconv.FormatInt(end-1, 10))
+	} else if start > 0 {
+		request.Header.Set("Range", "bytes="+strconv.FormatInt(start, 10)+"-")
+	}
+
+	response, err := s.Client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+
+	switch response.StatusCode {
+	case http.StatusOK, http.StatusPartialContent:
+		return response, nil
+	}
+
+	_ = response.Body.Close()
+
+	return nil, fmt.Errorf("%w %d for bytes %d-%d", ErrUnexpectedStatus, response.StatusCode, start, end)
+}
+
+// retry calls fn until it succeeds, the retries are exhausted or the context is done
+func (s Source) retry(ctx context.Context, fn func() error) (err error) {
+	backoff := s.Backoff
+
+	for attempt := 0; ; attempt++ {
+		if err = fn(); err == nil || attempt >= s.Retries {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+	}
+}
+
+// rangeReader reads the bytes in [position, end) of the file, resuming from the last byte received
+// when a request fails. A negative end reads up to the end of the file.
+type rangeReader struct {
+	source   Source
+	ctx      context.Context
+	position int64
+	end      int64
+	body     io.ReadCloser
+	// done indicates that the whole range was received
+	done bool
+}
+
+func (r *rangeReader) Read(p []byte) (n int, err error) {
+	if r.done || (r.end >= 0 && r.position >= r.end) {
+		return 0, io.EOF
+	}
+
+	if r.end >= 0 {
+		p = p[:min(int64(len(p)), r.end-r.position)]
+	}
+
+	err = r.source.retry(r.ctx, func() (err error) {
+		if r.body == nil {
+			response, err := r.source.get(r.ctx, r.position, r.end)
+			if err != nil {
+				return err
+			}
+
+			// The server ignored the Range request, only acceptable when the file is read from the beginning
+			if response.StatusCode == http.StatusOK && r.position > 0 {
+				_ = response.Body.Close()
+				return fmt.Errorf("%w %d for a Range request", ErrUnexpectedStatus, response.StatusCode)
+			}
+
+			r.body = response.Body
+		}
+
+		n, err = r.body.Read(p)
+		r.position += int64(n)
+
+		// The next Read resumes with a new request
+		if err != nil {
+			_ = r.Close()
+		}
+
+		switch {
+		case err == io.EOF && (r.end < 0 || r.position >= r.end):
+			r.done = true
+			return nil
+		case err == io.EOF && n == 0:
+			return io.ErrUnexpectedEOF
+		case n > 0:
+			return nil
+		}
+
+		return err
+	})
+
+	if err == nil && n == 0 && r.done {
+		err = io.EOF
+	}
+
+	return
+}
+
+// Close releases the current request
+func (r *rangeReader) Close() error {
+	if r.body == nil {
+		return nil
+	}
+
+	err := r.body.Close()
+	r.body = nil
+
+	return err
+}
+
+// rangeReaderAt reads the file at any position. A read that starts where a previous one ended continues its
+// request, so the ranges read by bread.Bread.EatAt are streamed instead of sending a request for each read.
+type rangeReaderAt struct {
+	source Source
+	ctx    context.Context
+	size   int64
+
+	mu sync.Mutex
+	// readers holds the readers not in use, by the position they continue from
+	readers map[int64]*rangeReader
+}
+
+func (r *rangeReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	reader := r.take(off, len(p))
+	defer r.put(reader)
+
+	n, err := io.ReadFull(reader, p)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+
+	return n, err
+}
+
+// take returns the reader that continues from off, or a reader of the n bytes at off when there is none
+func (r *rangeReaderAt) take(off int64, n int) *rangeReader {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if reader, ok := r.readers[off]; ok {
+		delete(r.readers, off)
+		return reader
+	}
+
+	// A single read, like the ones looking for the delimiters, only requests the bytes it needs
+	return &rangeReader{source: r.source, ctx: r.ctx, position: off, end: min(off+int64(n), r.size)}
+}
+
+// put keeps the reader for the read that continues from its position
+func (r *rangeReaderAt) put(reader *rangeReader) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// The next reads are streamed up to the end of the file, the request is only sent if they happen
+	if reader.position >= reader.end && reader.end < r.size {
+		_ = reader.Close()
+		reader = &rangeReader{source: r.source, ctx: r.ctx, position: reader.end, end: r.size}
+	}
+
+	if previous, ok := r.readers[reader.position]; ok {
+		_ = previous.Close()
+	}
+
+	r.readers[reader.position] = reader
+}
+
+// Close releases the requests of the readers not in use
+func (r *rangeReaderAt) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, reader := range r.readers {
+		_ = reader.Close()
+	}
+
+	clear(r.readers)
+
+	return nil
+}
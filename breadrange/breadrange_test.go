@@ -0,0 +1,167 @@
+package breadrange
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/yael-castro/bread"
+)
+
+func TestSource_Eat(t *testing.T) {
+	builder := strings.Builder{}
+	for i := 0; i < 500; i++ {
+		_, _ = fmt.Fprintf(&builder, "record %d %s\n", i, strings.Repeat("x", i%37))
+	}
+
+	data := builder.String()
+
+	// ranged serves the data honoring the Range requests, failing the first request of each range
+	ranged := func() http.Handler {
+		failed := sync.Map{}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, loaded := failed.LoadOrStore(r.Method+r.Header.Get("Range"), true); !loaded {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+
+			http.ServeContent(w, r, "", time.Time{}, strings.NewReader(data))
+		})
+	}
+
+	// plain serves the data ignoring the Range requests
+	plain := func() http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+
+			if r.Method == http.MethodGet {
+				_, _ = w.Write([]byte(data))
+			}
+		})
+	}
+
+	cases := [...]struct {
+		handler http.Handler
+		bread   bread.Bread
+		ranges  bool
+	}{
+		{handler: ranged(), bread: bread.Bread{Workers: 1, BufferSize: 256}, ranges: true},
+		{handler: ranged(), bread: bread.Bread{Workers: 4, BufferSize: 256}, ranges: true},
+		{handler: ranged(), bread: bread.Bread{Workers: 16, BufferSize: 64}, ranges: true},
+		// The ranges are aligned to the delimiters of several bytes
+		{handler: ranged(), bread: bread.Bread{Workers: 4, BufferSize: 64, DelimiterBytes: []byte("x\n")}, ranges: true},
+		{handler: ranged(), bread: bread.Bread{Workers: 4, BufferSize: 64, StartOffset: 1000, AlignToDelimiter: true}, ranges: true},
+		{handler: plain(), bread: bread.Bread{Workers: 4, BufferSize: 256}},
+		// EatAt does not support fixed size records
+		{handler: ranged(), bread: bread.Bread{Workers: 4, BufferSize: 256, RecordSize: 1}},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			var rangeRequests atomic.Int32
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Range") != "" {
+					rangeRequests.Add(1)
+				}
+
+				c.handler.ServeHTTP(w, r)
+			}))
+			defer server.Close()
+
+			separator := c.bread.DelimiterBytes
+			if separator == nil {
+				separator = []byte("\n")
+			}
+
+			start := 0
+			if c.bread.StartOffset > 0 {
+				start = int(c.bread.StartOffset) + strings.Index(data[c.bread.StartOffset:], "\n") + 1
+			}
+
+			mu := sync.Mutex{}
+			records := make([]string, 0)
+			indexes := make(map[uint64]bool)
+			size := 0
+
+			var running, maxRunning atomic.Int32
+
+			c.bread.WorkerBatchFunc = func(_ context.Context, batch bread.Batch) {
+				maxRunning.Store(max(maxRunning.Load(), running.Add(1)))
+				defer running.Add(-1)
+
+				// The batches of several ranges are processed at the same time
+				time.Sleep(time.Millisecond)
+
+				mu.Lock()
+				defer mu.Unlock()
+
+				if string(batch.Data) != data[batch.Offset:batch.Offset+int64(batch.Size)] {
+					t.Errorf("batch %d is not the file at offset %d", batch.Index, batch.Offset)
+				}
+
+				if indexes[batch.Index] {
+					t.Errorf("duplicated batch index %d", batch.Index)
+				}
+
+				indexes[batch.Index] = true
+				size += batch.Size
+
+				for _, record := range bytes.SplitAfter(batch.Data, separator) {
+					if len(record) > 0 {
+						records = append(records, string(record))
+					}
+				}
+			}
+
+			source := Source{
+				URL:     server.URL,
+				Backoff: time.Millisecond,
+			}
+
+			if err := source.Eat(context.Background(), c.bread); err != nil {
+				t.Fatal(err)
+			}
+
+			if size != len(data)-start {
+				t.Fatalf("expected %d bytes, got %d", len(data)-start, size)
+			}
+
+			if maxRunning.Load() > int32(c.bread.Workers) {
+				t.Fatalf("expected at most %d batches at a time, got %d", c.bread.Workers, maxRunning.Load())
+			}
+
+			expected := make([]string, 0)
+
+			for _, record := range bytes.SplitAfter([]byte(data[start:]), separator) {
+				if len(record) > 0 {
+					expected = append(expected, string(record))
+				}
+			}
+
+			sort.Strings(expected)
+			sort.Strings(records)
+
+			if c.bread.RecordSize == 0 && strings.Join(records, "|") != strings.Join(expected, "|") {
+				t.Fatal("the records do not match the file")
+			}
+
+			// Servers that ignore Range requests only receive the probe
+			if c.ranges != (rangeRequests.Load() > 1) {
+				t.Fatalf("unexpected number of Range requests %d", rangeRequests.Load())
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
@@ -0,0 +1,91 @@
+// Package breadslog logs the events of bread through log/slog
+package breadslog
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/yael-castro/bread"
+)
+
+// Observer is a bread.Observer that logs every event to Logger. The start, epoch and completion events are
+// logged at info level, the batch events at debug level, and the failures at error level.
+type Observer struct {
+	Logger *slog.Logger
+}
+
+// New returns an Observer that logs to logger, slog.Default is used when it is nil
+func New(logger *slog.Logger) *Observer {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &Observer{Logger: logger}
+}
+
+// OnStart implements bread.Observer
+func (o *Observer) OnStart(ctx context.Context, effective bread.Config) {
+	o.Logger.LogAttrs(ctx, slog.LevelInfo, "bread started",
+		slog.Uint64("workers", uint64(effective.Workers)),
+		slog.Uint64("buffer_size", uint64(effective.BufferSize)),
+		slog.Uint64("queue_depth", uint64(effective.QueueDepth)),
+	)
+}
+
+// OnBatchStart implements bread.Observer
+func (o *Observer) OnBatchStart(ctx context.Context, meta bread.BatchMeta, queueAge time.Duration) {
+	o.Logger.LogAttrs(ctx, slog.LevelDebug, "batch started", batch(meta), slog.Duration("queue_age", queueAge))
+}
+
+// OnBatchEnd implements bread.Observer
+func (o *Observer) OnBatchEnd(ctx context.Context, meta bread.BatchMeta, err error) {
+	if err != nil {
+		o.Logger.LogAttrs(ctx, slog.LevelError, "batch failed", batch(meta), slog.Any("error", err))
+		return
+	}
+
+	o.Logger.LogAttrs(ctx, slog.LevelDebug, "batch completed", batch(meta))
+}
+
+// OnBackpressure implements bread.Observer
+func (o *Observer) OnBackpressure(ctx context.Context, meta bread.BatchMeta, wait time.Duration) {
+	o.Logger.LogAttrs(ctx, slog.LevelDebug, "batch waited for a worker", batch(meta), slog.Duration("wait", wait))
+}
+
+// OnEpoch implements bread.Observer
+func (o *Observer) OnEpoch(ctx context.Context, epoch uint32, stats bread.EpochStats) {
+	o.Logger.LogAttrs(ctx, slog.LevelInfo, "epoch completed",
+		slog.Uint64("epoch", uint64(epoch)),
+		slog.Uint64("bytes", uint64(stats.BytesRead)),
+		slog.Uint64("batches", stats.Batches),
+		slog.Uint64("records", stats.Records),
+	)
+}
+
+// OnComplete implements bread.Observer
+func (o *Observer) OnComplete(ctx context.Context, stats bread.Stats, err error) {
+	attrs := []slog.Attr{
+		slog.Uint64("bytes", uint64(stats.BytesRead)),
+		slog.Uint64("batches", stats.Batches),
+		slog.Uint64("records", stats.Records),
+		slog.Uint64("failures", stats.Failures),
+		slog.Duration("duration", stats.Duration),
+	}
+
+	if err != nil {
+		o.Logger.LogAttrs(ctx, slog.LevelError, "bread failed", append(attrs, slog.Any("error", err))...)
+		return
+	}
+
+	o.Logger.LogAttrs(ctx, slog.LevelInfo, "bread completed", attrs...)
+}
+
+// batch groups the attributes of a batch
+func batch(meta bread.BatchMeta) slog.Attr {
+	return slog.Group("batch",
+		slog.Uint64("index", meta.Index),
+		slog.Int64("offset", meta.Offset),
+		slog.Int("size", meta.Size),
+	)
+}
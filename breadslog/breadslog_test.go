@@ -0,0 +1,91 @@
+package breadslog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/yael-castro/bread"
+)
+
+// failingSink fails the batch with index 1
+type failingSink struct{}
+
+var errSink = errors.New("sink failure")
+
+func (failingSink) Write(_ context.Context, meta bread.BatchMeta, _ []byte) error {
+	if meta.Index == 1 {
+		return errSink
+	}
+
+	return nil
+}
+
+func (failingSink) Flush(context.Context) error {
+	return nil
+}
+
+func TestObserver(t *testing.T) {
+	cases := [...]struct {
+		level    slog.Level
+		sink     bread.Sink
+		expected []string
+	}{
+		{
+			level:    slog.LevelInfo,
+			expected: []string{"bread started", "bread completed"},
+		},
+		{
+			level: slog.LevelDebug,
+			expected: []string{
+				"bread started",
+				"batch started", "batch completed",
+				"batch started", "batch completed",
+				"bread completed",
+			},
+		},
+		{
+			level:    slog.LevelInfo,
+			sink:     failingSink{},
+			expected: []string{"bread started", "batch failed", "bread failed"},
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			output := &bytes.Buffer{}
+			logger := slog.New(slog.NewTextHandler(output, &slog.HandlerOptions{Level: c.level}))
+
+			b := bread.Bread{
+				BufferSize: 1,
+				Sink:       c.sink,
+				WorkerFunc: func(context.Context, *[]byte) {},
+				Observer:   New(logger),
+			}
+
+			_ = b.Eat(context.TODO(), strings.NewReader("a\nb\n"))
+
+			var messages []string
+
+			for _, line := range strings.Split(strings.TrimSpace(output.String()), "\n") {
+				start := strings.Index(line, "msg=\"") + len("msg=\"")
+				message := line[start : start+strings.IndexByte(line[start:], '"')]
+
+				// Backpressure depends on the timing of the worker
+				if message != "batch waited for a worker" {
+					messages = append(messages, message)
+				}
+			}
+
+			if strings.Join(messages, "|") != strings.Join(c.expected, "|") {
+				t.Fatalf("expected messages %q, got %q", c.expected, messages)
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
@@ -0,0 +1,297 @@
+// Package breadsql provides a sink that inserts the records eaten by bread in a database/sql table
+package breadsql
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/yael-castro/bread"
+)
+
+// ErrColumnMismatch indicates that a record was bound to a different number of columns than the previous ones
+var ErrColumnMismatch = errors.New("column count mismatch")
+
+// InsertError indicates which record failed to be bound or inserted
+type InsertError struct {
+	// Position is the stream offset of the record, for failed inserts it is the first record of the statement
+	Position int64
+	Err      error
+}
+
+func (e *InsertError) Error() string {
+	return fmt.Sprintf("insert at offset %d: %v", e.Position, e.Err)
+}
+
+func (e *InsertError) Unwrap() error {
+	return e.Err
+}
+
+// Offset implements bread.Positioned
+func (e *InsertError) Offset() int64 {
+	return e.Position
+}
+
+// Inserter is a bread.Sink that inserts the records in a table using multi-row INSERT statements, see NewInserter.
+//
+// The rows are buffered across batches, in one buffer for each Write running at once, so one for each worker.
+// A buffer is inserted in its own transaction once it holds batchRows rows, and Flush inserts the rows left in every
+// buffer, even when the Eat call failed, since the batches they come from were written. The errors of Write and
+// Flush are returned by the Eat call. An Inserter can be used by one Eat call at a time.
+type Inserter struct {
+	// Delimiter delimits the records of a batch, empty records are skipped
+	//
+	// This member is optional. Default value bread.DefaultDelimiter
+	Delimiter byte
+	// Placeholder returns the placeholder of the n-th argument of a statement, starting from 1.
+	// Use it for drivers that do not support "?", e.g. "$" + strconv.Itoa(n) for PostgreSQL
+	//
+	// This member is optional. By default every placeholder is "?"
+	Placeholder func(n int) string
+	// Retryable reports whether the transaction of a buffer can be retried after err, e.g. on deadlocks
+	//
+	// This member is optional. By default transactions are not retried
+	Retryable func(err error) bool
+	// Retries is the maximum number of times the transaction of a buffer is retried, see Retryable
+	//
+	// This member is optional. Default value 0
+	Retries int
+
+	db        *sql.DB
+	stmt      string
+	bind      func(record []byte) ([]any, error)
+	batchRows int
+
+	mu sync.Mutex
+	// idle holds the buffers not used by any Write
+	idle []*rowBuffer
+}
+
+// rowBuffer holds the bound records waiting to be inserted, along with their stream offsets
+type rowBuffer struct {
+	values    [][]any
+	positions []int64
+}
+
+// NewInserter builds an Inserter that binds each record with bind and appends the resulting rows to stmt,
+// an INSERT statement ending in VALUES, e.g. "INSERT INTO logs (level, message) VALUES".
+//
+// Each statement inserts up to batchRows rows, and the rows are buffered until there are batchRows of them,
+// batchRows defaults to 1.
+func NewInserter(db *sql.DB, stmt string, bind func(record []byte) ([]any, error), batchRows int) *Inserter {
+	return &Inserter{
+		db:        db,
+		stmt:      strings.TrimSpace(stmt),
+		bind:      bind,
+		batchRows: max(batchRows, 1),
+	}
+}
+
+// Write implements bread.Sink, it binds the records of the batch and buffers them, inserting the buffer once it
+// holds batchRows rows. Either every record of the batch is buffered or none of them, so when the insert fails the
+// rows of the batch are dropped, while the rows of the batches written before are left for the next insert.
+func (i *Inserter) Write(ctx context.Context, meta bread.BatchMeta, data []byte) error {
+	// The values may point to the data, which is only valid during the call
+	batch, err := i.rows(meta.Offset, bytes.Clone(data))
+	if err != nil || len(batch.values) == 0 {
+		return err
+	}
+
+	buffer := i.take()
+	defer i.put(buffer)
+
+	if len(buffer.values) > 0 && len(batch.values[0]) != len(buffer.values[0]) {
+		return &InsertError{Position: batch.positions[0], Err: ErrColumnMismatch}
+	}
+
+	written := len(buffer.values)
+
+	buffer.values = append(buffer.values, batch.values...)
+	buffer.positions = append(buffer.positions, batch.positions...)
+
+	if len(buffer.values) < i.batchRows {
+		return nil
+	}
+
+	if err = i.flush(ctx, buffer); err != nil {
+		buffer.truncate(written)
+	}
+
+	return err
+}
+
+// Flush implements bread.Sink, it inserts the rows left in the buffers and empties them
+func (i *Inserter) Flush(ctx context.Context) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	errs := make([]error, 0)
+
+	for _, buffer := range i.idle {
+		if err := i.flush(ctx, buffer); err != nil {
+			errs = append(errs, err)
+		}
+
+		buffer.truncate(0)
+	}
+
+	return errors.Join(errs...)
+}
+
+// take returns an idle buffer, or a new one when every buffer is in use
+func (i *Inserter) take() *rowBuffer {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if len(i.idle) == 0 {
+		return &rowBuffer{}
+	}
+
+	buffer := i.idle[len(i.idle)-1]
+	i.idle = i.idle[:len(i.idle)-1]
+
+	return buffer
+}
+
+// put returns a buffer taken by take
+func (i *Inserter) put(buffer *rowBuffer) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.idle = append(i.idle, buffer)
+}
+
+// flush inserts the rows of the buffer in a single transaction, the buffer is emptied unless it fails
+func (i *Inserter) flush(ctx context.Context, buffer *rowBuffer) (err error) {
+	if len(buffer.values) == 0 {
+		return nil
+	}
+
+	for attempt := 0; ; attempt++ {
+		err = i.insert(ctx, buffer)
+		if err == nil {
+			buffer.truncate(0)
+			return nil
+		}
+
+		if attempt >= i.Retries || i.Retryable == nil || !i.Retryable(err) || ctx.Err() != nil {
+			return err
+		}
+	}
+}
+
+// truncate keeps the first n rows of the buffer
+func (b *rowBuffer) truncate(n int) {
+	clear(b.values[n:])
+	b.values, b.positions = b.values[:n], b.positions[:n]
+}
+
+// rows binds the records of data, starting at the stream offset offset
+func (i *Inserter) rows(offset int64, data []byte) (batch rowBuffer, err error) {
+	delimiter := i.Delimiter
+	if delimiter == 0 {
+		delimiter = bread.DefaultDelimiter
+	}
+
+	for start := 0; start < len(data); {
+		end := bytes.IndexByte(data[start:], delimiter)
+		if end < 0 {
+			end = len(data)
+		} else {
+			end += start
+		}
+
+		position := offset + int64(start)
+		record := data[start:end]
+		start = end + 1
+
+		if len(record) == 0 {
+			continue
+		}
+
+		row, err := i.bind(record)
+		if err != nil {
+			return rowBuffer{}, &InsertError{Position: position, Err: err}
+		}
+
+		if len(batch.values) > 0 && len(row) != len(batch.values[0]) {
+			return rowBuffer{}, &InsertError{Position: position, Err: ErrColumnMismatch}
+		}
+
+		batch.values = append(batch.values, row)
+		batch.positions = append(batch.positions, position)
+	}
+
+	return
+}
+
+// insert runs the transaction that inserts the rows of the buffer
+func (i *Inserter) insert(ctx context.Context, buffer *rowBuffer) (err error) {
+	tx, err := i.db.BeginTx(ctx, nil)
+	if err != nil {
+		return &InsertError{Position: buffer.positions[0], Err: err}
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	for start := 0; start < len(buffer.values); start += i.batchRows {
+		end := min(start+i.batchRows, len(buffer.values))
+
+		query, args := i.query(buffer.values[start:end])
+
+		if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+			return &InsertError{Position: buffer.positions[start], Err: err}
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return &InsertError{Position: buffer.positions[0], Err: err}
+	}
+
+	return nil
+}
+
+// query builds the multi-row statement that inserts rows
+func (i *Inserter) query(rows [][]any) (string, []any) {
+	builder := strings.Builder{}
+	builder.WriteString(i.stmt)
+
+	args := make([]any, 0, len(rows)*len(rows[0]))
+
+	for r, row := range rows {
+		if r > 0 {
+			builder.WriteByte(',')
+		}
+
+		builder.WriteString(" (")
+
+		for c := range row {
+			if c > 0 {
+				builder.WriteString(", ")
+			}
+
+			args = append(args, row[c])
+			builder.WriteString(i.placeholder(len(args)))
+		}
+
+		builder.WriteByte(')')
+	}
+
+	return builder.String(), args
+}
+
+func (i *Inserter) placeholder(n int) string {
+	if i.Placeholder == nil {
+		return "?"
+	}
+
+	return i.Placeholder(n)
+}
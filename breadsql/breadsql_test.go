@@ -0,0 +1,341 @@
+package breadsql
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/yael-castro/bread"
+	_ "modernc.org/sqlite"
+)
+
+// open returns an empty in-memory SQLite database with a numbers table
+func open(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", "file:"+t.Name()+"?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Concurrent transactions are serialized by the single connection
+	db.SetMaxOpenConns(1)
+
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	if _, err = db.Exec("CREATE TABLE numbers (n INTEGER PRIMARY KEY, square INTEGER)"); err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
+
+// count returns the number of rows and the sum of n in the numbers table
+func count(t *testing.T, db *sql.DB) (rows, sum int) {
+	t.Helper()
+
+	err := db.QueryRow("SELECT COUNT(*), COALESCE(SUM(n), 0) FROM numbers").Scan(&rows, &sum)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return
+}
+
+func bindNumber(record []byte) ([]any, error) {
+	n, err := strconv.Atoi(string(record))
+	if err != nil {
+		return nil, err
+	}
+
+	return []any{n, n * n}, nil
+}
+
+func TestInserter(t *testing.T) {
+	const total = 1_000
+
+	cases := [...]struct {
+		workers   int
+		batchRows int
+	}{
+		{workers: 1, batchRows: 1},
+		{workers: 4, batchRows: 7},
+		// The rows of several batches are inserted together
+		{workers: 8, batchRows: 500},
+		// The rows left in the buffers are inserted by Flush
+		{workers: 4, batchRows: 2 * total},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			db := open(t)
+
+			builder := strings.Builder{}
+			for n := 1; n <= total; n++ {
+				_, _ = fmt.Fprintln(&builder, n)
+			}
+
+			b := bread.Bread{
+				Workers:    c.workers,
+				BufferSize: 64,
+				Sink:       NewInserter(db, "INSERT INTO numbers (n, square) VALUES", bindNumber, c.batchRows),
+			}
+
+			if err := b.Eat(context.TODO(), strings.NewReader(builder.String())); err != nil {
+				t.Fatal(err)
+			}
+
+			if rows, sum := count(t, db); rows != total || sum != total*(total+1)/2 {
+				t.Fatalf("expected %d rows, got %d rows with sum %d", total, rows, sum)
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+func TestInserter_Eat(t *testing.T) {
+	cases := [...]struct {
+		data      string
+		batchRows int
+		position  int64
+		err       error
+	}{
+		{
+			// Bind errors point to the record
+			data:      "1\n2\n3\nx\n5\n",
+			batchRows: 1,
+			position:  6,
+			err:       strconv.ErrSyntax,
+		},
+		{
+			// Exec errors point to the first record of the statement
+			data:      "1\n2\n3\n3\n",
+			batchRows: 4,
+			position:  0,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			db := open(t)
+
+			b := bread.Bread{
+				Workers:    1,
+				BufferSize: 64,
+				Sink:       NewInserter(db, "INSERT INTO numbers (n, square) VALUES", bindNumber, c.batchRows),
+			}
+
+			// The errors of the Inserter are returned by Eat, within the error of the batch
+			err := b.Eat(context.TODO(), strings.NewReader(c.data))
+
+			var insertErr *InsertError
+			if !errors.As(err, &insertErr) || insertErr.Position != c.position {
+				t.Fatalf("expected an *InsertError at offset %d, got %v", c.position, err)
+			}
+
+			if c.err != nil && !errors.Is(err, c.err) {
+				t.Fatalf("expected error wrapping %v, got %v", c.err, err)
+			}
+
+			t.Logf("Success! %v", err)
+		})
+	}
+}
+
+// writtenSink counts the records of the batches written by the Inserter
+type writtenSink struct {
+	*Inserter
+	records atomic.Int64
+}
+
+func (s *writtenSink) Write(ctx context.Context, meta bread.BatchMeta, data []byte) error {
+	if err := s.Inserter.Write(ctx, meta, data); err != nil {
+		return err
+	}
+
+	s.records.Add(int64(bytes.Count(data, []byte("\n"))))
+	return nil
+}
+
+// TestInserter_Cancel checks that the rows of every batch written before the cancellation are inserted
+func TestInserter_Cancel(t *testing.T) {
+	const total = 1_000
+
+	builder := strings.Builder{}
+	for n := 1; n <= total; n++ {
+		_, _ = fmt.Fprintln(&builder, n)
+	}
+
+	for i, batchRows := range [...]int{1, 16, 2 * total} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			db := open(t)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			bind := func(record []byte) ([]any, error) {
+				if string(record) == "500" {
+					cancel()
+				}
+
+				return bindNumber(record)
+			}
+
+			sink := &writtenSink{Inserter: NewInserter(db, "INSERT INTO numbers (n, square) VALUES", bind, batchRows)}
+
+			b := bread.Bread{
+				Workers:    4,
+				BufferSize: 64,
+				Sink:       sink,
+			}
+
+			err := b.Eat(ctx, strings.NewReader(builder.String()))
+			if !errors.Is(err, context.Canceled) {
+				t.Fatalf("expected error %v, got %v", context.Canceled, err)
+			}
+
+			if rows, _ := count(t, db); rows != int(sink.records.Load()) || rows == 0 || rows == total {
+				t.Fatalf("expected the %d records written, got %d rows", sink.records.Load(), rows)
+			}
+
+			t.Logf("Success! %d rows", sink.records.Load())
+		})
+	}
+}
+
+func TestInserter_Write(t *testing.T) {
+	cases := [...]struct {
+		data      string
+		batchRows int
+		ctx       func() context.Context
+		position  int64
+		err       error
+		// written is the number of rows inserted by Write, rows the number of rows inserted once flushed
+		written int
+		rows    int
+	}{
+		{
+			data:      "1\n2\n\n3\n4",
+			batchRows: 3,
+			position:  -1,
+			written:   4,
+			rows:      4,
+		},
+		{
+			// The rows are buffered until Flush
+			data:      "1\n2\n\n3\n4",
+			batchRows: 8,
+			position:  -1,
+			rows:      4,
+		},
+		{
+			// Bind errors point to the record, no row of the batch is buffered
+			data:      "1\n2\nx\n4\n",
+			batchRows: 8,
+			position:  104,
+			err:       strconv.ErrSyntax,
+		},
+		{
+			// Exec errors point to the first record of the statement and roll back the whole buffer
+			data:      "1\n2\n3\n3\n",
+			batchRows: 2,
+			position:  104,
+		},
+		{
+			ctx: func() context.Context {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+
+				return ctx
+			},
+			data:      "1\n2\n3\n",
+			batchRows: 1,
+			position:  100,
+			err:       context.Canceled,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			db := open(t)
+
+			ctx := context.Background()
+			if c.ctx != nil {
+				ctx = c.ctx()
+			}
+
+			inserter := NewInserter(db, "INSERT INTO numbers (n, square) VALUES", bindNumber, c.batchRows)
+
+			err := inserter.Write(ctx, bread.BatchMeta{Offset: 100, Size: len(c.data)}, []byte(c.data))
+
+			if c.position < 0 && err != nil {
+				t.Fatal(err)
+			}
+
+			if c.position >= 0 {
+				if offset, ok := bread.OffsetOf(err); !ok || offset != c.position {
+					t.Fatalf("expected error at offset %d, got %v", c.position, err)
+				}
+
+				if c.err != nil && !errors.Is(err, c.err) {
+					t.Fatalf("expected error wrapping %v, got %v", c.err, err)
+				}
+			}
+
+			if rows, _ := count(t, db); rows != c.written {
+				t.Fatalf("expected %d rows before Flush, got %d", c.written, rows)
+			}
+
+			if err = inserter.Flush(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			if rows, _ := count(t, db); rows != c.rows {
+				t.Fatalf("expected %d rows, got %d", c.rows, rows)
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+func TestInserter_Retries(t *testing.T) {
+	db := open(t)
+
+	if _, err := db.Exec("INSERT INTO numbers (n, square) VALUES (2, 4)"); err != nil {
+		t.Fatal(err)
+	}
+
+	retries := 0
+
+	inserter := NewInserter(db, "INSERT INTO numbers (n, square) VALUES", bindNumber, 1)
+	inserter.Retries = 2
+	inserter.Retryable = func(error) bool {
+		retries++
+		return true
+	}
+
+	err := inserter.Write(context.Background(), bread.BatchMeta{}, []byte("1\n2\n"))
+	if err == nil {
+		t.Fatal("expected a constraint error")
+	}
+
+	if retries != 2 {
+		t.Fatalf("expected 2 retries, got %d", retries)
+	}
+
+	if rows, _ := count(t, db); rows != 1 {
+		t.Fatalf("expected the transactions to be rolled back, got %d rows", rows)
+	}
+
+	t.Log("Success!")
+}
@@ -0,0 +1,23 @@
+module github.com/yael-castro/bread/breadsql
+
+go 1.23.0
+
+require (
+	github.com/yael-castro/bread v0.0.0-20261015102506-61abdd47448b
+	modernc.org/sqlite v1.34.5
+)
+
+require (
+	github.com/dustin/go-humanize v1.0.1 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/mattn/go-isatty v0.0.20 // indirect
+	github.com/ncruces/go-strftime v0.1.9 // indirect
+	github.com/remyoudompheng/bigfft v0.0.0-20230129092748-24d4a6f8daec // indirect
+	golang.org/x/sys v0.22.0 // indirect
+	modernc.org/libc v1.55.3 // indirect
+	modernc.org/mathutil v1.6.0 // indirect
+	modernc.org/memory v1.8.0 // indirect
+)
+
+// Develops against the checkout of the core, drop it to build with the version required above
+replace github.com/yael-castro/bread => ../
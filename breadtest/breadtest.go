@@ -0,0 +1,184 @@
+// Package breadtest helps testing the code that uses bread, with readers that misbehave in a deterministic way
+// and a Recorder that captures the batches passed to the workers.
+package breadtest
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"io"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yael-castro/bread"
+)
+
+// Chunk is the result of a single Read of a ChunkReader
+type Chunk struct {
+	Data []byte
+	Err  error
+}
+
+// ChunkReader is an io.Reader that returns its chunks one by one, so the size of each read and the error it comes
+// with are scripted. A chunk larger than the buffer of the Read is returned across several reads, and its error
+// comes with the last one. Once every chunk was returned it keeps returning io.EOF.
+type ChunkReader struct {
+	chunks []Chunk
+}
+
+// NewChunkReader returns a ChunkReader that returns the chunks in order
+func NewChunkReader(chunks ...Chunk) *ChunkReader {
+	return &ChunkReader{chunks: slices.Clone(chunks)}
+}
+
+// Chunked returns a ChunkReader that returns data in reads of size bytes, the last one along with io.EOF
+func Chunked(data []byte, size int) *ChunkReader {
+	chunks := make([]Chunk, 0, len(data)/max(size, 1)+1)
+
+	for len(data) > size {
+		chunks = append(chunks, Chunk{Data: data[:size]})
+		data = data[size:]
+	}
+
+	return NewChunkReader(append(chunks, Chunk{Data: data, Err: io.EOF})...)
+}
+
+func (r *ChunkReader) Read(p []byte) (int, error) {
+	if len(r.chunks) == 0 {
+		return 0, io.EOF
+	}
+
+	chunk := &r.chunks[0]
+
+	n := copy(p, chunk.Data)
+	chunk.Data = chunk.Data[n:]
+
+	if len(chunk.Data) > 0 {
+		return n, nil
+	}
+
+	err := chunk.Err
+	r.chunks = r.chunks[1:]
+
+	return n, err
+}
+
+// errAfter is the reader returned by ErrAfter
+type errAfter struct {
+	reader io.Reader
+	n      int64
+	err    error
+}
+
+// ErrAfter returns a reader that reads the first n bytes of r and then fails with err. It ends like r when r is
+// shorter than n bytes.
+func ErrAfter(r io.Reader, n int64, err error) io.Reader {
+	return &errAfter{reader: r, n: n, err: err}
+}
+
+func (r *errAfter) Read(p []byte) (int, error) {
+	if r.n <= 0 {
+		return 0, r.err
+	}
+
+	if int64(len(p)) > r.n {
+		p = p[:r.n]
+	}
+
+	n, err := r.reader.Read(p)
+	r.n -= int64(n)
+
+	return n, err
+}
+
+// SlowReader is an io.Reader that waits Delay before each read of Reader
+type SlowReader struct {
+	Reader io.Reader
+	Delay  time.Duration
+}
+
+func (r SlowReader) Read(p []byte) (int, error) {
+	time.Sleep(r.Delay)
+
+	return r.Reader.Read(p)
+}
+
+// Recorder captures the batches passed to its WorkerBatchFunc, it is safe for concurrent use
+type Recorder struct {
+	mu      sync.Mutex
+	batches []bread.Batch
+}
+
+// WorkerBatchFunc copies the batch along with its metadata, it is meant to be set as Bread.WorkerBatchFunc
+// or passed to Bread.EatBatches
+func (r *Recorder) WorkerBatchFunc(_ context.Context, batch bread.Batch) {
+	batch = bread.Batch{BatchMeta: batch.BatchMeta, Data: bytes.Clone(batch.Data)}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.batches = append(r.batches, batch)
+}
+
+// Batches returns the batches recorded in stream order, the order of their epoch, source and index
+func (r *Recorder) Batches() []bread.Batch {
+	r.mu.Lock()
+	batches := slices.Clone(r.batches)
+	r.mu.Unlock()
+
+	slices.SortStableFunc(batches, func(a, b bread.Batch) int {
+		return cmp.Or(cmp.Compare(a.Epoch, b.Epoch), cmp.Compare(a.Source, b.Source), cmp.Compare(a.Index, b.Index))
+	})
+
+	return batches
+}
+
+// Data returns the data of the batches recorded, concatenated in stream order
+func (r *Recorder) Data() []byte {
+	data := make([]byte, 0)
+
+	for _, batch := range r.Batches() {
+		data = append(data, batch.Data...)
+	}
+
+	return data
+}
+
+// Records returns the number of records of the batches recorded, see BatchMeta.Records
+func (r *Recorder) Records() uint64 {
+	records := uint64(0)
+
+	for _, batch := range r.Batches() {
+		records += uint64(batch.Records)
+	}
+
+	return records
+}
+
+// Reset forgets the batches recorded
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.batches = nil
+}
+
+// AssertData fails the test unless the batches recorded, concatenated in stream order, are equal to expected.
+// The failure reports the offset of the first byte that differs.
+func AssertData(t testing.TB, r *Recorder, expected []byte) {
+	t.Helper()
+
+	got := r.Data()
+	if bytes.Equal(got, expected) {
+		return
+	}
+
+	offset := 0
+	for offset < min(len(got), len(expected)) && got[offset] == expected[offset] {
+		offset++
+	}
+
+	t.Fatalf("the batches differ from the source at offset %d: got %d bytes, expected %d bytes", offset, len(got), len(expected))
+}
@@ -0,0 +1,129 @@
+package breadtest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yael-castro/bread"
+)
+
+var errInjected = errors.New("injected failure")
+
+func TestChunkReader(t *testing.T) {
+	cases := [...]struct {
+		reader io.Reader
+		// size is the length of the buffer passed to each Read
+		size     int
+		expected []Chunk
+	}{
+		{
+			reader:   NewChunkReader(Chunk{Data: []byte("ab")}, Chunk{}, Chunk{Data: []byte("c"), Err: errInjected}),
+			size:     8,
+			expected: []Chunk{{Data: []byte("ab")}, {}, {Data: []byte("c"), Err: errInjected}, {Err: io.EOF}},
+		},
+		{
+			// The chunk is longer than the buffer, its error comes with its last byte
+			reader:   NewChunkReader(Chunk{Data: []byte("abcde"), Err: errInjected}),
+			size:     2,
+			expected: []Chunk{{Data: []byte("ab")}, {Data: []byte("cd")}, {Data: []byte("e"), Err: errInjected}, {Err: io.EOF}},
+		},
+		{
+			reader:   Chunked([]byte("abcde"), 2),
+			size:     8,
+			expected: []Chunk{{Data: []byte("ab")}, {Data: []byte("cd")}, {Data: []byte("e"), Err: io.EOF}},
+		},
+		{
+			reader:   ErrAfter(strings.NewReader("abcde"), 3, errInjected),
+			size:     2,
+			expected: []Chunk{{Data: []byte("ab")}, {Data: []byte("c")}, {Err: errInjected}},
+		},
+		{
+			reader:   ErrAfter(strings.NewReader("ab"), 3, errInjected),
+			size:     8,
+			expected: []Chunk{{Data: []byte("ab")}, {Err: io.EOF}},
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			for j, expected := range c.expected {
+				p := make([]byte, c.size)
+
+				n, err := c.reader.Read(p)
+				if !bytes.Equal(p[:n], expected.Data) || !errors.Is(err, expected.Err) {
+					t.Fatalf("read %d: expected %q and %v, got %q and %v", j, expected.Data, expected.Err, p[:n], err)
+				}
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+func TestSlowReader(t *testing.T) {
+	reader := SlowReader{Reader: strings.NewReader("abc"), Delay: 10 * time.Millisecond}
+
+	start := time.Now()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The read of the data and the one that returns io.EOF
+	if elapsed := time.Since(start); string(data) != "abc" || elapsed < 20*time.Millisecond {
+		t.Fatalf("expected %q in 20ms, got %q in %v", "abc", data, elapsed)
+	}
+
+	t.Log("Success!")
+}
+
+func TestRecorder(t *testing.T) {
+	data := []byte(strings.Repeat("record\n", 1000))
+
+	recorder := &Recorder{}
+
+	b := bread.Bread{BufferSize: 64, Workers: 4, Epochs: 2, WorkerBatchFunc: recorder.WorkerBatchFunc}
+
+	err := b.Eat(context.TODO(), Chunked(data, 100))
+	if !errors.Is(err, bread.ErrNotSeekable) {
+		t.Fatalf("expected error %v, got %v", bread.ErrNotSeekable, err)
+	}
+
+	b.Epochs = 1
+
+	if err = b.Eat(context.TODO(), Chunked(data, 100)); err != nil {
+		t.Fatal(err)
+	}
+
+	AssertData(t, recorder, data)
+
+	if records := recorder.Records(); records != 1000 {
+		t.Fatalf("expected 1000 records, got %d", records)
+	}
+
+	for i, batch := range recorder.Batches() {
+		if batch.Index != uint64(i) {
+			t.Fatalf("expected batch %d, got batch %d", i, batch.Index)
+		}
+	}
+
+	recorder.Reset()
+
+	// The epochs are recorded one after the other
+	b.Epochs = 2
+
+	if err = b.Eat(context.TODO(), bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+
+	AssertData(t, recorder, append(bytes.Clone(data), data...))
+
+	t.Log("Success!")
+}
@@ -0,0 +1,37 @@
+package bread
+
+import (
+	"bytes"
+	"context"
+)
+
+// EatBytes works like Eat over data that is already in memory. The batches are slices of data dispatched
+// straight to the workers, without copying them into pooled buffers. Eat takes the same path for a *bytes.Buffer.
+//
+// Workers must not modify the data or retain it after they return, like with any other input. The capacity of
+// the batches is limited to their length, so appending to them does not overwrite the next batch.
+// It only applies to FramingDelimiter with AlignForward and without MaxBatchSize, otherwise data is read
+// like any other io.Reader.
+func (b Bread) EatBytes(ctx context.Context, data []byte) error {
+	return b.feed(ctx, b.worker(), func(ctx context.Context, e *eater) error {
+		if !e.sliceable() || e.SkipLines > 0 || e.StartOffset > 0 {
+			return e.read(ctx, bytes.NewReader(data))
+		}
+
+		return e.readBytes(ctx, data)
+	})
+}
+
+// readBytes dispatches the slices of data, running the workers inline when data fits within SyncThreshold
+func (e *eater) readBytes(ctx context.Context, data []byte) error {
+	if len(data) <= e.SyncThreshold {
+		return e.readInline(ctx, data)
+	}
+
+	return e.readSlices(ctx, data)
+}
+
+// sliceable reports whether the batches can be sliced out of an input held in memory, see readSlices
+func (b Bread) sliceable() bool {
+	return b.Framing == FramingDelimiter && b.RecordSize == 0 && b.SplitFunc == nil && !b.NormalizeCRLF && b.Align == AlignForward && b.MaxBatchSize == 0 && b.TruncateRecordsAt == 0
+}
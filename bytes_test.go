@@ -0,0 +1,141 @@
+package bread
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestBread_EatBytes(t *testing.T) {
+	cases := [...]struct {
+		bread Bread
+		data  string
+	}{
+		{
+			bread: Bread{BufferSize: 4},
+			data:  "a\nbb\nccc\ndddddddddd\ne\n",
+		},
+		{
+			// Unterminated last record
+			bread: Bread{Workers: 4, BufferSize: 3},
+			data:  "aaaa\nb\ncc\nddd",
+		},
+		{
+			bread: Bread{Workers: 4, BufferSize: 8, MaxRecords: 3},
+			data:  "a\nb\nc\nd\ne\n",
+		},
+		{
+			bread: Bread{Workers: 2, BufferSize: 4, SyncThreshold: 64},
+			data:  strings.Repeat("record\n", 5),
+		},
+		{
+			bread: Bread{BufferSize: 4},
+		},
+		{
+			// Not sliceable, data is read like any other input
+			bread: Bread{BufferSize: 4, Align: AlignBackward},
+			data:  "a\nbb\nccc\n",
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			// eat collects the batches produced by eat
+			eat := func(eat func(Bread) error) []Batch {
+				sink := &collectingSink{}
+
+				bread := c.bread
+				bread.Sink = sink
+
+				if err := eat(bread); err != nil {
+					t.Fatal(err)
+				}
+
+				return sink.batches
+			}
+
+			// The generic path does not know the size of this reader
+			expected := eat(func(b Bread) error {
+				return b.Eat(context.TODO(), io.MultiReader(strings.NewReader(c.data)))
+			})
+
+			sliced := eat(func(b Bread) error {
+				return b.EatBytes(context.TODO(), []byte(c.data))
+			})
+
+			buffered := eat(func(b Bread) error {
+				return b.Eat(context.TODO(), bytes.NewBufferString(c.data))
+			})
+
+			for _, batches := range [...][]Batch{sliced, buffered} {
+				if len(batches) != len(expected) {
+					t.Fatalf("expected %d batches, got %d", len(expected), len(batches))
+				}
+
+				for j := range batches {
+					if batches[j].BatchMeta != expected[j].BatchMeta || !bytes.Equal(batches[j].Data, expected[j].Data) {
+						t.Fatalf("expected batch %+v %q, got %+v %q", expected[j].BatchMeta, expected[j].Data, batches[j].BatchMeta, batches[j].Data)
+					}
+				}
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+func TestBread_EatBytes_Append(t *testing.T) {
+	data := []byte("a\nb\nc\n")
+
+	bread := Bread{
+		BufferSize: 1,
+		WorkerFunc: func(_ context.Context, buffer *[]byte) {
+			*buffer = append(*buffer, "overwritten"...)
+		},
+	}
+
+	if err := bread.EatBytes(context.TODO(), data); err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != "a\nb\nc\n" {
+		t.Fatalf("expected the input to be untouched, got %q", data)
+	}
+
+	t.Log("Success!")
+}
+
+func BenchmarkBread_EatBytes(b *testing.B) {
+	data := []byte(strings.Repeat("{\"key\":\"value\"}\n", 1<<16))
+
+	bread := Bread{
+		Workers:    4,
+		BufferSize: 64 << 10,
+		WorkerFunc: func(context.Context, *[]byte) {},
+	}
+
+	b.Run("Reader", func(b *testing.B) {
+		b.SetBytes(int64(len(data)))
+		b.ReportAllocs()
+
+		for n := 0; n < b.N; n++ {
+			if err := bread.Eat(context.TODO(), bytes.NewReader(data)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("Bytes", func(b *testing.B) {
+		b.SetBytes(int64(len(data)))
+		b.ReportAllocs()
+
+		for n := 0; n < b.N; n++ {
+			if err := bread.EatBytes(context.TODO(), data); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,144 @@
+package bread
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Size units, in bytes
+const (
+	B  = 1
+	KB = 1 << 10
+	MB = 1 << 20
+	GB = 1 << 30
+	TB = 1 << 40
+)
+
+// ErrInvalidByteSize indicates that a text could not be parsed as a ByteSize
+var ErrInvalidByteSize = errors.New("invalid byte size")
+
+// byteUnit is a size suffix along with its value
+type byteUnit struct {
+	suffix string
+	size   float64
+}
+
+// Suffixes sorted from the biggest unit, the binary ones are always powers of 1024
+var (
+	binaryUnits = [...]byteUnit{
+		{"tib", 1 << 40}, {"gib", 1 << 30}, {"mib", 1 << 20}, {"kib", 1 << 10},
+		{"tb", 1 << 40}, {"gb", 1 << 30}, {"mb", 1 << 20}, {"kb", 1 << 10},
+		{"t", 1 << 40}, {"g", 1 << 30}, {"m", 1 << 20}, {"k", 1 << 10},
+		{"b", 1}, {"", 1},
+	}
+	decimalUnits = [...]byteUnit{
+		{"tib", 1 << 40}, {"gib", 1 << 30}, {"mib", 1 << 20}, {"kib", 1 << 10},
+		{"tb", 1e12}, {"gb", 1e9}, {"mb", 1e6}, {"kb", 1e3},
+		{"t", 1e12}, {"g", 1e9}, {"m", 1e6}, {"k", 1e3},
+		{"b", 1}, {"", 1},
+	}
+)
+
+// ByteSize is an amount of bytes that can be parsed from and rendered to text like "16MB" or "1.5GB"
+type ByteSize int64
+
+// ParseByteSize parses texts like "512k", "16MB" or "1.5GiB", case-insensitive.
+// Every suffix is a power of 1024, matching the B, KB, MB, GB and TB constants.
+func ParseByteSize(s string) (ByteSize, error) {
+	return parseByteSize(s, binaryUnits[:])
+}
+
+// ParseDecimalByteSize works like ParseByteSize, but the SI suffixes (k, KB, MB, ...) are powers of 1000,
+// while the IEC ones (KiB, MiB, ...) remain powers of 1024
+func ParseDecimalByteSize(s string) (ByteSize, error) {
+	return parseByteSize(s, decimalUnits[:])
+}
+
+func parseByteSize(s string, units []byteUnit) (ByteSize, error) {
+	text := strings.ToLower(strings.TrimSpace(s))
+
+	for _, unit := range units {
+		number, ok := strings.CutSuffix(text, unit.suffix)
+		if !ok {
+			continue
+		}
+
+		number = strings.TrimSpace(number)
+
+		// Only plain decimal numbers are allowed: no signs, exponents, infinities, etc.
+		if number == "" || strings.Trim(number, "0123456789.") != "" {
+			break
+		}
+
+		value, err := strconv.ParseFloat(number, 64)
+		if err != nil {
+			break
+		}
+
+		value *= unit.size
+
+		if value >= math.MaxInt64 {
+			return 0, fmt.Errorf("%w %q: overflow", ErrInvalidByteSize, s)
+		}
+
+		return ByteSize(math.Round(value)), nil
+	}
+
+	return 0, fmt.Errorf("%w %q", ErrInvalidByteSize, s)
+}
+
+// String renders the size with the biggest unit that represents it exactly, e.g. "16MB", "1.5KB" or "1000B"
+func (s ByteSize) String() string {
+	units := [...]struct {
+		suffix string
+		size   ByteSize
+	}{
+		{"TB", TB}, {"GB", GB}, {"MB", MB}, {"KB", KB},
+	}
+
+	sign, n := "", s
+	if n < 0 {
+		sign, n = "-", -n
+	}
+
+	for _, unit := range units {
+		if n < unit.size {
+			continue
+		}
+
+		if n%unit.size == 0 {
+			return fmt.Sprintf("%s%d%s", sign, n/unit.size, unit.suffix)
+		}
+
+		// Short fractions are only used when they are exact
+		text := strconv.FormatFloat(float64(n)/float64(unit.size), 'f', -1, 64)
+		if len(text)-strings.IndexByte(text, '.') > 4 {
+			continue
+		}
+
+		if parsed, err := ParseByteSize(text + unit.suffix); err == nil && parsed == n {
+			return sign + text + unit.suffix
+		}
+	}
+
+	return fmt.Sprintf("%s%dB", sign, n)
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (s ByteSize) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, see ParseByteSize
+func (s *ByteSize) UnmarshalText(text []byte) error {
+	size, err := ParseByteSize(string(text))
+	if err != nil {
+		return err
+	}
+
+	*s = size
+	return nil
+}
@@ -0,0 +1,141 @@
+package bread
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func TestParseByteSize(t *testing.T) {
+	cases := [...]struct {
+		text     string
+		decimal  bool
+		expected ByteSize
+		err      bool
+	}{
+		{text: "0", expected: 0},
+		{text: "0KB", expected: 0},
+		{text: "512", expected: 512},
+		{text: "512b", expected: 512},
+		{text: "512k", expected: 512 * KB},
+		{text: "512K", expected: 512 * KB},
+		{text: "16MB", expected: 16 * MB},
+		{text: "16 mb", expected: 16 * MB},
+		{text: "1.5GB", expected: 1536 * MB},
+		{text: "1.5GiB", expected: 1536 * MB},
+		{text: "2t", expected: 2 << 40},
+		{text: ".5k", expected: 512},
+		{text: "1.5GB", decimal: true, expected: 1_500_000_000},
+		{text: "512k", decimal: true, expected: 512_000},
+		{text: "1MiB", decimal: true, expected: MB},
+		{text: "-1KB", err: true},
+		{text: "+1KB", err: true},
+		{text: "1e3KB", err: true},
+		{text: "9999999999TB", err: true},
+		{text: "9223372036854775808", err: true},
+		{text: "", err: true},
+		{text: "KB", err: true},
+		{text: "1.2.3MB", err: true},
+		{text: "12XB", err: true},
+		{text: "NaN", err: true},
+		{text: "Inf", err: true},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			parse := ParseByteSize
+			if c.decimal {
+				parse = ParseDecimalByteSize
+			}
+
+			size, err := parse(c.text)
+			if c.err {
+				if !errors.Is(err, ErrInvalidByteSize) {
+					t.Fatalf("expected an error parsing %q, got %v (%d)", c.text, err, size)
+				}
+
+				t.Log("Success!")
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if size != c.expected {
+				t.Fatalf("expected %d, got %d", c.expected, size)
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+func TestByteSize_String(t *testing.T) {
+	cases := [...]struct {
+		size     ByteSize
+		expected string
+	}{
+		{size: 0, expected: "0B"},
+		{size: 1000, expected: "1000B"},
+		{size: KB, expected: "1KB"},
+		{size: 1536, expected: "1.5KB"},
+		{size: 16 * MB, expected: "16MB"},
+		{size: 16*MB + 1, expected: "16777217B"},
+		{size: 1536 * MB, expected: "1.5GB"},
+		{size: 3 << 40, expected: "3TB"},
+		{size: -2 * KB, expected: "-2KB"},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			if c.size.String() != c.expected {
+				t.Fatalf("expected %s, got %s", c.expected, c.size)
+			}
+
+			// Round trip
+			if c.size >= 0 {
+				size, err := ParseByteSize(c.size.String())
+				if err != nil || size != c.size {
+					t.Fatalf("round trip of %s failed: %d %v", c.size, size, err)
+				}
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+func TestByteSize_Text(t *testing.T) {
+	type config struct {
+		BufferSize ByteSize `json:"buffer_size"`
+	}
+
+	var c config
+
+	err := json.Unmarshal([]byte(`{"buffer_size":"16MB"}`), &c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.BufferSize != 16*MB {
+		t.Fatalf("unexpected size %d", c.BufferSize)
+	}
+
+	data, err := json.Marshal(config{BufferSize: 1536})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != `{"buffer_size":"1.5KB"}` {
+		t.Fatalf("unexpected json %s", data)
+	}
+
+	err = json.Unmarshal([]byte(`{"buffer_size":"big"}`), &c)
+	if !errors.Is(err, ErrInvalidByteSize) {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	t.Log("Success!")
+}
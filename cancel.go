@@ -0,0 +1,35 @@
+package bread
+
+import "context"
+
+// CancelMode selects what happens to the batches being processed when the context of Eat is done
+type CancelMode uint8
+
+// Supported values for CancelMode
+const (
+	// CancelAbort stops the reading and cancels the context of the batches being processed, so the workers
+	// that watch their context end early
+	CancelAbort CancelMode = iota
+	// CancelDrain stops the reading and lets the batches being processed run to completion with a context
+	// that is not cancelled, only a failed batch cancels it
+	CancelDrain
+)
+
+// contexts returns the context of the reading and the one of the workers, cancel cancels both of them.
+// The workers only see the cancellation of ctx with CancelAbort, see CancelMode.
+func (e *eater) contexts(ctx context.Context) (readCtx, workCtx context.Context, cancel context.CancelFunc) {
+	if e.CancelMode != CancelDrain {
+		workCtx, cancel = context.WithCancel(ctx)
+		return workCtx, workCtx, cancel
+	}
+
+	workCtx, cancelWork := context.WithCancel(context.WithoutCancel(ctx))
+	readCtx, cancelRead := context.WithCancel(workCtx)
+	stop := context.AfterFunc(ctx, cancelRead)
+
+	return readCtx, workCtx, func() {
+		stop()
+		cancelRead()
+		cancelWork()
+	}
+}
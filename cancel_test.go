@@ -0,0 +1,107 @@
+package bread
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"runtime"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBread_CancelMode(t *testing.T) {
+	const work = 300 * time.Millisecond
+
+	cases := [...]struct {
+		bread Bread
+		// slow tells whether Eat waits for the whole work of the running batches
+		slow bool
+	}{
+		{
+			bread: Bread{CancelMode: CancelAbort},
+		},
+		{
+			bread: Bread{CancelMode: CancelDrain},
+			slow:  true,
+		},
+		{
+			bread: Bread{CancelMode: CancelAbort, QueueDepth: 8},
+		},
+		{
+			bread: Bread{CancelMode: CancelDrain, QueueDepth: 8},
+			slow:  true,
+		},
+		{
+			// The batches run inline
+			bread: Bread{CancelMode: CancelDrain, SyncThreshold: MB},
+			slow:  true,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			goroutines := runtime.NumGoroutine()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			c.bread.Workers = 4
+			c.bread.BufferSize = 16
+			c.bread.PoolStrategy = PoolFreelist
+
+			started, finished := atomic.Int64{}, atomic.Int64{}
+			stats := Stats{}
+
+			c.bread.OnComplete = func(_ context.Context, s Stats, _ error) {
+				stats = s
+			}
+
+			start := time.Now()
+
+			err := c.bread.EatBatches(ctx, bytes.NewReader(memoryData(MB)), func(ctx context.Context, _ Batch) {
+				started.Add(1)
+				cancel()
+
+				select {
+				case <-time.After(work):
+					finished.Add(1)
+				case <-ctx.Done():
+				}
+			})
+			if !errors.Is(err, context.Canceled) {
+				t.Fatalf("expected error %v, got %v", context.Canceled, err)
+			}
+
+			elapsed := time.Since(start)
+
+			switch {
+			case c.slow && elapsed < work:
+				t.Fatalf("Eat returned after %v, before the running batches finished", elapsed)
+			case c.slow && finished.Load() != started.Load():
+				t.Fatalf("%d batches finished of %d started", finished.Load(), started.Load())
+			case !c.slow && elapsed >= work/2:
+				t.Fatalf("Eat returned after %v, the running batches were not cancelled", elapsed)
+			case !c.slow && finished.Load() != 0:
+				t.Fatalf("%d batches finished after the cancellation", finished.Load())
+			}
+
+			// Every buffer was returned to the freelist, so none was allocated past its size
+			if stats.Allocations > uint64(c.bread.Workers+int(c.bread.QueueDepth)+1) {
+				t.Fatalf("unexpected allocations %d", stats.Allocations)
+			}
+
+			deadline := time.Now().Add(time.Second)
+			for runtime.NumGoroutine() > goroutines && time.Now().Before(deadline) {
+				time.Sleep(10 * time.Millisecond)
+			}
+
+			if runtime.NumGoroutine() > goroutines {
+				t.Fatalf("%d goroutines leaked", runtime.NumGoroutine()-goroutines)
+			}
+
+			t.Logf("Success! %v after %v", err, elapsed)
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package bread
+
+import (
+	"context"
+	"io"
+)
+
+// EatChan reads the batches of the reader on a new goroutine and sends them to the returned channel, so they can
+// be consumed by an existing pipeline instead of a worker function. The batches are sent in stream order, and the
+// reading stalls while the consumer does not receive them.
+//
+// The consumer owns each batch until it calls Batch.Release, that returns its buffer to the pool. A batch that is
+// never released is collected by the garbage collector, but with PoolFreelist the reading stalls once every buffer
+// is held by the consumer.
+//
+// The batch channel is closed once the reading ends, then the error channel receives the error that ended it, if
+// any, and it is closed too. When the context is done the batch channel is closed without waiting for the consumer.
+//
+// NOTE: the worker functions, Workers and Sink are ignored by EatChan
+func (b Bread) EatChan(ctx context.Context, reader io.Reader) (<-chan Batch, <-chan error) {
+	batches, errs := make(chan Batch), make(chan error, 1)
+
+	var owner *eater
+
+	b.Sink = nil
+
+	go func() {
+		defer close(errs)
+
+		err := b.feed(ctx, func(ctx context.Context, batch Batch) error {
+			// The batches that are not pooled have nothing to release
+			if !owner.sliced && owner.shuffle == nil {
+				batch.pool = owner.pool
+			}
+
+			select {
+			case batches <- batch:
+			case <-ctx.Done():
+				batch.Release()
+			}
+
+			return nil
+		}, func(ctx context.Context, e *eater) error {
+			if reader == nil {
+				return ErrNilReader
+			}
+
+			owner, e.inline, e.owned = e, true, true
+
+			return e.read(ctx, reader)
+		})
+
+		close(batches)
+
+		if err != nil {
+			errs <- err
+		}
+	}()
+
+	return batches, errs
+}
@@ -0,0 +1,129 @@
+package bread
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"runtime"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBread_EatChan(t *testing.T) {
+	cases := [...]struct {
+		bread Bread
+		data  []byte
+	}{
+		{
+			bread: Bread{BufferSize: KB},
+			data:  memoryData(MB),
+		},
+		{
+			bread: Bread{BufferSize: KB, PoolStrategy: PoolFreelist, FreelistSize: 2},
+			data:  memoryData(MB),
+		},
+		{
+			bread: Bread{BufferSize: 7, Delimiter: ',', SyncThreshold: KB},
+			data:  []byte("a,bb,ccc,dddd,eeeee,ffffff,ggggggg,hhhhhhhh"),
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			batches, errs := c.bread.EatChan(context.TODO(), bytes.NewReader(c.data))
+
+			got := make([]byte, 0, len(c.data))
+
+			for batch := range batches {
+				if batch.Offset != int64(len(got)) {
+					t.Fatalf("batch %d starts at %d, expected %d", batch.Index, batch.Offset, len(got))
+				}
+
+				got = append(got, batch.Data...)
+
+				// The freelist only has two buffers, so the reading stalls unless they are released
+				batch.Release()
+			}
+
+			if err := <-errs; err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(got, c.data) {
+				t.Fatal("the batches do not reproduce the input")
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+// countingReader counts the bytes read from the underlying io.Reader
+type countingReader struct {
+	io.Reader
+	n atomic.Int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.n.Add(int64(n))
+
+	return n, err
+}
+
+func TestBread_EatChanBackpressure(t *testing.T) {
+	goroutines := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	reader := &countingReader{Reader: bytes.NewReader(memoryData(16 * MB))}
+
+	batches, errs := Bread{BufferSize: KB}.EatChan(ctx, reader)
+
+	(<-batches).Release()
+
+	// The consumer stalls, so the reading stalls too
+	time.Sleep(20 * time.Millisecond)
+
+	read := reader.n.Load()
+	if read > 64*KB {
+		t.Fatalf("expected the reading to stall, %d bytes were read", read)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	if reader.n.Load() != read {
+		t.Fatalf("expected the reading to stall, %d bytes were read", reader.n.Load()-read)
+	}
+
+	cancel()
+
+	// The channel is closed without receiving the pending batch
+	timeout := time.After(time.Second)
+
+	for open := true; open; {
+		select {
+		case _, open = <-batches:
+		case <-timeout:
+			t.Fatal("the batch channel was not closed")
+		}
+	}
+
+	if err := <-errs; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected %v, got %v", context.Canceled, err)
+	}
+
+	for deadline := time.Now().Add(time.Second); runtime.NumGoroutine() > goroutines; {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d goroutines, got %d", goroutines, runtime.NumGoroutine())
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	t.Log("Success!")
+}
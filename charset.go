@@ -0,0 +1,147 @@
+package bread
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Byte order marks recognized by SniffBOM
+var (
+	bomUTF8    = []byte{0xef, 0xbb, 0xbf}
+	bomUTF16LE = []byte{0xff, 0xfe}
+	bomUTF16BE = []byte{0xfe, 0xff}
+)
+
+// SniffBOM is a WrapReader that recognizes the encoding from the byte order mark at the start of the reader:
+// UTF-16 streams, little or big endian, are decoded into UTF-8 and the UTF-8 byte order mark is dropped.
+// The streams without a byte order mark are passed through untouched.
+//
+// The byte order mark is peeked through the reader when it is a *bufio.Reader, so no byte is lost.
+func SniffBOM(r io.Reader) (io.Reader, error) {
+	buffered, ok := r.(*bufio.Reader)
+	if !ok {
+		buffered = bufio.NewReader(r)
+	}
+
+	bom, err := buffered.Peek(len(bomUTF8))
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	switch {
+	case bytes.HasPrefix(bom, bomUTF8):
+		_, _ = buffered.Discard(len(bomUTF8))
+	case bytes.HasPrefix(bom, bomUTF16LE):
+		_, _ = buffered.Discard(len(bomUTF16LE))
+		return &runeDecoder{r: buffered, next: nextUTF16(false)}, nil
+	case bytes.HasPrefix(bom, bomUTF16BE):
+		_, _ = buffered.Discard(len(bomUTF16BE))
+		return &runeDecoder{r: buffered, next: nextUTF16(true)}, nil
+	}
+
+	return buffered, nil
+}
+
+// DecodeLatin1 is a WrapReader that decodes an ISO-8859-1 stream into UTF-8
+func DecodeLatin1(r io.Reader) (io.Reader, error) {
+	return &runeDecoder{r: bufio.NewReader(r), next: nextLatin1}, nil
+}
+
+// runeDecoder encodes into UTF-8 the runes decoded by next
+type runeDecoder struct {
+	r    *bufio.Reader
+	next func(r *bufio.Reader) (rune, error)
+	// pending holds the bytes of the last rune that did not fit in the previous read
+	pending []byte
+	buffer  [utf8.UTFMax]byte
+}
+
+func (d *runeDecoder) Read(p []byte) (n int, err error) {
+	for n < len(p) {
+		if len(d.pending) > 0 {
+			copied := copy(p[n:], d.pending)
+			d.pending = d.pending[copied:]
+			n += copied
+
+			continue
+		}
+
+		// Once some bytes were decoded, the read stops when the reader has nothing buffered
+		if n > 0 && d.r.Buffered() == 0 {
+			return n, nil
+		}
+
+		var r rune
+
+		r, err = d.next(d.r)
+		if err != nil {
+			if err == io.EOF && n > 0 {
+				err = nil
+			}
+
+			return
+		}
+
+		if utf8.RuneLen(r) <= len(p)-n {
+			n += utf8.EncodeRune(p[n:], r)
+			continue
+		}
+
+		d.pending = utf8.AppendRune(d.buffer[:0], r)
+	}
+
+	return n, nil
+}
+
+// nextLatin1 decodes the next ISO-8859-1 character, which matches the code point of its byte
+func nextLatin1(r *bufio.Reader) (rune, error) {
+	c, err := r.ReadByte()
+	return rune(c), err
+}
+
+// nextUTF16 returns the function that decodes the next UTF-16 character, the invalid code units and a truncated
+// last unit are decoded as utf8.RuneError
+func nextUTF16(bigEndian bool) func(r *bufio.Reader) (rune, error) {
+	unit := func(b []byte) rune {
+		if bigEndian {
+			return rune(b[0])<<8 | rune(b[1])
+		}
+
+		return rune(b[1])<<8 | rune(b[0])
+	}
+
+	return func(r *bufio.Reader) (rune, error) {
+		b, err := r.Peek(2)
+		if len(b) < 2 {
+			if len(b) == 1 {
+				_, _ = r.Discard(1)
+				return utf8.RuneError, nil
+			}
+
+			return 0, err
+		}
+
+		_, _ = r.Discard(2)
+
+		high := unit(b)
+		if !utf16.IsSurrogate(high) {
+			return high, nil
+		}
+
+		// The low surrogate is only consumed when it completes the pair
+		b, _ = r.Peek(2)
+		if len(b) < 2 {
+			return utf8.RuneError, nil
+		}
+
+		decoded := utf16.DecodeRune(high, unit(b))
+		if decoded != utf8.RuneError {
+			_, _ = r.Discard(2)
+		}
+
+		return decoded, nil
+	}
+}
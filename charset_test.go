@@ -0,0 +1,127 @@
+package bread
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"unicode/utf16"
+)
+
+// encodeUTF16 encodes the text in UTF-16 with its byte order mark
+func encodeUTF16(text string, order binary.AppendByteOrder) []byte {
+	data := order.AppendUint16(nil, 0xfeff)
+
+	for _, unit := range utf16.Encode([]rune(text)) {
+		data = order.AppendUint16(data, unit)
+	}
+
+	return data
+}
+
+func TestSniffBOM(t *testing.T) {
+	text := "línea uno\nline 2 😀\n\n日本語\n"
+
+	cases := [...]struct {
+		data     []byte
+		expected string
+	}{
+		{data: encodeUTF16(text, binary.LittleEndian), expected: text},
+		{data: encodeUTF16(text, binary.BigEndian), expected: text},
+		{data: append([]byte("\xef\xbb\xbf"), text...), expected: text},
+		{data: []byte(text), expected: text},
+		{data: nil, expected: ""},
+		// Truncated unit and unpaired surrogates
+		{data: []byte{0xff, 0xfe, 'a', 0, 0x3d, 0xd8, 'b', 0, 'c'}, expected: "a�b�"},
+		{data: []byte{0xff, 0xfe, 0x00, 0xde, 'a', 0}, expected: "�a"},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			reader, err := SniffBOM(iotest.OneByteReader(bytes.NewReader(c.data)))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := io.ReadAll(iotest.OneByteReader(reader))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(got) != c.expected {
+				t.Fatalf("expected %q, got %q", c.expected, got)
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+func TestDecodeLatin1(t *testing.T) {
+	reader, err := DecodeLatin1(bytes.NewReader([]byte("caf\xe9\nna\xefve\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(got) != "café\nnaïve\n" {
+		t.Fatalf("unexpected text %q", got)
+	}
+
+	t.Log("Success!")
+}
+
+func TestBread_WrapReader(t *testing.T) {
+	lines := make([]string, 500)
+	for i := range lines {
+		lines[i] = strconv.Itoa(i) + " " + strings.Repeat("ñ😀", i%7) + " fin"
+	}
+
+	text := strings.Join(lines, "\n") + "\n"
+
+	cases := [...]struct {
+		data []byte
+	}{
+		{data: encodeUTF16(text, binary.LittleEndian)},
+		{data: encodeUTF16(text, binary.BigEndian)},
+		{data: []byte(text)},
+	}
+
+	bread := Bread{BufferSize: 64, Workers: 4}
+
+	expected, err := collect(t, bread, strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bread.WrapReader = SniffBOM
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			batches, err := collect(t, bread, iotest.HalfReader(bytes.NewReader(c.data)))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(batches) != len(expected) {
+				t.Fatalf("expected %d batches, got %d", len(expected), len(batches))
+			}
+
+			// The batches match the ones of the UTF-8 text
+			for j, batch := range batches {
+				if batch.BatchMeta != expected[j].BatchMeta || !bytes.Equal(batch.Data, expected[j].Data) {
+					t.Fatalf("expected batch %+v %q, got %+v %q", expected[j].BatchMeta, expected[j].Data, batch.BatchMeta, batch.Data)
+				}
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
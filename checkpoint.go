@@ -0,0 +1,125 @@
+package bread
+
+import (
+	"context"
+	"sync"
+)
+
+// checkpointer tracks the end of the contiguous prefix of processed batches and reports it to CheckpointFunc
+// from its own goroutine, see Bread.CheckpointFunc
+type checkpointer struct {
+	mu sync.Mutex
+	// next is the index of the first batch not processed yet
+	next uint64
+	// ends holds the end offset of the batches processed after a batch still in progress
+	ends map[uint64]int64
+	// offset is the end of the contiguous prefix
+	offset int64
+
+	// advanced signals that the offset advanced, it holds at most one pending signal
+	advanced chan struct{}
+	stop     chan struct{}
+	stopped  chan struct{}
+	// reported is the last offset passed to CheckpointFunc, and failed tells whether it failed.
+	// Owned by the goroutine until it stops
+	reported int64
+	failed   bool
+}
+
+// startCheckpoints starts the goroutine that calls CheckpointFunc, if any
+func (e *eater) startCheckpoints(ctx context.Context) {
+	if e.CheckpointFunc == nil {
+		return
+	}
+
+	c := &checkpointer{
+		ends:     make(map[uint64]int64),
+		advanced: make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+
+	e.checkpoints = c
+
+	go func() {
+		defer close(c.stopped)
+
+		for {
+			select {
+			case <-c.stop:
+				return
+			case <-c.advanced:
+			}
+
+			e.checkpoint(ctx)
+		}
+	}()
+}
+
+// complete records a processed batch, the signals of the offset not taken yet are merged
+func (c *checkpointer) complete(batch Batch) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+
+	c.ends[batch.Index] = batch.Offset + int64(batch.Size)
+
+	advanced := false
+
+	for end, ok := c.ends[c.next]; ok; end, ok = c.ends[c.next] {
+		delete(c.ends, c.next)
+
+		c.next++
+		c.offset = end
+		advanced = true
+	}
+
+	c.mu.Unlock()
+
+	if !advanced {
+		return
+	}
+
+	select {
+	case c.advanced <- struct{}{}:
+	default:
+	}
+}
+
+// checkpoint passes the offset to CheckpointFunc if it advanced, its error fails the Eat call
+func (e *eater) checkpoint(ctx context.Context) {
+	c := e.checkpoints
+
+	c.mu.Lock()
+	offset := c.offset
+	c.mu.Unlock()
+
+	// Nothing is reported after a failed checkpoint
+	if c.failed || offset <= c.reported {
+		return
+	}
+
+	if err := e.CheckpointFunc(ctx, offset); err != nil {
+		c.failed = true
+		e.fail(err)
+
+		return
+	}
+
+	c.reported = offset
+}
+
+// stopCheckpoints waits for the goroutine of the checkpoints and reports the final offset
+func (e *eater) stopCheckpoints(ctx context.Context) {
+	c := e.checkpoints
+	if c == nil {
+		return
+	}
+
+	close(c.stop)
+	<-c.stopped
+
+	e.checkpoint(ctx)
+}
@@ -0,0 +1,150 @@
+package bread
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/rand"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBread_CheckpointFunc(t *testing.T) {
+	errWorker := errors.New("worker failure")
+	errCheckpoint := errors.New("checkpoint failure")
+
+	data := memoryData(64 * KB)
+
+	cases := [...]struct {
+		bread Bread
+		// fail is the index of the batch that fails, -1 means none
+		fail int
+		// failCheckpoint is the number of the checkpoint call that fails, 0 means none
+		failCheckpoint int
+		err            error
+	}{
+		{
+			bread: Bread{Workers: 8, BufferSize: 512},
+			fail:  -1,
+		},
+		{
+			bread: Bread{Workers: 8, BufferSize: 512, QueueDepth: 8, SkipLines: 3},
+			fail:  -1,
+		},
+		{
+			bread: Bread{Workers: 8, BufferSize: 512},
+			fail:  20,
+			err:   errWorker,
+		},
+		{
+			bread:          Bread{Workers: 8, BufferSize: 512},
+			fail:           -1,
+			failCheckpoint: 2,
+			err:            errCheckpoint,
+		},
+		{
+			bread: Bread{Workers: 8, BufferSize: 512, Epochs: 2},
+			fail:  -1,
+			err:   ErrCheckpoint,
+		},
+		{
+			bread: Bread{Workers: 8, BufferSize: 512, ShuffleBuffer: 16},
+			fail:  -1,
+			err:   ErrCheckpoint,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			random := rand.New(rand.NewSource(int64(i)))
+			delays := make([]time.Duration, 1024)
+
+			for j := range delays {
+				delays[j] = time.Duration(random.Intn(500)) * time.Microsecond
+			}
+
+			// skipped is the size of the lines skipped before the first batch
+			skipped := int64(0)
+			for range c.bread.SkipLines {
+				skipped += int64(bytes.IndexByte(data[skipped:], '\n') + 1)
+			}
+
+			mu := sync.Mutex{}
+			// processed holds the size of the processed batches by offset
+			processed := make(map[int64]int)
+			checkpoints := make([]int64, 0)
+			// failedAt is the offset of the failed batch
+			failedAt := int64(-1)
+
+			c.bread.CheckpointFunc = func(_ context.Context, offset int64) error {
+				mu.Lock()
+				defer mu.Unlock()
+
+				checkpoints = append(checkpoints, offset)
+
+				// Every batch before the offset was processed
+				covered := skipped
+				for start, size := range processed {
+					if start < offset {
+						covered += int64(size)
+					}
+				}
+
+				if covered != offset {
+					t.Errorf("checkpoint %d skips past unfinished batches, %d bytes processed before it", offset, covered)
+				}
+
+				if len(checkpoints) == c.failCheckpoint {
+					return errCheckpoint
+				}
+
+				return nil
+			}
+
+			err := c.bread.eat(context.TODO(), bytes.NewReader(data), func(_ context.Context, batch Batch) error {
+				time.Sleep(delays[batch.Index%uint64(len(delays))])
+
+				if int(batch.Index) == c.fail {
+					mu.Lock()
+					failedAt = batch.Offset
+					mu.Unlock()
+
+					return errWorker
+				}
+
+				mu.Lock()
+				processed[batch.Offset] = len(batch.Data)
+				mu.Unlock()
+
+				return nil
+			})
+			if !errors.Is(err, c.err) {
+				t.Fatalf("expected error %v, got %v", c.err, err)
+			}
+
+			for j := 1; j < len(checkpoints); j++ {
+				if checkpoints[j] <= checkpoints[j-1] {
+					t.Fatalf("the checkpoints went backwards: %v", checkpoints)
+				}
+			}
+
+			last := int64(0)
+			if len(checkpoints) > 0 {
+				last = checkpoints[len(checkpoints)-1]
+			}
+
+			switch {
+			case c.err == nil && last != int64(len(data)):
+				t.Fatalf("expected a last checkpoint at %d, got %d", len(data), last)
+			case failedAt >= 0 && last > failedAt:
+				t.Fatalf("checkpoint %d past the failed batch at %d", last, failedAt)
+			case c.failCheckpoint > 0 && len(checkpoints) != c.failCheckpoint:
+				t.Fatalf("%d checkpoints after the failed one", len(checkpoints)-c.failCheckpoint)
+			}
+
+			t.Logf("Success! %d checkpoints, %v", len(checkpoints), err)
+		})
+	}
+}
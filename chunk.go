@@ -0,0 +1,98 @@
+package bread
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"iter"
+)
+
+// Chunker returns the batches of a reader one at a time, without any concurrency, see Bread.NewChunker
+type Chunker struct {
+	// Copy makes Next return a copy of each batch. Otherwise the batch is held by a buffer that is reused by the
+	// next call.
+	Copy bool
+
+	bread  Bread
+	reader io.Reader
+	ctx    context.Context
+	cancel context.CancelFunc
+	next   func() ([]byte, error, bool)
+	stop   func()
+	err    error
+}
+
+// NewChunker returns a Chunker that cuts the reader in the same batches Eat does, on the goroutine that calls
+// Next. The batches come from the same reading code as Eat, so BufferSize, Delimiter, DelimiterBytes, RecordSize,
+// Framing (see WithNoDelimiter), TrimDelimiter, MaxRecordSize and the rest of the reading settings cannot behave
+// differently.
+//
+// NOTE: the worker functions, Workers and Sink are ignored by the Chunker, like they are by Batches
+func (b Bread) NewChunker(r io.Reader) *Chunker {
+	return &Chunker{bread: b, reader: r}
+}
+
+// Next returns the next batch, or io.EOF once the reader is exhausted. When the context is done the reading
+// stops and its error is returned, the Chunker keeps returning the error that stopped it from then on.
+//
+// The values of the context of the first call are seen by the reading, like the Logger does.
+func (c *Chunker) Next(ctx context.Context) ([]byte, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	if err := ctx.Err(); err != nil {
+		c.close(err)
+		return nil, err
+	}
+
+	if c.next == nil {
+		c.ctx, c.cancel = context.WithCancel(context.WithoutCancel(ctx))
+		c.next, c.stop = iter.Pull2(c.bread.Batches(c.ctx, c.reader))
+	}
+
+	// The reading stops as soon as the context of this call is done
+	stop := context.AfterFunc(ctx, c.cancel)
+	data, err, ok := c.next()
+	stop()
+
+	switch {
+	case ctx.Err() != nil:
+		c.close(ctx.Err())
+	case err != nil:
+		c.close(err)
+	case !ok:
+		c.close(io.EOF)
+	}
+
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	if c.Copy {
+		data = bytes.Clone(data)
+	}
+
+	return data, nil
+}
+
+// Close stops the reading, it must be called unless Next returned an error. The next calls to Next return io.EOF.
+func (c *Chunker) Close() error {
+	if c.err == nil {
+		c.close(io.EOF)
+	}
+
+	return nil
+}
+
+// close keeps the error returned by the next calls to Next and releases the reading
+func (c *Chunker) close(err error) {
+	c.err = err
+
+	if c.next == nil {
+		return
+	}
+
+	c.cancel()
+	c.stop()
+}
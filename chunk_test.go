@@ -0,0 +1,170 @@
+package bread
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChunker_Next(t *testing.T) {
+	lines := strings.Repeat("one\ntwo\nthree\nfour\nfive\n", 100)
+
+	cases := [...]struct {
+		bread Bread
+		data  string
+		copy  bool
+		err   error
+	}{
+		{
+			bread: Bread{BufferSize: 16},
+			data:  lines,
+		},
+		{
+			bread: Bread{BufferSize: KB},
+			data:  string(memoryData(64 * KB)),
+		},
+		{
+			bread: Bread{BufferSize: 7, Delimiter: ','},
+			data:  "a,bb,ccc,dddd,eeeee,ffffff,ggggggg,hhhhhhhh",
+		},
+		{
+			bread: Bread{BufferSize: 4, DelimiterBytes: []byte("\r\n")},
+			data:  "a\r\nbb\r\nccccc\r\nd",
+		},
+		{
+			// No delimiter, see WithNoDelimiter
+			bread: Bread{BufferSize: 10, Framing: FramingNone},
+			data:  lines,
+		},
+		{
+			bread: Bread{BufferSize: 16, TrimDelimiter: true},
+			data:  lines,
+			copy:  true,
+		},
+		{
+			bread: Bread{BufferSize: 16, MaxRecordSize: 8},
+			data:  lines,
+		},
+		{
+			bread: Bread{BufferSize: 4, MaxRecordSize: 8},
+			data:  "one\ntwo\n" + strings.Repeat("x", 20) + "\nthree\n",
+			err:   ErrRecordTooLarge,
+		},
+		{
+			bread: Bread{BufferSize: 16},
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			expected, err := collect(t, c.bread, strings.NewReader(c.data))
+			if !errors.Is(err, c.err) {
+				t.Fatalf("expected Eat error %v, got %v", c.err, err)
+			}
+
+			chunker := c.bread.NewChunker(strings.NewReader(c.data))
+			chunker.Copy = c.copy
+
+			got := make([][]byte, 0, len(expected))
+
+			for {
+				data, err := chunker.Next(context.TODO())
+				if err != nil {
+					if c.err == nil && err != io.EOF || c.err != nil && !errors.Is(err, c.err) {
+						t.Fatalf("expected error %v, got %v", c.err, err)
+					}
+
+					break
+				}
+
+				got = append(got, bytes.Clone(data))
+			}
+
+			if len(got) != len(expected) {
+				t.Fatalf("expected %d batches, got %d", len(expected), len(got))
+			}
+
+			for j, batch := range expected {
+				if !bytes.Equal(got[j], batch.Data) {
+					t.Fatalf("expected batch %d %q, got %q", j, batch.Data, got[j])
+				}
+			}
+
+			t.Logf("Success! %d batches", len(got))
+		})
+	}
+}
+
+func TestChunker_Copy(t *testing.T) {
+	lines := make([]string, KB)
+	for i := range lines {
+		lines[i] = strconv.Itoa(i)
+	}
+
+	chunker := Bread{BufferSize: 64}.NewChunker(strings.NewReader(strings.Join(lines, "\n")))
+	chunker.Copy = true
+
+	defer chunker.Close()
+
+	first, err := chunker.Next(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := bytes.Clone(first)
+
+	for range 8 {
+		if _, err = chunker.Next(context.TODO()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The copies are not held by the buffers reused by the next calls
+	if !bytes.Equal(first, expected) {
+		t.Fatalf("expected batch %q, got %q", expected, first)
+	}
+
+	t.Log("Success!")
+}
+
+func TestChunker_Close(t *testing.T) {
+	goroutines := runtime.NumGoroutine()
+
+	chunker := Bread{BufferSize: 16}.NewChunker(bytes.NewReader(memoryData(MB)))
+
+	if _, err := chunker.Next(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+
+	if _, err := chunker.Next(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected error %v, got %v", context.Canceled, err)
+	}
+
+	// The Chunker keeps the error that stopped it
+	if _, err := chunker.Next(context.TODO()); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected error %v, got %v", context.Canceled, err)
+	}
+
+	if err := chunker.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for deadline := time.Now().Add(time.Second); runtime.NumGoroutine() > goroutines; {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d goroutines, got %d", goroutines, runtime.NumGoroutine())
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	t.Log("Success!")
+}
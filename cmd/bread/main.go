@@ -0,0 +1,80 @@
+// Command bread splits and processes delimited files with the bread library.
+//
+// Usage:
+//
+//	bread split [flags] file
+//	bread run [flags] file
+//
+// Run "bread <command> -h" for the flags of each command.
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+// Exit codes
+const (
+	exitOK = iota
+	// exitFailure indicates that the command failed before processing any data
+	exitFailure
+	// exitUsage indicates invalid arguments
+	exitUsage
+	// exitPartial indicates that some batches failed after others were processed
+	exitPartial
+)
+
+func main() {
+	os.Exit(cli(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// cli runs the command selected by args and returns the exit code
+func cli(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return exitUsage
+	}
+
+	switch args[0] {
+	case "split":
+		return split(args[1:], stdout, stderr)
+	case "run":
+		return run(args[1:], stdout, stderr)
+	case "-h", "-help", "--help", "help":
+		usage(stdout)
+		return exitOK
+	}
+
+	_, _ = fmt.Fprintf(stderr, "bread: unknown command %q\n", args[0])
+	usage(stderr)
+
+	return exitUsage
+}
+
+func usage(w io.Writer) {
+	_, _ = fmt.Fprint(w, `Usage:
+	bread split [flags] file	split a file into parts aligned to record boundaries
+	bread run [flags] file	stream a file through concurrent workers
+
+Run "bread <command> -h" for the flags of each command.
+`)
+}
+
+// delimiterFlag is a byte flag that accepts Go escape sequences, like \n or \t
+type delimiterFlag byte
+
+func (d *delimiterFlag) String() string {
+	return strconv.QuoteRune(rune(*d))
+}
+
+func (d *delimiterFlag) Set(s string) error {
+	value, err := strconv.Unquote("'" + s + "'")
+	if err != nil || len(value) != 1 {
+		return fmt.Errorf("the delimiter must be a single byte, got %q", s)
+	}
+
+	*d = delimiterFlag(value[0])
+	return nil
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// writeLines writes a file with n lines and returns its path and content
+func writeLines(t *testing.T, n int) (string, string) {
+	t.Helper()
+
+	builder := strings.Builder{}
+	for i := 0; i < n; i++ {
+		_, _ = fmt.Fprintf(&builder, "line %d %s\n", i, strings.Repeat("x", i%13))
+	}
+
+	path := filepath.Join(t.TempDir(), "input.txt")
+
+	if err := os.WriteFile(path, []byte(builder.String()), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path, builder.String()
+}
+
+func TestCLI_Split(t *testing.T) {
+	cases := [...]struct {
+		lines int
+		parts int
+	}{
+		{lines: 1_000, parts: 1},
+		{lines: 1_000, parts: 7},
+		{lines: 3, parts: 10},
+		{lines: 0, parts: 3},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			path, data := writeLines(t, c.lines)
+			stdout, stderr := bytes.Buffer{}, bytes.Buffer{}
+
+			code := cli([]string{"split", "-parts", strconv.Itoa(c.parts), path}, &stdout, &stderr)
+			if code != exitOK {
+				t.Fatalf("unexpected exit code %d: %s", code, stderr.String())
+			}
+
+			names, _ := filepath.Glob(path + ".*")
+			if len(names) == 0 || len(names) > c.parts {
+				t.Fatalf("expected up to %d parts, got %d", c.parts, len(names))
+			}
+
+			joined := ""
+
+			for _, name := range names {
+				part, err := os.ReadFile(name)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if len(part) > 0 && part[len(part)-1] != '\n' {
+					t.Fatalf("the part %s is not aligned to a record", name)
+				}
+
+				joined += string(part)
+			}
+
+			if joined != data {
+				t.Fatal("the parts do not reproduce the file")
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+func TestCLI_Run(t *testing.T) {
+	path, _ := writeLines(t, 1_000)
+
+	cases := [...]struct {
+		args   []string
+		code   int
+		stdout string
+	}{
+		{
+			args:   []string{"run", "-workers", "4", "-buffer-size", "1KiB", path},
+			stdout: "1000\n",
+		},
+		{
+			args:   []string{"run", "-workers", "4", "-buffer-size", "1KiB", "-exec", "wc -l >/dev/null", path},
+			stdout: "",
+		},
+		{
+			// Batches are processed until the one holding "line 500 " fails
+			args: []string{"run", "-buffer-size", "1KiB", "-exec", "! grep -q 'line 500 '", path},
+			code: exitPartial,
+		},
+		{
+			args: []string{"run", "-exec", "exit 1", path},
+			code: exitFailure,
+		},
+		{
+			args: []string{"run", filepath.Join(t.TempDir(), "missing")},
+			code: exitFailure,
+		},
+		{
+			args: []string{"run", "-buffer-size", "lots", path},
+			code: exitUsage,
+		},
+		{
+			args: []string{"bake"},
+			code: exitUsage,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			stdout, stderr := bytes.Buffer{}, bytes.Buffer{}
+
+			code := cli(c.args, &stdout, &stderr)
+			if code != c.code {
+				t.Fatalf("expected exit code %d, got %d: %s", c.code, code, stderr.String())
+			}
+
+			if c.code == exitOK && stdout.String() != c.stdout {
+				t.Fatalf("expected output %q, got %q", c.stdout, stdout.String())
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
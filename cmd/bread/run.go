@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sync"
+	"time"
+
+	"github.com/yael-castro/bread"
+)
+
+// run implements the run command
+func run(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("run", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+
+	config := bread.Config{
+		Workers:    bread.DefaultWorkers,
+		BufferSize: 64 * bread.KB,
+	}
+
+	bufferSize := bread.ByteSize(config.BufferSize)
+	delimiter := delimiterFlag(bread.DefaultDelimiter)
+
+	flags.Func("workers", "number of concurrent workers (default 1)", uintFlag(&config.Workers))
+	flags.Func("buffer-seed", "number of buffers allocated in advance", uintFlag(&config.BufferSeed))
+	flags.Func("queue-depth", "number of batches read in advance", uintFlag(&config.QueueDepth))
+	flags.TextVar(&bufferSize, "buffer-size", bufferSize, "size of the batches, e.g. 64KiB")
+	flags.Var(&delimiter, "delimiter", "record delimiter")
+
+	command := flags.String("exec", "", "shell command that receives each batch through its standard input (default count the records)")
+	interval := flags.Duration("progress", 0, "interval between progress reports, zero disables them")
+
+	flags.Usage = func() {
+		_, _ = fmt.Fprintln(stderr, "Usage: bread run [flags] file")
+		_, _ = fmt.Fprintln(stderr, `Use "-" as file to read the standard input.`)
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	if flags.NArg() != 1 || bufferSize <= 0 || bufferSize > math.MaxInt {
+		flags.Usage()
+		return exitUsage
+	}
+
+	config.BufferSize = int(bufferSize)
+
+	var input io.Reader = os.Stdin
+
+	if path := flags.Arg(0); path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			_, _ = fmt.Fprintln(stderr, "bread:", err)
+			return exitFailure
+		}
+		defer file.Close()
+
+		input = file
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var stats bread.Stats
+
+	b := bread.Bread{
+		Workers:    config.Workers,
+		BufferSeed: config.BufferSeed,
+		BufferSize: config.BufferSize,
+		Delimiter:  byte(delimiter),
+		QueueDepth: config.QueueDepth,
+		Tracker:    new(bread.Tracker),
+		DetectSize: true,
+		WorkerFunc: func(context.Context, *[]byte) {},
+		OnComplete: func(_ context.Context, s bread.Stats, _ error) {
+			stats = s
+		},
+	}
+
+	if *command != "" {
+		b.Sink = &execSink{command: *command, stdout: &lockedWriter{w: stdout}, stderr: &lockedWriter{w: stderr}}
+	}
+
+	if *interval > 0 {
+		done := make(chan struct{})
+		defer close(done)
+
+		go report(b.Tracker, *interval, stderr, done)
+	}
+
+	err := b.Eat(ctx, input)
+
+	_, _ = fmt.Fprintf(stderr, "records %d, batches %d, failures %d, bytes %s, duration %s\n",
+		stats.Records, stats.Batches, stats.Failures, stats.BytesRead, stats.Duration.Round(time.Millisecond))
+
+	if err == nil {
+		if *command == "" {
+			_, _ = fmt.Fprintln(stdout, stats.Records)
+		}
+
+		return exitOK
+	}
+
+	_, _ = fmt.Fprintln(stderr, "bread:", err)
+
+	if stats.Failures > 0 && stats.Batches > stats.Failures {
+		return exitPartial
+	}
+
+	return exitFailure
+}
+
+// uintFlag parses a non-negative integer flag
+func uintFlag[T int | uint32](dst *T) func(string) error {
+	return func(s string) error {
+		var value T
+
+		if _, err := fmt.Sscan(s, &value); err != nil || value < 0 {
+			return errors.New("invalid unsigned integer")
+		}
+
+		*dst = value
+		return nil
+	}
+}
+
+// report prints the progress every interval until done is closed
+func report(tracker *bread.Tracker, interval time.Duration, w io.Writer, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		progress := tracker.Progress()
+
+		_, _ = fmt.Fprintf(w, "read %s, batches %d/%d, records %d, errors %d",
+			progress.BytesRead, progress.BatchesCompleted, progress.BatchesDispatched, progress.Records, progress.Errors)
+
+		if progress.Fraction > 0 && !progress.SizeExceeded {
+			_, _ = fmt.Fprintf(w, ", %.1f%%, %s left", progress.Fraction*100, progress.ETA.Round(time.Second))
+		}
+
+		_, _ = fmt.Fprintln(w)
+	}
+}
+
+// execSink pipes each batch to a new process of the shell command
+type execSink struct {
+	command        string
+	stdout, stderr io.Writer
+}
+
+func (s *execSink) Write(ctx context.Context, meta bread.BatchMeta, data []byte) error {
+	cmd := exec.CommandContext(ctx, "sh", "-c", s.command)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stdout = s.stdout
+	cmd.Stderr = s.stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("batch %d at offset %d: %w", meta.Index, meta.Offset, err)
+	}
+
+	return nil
+}
+
+func (s *execSink) Flush(context.Context) error {
+	return nil
+}
+
+// lockedWriter serializes the writes of concurrent processes
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.w.Write(p)
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/yael-castro/bread"
+)
+
+// split implements the split command
+func split(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("split", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+
+	delimiter := delimiterFlag(bread.DefaultDelimiter)
+
+	parts := flags.Int("parts", 2, "number of parts")
+	prefix := flags.String("prefix", "", "prefix of the part files, the part number is appended to it (default the file path)")
+	flags.Var(&delimiter, "delimiter", "record delimiter")
+
+	flags.Usage = func() {
+		_, _ = fmt.Fprintln(stderr, "Usage: bread split [flags] file")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	if flags.NArg() != 1 || *parts < 1 {
+		flags.Usage()
+		return exitUsage
+	}
+
+	path := flags.Arg(0)
+	if *prefix == "" {
+		*prefix = path
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, "bread:", err)
+		return exitFailure
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, "bread:", err)
+		return exitFailure
+	}
+
+	boundaries, err := alignedBoundaries(file, info.Size(), *parts, byte(delimiter))
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, "bread:", err)
+		return exitFailure
+	}
+
+	for i := 0; i < len(boundaries)-1; i++ {
+		name := fmt.Sprintf("%s.%03d", *prefix, i)
+		size := boundaries[i+1] - boundaries[i]
+
+		if err = writePart(name, io.NewSectionReader(file, boundaries[i], size)); err != nil {
+			_, _ = fmt.Fprintln(stderr, "bread:", err)
+
+			if i > 0 {
+				return exitPartial
+			}
+
+			return exitFailure
+		}
+
+		_, _ = fmt.Fprintf(stdout, "%s\t%s\n", name, bread.ByteSize(size))
+	}
+
+	return exitOK
+}
+
+// alignedBoundaries splits [0, size) in up to parts ranges, moving each boundary forward
+// to the byte after the next delimiter. Ranges that would be empty are dropped.
+func alignedBoundaries(r io.ReaderAt, size int64, parts int, delimiter byte) ([]int64, error) {
+	boundaries := []int64{0}
+
+	for k := 1; k < parts; k++ {
+		nominal := size * int64(k) / int64(parts)
+		if nominal <= boundaries[len(boundaries)-1] {
+			continue
+		}
+
+		reader := bufio.NewReader(io.NewSectionReader(r, nominal-1, size-nominal+1))
+
+		// Bytes up to the next delimiter, included
+		skipped := int64(0)
+
+		for {
+			slice, err := reader.ReadSlice(delimiter)
+			skipped += int64(len(slice))
+
+			if err == io.EOF {
+				return append(boundaries, size), nil
+			}
+
+			if err == nil {
+				break
+			}
+
+			if err != bufio.ErrBufferFull {
+				return nil, err
+			}
+		}
+
+		if boundary := nominal - 1 + skipped; boundary < size {
+			boundaries = append(boundaries, boundary)
+		}
+	}
+
+	return append(boundaries, size), nil
+}
+
+// writePart copies the part to a new file
+func writePart(name string, part io.Reader) error {
+	file, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(file, part)
+
+	return errors.Join(err, file.Close())
+}
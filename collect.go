@@ -0,0 +1,117 @@
+package bread
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+// Collect maps the records of the reader with fn concurrently and returns the results in the order of the records,
+// like a parallel map over the stream. The records are delimited like the ones of RecordFunc, and they are only
+// valid until fn returns. An empty reader returns an empty slice.
+//
+// The first error of fn fails its batch with a *RecordError and stops the reading, the batches being processed are
+// cancelled and Collect returns the error once they finished, without any result.
+//
+// NOTE: the worker functions, the Sink, OnDone and Output of b are ignored by Collect
+func Collect[T any](ctx context.Context, b Bread, reader io.Reader, fn func(context.Context, []byte) (T, error)) ([]T, error) {
+	results := make([]T, 0)
+
+	err := CollectFunc(ctx, b, reader, fn, func(result T) error {
+		results = append(results, result)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// CollectFunc works like Collect, but it passes the results to emit in the order of the records as soon as the
+// previous ones were emitted, so they are not held in memory. The calls to emit are serialized, and its error stops
+// the reading like an error of fn.
+func CollectFunc[T any](ctx context.Context, b Bread, reader io.Reader, fn func(context.Context, []byte) (T, error), emit func(T) error) error {
+	if fn == nil || emit == nil {
+		return ErrMissingWorkerFunc
+	}
+
+	if b.Framing == FramingNone {
+		return ErrNoRecords
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu      sync.Mutex
+		pending = make(map[uint64][]T)
+		// emitErr and stopped are only used by OnDone, whose calls are serialized
+		emitErr error
+		stopped bool
+	)
+
+	b = b.delimiters()
+	b.Sink, b.Output = nil, nil
+	b.Ordered = true
+
+	// The batches are done in stream order
+	b.OnDone = func(_ context.Context, batch Batch) {
+		mu.Lock()
+		results, ok := pending[batch.Index]
+		delete(pending, batch.Index)
+		mu.Unlock()
+
+		// The batch was cancelled, the next results would leave a gap
+		stopped = stopped || !ok
+
+		for _, result := range results {
+			if stopped || emitErr != nil {
+				return
+			}
+
+			if emitErr = emit(result); emitErr != nil {
+				cancel()
+			}
+		}
+	}
+
+	err := b.eat(ctx, reader, func(ctx context.Context, batch Batch) error {
+		records := recordSlices.Get().(*[][]byte)
+		*records = b.batchRecords((*records)[:0], batch)
+
+		defer func() {
+			clear(*records)
+			recordSlices.Put(records)
+		}()
+
+		// Every record has its result, or the batch fails
+		results := make([]T, len(*records))
+
+		for i, data := range *records {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			result, err := fn(ctx, data)
+			if err != nil {
+				record := Record{Number: batch.FirstRecord + uint64(i) + 1, Offset: recordOffset(batch, data), Parent: batch.BatchMeta}
+				return &RecordError{Record: record, Err: err}
+			}
+
+			results[i] = result
+		}
+
+		mu.Lock()
+		pending[batch.Index] = results
+		mu.Unlock()
+
+		return nil
+	})
+
+	if emitErr != nil {
+		return emitErr
+	}
+
+	return err
+}
@@ -0,0 +1,130 @@
+package bread
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCollect(t *testing.T) {
+	lines := make([]string, 1000)
+	expected := make([]int, len(lines))
+
+	for i := range lines {
+		lines[i] = strconv.Itoa(i)
+		expected[i] = i * 2
+	}
+
+	data := strings.Join(lines, "\n") + "\n"
+
+	// The later records are processed faster, so the batches complete out of order
+	double := func(_ context.Context, record []byte) (int, error) {
+		n, err := strconv.Atoi(string(record))
+		if err != nil {
+			return 0, err
+		}
+
+		if n%100 == 0 {
+			time.Sleep(time.Duration(len(lines)-n) * time.Microsecond)
+		}
+
+		return n * 2, nil
+	}
+
+	cases := [...]struct {
+		bread    Bread
+		data     string
+		expected []int
+		err      error
+	}{
+		{
+			bread:    Bread{BufferSize: 16, Workers: 8},
+			data:     data,
+			expected: expected,
+		},
+		{
+			bread:    Bread{BufferSize: 16, Workers: 8, DelimiterBytes: []byte(", ")},
+			data:     strings.Join(lines, ", "),
+			expected: expected,
+		},
+		{
+			bread:    Bread{BufferSize: 16, Workers: 8},
+			expected: []int{},
+		},
+		{
+			bread: Bread{BufferSize: 16, Workers: 8},
+			data:  strings.Replace(data, "\n500\n", "\nfive hundred\n", 1),
+			err:   strconv.ErrSyntax,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			results, err := Collect(context.TODO(), c.bread, strings.NewReader(c.data), double)
+
+			if c.err != nil {
+				var recordErr *RecordError
+				if !errors.As(err, &recordErr) || !errors.Is(err, c.err) {
+					t.Fatalf("expected a *RecordError wrapping %v, got %v", c.err, err)
+				}
+
+				if recordErr.Record.Number != 501 || results != nil {
+					t.Fatalf("expected record 501 without results, got %d and %d results", recordErr.Record.Number, len(results))
+				}
+
+				t.Logf("Success! %v", err)
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if results == nil || !slices.Equal(results, c.expected) {
+				t.Fatalf("expected %d results in order, got %v", len(c.expected), results)
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+func TestCollectFunc(t *testing.T) {
+	errEmit := errors.New("emit")
+
+	lines := make([]string, 1000)
+	for i := range lines {
+		lines[i] = strconv.Itoa(i)
+	}
+
+	emitted := make([]string, 0)
+
+	err := CollectFunc(context.TODO(), Bread{BufferSize: 16, Workers: 8}, strings.NewReader(strings.Join(lines, "\n")),
+		func(_ context.Context, record []byte) (string, error) {
+			return string(record), nil
+		},
+		func(line string) error {
+			emitted = append(emitted, line)
+			if len(emitted) == 100 {
+				return errEmit
+			}
+
+			return nil
+		},
+	)
+
+	if !errors.Is(err, errEmit) {
+		t.Fatalf("expected error %v, got %v", errEmit, err)
+	}
+
+	// The results after the failed one are not emitted
+	if !slices.Equal(emitted, lines[:100]) {
+		t.Fatalf("expected the first 100 lines in order, got %v", emitted)
+	}
+
+	t.Logf("Success! %v", err)
+}
@@ -0,0 +1,250 @@
+package bread
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// ErrUnsupportedCompression indicates that SniffCompression found a compression format it cannot decompress
+var ErrUnsupportedCompression = errors.New("unsupported compression")
+
+// Magic bytes of the compression formats recognized by SniffCompression
+var (
+	magicGzip  = []byte{0x1f, 0x8b}
+	magicZstd  = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	magicBzip2 = []byte("BZh")
+)
+
+// SniffCompression is a Decompressor that recognizes the compression format from the magic bytes of the reader,
+// the data that is not compressed is passed through untouched. gzip and bzip2 are decompressed, while zstd
+// fails with ErrUnsupportedCompression, so a Decompressor that supports it can handle it before calling
+// SniffCompression.
+//
+// The magic bytes are peeked through the reader when it is a *bufio.Reader, so no byte is lost.
+func SniffCompression(r io.Reader) (io.ReadCloser, error) {
+	buffered, ok := r.(*bufio.Reader)
+	if !ok {
+		buffered = bufio.NewReader(r)
+	}
+
+	magic, err := buffered.Peek(len(magicZstd))
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	switch {
+	case bytes.HasPrefix(magic, magicGzip):
+		return gzip.NewReader(buffered)
+	case bytes.HasPrefix(magic, magicBzip2):
+		return io.NopCloser(bzip2.NewReader(buffered)), nil
+	case bytes.HasPrefix(magic, magicZstd):
+		return nil, fmt.Errorf("%w: zstd", ErrUnsupportedCompression)
+	}
+
+	return io.NopCloser(buffered), nil
+}
+
+// DecompressError indicates that the Decompressor failed, unlike the errors of the reader given to Eat
+type DecompressError struct {
+	Err error
+}
+
+func (e *DecompressError) Error() string {
+	return fmt.Sprintf("decompress: %v", e.Err)
+}
+
+func (e *DecompressError) Unwrap() error {
+	return e.Err
+}
+
+// decompress wraps the reader with the Decompressor, see Bread.Decompressor
+func (b Bread) decompress(reader io.Reader) (*decompressReader, error) {
+	// The failed decompressor is started again on the rest of the source, see ContinueOnError
+	if b.ContinueOnError {
+		return &decompressReader{decompress: b.Decompressor, source: &replayReader{r: bufio.NewReader(sourceReader{reader})}}, nil
+	}
+
+	decompressor, err := b.Decompressor(sourceReader{reader})
+	if err != nil {
+		return nil, decompressError(err)
+	}
+
+	return &decompressReader{decompressor: decompressor}, nil
+}
+
+// decompressReader wraps the errors of the decompressor in a *DecompressError, except the errors of the source
+type decompressReader struct {
+	decompressor io.ReadCloser
+	// decompress starts the decompressor again on the source after its errors, when it is set
+	decompress func(io.Reader) (io.ReadCloser, error)
+	source     *replayReader
+}
+
+// Read fills p unless the data ends, so the batches match the ones of the data without compression
+func (d *decompressReader) Read(p []byte) (n int, err error) {
+	if d.decompressor == nil {
+		if err = d.restart(); err != nil {
+			return 0, err
+		}
+	}
+
+	for n < len(p) && err == nil {
+		var m int
+
+		m, err = d.decompressor.Read(p[n:])
+		n += m
+	}
+
+	switch {
+	case err == nil:
+		return n, nil
+	case err == io.EOF:
+		// The io.EOF is returned again by the next call
+		if n > 0 {
+			return n, nil
+		}
+
+		return 0, io.EOF
+	}
+
+	err = decompressError(err)
+
+	// The next read starts the decompressor again
+	if _, ok := err.(*DecompressError); ok && d.decompress != nil {
+		_ = d.decompressor.Close()
+		d.decompressor = nil
+	}
+
+	return n, err
+}
+
+// restart starts the decompressor on the rest of the source, one byte further each time it fails.
+// The data ends once the source is exhausted.
+func (d *decompressReader) restart() error {
+	for {
+		d.source.read = d.source.read[:0]
+		d.source.recording = true
+
+		decompressor, err := d.decompress(d.source)
+
+		d.source.recording = false
+
+		if err == nil {
+			d.decompressor = decompressor
+			return nil
+		}
+
+		var source *sourceError
+		if errors.As(err, &source) {
+			return source.err
+		}
+
+		// The bytes read by the failed attempt are read again but the first one
+		if len(d.source.read) > 0 {
+			d.source.pending = append(slices.Clone(d.source.read[1:]), d.source.pending...)
+			continue
+		}
+
+		if _, err := d.source.ReadByte(); err != nil {
+			if err != io.EOF {
+				return decompressError(err)
+			}
+
+			d.decompressor = io.NopCloser(bytes.NewReader(nil))
+			return nil
+		}
+	}
+}
+
+// replayReader reads again the bytes read by a failed decompressor, see decompressReader.restart
+type replayReader struct {
+	r *bufio.Reader
+	// pending holds the bytes read before the ones of r
+	pending []byte
+	// read records the bytes read while recording is set
+	read      []byte
+	recording bool
+}
+
+func (r *replayReader) Read(p []byte) (n int, err error) {
+	if len(r.pending) > 0 {
+		n = copy(p, r.pending)
+		r.pending = r.pending[n:]
+	} else {
+		n, err = r.r.Read(p)
+	}
+
+	if r.recording {
+		r.read = append(r.read, p[:n]...)
+	}
+
+	return n, err
+}
+
+// ReadByte implements io.ByteReader, so the decompressors do not read ahead
+func (r *replayReader) ReadByte() (byte, error) {
+	var p [1]byte
+
+	if n, err := r.Read(p[:]); n == 0 {
+		return 0, cmp.Or(err, io.ErrNoProgress)
+	}
+
+	return p[0], nil
+}
+
+func (d *decompressReader) Close() error {
+	if d.decompressor == nil {
+		return nil
+	}
+
+	if err := d.decompressor.Close(); err != nil {
+		return &DecompressError{Err: err}
+	}
+
+	return nil
+}
+
+// sourceReader marks the errors of the reader given to Eat, so they are not taken for decompression errors
+type sourceReader struct {
+	io.Reader
+}
+
+func (r sourceReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if err != nil && err != io.EOF {
+		return n, &sourceError{err: err}
+	}
+
+	return n, err
+}
+
+// sourceError is an error of the reader given to Eat
+type sourceError struct {
+	err error
+}
+
+func (e *sourceError) Error() string {
+	return e.err.Error()
+}
+
+func (e *sourceError) Unwrap() error {
+	return e.err
+}
+
+// decompressError wraps an error of the decompressor in a *DecompressError, the errors of the reader given
+// to Eat are returned as they are
+func decompressError(err error) error {
+	var source *sourceError
+	if errors.As(err, &source) {
+		return source.err
+	}
+
+	return &DecompressError{Err: err}
+}
@@ -0,0 +1,119 @@
+package bread
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestBread_Decompressor(t *testing.T) {
+	errRead := errors.New("read failure")
+
+	data := make([]byte, 0)
+	for i := 0; i < 10_000; i++ {
+		data = fmt.Appendf(data, "record %d\n", i)
+	}
+
+	gzipped := bytes.Buffer{}
+
+	writer := gzip.NewWriter(&gzipped)
+	_, _ = writer.Write(data)
+	_ = writer.Close()
+
+	bzipped, err := os.ReadFile("testdata/compress/records.txt.bz2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := [...]struct {
+		reader io.Reader
+		err    error
+		// decompress indicates that the error comes from the decompression
+		decompress bool
+	}{
+		{
+			reader: bytes.NewReader(data),
+		},
+		{
+			reader: bytes.NewReader(gzipped.Bytes()),
+		},
+		{
+			reader: bytes.NewReader(bzipped),
+		},
+		{
+			reader:     bytes.NewReader(append([]byte{0x28, 0xb5, 0x2f, 0xfd}, data...)),
+			err:        ErrUnsupportedCompression,
+			decompress: true,
+		},
+		// The stream is cut in the middle
+		{
+			reader:     bytes.NewReader(gzipped.Bytes()[:gzipped.Len()/2]),
+			err:        io.ErrUnexpectedEOF,
+			decompress: true,
+		},
+		{
+			reader: io.MultiReader(bytes.NewReader(gzipped.Bytes()[:gzipped.Len()/2]), failedReader{err: errRead}),
+			err:    errRead,
+		},
+	}
+
+	// The batches of the data without compression
+	expected := make(map[BatchMeta]string)
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			mu := sync.Mutex{}
+			batches := make(map[BatchMeta]string)
+
+			bread := Bread{
+				Workers:      4,
+				BufferSize:   KB,
+				Decompressor: SniffCompression,
+				WorkerBatchFunc: func(_ context.Context, batch Batch) {
+					mu.Lock()
+					defer mu.Unlock()
+
+					batches[batch.BatchMeta] = string(batch.Data)
+				},
+			}
+
+			err := bread.Eat(context.TODO(), c.reader)
+			if !errors.Is(err, c.err) {
+				t.Fatalf("expected error %v, got %v", c.err, err)
+			}
+
+			var decompressErr *DecompressError
+			if errors.As(err, &decompressErr) != c.decompress {
+				t.Fatalf("unexpected decompression error %v", err)
+			}
+
+			if c.err != nil {
+				t.Logf("Success! %v", err)
+				return
+			}
+
+			if i == 0 {
+				expected = batches
+			}
+
+			if len(batches) != len(expected) {
+				t.Fatalf("expected %d batches, got %d", len(expected), len(batches))
+			}
+
+			for meta, data := range expected {
+				if batches[meta] != data {
+					t.Fatalf("batch %d differs from the data without compression", meta.Index)
+				}
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package bread
+
+// Config is a snapshot of the effective settings of an Eat call, after the defaults were applied
+type Config struct {
+	Workers    int
+	BufferSeed int
+	BufferSize int
+	// Delimiter is the sequence that ends the records, either the Delimiter or the DelimiterBytes of the settings
+	Delimiter  string
+	QueueDepth uint32
+	Align      Align
+	// PoolStrategy is ignored when the settings have a Pool, FreelistSize is only set for PoolFreelist
+	PoolStrategy PoolStrategy
+	FreelistSize int
+	// MaxBufferCap is zero when the settings have a Pool
+	MaxBufferCap int
+	// MinBufferSize and MaxBufferSize are only set with AdaptiveBuffer
+	AdaptiveBuffer bool
+	MinBufferSize  int
+	MaxBufferSize  int
+	// ReaderBufferSize is the size of the bufio.Reader that buffers the stream
+	ReaderBufferSize int
+}
+
+// config takes a snapshot of the settings
+func (b Bread) config() Config {
+	config := Config{
+		Workers:          b.Workers,
+		BufferSeed:       b.BufferSeed,
+		BufferSize:       b.BufferSize,
+		Delimiter:        string(b.separator()),
+		QueueDepth:       b.QueueDepth,
+		Align:            b.Align,
+		PoolStrategy:     b.PoolStrategy,
+		ReaderBufferSize: b.readerBufferSize(),
+	}
+
+	if b.PoolStrategy == PoolFreelist {
+		config.FreelistSize = b.freelistSize()
+	}
+
+	if b.Pool == nil {
+		config.MaxBufferCap = b.maxBufferCap()
+	}
+
+	if b.AdaptiveBuffer {
+		config.AdaptiveBuffer = true
+		config.MinBufferSize, config.MaxBufferSize = b.minBufferSize(), b.maxBufferSize()
+	}
+
+	return config
+}
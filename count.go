@@ -0,0 +1,189 @@
+package bread
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"io"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultCountBufferSize is the buffer size used by Count when CountOptions.BufferSize is not set
+const DefaultCountBufferSize = 1 * MB
+
+// CountOptions configures Count
+type CountOptions struct {
+	// Workers number of concurrent workers
+	//
+	// This member is optional. Default value DefaultWorkers
+	Workers int
+	// BufferSize is the maximum size of each batch
+	//
+	// This member is optional. Default value DefaultCountBufferSize
+	BufferSize int
+}
+
+// Counts are the results of Count, like the ones reported by wc
+type Counts struct {
+	// Lines is the number of line feeds
+	Lines uint64
+	// Words is the number of sequences of bytes separated by white space
+	Words uint64
+	// Bytes is the size of the stream
+	Bytes uint64
+}
+
+// Count counts the lines, words and bytes of the reader like wc in the C locale, the batches are counted
+// concurrently and their partial counts are merged in stream order.
+//
+// Batches never exceed BufferSize, so the lines longer than that are split. The words that span two batches are
+// counted once by checking whether the first batch ends in a word and the next one starts in a word.
+func Count(ctx context.Context, r io.Reader, opts CountOptions) (Counts, error) {
+	if opts.BufferSize == 0 {
+		opts.BufferSize = DefaultCountBufferSize
+	}
+
+	b := Bread{
+		Workers:      opts.Workers,
+		BufferSize:   opts.BufferSize,
+		MaxBatchSize: opts.BufferSize,
+	}
+
+	var (
+		mu       sync.Mutex
+		partials []countPartial
+	)
+
+	err := b.eat(ctx, r, func(ctx context.Context, batch Batch) error {
+		partial := count(batch)
+
+		mu.Lock()
+		partials = append(partials, partial)
+		mu.Unlock()
+
+		return nil
+	})
+	if err != nil {
+		return Counts{}, err
+	}
+
+	slices.SortFunc(partials, func(a, b countPartial) int {
+		return cmp.Compare(a.index, b.index)
+	})
+
+	counts := Counts{}
+
+	for i, partial := range partials {
+		counts.Lines += partial.lines
+		counts.Words += partial.words
+		counts.Bytes += partial.bytes
+
+		// The word continues from the previous batch
+		if i > 0 && partials[i-1].endsInWord && partial.startsInWord {
+			counts.Words--
+		}
+	}
+
+	return counts, nil
+}
+
+// countPartial holds the counts of a single batch
+type countPartial struct {
+	index        uint64
+	lines        uint64
+	words        uint64
+	bytes        uint64
+	startsInWord bool
+	endsInWord   bool
+}
+
+// space reports the white space bytes of the C locale
+var space = [256]bool{' ': true, '\t': true, '\n': true, '\v': true, '\f': true, '\r': true}
+
+// count counts the batch, words are counted at their first byte
+func count(batch Batch) countPartial {
+	data := batch.Data
+
+	partial := countPartial{
+		index: batch.Index,
+		lines: uint64(bytes.Count(data, []byte{'\n'})),
+		bytes: uint64(len(data)),
+	}
+
+	if len(data) == 0 {
+		return partial
+	}
+
+	inWord := false
+
+	for _, c := range data {
+		if space[c] {
+			inWord = false
+			continue
+		}
+
+		if !inWord {
+			partial.words++
+			inWord = true
+		}
+	}
+
+	partial.startsInWord = !space[data[0]]
+	partial.endsInWord = inWord
+
+	return partial
+}
+
+// Count reads the reader with the same settings as Eat, including the Delimiter, RecordSize and Framing, and
+// returns the number of records and bytes of its batches without calling any worker. The batches are counted on
+// the calling goroutine as they are read, so the same buffer is reused for the whole stream.
+//
+// Unlike the Lines of the package-level Count, a last record without a trailing delimiter counts as one.
+// LimitBytes, MaxRecords and MaxBatches stop the count like they stop Eat.
+//
+// NOTE: the worker functions, Workers, Sink, Output and the sampling settings are ignored by Count
+func (b Bread) Count(ctx context.Context, reader io.Reader) (records uint64, size int64, err error) {
+	if reader == nil {
+		return 0, 0, ErrNilReader
+	}
+
+	b.Sink = nil
+	b.Output, b.Ordered = nil, false
+	b.SampleEvery, b.SampleRate = 0, 0
+
+	err = b.feed(ctx, func(_ context.Context, batch Batch) error {
+		records += uint64(batch.Records)
+		size += int64(batch.Size)
+
+		return nil
+	}, func(ctx context.Context, e *eater) error {
+		e.inline = true
+
+		return e.read(ctx, reader)
+	})
+
+	return records, size, err
+}
+
+// CountAt works like Count over the first size bytes of r, counting the ranges of EatAt concurrently, each one on
+// the goroutine that reads it. The settings EatAt ignores are ignored by CountAt too, see EatAt.
+func (b Bread) CountAt(ctx context.Context, r io.ReaderAt, size int64) (records uint64, read int64, err error) {
+	var (
+		counted      atomic.Uint64
+		bytesCounted atomic.Int64
+	)
+
+	b.Sink = nil
+	b.SampleEvery, b.SampleRate = 0, 0
+
+	err = b.eatAt(ctx, r, size, func(_ context.Context, batch Batch) error {
+		counted.Add(uint64(batch.Records))
+		bytesCounted.Add(int64(batch.Size))
+
+		return nil
+	})
+
+	return counted.Load(), bytesCounted.Load(), err
+}
@@ -0,0 +1,308 @@
+package bread
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestCount(t *testing.T) {
+	// Expected values reported by LC_ALL=C wc
+	cases := [...]struct {
+		file     string
+		opts     CountOptions
+		expected Counts
+	}{
+		{
+			file: "empty.txt",
+		},
+		{
+			file:     "lines.txt",
+			expected: Counts{Lines: 2, Words: 9, Bytes: 44},
+		},
+		{
+			file:     "unterminated.txt",
+			opts:     CountOptions{BufferSize: 4},
+			expected: Counts{Lines: 0, Words: 4, Bytes: 24},
+		},
+		{
+			file:     "long.txt",
+			expected: Counts{Lines: 2, Words: 401, Bytes: 9898},
+		},
+		{
+			// Long lines are split in the middle of the words
+			file:     "long.txt",
+			opts:     CountOptions{Workers: 8, BufferSize: 7},
+			expected: Counts{Lines: 2, Words: 401, Bytes: 9898},
+		},
+		{
+			file:     "spaces.txt",
+			opts:     CountOptions{Workers: 4, BufferSize: 3},
+			expected: Counts{Lines: 4, Words: 7, Bytes: 60},
+		},
+		{
+			file:     "utf8.txt",
+			opts:     CountOptions{BufferSize: 2},
+			expected: Counts{Lines: 2, Words: 5, Bytes: 22},
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			f, err := os.Open(filepath.Join("testdata", "wc", c.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer f.Close()
+
+			counts, err := Count(context.TODO(), f, c.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if counts != c.expected {
+				t.Fatalf("expected %+v, got %+v", c.expected, counts)
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+func TestBread_CountAt(t *testing.T) {
+	cases := [...]struct {
+		file  string
+		bread Bread
+	}{
+		{file: "empty.txt", bread: Bread{BufferSize: 4}},
+		{file: "lines.txt", bread: Bread{BufferSize: 1, Workers: 7}},
+		{file: "unterminated.txt", bread: Bread{BufferSize: 3}},
+		{file: "long.txt", bread: Bread{BufferSize: KB}},
+		{file: "long.txt", bread: Bread{BufferSize: 100, Workers: 16}},
+		{file: "spaces.txt", bread: Bread{BufferSize: 2, Workers: 3}},
+		{file: "spaces.txt", bread: Bread{BufferSize: 2, Workers: 3, Delimiter: ' '}},
+		{file: "utf8.txt", bread: Bread{BufferSize: 5}},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", "wc", c.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// The ranges count the same records as the sequential read
+			expected, _, err := c.bread.Count(context.TODO(), bytes.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			records, size, err := c.bread.CountAt(context.TODO(), bytes.NewReader(data), int64(len(data)))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if records != expected || size != int64(len(data)) {
+				t.Fatalf("expected %d records of %d bytes, got %d records of %d bytes", expected, len(data), records, size)
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+func TestBread_CountAt_Errors(t *testing.T) {
+	errRead := errors.New("read failure")
+
+	canceled, cancel := context.WithCancel(context.TODO())
+	cancel()
+
+	cases := [...]struct {
+		ctx    context.Context
+		reader io.ReaderAt
+		err    error
+	}{
+		{
+			ctx:    canceled,
+			reader: strings.NewReader("a\nb\n"),
+			err:    context.Canceled,
+		},
+		{
+			ctx:    context.TODO(),
+			reader: failingReaderAt{err: errRead},
+			err:    errRead,
+		},
+		{
+			ctx: context.TODO(),
+			err: ErrNilReader,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			bread := Bread{BufferSize: 1, Workers: 2}
+
+			_, _, err := bread.CountAt(c.ctx, c.reader, 4)
+			if !errors.Is(err, c.err) {
+				t.Fatalf("expected error %v, got %v", c.err, err)
+			}
+
+			t.Logf("Success! %v", err)
+		})
+	}
+}
+
+// failingReaderAt fails every read
+type failingReaderAt struct {
+	err error
+}
+
+func (r failingReaderAt) ReadAt([]byte, int64) (int, error) {
+	return 0, r.err
+}
+
+func TestBread_Count(t *testing.T) {
+	cases := [...]struct {
+		bread   Bread
+		data    []byte
+		records uint64
+		size    int64
+		err     error
+	}{
+		{
+			bread:   Bread{BufferSize: 4},
+			data:    []byte("a\nbb\n\nccc\ndddd\n"),
+			records: 5,
+			size:    15,
+		},
+		{
+			// The last record is not terminated
+			bread:   Bread{BufferSize: 4},
+			data:    []byte("a\nbb\n\nccc\ndddd"),
+			records: 5,
+			size:    14,
+		},
+		{
+			bread: Bread{BufferSize: 4},
+		},
+		{
+			bread:   Bread{BufferSize: 8, DelimiterBytes: []byte("\r\n")},
+			data:    []byte("a\r\nbb\r\nccc\r\n"),
+			records: 3,
+			size:    12,
+		},
+		{
+			// The batches hold no records, see WithNoDelimiter
+			bread: Bread{BufferSize: 4, Framing: FramingNone},
+			data:  []byte("abcdefghij"),
+			size:  10,
+		},
+		{
+			bread:   Bread{BufferSize: 4, Framing: FramingUvarint},
+			data:    prefixed(FramingUvarint, "a", "", "bc", strings.Repeat("m", 100)),
+			records: 4,
+			size:    103,
+		},
+		{
+			// The reading stops at the end of the batch that reaches the limit
+			bread:   Bread{BufferSize: 4, LimitBytes: 6},
+			data:    []byte("aaaa\nbbbb\ncccc\ndddd\n"),
+			records: 2,
+			size:    10,
+		},
+		{
+			bread:   Bread{BufferSize: 4, MaxRecords: 3},
+			data:    []byte("a\nb\nc\nd\ne\n"),
+			records: 3,
+			size:    6,
+		},
+		{
+			bread: Bread{BufferSize: 4, MaxRecordSize: 8},
+			data:  []byte("a\n" + strings.Repeat("b", 100)),
+			err:   ErrRecordTooLarge,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			records, size, err := c.bread.Count(context.TODO(), bytes.NewReader(c.data))
+			if c.err != nil {
+				if !errors.Is(err, c.err) {
+					t.Fatalf("expected error %v, got %v", c.err, err)
+				}
+
+				t.Logf("Success! %v", err)
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if records != c.records || size != c.size {
+				t.Fatalf("expected %d records of %d bytes, got %d records of %d bytes", c.records, c.size, records, size)
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+func BenchmarkBread_Count(b *testing.B) {
+	path := filepath.Join(b.TempDir(), "data")
+
+	if err := os.WriteFile(path, bytes.Repeat([]byte("{\"key\":\"value\"}\n"), 64*MB/16), 0o600); err != nil {
+		b.Fatal(err)
+	}
+
+	read := func(b *testing.B, f func(*os.File) error) {
+		b.SetBytes(64 * MB)
+
+		for n := 0; n < b.N; n++ {
+			file, err := os.Open(path)
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			err = f(file)
+			_ = file.Close()
+
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+
+	// The plain read of the file is the upper bound of Count
+	b.Run("read", func(b *testing.B) {
+		read(b, func(file *os.File) error {
+			_, err := io.Copy(io.Discard, file)
+			return err
+		})
+	})
+
+	b.Run("count", func(b *testing.B) {
+		bread := Bread{BufferSize: MB}
+
+		read(b, func(file *os.File) error {
+			_, _, err := bread.Count(context.TODO(), file)
+			return err
+		})
+	})
+
+	b.Run("count at", func(b *testing.B) {
+		bread := Bread{BufferSize: MB}
+
+		read(b, func(file *os.File) error {
+			_, _, err := bread.CountAt(context.TODO(), file, 64*MB)
+			return err
+		})
+	})
+}
@@ -0,0 +1,49 @@
+package bread
+
+import "bytes"
+
+// crlf is the line ending normalized by NormalizeCRLF
+var crlf = []byte("\r\n")
+
+// normalizesCRLF reports whether NormalizeCRLF applies to the settings
+func (b Bread) normalizesCRLF() bool {
+	return b.NormalizeCRLF && b.Framing == FramingDelimiter && b.SplitFunc == nil && !b.CSVMode && b.RecordSize == 0
+}
+
+// normalize replaces the CRLF line endings of the batch data with line feeds, see NormalizeCRLF
+func (e *eater) normalize(batch *Batch) {
+	if !e.normalizesCRLF() || !bytes.Equal(e.separator, []byte{'\n'}) {
+		return
+	}
+
+	batch.Data = normalizeCRLF(batch.Data)
+
+	if batch.buffer != nil {
+		*batch.buffer = batch.Data
+	}
+}
+
+// normalizeCRLF replaces each "\r\n" of data with "\n" in place, the lone carriage returns are kept
+func normalizeCRLF(data []byte) []byte {
+	i := bytes.Index(data, crlf)
+	if i < 0 {
+		return data
+	}
+
+	n := i
+
+	for rest := data[i:]; ; {
+		// rest starts with "\r\n"
+		data[n] = '\n'
+		n++
+		rest = rest[len(crlf):]
+
+		i = bytes.Index(rest, crlf)
+		if i < 0 {
+			return data[:n+copy(data[n:], rest)]
+		}
+
+		n += copy(data[n:], rest[:i])
+		rest = rest[i:]
+	}
+}
@@ -0,0 +1,132 @@
+package bread
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestNormalizeCRLF(t *testing.T) {
+	cases := [...]struct {
+		data     string
+		expected string
+	}{
+		{data: "", expected: ""},
+		{data: "a\nb\n", expected: "a\nb\n"},
+		{data: "a\r\nb\r\n", expected: "a\nb\n"},
+		{data: "a\r\nb\nc\r\n", expected: "a\nb\nc\n"},
+		{data: "a\rb\r\r\n\r", expected: "a\rb\r\n\r"},
+		{data: "\r\n\r\n", expected: "\n\n"},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			got := normalizeCRLF([]byte(c.data))
+			if string(got) != c.expected {
+				t.Fatalf("expected %q, got %q", c.expected, got)
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+func TestBread_NormalizeCRLF(t *testing.T) {
+	cases := [...]struct {
+		bread    Bread
+		data     string
+		expected string
+	}{
+		{
+			// LF only
+			bread:    Bread{BufferSize: 4},
+			data:     "aaa\nbbbbbb\ncc\n",
+			expected: "aaa\nbbbbbb\ncc\n",
+		},
+		{
+			// CRLF only, the carriage return of "aaa\r\n" is the last byte of the buffer
+			bread:    Bread{BufferSize: 4},
+			data:     "aaa\r\nbbbbbb\r\ncc\r\n",
+			expected: "aaa\nbbbbbb\ncc\n",
+		},
+		{
+			// Mixed, with lone carriage returns in the middle of the records
+			bread:    Bread{BufferSize: 4},
+			data:     "a\ra\r\nbb\nc\rc\r\ndd",
+			expected: "a\ra\nbb\nc\rc\ndd",
+		},
+		{
+			bread:    Bread{BufferSize: 4, TrimDelimiter: true},
+			data:     "aaa\r\nbbbbbb\nc\r",
+			expected: "aaabbbbbbc\r",
+		},
+		{
+			bread:    Bread{BufferSize: 4, Align: AlignBackward},
+			data:     "a\r\nb\nc\r\n",
+			expected: "a\nb\nc\n",
+		},
+		{
+			// The input held in memory is read into pooled buffers
+			bread:    Bread{BufferSize: 4, SyncThreshold: KB},
+			data:     "aaa\r\nbbbbbb\r\ncc\r\n",
+			expected: "aaa\nbbbbbb\ncc\n",
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			c.bread.NormalizeCRLF = true
+			c.bread.Workers = 2
+
+			batches, err := collect(t, c.bread, iotest.HalfReader(strings.NewReader(c.data)))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := make([]byte, 0, len(c.data))
+			for _, batch := range batches {
+				got = append(got, batch.Data...)
+			}
+
+			if string(got) != c.expected {
+				t.Fatalf("expected %q, got %q", c.expected, got)
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+func TestBread_NormalizeCRLFBytes(t *testing.T) {
+	data := []byte("aaa\r\nbbbbbb\r\ncc\r\n")
+	original := bytes.Clone(data)
+
+	got := make([]byte, 0)
+
+	bread := Bread{
+		BufferSize:    4,
+		NormalizeCRLF: true,
+		WorkerFunc: func(_ context.Context, buffer *[]byte) {
+			got = append(got, *buffer...)
+		},
+	}
+
+	err := bread.EatBytes(context.TODO(), data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(got) != "aaa\nbbbbbb\ncc\n" {
+		t.Fatalf("unexpected batches %q", got)
+	}
+
+	// The input is not modified
+	if !bytes.Equal(data, original) {
+		t.Fatalf("the input was modified: %q", data)
+	}
+
+	t.Log("Success!")
+}
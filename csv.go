@@ -0,0 +1,59 @@
+package bread
+
+import (
+	"bytes"
+	"errors"
+)
+
+// ErrUnterminatedQuote indicates that a CSV stream ends inside a quoted field, see Bread.CSVMode
+var ErrUnterminatedQuote = errors.New("unterminated quoted field")
+
+// csvSplitter is the SplitFunc of CSVMode, it ends the rows at the line feeds outside quoted fields.
+// The rows are scanned once, the state of the row is kept while the function asks for more data.
+type csvSplitter struct {
+	// scanned is the number of bytes of the row already scanned
+	scanned int
+	// quoted reports whether the scanned bytes end inside a quoted field
+	quoted bool
+}
+
+func (s *csvSplitter) split(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	for s.scanned < len(data) {
+		// Escaped quotes toggle the state twice, so only the parity of the quotes matters
+		var i int
+		if s.quoted {
+			i = bytes.IndexByte(data[s.scanned:], '"')
+		} else {
+			i = bytes.IndexAny(data[s.scanned:], "\"\n")
+		}
+
+		if i < 0 {
+			s.scanned = len(data)
+			break
+		}
+
+		s.scanned += i + 1
+
+		if data[s.scanned-1] == '"' {
+			s.quoted = !s.quoted
+			continue
+		}
+
+		advance = s.scanned
+		*s = csvSplitter{}
+
+		return advance, data[:advance], nil
+	}
+
+	if !atEOF || len(data) == 0 {
+		return 0, nil, nil
+	}
+
+	if s.quoted {
+		return 0, nil, ErrUnterminatedQuote
+	}
+
+	*s = csvSplitter{}
+
+	return len(data), data, nil
+}
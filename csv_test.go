@@ -0,0 +1,118 @@
+package bread
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestBread_CSVMode(t *testing.T) {
+	// Every other row has a quoted field with line feeds and escaped quotes
+	rows := make([]string, 300)
+	for i := range rows {
+		if i%2 == 0 {
+			rows[i] = fmt.Sprintf("%d,plain,value\n", i)
+			continue
+		}
+
+		rows[i] = fmt.Sprintf("%d,\"line one\nsaid \"\"hi\"\"\n%s\",value\r\n", i, strings.Repeat("\n", i%5))
+	}
+
+	// A quoted field longer than the buffers
+	rows[100] = "100,\"" + strings.Repeat("long\n", 100) + "\",value\n"
+
+	data := strings.Join(rows, "")
+
+	cases := [...]struct {
+		bread  Bread
+		data   string
+		reader func(io.Reader) io.Reader
+		// expected is the number of rows delivered
+		expected int
+		err      error
+	}{
+		{
+			bread:    Bread{BufferSize: 64, Workers: 4, CSVMode: true},
+			data:     data,
+			expected: len(rows),
+		},
+		{
+			bread:    Bread{BufferSize: 64, Workers: 4, CSVMode: true},
+			data:     data,
+			reader:   iotest.OneByteReader,
+			expected: len(rows),
+		},
+		{
+			bread:    Bread{BufferSize: 1024, Workers: 4, CSVMode: true, Epochs: 2},
+			data:     data,
+			expected: 2 * len(rows),
+		},
+		{
+			// The last row is not terminated
+			bread:    Bread{BufferSize: 64, CSVMode: true},
+			data:     "a,\"b\nc\"\nd,e",
+			expected: 2,
+		},
+		{
+			bread: Bread{BufferSize: 64, CSVMode: true},
+			data:  "a,b\nc,\"d\ne",
+			err:   ErrUnterminatedQuote,
+		},
+		{
+			bread: Bread{BufferSize: 64, CSVMode: true, MaxRecordSize: 100},
+			data:  data,
+			err:   ErrRecordTooLarge,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			var reader io.Reader = strings.NewReader(c.data)
+			if c.reader != nil {
+				reader = c.reader(reader)
+			}
+
+			batches, err := collect(t, c.bread, reader)
+			if c.err != nil {
+				if !errors.Is(err, c.err) {
+					t.Fatalf("expected error %v, got %v", c.err, err)
+				}
+
+				t.Logf("Success! %v", err)
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			delivered := 0
+
+			// Each batch holds whole rows
+			for _, batch := range batches {
+				parsed, err := csv.NewReader(bytes.NewReader(batch.Data)).ReadAll()
+				if err != nil {
+					t.Fatalf("batch %d does not hold whole rows: %v", batch.Index, err)
+				}
+
+				if len(parsed) != int(batch.Records) {
+					t.Fatalf("batch %d holds %d rows, %d records reported", batch.Index, len(parsed), batch.Records)
+				}
+
+				delivered += len(parsed)
+			}
+
+			if delivered != c.expected {
+				t.Fatalf("expected %d rows, got %d", c.expected, delivered)
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
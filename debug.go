@@ -0,0 +1,110 @@
+package bread
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+)
+
+// debugs reports whether the debug events are logged, see Bread.Logger. The attributes of an event are only built
+// when it is logged, so a Logger that filters the debug level costs a call to Enabled per event.
+func (e *eater) debugs(ctx context.Context) bool {
+	return e.Logger != nil && e.Logger.Enabled(ctx, slog.LevelDebug)
+}
+
+// debugWrapped logs that the reader was wrapped
+func (e *eater) debugWrapped(ctx context.Context, wrapper string) {
+	if e.debugs(ctx) {
+		e.Logger.LogAttrs(ctx, slog.LevelDebug, "reader wrapped", slog.String("wrapper", wrapper))
+	}
+}
+
+// debugComplement logs the bytes read past BufferSize to complete a batch
+func (e *eater) debugComplement(ctx context.Context, complement int) {
+	if e.debugs(ctx) {
+		e.Logger.LogAttrs(ctx, slog.LevelDebug, "batch completed up to the delimiter",
+			slog.Uint64("index", e.index),
+			slog.Int("complement", complement),
+		)
+	}
+}
+
+// debugDispatched logs that a batch was read and handed to the workers
+func (e *eater) debugDispatched(ctx context.Context, batch Batch) {
+	if e.debugs(ctx) {
+		e.Logger.LogAttrs(ctx, slog.LevelDebug, "batch dispatched",
+			slog.Uint64("index", batch.Index),
+			slog.Int64("offset", batch.Offset),
+			slog.Int("size", batch.Size),
+			slog.Uint64("records", uint64(batch.Records)),
+		)
+	}
+}
+
+// debugProcessed logs that a worker finished processing a batch
+func (e *eater) debugProcessed(ctx context.Context, batch Batch, err error) {
+	if e.debugs(ctx) {
+		e.Logger.LogAttrs(ctx, slog.LevelDebug, "batch processed",
+			slog.Uint64("index", batch.Index),
+			slog.Any("err", err),
+		)
+	}
+}
+
+// debugEnded logs the reason the Eat call ended
+func (e *eater) debugEnded(ctx context.Context, err error) {
+	if !e.debugs(ctx) {
+		return
+	}
+
+	reason := "end of input"
+
+	switch {
+	case errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) && ctx.Err() != nil:
+		reason = "cancelled"
+	case e.failed.Load():
+		reason = "batch failed"
+	case err != nil:
+		reason = "error"
+	case e.limited:
+		reason = "byte limit reached"
+	case e.stopped:
+		reason = "limit reached"
+	}
+
+	e.Logger.LogAttrs(ctx, slog.LevelDebug, "eat ended",
+		slog.String("reason", reason),
+		slog.Uint64("batches", e.counters.batches.Load()),
+		slog.Int64("bytes_read", e.counters.bytesRead.Load()),
+		slog.Any("err", err),
+	)
+}
+
+// debugReader logs each read, it is only used when the debug events are logged at the start of the Eat call
+type debugReader struct {
+	io.Reader
+	ctx    context.Context
+	logger *slog.Logger
+}
+
+func (r debugReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+
+	if r.logger.Enabled(r.ctx, slog.LevelDebug) {
+		r.logger.LogAttrs(r.ctx, slog.LevelDebug, "read", slog.Int("n", n), slog.Any("err", err))
+	}
+
+	return n, err
+}
+
+// debugReader wraps the reader to log its reads, when the debug events are logged
+func (e *eater) debugReader(ctx context.Context, reader io.Reader) io.Reader {
+	// The contents of a bytes.Buffer are taken without reading them, see read
+	if _, ok := reader.(*bytes.Buffer); ok || !e.debugs(ctx) {
+		return reader
+	}
+
+	return debugReader{Reader: reader, ctx: ctx, logger: e.Logger}
+}
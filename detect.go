@@ -0,0 +1,53 @@
+package bread
+
+import (
+	"bufio"
+	"bytes"
+)
+
+// DelimiterSample is the number of leading bytes sampled by DetectDelimiter
+const DelimiterSample = 4 * KB
+
+// delimiterCandidates are the line endings considered by DetectDelimiter, before DelimiterCandidates
+var delimiterCandidates = [][]byte{{'\n'}, []byte("\r\n")}
+
+// detectDelimiter picks the delimiter of the stream out of its leading bytes, peeked through r so they are still
+// read by the batches. The delimiter found most often wins, the longer one when they are found as often, so the
+// CRLF line endings win over their line feeds. See Bread.DetectDelimiter
+func (e *eater) detectDelimiter(r *bufio.Reader) {
+	sample, _ := r.Peek(DelimiterSample)
+
+	// The sequences of several bytes are not supported by every setting, see Bread.DelimiterBytes
+	multiple := e.sliceable() && e.ShuffleBuffer == 0
+
+	var (
+		detected []byte
+		count    int
+	)
+
+	for _, candidate := range append(delimiterCandidates, e.DelimiterCandidates...) {
+		if len(candidate) == 0 || len(candidate) > 1 && !multiple {
+			continue
+		}
+
+		n := bytes.Count(sample, candidate)
+		if n > count || n == count && n > 0 && len(candidate) > len(detected) {
+			detected, count = candidate, n
+		}
+	}
+
+	// The sample holds a single record
+	if detected == nil {
+		detected = []byte{DefaultDelimiter}
+	}
+
+	e.Bread = e.delimitedBy(detected)
+	e.separator = e.Bread.separator()
+	e.detected = string(detected)
+}
+
+// delimitedBy returns the settings with the separator as the Delimiter, or as the DelimiterBytes when it is longer
+func (b Bread) delimitedBy(separator []byte) Bread {
+	b.Delimiter, b.DelimiterBytes = 0, separator
+	return b.delimiters()
+}
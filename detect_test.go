@@ -0,0 +1,126 @@
+package bread
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestBread_DetectDelimiter(t *testing.T) {
+	cases := [...]struct {
+		bread     Bread
+		data      string
+		delimiter string
+		expected  []string
+		err       error
+	}{
+		{
+			data:      "a\nb\nc\n",
+			delimiter: "\n",
+			expected:  []string{"a", "b", "c"},
+		},
+		{
+			data:      "a\r\nb\r\nc",
+			delimiter: "\r\n",
+			expected:  []string{"a", "b", "c"},
+		},
+		{
+			// A record ends with a carriage return
+			data:      "a\r\nb\r\nc\r\r\n",
+			delimiter: "\r\n",
+			expected:  []string{"a", "b", "c\r"},
+		},
+		{
+			data:      "a\r\nb\nc\n",
+			delimiter: "\n",
+			expected:  []string{"a\r", "b", "c"},
+		},
+		{
+			bread:     Bread{DelimiterCandidates: [][]byte{DelimiterNUL}},
+			data:      "a\nb\x00c\x00d\x00",
+			delimiter: "\x00",
+			expected:  []string{"a\nb", "c", "d"},
+		},
+		{
+			bread:     Bread{DelimiterCandidates: [][]byte{[]byte("|")}},
+			data:      "a|b|c|\n",
+			delimiter: "|",
+			expected:  []string{"a", "b", "c", "\n"},
+		},
+		{
+			data:      "no delimiter",
+			delimiter: "\n",
+			expected:  []string{"no delimiter"},
+		},
+		{
+			// The sequences of several bytes are not supported
+			bread:     Bread{Align: AlignBackward},
+			data:      "a\r\nb\r\n",
+			delimiter: "\n",
+			expected:  []string{"a\r", "b\r"},
+		},
+		{
+			// Only the sample counts
+			data:      "a\r\n" + strings.Repeat("b", DelimiterSample) + "\nc\nd\n",
+			delimiter: "\r\n",
+			expected:  []string{"a", strings.Repeat("b", DelimiterSample) + "\nc\nd\n"},
+		},
+		{
+			bread: Bread{RecordSize: 4},
+			data:  "abcd",
+			err:   ErrDetectDelimiter,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			stats, effective, starts := Stats{}, Config{}, 0
+
+			c.bread.BufferSize = 4
+			c.bread.Workers = 4
+			c.bread.DetectDelimiter = true
+			c.bread.OnStart = func(_ context.Context, config Config) {
+				effective = config
+				starts++
+			}
+			c.bread.OnComplete = func(_ context.Context, s Stats, _ error) {
+				stats = s
+			}
+
+			records, err := Collect(context.TODO(), c.bread, strings.NewReader(c.data), func(_ context.Context, record []byte) (string, error) {
+				return string(record), nil
+			})
+
+			if c.err != nil {
+				if !errors.Is(err, c.err) {
+					t.Fatalf("expected error %v, got %v", c.err, err)
+				}
+
+				t.Logf("Success! %v", err)
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if stats.Delimiter != c.delimiter {
+				t.Fatalf("expected delimiter %q, got %q", c.delimiter, stats.Delimiter)
+			}
+
+			// OnStart waits for the detection
+			if starts != 1 || effective.Delimiter != c.delimiter {
+				t.Fatalf("expected one call to OnStart with delimiter %q, got %d calls with %q", c.delimiter, starts, effective.Delimiter)
+			}
+
+			if !slices.Equal(records, c.expected) {
+				t.Fatalf("expected records %q, got %q", c.expected, records)
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package bread
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"fmt"
+	"hash"
+	"io"
+
+	// SHA-256 is the default DigestAlgo
+	_ "crypto/sha256"
+)
+
+// DigestMismatchError indicates that the digest of the input does not match ExpectedDigest.
+// It matches ErrDigestMismatch with errors.Is.
+type DigestMismatchError struct {
+	Algo     crypto.Hash
+	Expected []byte
+	Actual   []byte
+}
+
+func (e *DigestMismatchError) Error() string {
+	return fmt.Sprintf("%v: expected %s %x, got %x", ErrDigestMismatch, e.Algo, e.Expected, e.Actual)
+}
+
+func (e *DigestMismatchError) Unwrap() error {
+	return ErrDigestMismatch
+}
+
+// digester hashes the bytes read from the first pass over the reader, see ExpectedDigest and Digest
+type digester struct {
+	reader io.Reader
+	// hash is compared with ExpectedDigest, nil when it is not set
+	hash hash.Hash
+	// digest is the Digest of the caller, nil when it is not set
+	digest hash.Hash
+	e      *eater
+}
+
+func (d *digester) Read(p []byte) (int, error) {
+	n, err := d.reader.Read(p)
+
+	// Later epochs read the same bytes again
+	if d.e.epoch == 0 {
+		if d.hash != nil {
+			_, _ = d.hash.Write(p[:n])
+		}
+
+		if d.digest != nil {
+			_, _ = d.digest.Write(p[:n])
+		}
+	}
+
+	return n, err
+}
+
+// digestAlgo returns the effective DigestAlgo
+func (b Bread) digestAlgo() crypto.Hash {
+	if b.DigestAlgo == 0 {
+		return crypto.SHA256
+	}
+
+	return b.DigestAlgo
+}
+
+// digest wraps the reader to hash every byte consumed, or returns nil when neither ExpectedDigest nor Digest are set
+func (e *eater) digest(reader io.Reader) *digester {
+	if e.ExpectedDigest == nil && e.Digest == nil {
+		return nil
+	}
+
+	d := &digester{reader: reader, digest: e.Digest, e: e}
+	if e.ExpectedDigest != nil {
+		d.hash = e.digestAlgo().New()
+	}
+
+	return d
+}
+
+// verify compares the digest of the input with ExpectedDigest. The comparison is skipped when the input was
+// not read to the end, see Stats.DigestSkipped.
+func (e *eater) verify(ctx context.Context, d *digester) error {
+	if d.hash == nil {
+		return nil
+	}
+
+	if e.failed.Load() || e.stopped || ctx.Err() != nil {
+		e.digestSkipped = true
+		return nil
+	}
+
+	actual := d.hash.Sum(nil)
+	if bytes.Equal(actual, e.ExpectedDigest) {
+		return nil
+	}
+
+	return &DigestMismatchError{Algo: e.digestAlgo(), Expected: e.ExpectedDigest, Actual: actual}
+}
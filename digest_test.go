@@ -0,0 +1,151 @@
+package bread
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"math/rand"
+	"strconv"
+	"sync/atomic"
+	"testing"
+)
+
+func TestBread_ExpectedDigest(t *testing.T) {
+	data := bytes.Repeat([]byte("record\n"), 100)
+	sum := sha256.Sum256(data)
+
+	cancelled, cancel := context.WithCancel(context.TODO())
+	cancel()
+
+	cases := [...]struct {
+		ctx     context.Context
+		bread   Bread
+		skipped bool
+		err     error
+	}{
+		{
+			bread: Bread{BufferSize: 16, Workers: 4, ExpectedDigest: sum[:]},
+		},
+		{
+			// Every batch succeeds, but the input is not the expected one
+			bread: Bread{BufferSize: 16, Workers: 4, ExpectedDigest: make([]byte, sha256.Size)},
+			err:   ErrDigestMismatch,
+		},
+		{
+			bread: Bread{BufferSize: 16, Epochs: 3, ExpectedDigest: sum[:]},
+		},
+		{
+			bread:   Bread{BufferSize: 16, MaxRecords: 10, ExpectedDigest: make([]byte, sha256.Size)},
+			skipped: true,
+		},
+		{
+			ctx:     cancelled,
+			bread:   Bread{BufferSize: 16, ExpectedDigest: make([]byte, sha256.Size)},
+			skipped: true,
+			err:     context.Canceled,
+		},
+		{
+			bread: Bread{BufferSize: 16, ExpectedDigest: sum[:], DigestAlgo: crypto.MD4},
+			err:   ErrDigestUnavailable,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			if c.ctx == nil {
+				c.ctx = context.TODO()
+			}
+
+			var stats Stats
+
+			c.bread.OnComplete = func(_ context.Context, s Stats, _ error) {
+				stats = s
+			}
+
+			records := atomic.Uint64{}
+
+			err := c.bread.EatBatches(c.ctx, bytes.NewReader(data), func(_ context.Context, batch Batch) {
+				records.Add(uint64(batch.Records))
+			})
+			if !errors.Is(err, c.err) {
+				t.Fatalf("expected error %v, got %v", c.err, err)
+			}
+
+			if c.err == ErrDigestUnavailable {
+				t.Logf("Success! %v", err)
+				return
+			}
+
+			if stats.DigestSkipped != c.skipped {
+				t.Fatalf("expected DigestSkipped %v, got %v", c.skipped, stats.DigestSkipped)
+			}
+
+			// The mismatch is reported after the workers processed the whole input
+			if c.err != nil && records.Load() != stats.Records {
+				t.Fatalf("expected %d records processed, got %d", stats.Records, records.Load())
+			}
+
+			t.Logf("Success! %d records, %v", records.Load(), err)
+		})
+	}
+}
+
+func TestBread_Digest(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+
+	data := make([]byte, 0, 64*KB)
+	for len(data) < 64*KB {
+		data = append(data, bytes.Repeat([]byte{'a' + byte(random.Intn(26))}, random.Intn(200))...)
+		data = append(data, "\r\n"[random.Intn(2):]...)
+	}
+
+	cases := [...]struct {
+		bread Bread
+		data  []byte
+	}{
+		{bread: Bread{BufferSize: 16}, data: data},
+		{bread: Bread{BufferSize: 1024}, data: data},
+		{bread: Bread{BufferSize: 4 * KB, Workers: 4}, data: data},
+		// The last record is not terminated
+		{bread: Bread{BufferSize: 1024}, data: data[:len(data)-1]},
+		{bread: Bread{BufferSize: 1024, Align: AlignBackward}, data: data},
+		{bread: Bread{BufferSize: 1024, DelimiterBytes: []byte("\r\n")}, data: data},
+		{bread: Bread{BufferSize: 1024, RecordSize: 8, AllowTrailing: true}, data: data},
+		// The skipped lines are hashed too
+		{bread: Bread{BufferSize: 1024, SkipLines: 10}, data: data},
+		{bread: Bread{BufferSize: 1024, ExpectedDigest: sha256Sum(data)}, data: data},
+		{bread: Bread{BufferSize: 1024}},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			expected := sha256.New()
+			if _, err := io.Copy(expected, bytes.NewReader(c.data)); err != nil {
+				t.Fatal(err)
+			}
+
+			c.bread.Digest = sha256.New()
+			c.bread.WorkerFunc = func(context.Context, *[]byte) {}
+
+			err := c.bread.Eat(context.TODO(), bytes.NewReader(c.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(c.bread.Digest.Sum(nil), expected.Sum(nil)) {
+				t.Fatalf("expected digest %x, got %x", expected.Sum(nil), c.bread.Digest.Sum(nil))
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+// sha256Sum returns the SHA-256 digest of data
+func sha256Sum(data []byte) []byte {
+	sum := sha256.Sum256(data)
+	return sum[:]
+}
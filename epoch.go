@@ -0,0 +1,49 @@
+package bread
+
+import (
+	"context"
+	"io"
+)
+
+// readEpochs reads the reader Epochs times, seeking back to its initial position after each pass
+func (e *eater) readEpochs(ctx context.Context, reader io.Reader, seeker io.Seeker) error {
+	start, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return &ReadError{Err: err}
+	}
+
+	for ; ; e.epoch++ {
+		if e.epoch > 0 {
+			if _, err = seeker.Seek(start, io.SeekStart); err != nil {
+				return &ReadError{Err: err}
+			}
+
+			e.offset = 0
+		}
+
+		err = e.read(ctx, reader)
+
+		e.endEpoch(ctx)
+
+		if err != nil || e.failed.Load() || e.stopped || ctx.Err() != nil || e.epoch+1 == e.Epochs {
+			return err
+		}
+	}
+}
+
+// endEpoch reports the counts of the current epoch to the observer
+func (e *eater) endEpoch(ctx context.Context) {
+	total := EpochStats{
+		BytesRead: ByteSize(e.counters.bytesRead.Load()),
+		Batches:   e.counters.batches.Load(),
+		Records:   e.counters.records.Load(),
+	}
+
+	e.observer.OnEpoch(ctx, e.epoch, EpochStats{
+		BytesRead: total.BytesRead - e.epochBase.BytesRead,
+		Batches:   total.Batches - e.epochBase.Batches,
+		Records:   total.Records - e.epochBase.Records,
+	})
+
+	e.epochBase = total
+}
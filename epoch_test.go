@@ -0,0 +1,132 @@
+package bread
+
+import (
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestBread_Epochs(t *testing.T) {
+	data := "1\n22\n333\n4444\n55555\n"
+
+	cases := [...]struct {
+		bread  Bread
+		reader func() io.Reader
+		// cancel cancels the context when a batch of this epoch is processed
+		cancel uint32
+		// expected is the number of records per epoch, unless the context is canceled
+		expected []uint64
+		err      error
+	}{
+		{
+			bread:    Bread{Epochs: 3},
+			expected: []uint64{5, 5, 5},
+		},
+		{
+			// The reader is rewound to its initial position
+			bread: Bread{Epochs: 2},
+			reader: func() io.Reader {
+				r := strings.NewReader(data)
+				_, _ = r.Seek(5, io.SeekStart)
+
+				return r
+			},
+			expected: []uint64{3, 3},
+		},
+		{
+			// MaxRecords limits all the epochs together
+			bread:    Bread{Epochs: 3, MaxRecords: 7},
+			expected: []uint64{5, 2},
+		},
+		{
+			bread:  Bread{Epochs: 3, Workers: 1},
+			cancel: 1,
+			err:    context.Canceled,
+		},
+		{
+			bread:  Bread{Epochs: 2},
+			reader: func() io.Reader { return readFunc(strings.NewReader(data).Read) },
+			err:    ErrNotSeekable,
+		},
+		{
+			// A single epoch does not need to seek
+			bread:    Bread{Epochs: 1},
+			reader:   func() io.Reader { return readFunc(strings.NewReader(data).Read) },
+			expected: []uint64{5},
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.TODO())
+			defer cancel()
+
+			var reader io.Reader = strings.NewReader(data)
+			if c.reader != nil {
+				reader = c.reader()
+			}
+
+			var (
+				mu      sync.Mutex
+				records []uint64
+				epochs  []uint64
+			)
+
+			c.bread.BufferSize = 1
+			c.bread.OnEpoch = func(_ context.Context, epoch uint32, stats EpochStats) {
+				if int(epoch) != len(epochs) {
+					t.Errorf("expected epoch %d, got %d", len(epochs), epoch)
+				}
+
+				epochs = append(epochs, stats.Records)
+			}
+
+			err := c.bread.EatBatches(ctx, reader, func(_ context.Context, batch Batch) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				for len(records) <= int(batch.Epoch) {
+					records = append(records, 0)
+				}
+
+				records[batch.Epoch] += uint64(batch.Records)
+
+				if c.cancel > 0 && batch.Epoch == c.cancel {
+					cancel()
+				}
+			})
+			if !errors.Is(err, c.err) {
+				t.Fatalf("expected error %v, got %v", c.err, err)
+			}
+
+			if c.err != nil && c.cancel == 0 {
+				t.Log("Success!")
+				return
+			}
+
+			if c.cancel > 0 {
+				if len(records) != int(c.cancel)+1 || records[c.cancel] >= records[0] {
+					t.Fatalf("expected epoch %d to be interrupted, got records %v", c.cancel, records)
+				}
+
+				t.Log("Success!")
+				return
+			}
+
+			if c.bread.Epochs > 1 && !slices.Equal(epochs, c.expected) {
+				t.Fatalf("expected epochs %v, got %v", c.expected, epochs)
+			}
+
+			if !slices.Equal(records, c.expected) {
+				t.Fatalf("expected records %v, got %v", c.expected, records)
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
@@ -0,0 +1,424 @@
+package bread
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Positioned is implemented by the errors that know where in the stream they happened
+//
+// Use errors.As to find the position of an error, even if it is wrapped:
+//
+//	var p bread.Positioned
+//	if errors.As(err, &p) {
+//		log.Println("failed at offset", p.Offset())
+//	}
+type Positioned interface {
+	Offset() int64
+}
+
+// OffsetOf returns the stream offset of the first Positioned error found in the tree of err
+func OffsetOf(err error) (int64, bool) {
+	var p Positioned
+
+	if !errors.As(err, &p) {
+		return 0, false
+	}
+
+	return p.Offset(), true
+}
+
+// ReadError indicates that reading from the io.Reader failed
+type ReadError struct {
+	// Position is the stream offset where the read failed
+	Position int64
+	Err      error
+}
+
+func (e *ReadError) Error() string {
+	return fmt.Sprintf("read at offset %d: %v", e.Position, e.Err)
+}
+
+func (e *ReadError) Unwrap() error {
+	return e.Err
+}
+
+// Offset implements Positioned
+func (e *ReadError) Offset() int64 {
+	return e.Position
+}
+
+// WorkerError indicates which batch the worker failed to process
+type WorkerError struct {
+	// Meta describes the failed batch
+	Meta BatchMeta
+	// Preview holds the leading bytes of the batch, see Bread.ErrorContextBytes
+	Preview string
+	Err     error
+}
+
+func (e *WorkerError) Error() string {
+	if e.Preview != "" {
+		return fmt.Sprintf("batch %d at offset %d near \"%s\": %v", e.Meta.Index, e.Meta.Offset, e.Preview, e.Err)
+	}
+
+	return fmt.Sprintf("batch %d at offset %d: %v", e.Meta.Index, e.Meta.Offset, e.Err)
+}
+
+func (e *WorkerError) Unwrap() error {
+	return e.Err
+}
+
+// Offset implements Positioned
+func (e *WorkerError) Offset() int64 {
+	return e.Meta.Offset
+}
+
+// DeadlineError indicates that a worker did not finish processing a batch before its deadline
+type DeadlineError struct {
+	// Meta describes the batch that was being processed
+	Meta BatchMeta
+	// Deadline is the time the worker was given to process the batch
+	Deadline time.Duration
+	// Elapsed is the time the worker actually took
+	Elapsed time.Duration
+	// Abandoned reports that the worker was still running when the batch failed, see Bread.FailOnBatchTimeout
+	Abandoned bool
+}
+
+func (e *DeadlineError) Error() string {
+	if e.Abandoned {
+		return fmt.Sprintf(
+			"batch %d (%d bytes at offset %d) exceeded its deadline of %s, abandoned after %s",
+			e.Meta.Index,
+			e.Meta.Size,
+			e.Meta.Offset,
+			e.Deadline,
+			e.Elapsed,
+		)
+	}
+
+	return fmt.Sprintf(
+		"batch %d (%d bytes at offset %d) exceeded its deadline of %s after %s",
+		e.Meta.Index,
+		e.Meta.Size,
+		e.Meta.Offset,
+		e.Deadline,
+		e.Elapsed,
+	)
+}
+
+func (e *DeadlineError) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
+// Offset implements Positioned
+func (e *DeadlineError) Offset() int64 {
+	return e.Meta.Offset
+}
+
+// FileError indicates which file failed while eating multiple files
+type FileError struct {
+	// Path of the file inside the file system
+	Path string
+	Err  error
+}
+
+func (e *FileError) Error() string {
+	return fmt.Sprintf("file %s: %v", e.Path, e.Err)
+}
+
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+// PartError indicates which part failed while eating a multipart body, see EatMultipart
+type PartError struct {
+	// Index is the position of the part in the body
+	Index int
+	// Name is the form name of the part, if any
+	Name string
+	Err  error
+}
+
+func (e *PartError) Error() string {
+	if e.Name != "" {
+		return fmt.Sprintf("part %d (%s): %v", e.Index, e.Name, e.Err)
+	}
+
+	return fmt.Sprintf("part %d: %v", e.Index, e.Err)
+}
+
+func (e *PartError) Unwrap() error {
+	return e.Err
+}
+
+// ReaderError indicates which reader failed while eating multiple readers, see EatAll
+type ReaderError struct {
+	// Index is the position of the reader in the readers given to EatAll
+	Index int
+	Err   error
+}
+
+func (e *ReaderError) Error() string {
+	return fmt.Sprintf("reader %d: %v", e.Index, e.Err)
+}
+
+func (e *ReaderError) Unwrap() error {
+	return e.Err
+}
+
+// OutputError indicates which output failed while splitting a stream, see Split
+type OutputError struct {
+	// Index is the position of the output in the outputs given to Split
+	Index int
+	Err   error
+}
+
+func (e *OutputError) Error() string {
+	return fmt.Sprintf("output %d: %v", e.Index, e.Err)
+}
+
+func (e *OutputError) Unwrap() error {
+	return e.Err
+}
+
+// StageError indicates which stage failed while running a pipeline, see Pipe
+type StageError struct {
+	// Index is the position of the stage in the stages given to Pipe
+	Index int
+	Err   error
+}
+
+func (e *StageError) Error() string {
+	return fmt.Sprintf("stage %d: %v", e.Index, e.Err)
+}
+
+func (e *StageError) Unwrap() error {
+	return e.Err
+}
+
+// RecordError indicates which record of the parent stream failed while eating its sub-fields, see EatNested.
+// The offsets of the errors it wraps are relative to the record. EatTyped reports the records that failed to
+// decode or to be processed with it too.
+type RecordError struct {
+	Record Record
+	Err    error
+}
+
+func (e *RecordError) Error() string {
+	return fmt.Sprintf("record %d at offset %d: %v", e.Record.Number, e.Record.Offset, e.Err)
+}
+
+func (e *RecordError) Unwrap() error {
+	return e.Err
+}
+
+// Offset implements Positioned
+func (e *RecordError) Offset() int64 {
+	return e.Record.Offset
+}
+
+// JSONError indicates that a line of EatJSON is not valid JSON, it is wrapped in a *RecordError whose record number
+// is the line number
+type JSONError struct {
+	// Preview holds the leading bytes of the line, see Bread.ErrorContextBytes
+	Preview string
+	Err     error
+}
+
+func (e *JSONError) Error() string {
+	if e.Preview == "" {
+		return fmt.Sprintf("invalid JSON line: %v", e.Err)
+	}
+
+	return fmt.Sprintf("invalid JSON line near \"%s\": %v", e.Preview, e.Err)
+}
+
+func (e *JSONError) Unwrap() error {
+	return e.Err
+}
+
+// ErrRecordTooLarge indicates that a record does not fit in the allowed size, see RecordTooLargeError
+var ErrRecordTooLarge = errors.New("record too large")
+
+// RecordTooLargeError indicates that a record starting at Position is larger than Limit.
+// It matches ErrRecordTooLarge with errors.Is.
+type RecordTooLargeError struct {
+	// Position is the stream offset where the record starts
+	Position int64
+	// Limit is the maximum size allowed for the record
+	Limit int
+	// Preview holds the leading bytes of the record, see Bread.ErrorContextBytes
+	Preview string
+}
+
+func (e *RecordTooLargeError) Error() string {
+	msg := fmt.Sprintf("%v: the record at offset %d exceeds %d bytes", ErrRecordTooLarge, e.Position, e.Limit)
+
+	if e.Preview != "" {
+		msg += fmt.Sprintf(" near \"%s\"", e.Preview)
+	}
+
+	return msg
+}
+
+func (e *RecordTooLargeError) Unwrap() error {
+	return ErrRecordTooLarge
+}
+
+// Offset implements Positioned
+func (e *RecordTooLargeError) Offset() int64 {
+	return e.Position
+}
+
+// ErrTrailingRecord indicates that a stream of fixed-size records ends in the middle of a record,
+// see TrailingRecordError
+var ErrTrailingRecord = errors.New("trailing partial record")
+
+// TrailingRecordError indicates that the stream ends with a partial record of Size bytes starting at Position,
+// see Bread.RecordSize. It matches ErrTrailingRecord with errors.Is.
+type TrailingRecordError struct {
+	// Position is the stream offset where the partial record starts
+	Position int64
+	// Size is the number of bytes of the partial record
+	Size int
+	// RecordSize is the expected size of the record
+	RecordSize int
+}
+
+func (e *TrailingRecordError) Error() string {
+	return fmt.Sprintf("%v: %d of %d bytes at offset %d", ErrTrailingRecord, e.Size, e.RecordSize, e.Position)
+}
+
+func (e *TrailingRecordError) Unwrap() error {
+	return ErrTrailingRecord
+}
+
+// Offset implements Positioned
+func (e *TrailingRecordError) Offset() int64 {
+	return e.Position
+}
+
+// ScratchTooSmallError indicates that the bytes carried to the next batch starting at Position do not fit
+// in the Scratch. It matches ErrScratchTooSmall with errors.Is.
+type ScratchTooSmallError struct {
+	// Position is the stream offset of the carried bytes
+	Position int64
+	// Size is the number of carried bytes
+	Size int
+	// Capacity is the capacity of the Scratch
+	Capacity int
+}
+
+func (e *ScratchTooSmallError) Error() string {
+	return fmt.Sprintf("%v: %d bytes at offset %d exceed its capacity of %d", ErrScratchTooSmall, e.Size, e.Position, e.Capacity)
+}
+
+func (e *ScratchTooSmallError) Unwrap() error {
+	return ErrScratchTooSmall
+}
+
+// Offset implements Positioned
+func (e *ScratchTooSmallError) Offset() int64 {
+	return e.Position
+}
+
+// ErrSkipped indicates that corrupt segments of the stream were skipped, see SkipError
+var ErrSkipped = errors.New("corrupt segments skipped")
+
+// SkipError summarizes the corrupt segments skipped by Bread.ContinueOnError, Eat returns it once the reading
+// otherwise succeeded. It matches ErrSkipped and the error of the first segment with errors.Is.
+type SkipError struct {
+	// Segments is the number of segments skipped
+	Segments uint64
+	// Bytes is the number of bytes skipped
+	Bytes int64
+	// Err is the error of the first segment
+	Err error
+}
+
+func (e *SkipError) Error() string {
+	return fmt.Sprintf("%v: %d segments of %d bytes, the first one: %v", ErrSkipped, e.Segments, e.Bytes, e.Err)
+}
+
+func (e *SkipError) Unwrap() []error {
+	return []error{ErrSkipped, e.Err}
+}
+
+// FramingError indicates that the stream does not follow the selected Framing
+type FramingError struct {
+	// Position is the stream offset of the invalid frame
+	Position int64
+	// Line is the line number of the invalid frame starting at 1, only set by the line oriented framings
+	Line int
+	// Reason describes the violation
+	Reason string
+	// Err is the underlying error, if any
+	Err error
+	// Preview holds the leading bytes of the invalid frame, see Bread.ErrorContextBytes
+	Preview string
+}
+
+func (e *FramingError) Error() string {
+	msg := fmt.Sprintf("invalid frame at offset %d", e.Position)
+
+	if e.Line > 0 {
+		msg += fmt.Sprintf(" (line %d)", e.Line)
+	}
+
+	if e.Preview != "" {
+		msg += fmt.Sprintf(" near \"%s\"", e.Preview)
+	}
+
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %s: %v", msg, e.Reason, e.Err)
+	}
+
+	return fmt.Sprintf("%s: %s", msg, e.Reason)
+}
+
+func (e *FramingError) Unwrap() error {
+	return e.Err
+}
+
+// Offset implements Positioned
+func (e *FramingError) Offset() int64 {
+	return e.Position
+}
+
+// preview copies the leading bytes of data for an error, see Bread.ErrorContextBytes.
+// The non-printable bytes are hex-escaped and the cut is marked with an ellipsis.
+func (b Bread) preview(data []byte) string {
+	n := b.ErrorContextBytes
+	if n == 0 {
+		n = DefaultErrorContextBytes
+	}
+
+	if n < 0 || len(data) == 0 {
+		return ""
+	}
+
+	n = min(n, MaxErrorContextBytes, len(data))
+
+	builder := strings.Builder{}
+	builder.Grow(n)
+
+	for _, c := range data[:n] {
+		if c >= ' ' && c <= '~' && c != '\\' && c != '"' {
+			builder.WriteByte(c)
+			continue
+		}
+
+		fmt.Fprintf(&builder, "\\x%02x", c)
+	}
+
+	if n < len(data) {
+		builder.WriteString("...")
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,170 @@
+package bread
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"testing"
+)
+
+func TestOffsetOf(t *testing.T) {
+	cases := [...]struct {
+		err      error
+		offset   int64
+		expected bool
+	}{
+		{
+			err:      &ReadError{Position: 10, Err: io.ErrUnexpectedEOF},
+			offset:   10,
+			expected: true,
+		},
+		{
+			err:      &DeadlineError{Meta: BatchMeta{Offset: 20}},
+			offset:   20,
+			expected: true,
+		},
+		{
+			err:      fmt.Errorf("wrapped: %w", &ReadError{Position: 30}),
+			offset:   30,
+			expected: true,
+		},
+		{
+			err:      &FileError{Path: "file.txt", Err: &DeadlineError{Meta: BatchMeta{Offset: 40}}},
+			offset:   40,
+			expected: true,
+		},
+		{
+			err:      errors.Join(errors.New("first"), &ReadError{Position: 50}),
+			offset:   50,
+			expected: true,
+		},
+		{
+			err: &FileError{Path: "file.txt", Err: ErrMissingBufferSize},
+		},
+		{
+			err: nil,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			offset, ok := OffsetOf(c.err)
+			if ok != c.expected {
+				t.Fatalf("expected %v, got %v", c.expected, ok)
+			}
+
+			if offset != c.offset {
+				t.Fatalf("expected offset %d, got %d", c.offset, offset)
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+func TestBread_Eat_ReadError(t *testing.T) {
+	cause := errors.New("broken reader")
+
+	bread := Bread{
+		BufferSize: 4,
+		WorkerFunc: func(context.Context, *[]byte) {},
+	}
+
+	reader := io.MultiReader(bytes.NewReader([]byte("aaaa\nbbbb\n")), errReader{err: cause})
+
+	err := bread.Eat(context.TODO(), reader)
+	if !errors.Is(err, cause) {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	offset, ok := OffsetOf(err)
+	if !ok || offset != 10 {
+		t.Fatalf("expected the error at offset 10, got %d", offset)
+	}
+
+	t.Log("Success!")
+}
+
+// errReader always fails with err
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
+
+func TestBread_ErrorContextBytes(t *testing.T) {
+	cause := errors.New("bad record")
+	failing := sinkFunc(func(context.Context, BatchMeta, []byte) error { return cause })
+
+	cases := [...]struct {
+		bread    Bread
+		data     string
+		expected string
+		target   any
+	}{
+		{
+			bread:    Bread{BufferSize: 8, Sink: failing},
+			data:     "\x00\xff\"\\\ttab\n",
+			expected: `\x00\xff\x22\x5c\x09tab\x0a`,
+			target:   new(*WorkerError),
+		},
+		{
+			bread:    Bread{BufferSize: 8, ErrorContextBytes: 4, Sink: failing},
+			data:     "abcdefgh\n",
+			expected: "abcd...",
+			target:   new(*WorkerError),
+		},
+		{
+			bread:  Bread{BufferSize: 8, ErrorContextBytes: -1, Sink: failing},
+			data:   "secret\n",
+			target: new(*WorkerError),
+		},
+		{
+			bread:    Bread{BufferSize: 8, Align: AlignBackward, ErrorContextBytes: MaxErrorContextBytes + 1},
+			data:     "this record is too large\n",
+			expected: "this rec",
+			target:   new(*RecordTooLargeError),
+		},
+		{
+			bread:    Bread{BufferSize: 8, Framing: FramingSyslog},
+			data:     "12 <1>short",
+			expected: "<1>short",
+			target:   new(*FramingError),
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			if c.bread.Sink == nil {
+				c.bread.WorkerFunc = func(context.Context, *[]byte) {}
+			}
+
+			err := c.bread.Eat(context.TODO(), bytes.NewReader([]byte(c.data)))
+			if !errors.As(err, c.target) {
+				t.Fatalf("expected %T, got %v", c.target, err)
+			}
+
+			var preview string
+
+			switch target := c.target.(type) {
+			case **WorkerError:
+				preview = (*target).Preview
+			case **RecordTooLargeError:
+				preview = (*target).Preview
+			case **FramingError:
+				preview = (*target).Preview
+			}
+
+			if preview != c.expected {
+				t.Fatalf("expected preview %q, got %q", c.expected, preview)
+			}
+
+			t.Logf("Success! %v", err)
+		})
+	}
+}
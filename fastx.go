@@ -0,0 +1,139 @@
+package bread
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// fastaFramer splits the stream on the header lines of a FASTA file
+type fastaFramer struct {
+	// line is the number of lines consumed so far
+	line int
+}
+
+func (f *fastaFramer) next(r *bufio.Reader, dst *[]byte, offset int64) (before, after int, err error) {
+	before, err = skipBlankLines(r, dst, '>', &f.line)
+	if err != nil {
+		if err == errMissingHeader {
+			err = &FramingError{Position: offset + int64(before), Line: f.line, Reason: "missing FASTA header"}
+		}
+
+		return
+	}
+
+	for {
+		n, err := complete(r, dst, '\n')
+		if n > 0 {
+			f.line++
+		}
+
+		if err == io.EOF {
+			return before, 0, nil
+		}
+
+		if err != nil {
+			return before, 0, err
+		}
+
+		if head, _ := r.Peek(1); len(head) == 0 || head[0] == '>' {
+			return before, 0, nil
+		}
+	}
+}
+
+// fastqFramer splits the stream in the four line records of a FASTQ file
+type fastqFramer struct {
+	// line is the number of lines consumed so far
+	line int
+}
+
+func (f *fastqFramer) next(r *bufio.Reader, dst *[]byte, offset int64) (before, after int, err error) {
+	before, err = skipBlankLines(r, dst, '@', &f.line)
+	if err != nil {
+		if err == errMissingHeader {
+			err = &FramingError{Position: offset + int64(before), Line: f.line, Reason: "missing FASTQ header"}
+		}
+
+		return
+	}
+
+	first := f.line + 1
+	invalid := func(line int, reason string, err error) *FramingError {
+		return &FramingError{Position: offset + int64(before), Line: line, Reason: reason, Err: err}
+	}
+
+	// Offsets where each line of the record ends
+	var ends [4]int
+
+	for i := range ends {
+		n, err := complete(r, dst, '\n')
+		if n > 0 {
+			f.line++
+		}
+
+		ends[i] = len(*dst)
+
+		if err == io.EOF && (n == 0 || i < len(ends)-1) {
+			return before, 0, invalid(first, fmt.Sprintf("truncated FASTQ record with %d lines", f.line-first+1), io.ErrUnexpectedEOF)
+		}
+
+		if err != nil && err != io.EOF {
+			return before, 0, err
+		}
+	}
+
+	line := func(i int) []byte {
+		start := 0
+		if i > 0 {
+			start = ends[i-1]
+		}
+
+		return bytes.TrimRight((*dst)[start:ends[i]], "\r\n")
+	}
+
+	if !bytes.HasPrefix(line(2), []byte("+")) {
+		return before, 0, invalid(first+2, "missing FASTQ separator line", nil)
+	}
+
+	if sequence, quality := len(line(1)), len(line(3)); sequence != quality {
+		return before, 0, invalid(first+3, fmt.Sprintf("quality length %d does not match sequence length %d", quality, sequence), nil)
+	}
+
+	return before, 0, nil
+}
+
+// errMissingHeader is returned by skipBlankLines when a line that is not blank does not start with the header prefix
+var errMissingHeader = errors.New("missing header")
+
+// skipBlankLines skips the blank lines until the next line starting with prefix, line is incremented for each
+// consumed line. It returns the number of bytes skipped.
+func skipBlankLines(r *bufio.Reader, dst *[]byte, prefix byte, line *int) (skipped int, err error) {
+	for {
+		head, err := r.Peek(1)
+		if err != nil {
+			return skipped, err
+		}
+
+		if head[0] == prefix {
+			return skipped, nil
+		}
+
+		n, err := complete(r, dst, '\n')
+		*line++
+
+		if len(bytes.TrimSpace(*dst)) > 0 {
+			*dst = (*dst)[:0]
+			return skipped, errMissingHeader
+		}
+
+		skipped += n
+		*dst = (*dst)[:0]
+
+		if err != nil {
+			return skipped, err
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package bread
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+const (
+	fastaRecord1 = ">sp|P69905|HBA_HUMAN Hemoglobin subunit alpha OS=Homo sapiens\n" +
+		"MVLSPADKTNVKAAWGKVGAHAGEYGAEALERMFLSFPTTKTYFPHFDLSHGSAQVKGHGKKVADAL\n" +
+		"TNAVAHVDDMPNALSALSDLHAHKLRVDPVNFKLLSHCLLVTLAAHLPAEFTPAVHASLDKFLASV\n" +
+		"STVLTSKYR\n"
+	fastaRecord2 = ">sp|P68871|HBB_HUMAN Hemoglobin subunit beta OS=Homo sapiens\n" +
+		"MVHLTPEEKSAVTALWGKVNVDEVGGEALGRLLVVYPWTQRFFESFGDLSTPDAVMGNPKVKAHGKKVLGAFSDGLAHLDNLKGTFATLSELHCDKLHVDPENFRLLGNVLVCVLAHHFGKEFTPPVQAAYQKVVAGVANALAHKYH"
+
+	fastqRecord1 = "@SRR001666.1 071112_SLXA-EAS1_s_7:5:1:817:345 length=36\n" +
+		"GGGTGATGGCCGCTGCCGATGGCGTCAAATCCCACC\n" +
+		"+SRR001666.1 071112_SLXA-EAS1_s_7:5:1:817:345 length=36\n" +
+		"IIIIIIIIIIIIIIIIIIIIIIIIIIIIII9IG9IC\n"
+	// The quality line starts with "@"
+	fastqRecord2 = "@SRR001666.2 071112_SLXA-EAS1_s_7:5:1:801:338 length=36\n" +
+		"GTTCAGGGATACGACGTTTGTATTTTAAGAATCTGA\n" +
+		"+\n" +
+		"@IIIIIIIIIIIIIIIIIIIIIIIIIIIIIII6IBI\n"
+)
+
+func TestBread_FramingFASTX(t *testing.T) {
+	cases := [...]struct {
+		framing  Framing
+		data     string
+		reader   func(io.Reader) io.Reader
+		expected []string
+		// line of the expected *FramingError, 0 means no error
+		line int
+	}{
+		{
+			framing:  FramingFASTA,
+			data:     fastaRecord1 + fastaRecord2,
+			expected: []string{fastaRecord1, fastaRecord2},
+		},
+		{
+			// Records spanning internal reads, the leading blank lines are skipped
+			framing:  FramingFASTA,
+			data:     "\n" + fastaRecord1 + "\n\n" + fastaRecord1 + fastaRecord2,
+			reader:   iotest.OneByteReader,
+			expected: []string{fastaRecord1 + "\n\n", fastaRecord1, fastaRecord2},
+		},
+		{
+			framing: FramingFASTA,
+			data:    "\nMVLSPADKTNVKAAWGKVGAHAGEYGAEALERMFLSFPTTKTYFPHFDLSHGSAQVKGHGKKVADAL\n",
+			line:    2,
+		},
+		{
+			framing:  FramingFASTQ,
+			data:     fastqRecord1 + fastqRecord2 + fastqRecord1,
+			expected: []string{fastqRecord1, fastqRecord2, fastqRecord1},
+		},
+		{
+			framing:  FramingFASTQ,
+			data:     fastqRecord2 + strings.TrimSuffix(fastqRecord1, "\n"),
+			reader:   iotest.OneByteReader,
+			expected: []string{fastqRecord2, strings.TrimSuffix(fastqRecord1, "\n")},
+		},
+		{
+			// Wrong line count
+			framing:  FramingFASTQ,
+			data:     fastqRecord1 + strings.Join(strings.SplitAfter(fastqRecord2, "\n")[:3], ""),
+			expected: []string{fastqRecord1},
+			line:     5,
+		},
+		{
+			// Missing header
+			framing:  FramingFASTQ,
+			data:     fastqRecord1 + fastqRecord1[1:],
+			expected: []string{fastqRecord1},
+			line:     5,
+		},
+		{
+			// Missing separator
+			framing: FramingFASTQ,
+			data:    strings.Replace(fastqRecord2, "\n+\n", "\n\n", 1),
+			line:    3,
+		},
+		{
+			// Quality length mismatch
+			framing: FramingFASTQ,
+			data:    strings.Replace(fastqRecord1, "9IC\n", "9I\n", 1),
+			line:    4,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			bread := Bread{
+				Framing:    c.framing,
+				BufferSize: 16,
+				Workers:    4,
+			}
+
+			var reader io.Reader = strings.NewReader(c.data)
+			if c.reader != nil {
+				reader = c.reader(reader)
+			}
+
+			batches, err := collect(t, bread, reader)
+
+			if c.line == 0 && err != nil {
+				t.Fatal(err)
+			}
+
+			if c.line > 0 {
+				var framingErr *FramingError
+				if !errors.As(err, &framingErr) {
+					t.Fatalf("expected a *FramingError, got %v", err)
+				}
+
+				if framingErr.Line != c.line {
+					t.Fatalf("expected error at line %d, got %d", c.line, framingErr.Line)
+				}
+			}
+
+			if len(batches) != len(c.expected) {
+				t.Fatalf("expected %d records, got %d", len(c.expected), len(batches))
+			}
+
+			for j, batch := range batches {
+				if string(batch.Data) != c.expected[j] {
+					t.Fatalf("expected record %q, got %q", c.expected[j], batch.Data)
+				}
+
+				if c.data[batch.Offset:batch.Offset+int64(batch.Size)] != c.expected[j] {
+					t.Fatalf("the offset %d does not point to the record", batch.Offset)
+				}
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package bread
+
+import (
+	"context"
+	"io"
+)
+
+// Feeder is an io.Writer that eats the bytes written to it, for the sources that push their data like
+// io.Copy, see NewFeeder. The batches are identical to the ones of Eat over the same bytes.
+type Feeder struct {
+	writer *io.PipeWriter
+	done   chan struct{}
+	err    error
+}
+
+// NewFeeder starts eating the bytes written to the returned Feeder with the Eat settings, on a new goroutine.
+// Close must be called once the whole stream was written, it dispatches the last batch and waits for the workers.
+//
+// Each batch is dispatched once BufferSize bytes were written, no matter the size of the writes.
+//
+// A Write fails once the Eat call ended, because a batch failed or the context was done.
+func (b Bread) NewFeeder(ctx context.Context) *Feeder {
+	reader, writer := io.Pipe()
+
+	f := &Feeder{
+		writer: writer,
+		done:   make(chan struct{}),
+	}
+
+	go func() {
+		defer close(f.done)
+
+		f.err = b.Eat(ctx, fullReader{reader})
+
+		// The pending and later writes fail
+		if f.err != nil {
+			reader.CloseWithError(f.err)
+			return
+		}
+
+		reader.CloseWithError(io.ErrClosedPipe)
+	}()
+
+	return f
+}
+
+// Write implements io.Writer, it blocks while the workers are busy like the reading of Eat
+func (f *Feeder) Write(p []byte) (int, error) {
+	return f.writer.Write(p)
+}
+
+// Close implements io.Closer, it ends the stream and returns the error of the Eat call
+func (f *Feeder) Close() error {
+	f.writer.Close()
+
+	<-f.done
+
+	return f.err
+}
+
+// fullReader fills each read unless the stream ends, so the batches do not depend on the size of the writes
+type fullReader struct {
+	io.Reader
+}
+
+func (r fullReader) Read(p []byte) (int, error) {
+	n, err := io.ReadFull(r.Reader, p)
+
+	// The io.EOF is returned by the next call
+	if err == io.ErrUnexpectedEOF {
+		err = nil
+	}
+
+	return n, err
+}
@@ -0,0 +1,153 @@
+package bread
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestBread_NewFeeder(t *testing.T) {
+	errBad := errors.New("bad record")
+
+	cases := [...]struct {
+		bread Bread
+		data  string
+		// chunk is the size of each write, zero writes everything at once
+		chunk int
+		err   error
+	}{
+		{
+			bread: Bread{Workers: 4, BufferSize: 8},
+			data:  strings.Repeat("record\n", 100),
+		},
+		// Writes ending in the middle of a record
+		{
+			bread: Bread{Workers: 4, BufferSize: 8},
+			data:  strings.Repeat("record\n", 100) + "last",
+			chunk: 5,
+		},
+		{
+			bread: Bread{Workers: 2, BufferSize: 4, Delimiter: ','},
+			data:  "a,bb,ccc,dddd,eeeee,ffffff",
+			chunk: 1,
+		},
+		{
+			bread: Bread{Workers: 2, BufferSize: 1},
+			data:  "ok\nbad\n" + strings.Repeat("ok\n", 10_000),
+			chunk: 3,
+			err:   errBad,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			mu := sync.Mutex{}
+			batches := make([]string, 0)
+
+			c.bread.WorkerErrFunc = func(_ context.Context, buffer *[]byte) error {
+				mu.Lock()
+				defer mu.Unlock()
+
+				batches = append(batches, string(*buffer))
+
+				if string(*buffer) == "bad\n" {
+					return errBad
+				}
+
+				return nil
+			}
+
+			// The batches of Eat over the same bytes
+			if err := c.bread.Eat(context.TODO(), strings.NewReader(c.data)); !errors.Is(err, c.err) {
+				t.Fatalf("expected error %v, got %v", c.err, err)
+			}
+
+			expected := batches
+			batches = make([]string, 0)
+
+			feeder := c.bread.NewFeeder(context.TODO())
+
+			var err error
+
+			for data := []byte(c.data); len(data) > 0 && err == nil; {
+				n := len(data)
+				if c.chunk > 0 {
+					n = min(n, c.chunk)
+				}
+
+				_, err = feeder.Write(data[:n])
+				data = data[n:]
+			}
+
+			if closeErr := feeder.Close(); !errors.Is(closeErr, c.err) {
+				t.Fatalf("expected error %v, got %v", c.err, closeErr)
+			}
+
+			// The writes fail once a batch failed
+			if c.err != nil {
+				if !errors.Is(err, c.err) {
+					t.Fatalf("expected the writes to fail with %v, got %v", c.err, err)
+				}
+
+				t.Logf("Success! %v", err)
+				return
+			}
+
+			slices.Sort(batches)
+			slices.Sort(expected)
+
+			if !slices.Equal(batches, expected) {
+				t.Fatalf("expected the batches %q, got %q", expected, batches)
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+func TestFeeder_Copy(t *testing.T) {
+	data := memoryData(MB)
+
+	got := make([]byte, 0, len(data))
+	mu := sync.Mutex{}
+
+	bread := Bread{
+		Workers:    4,
+		BufferSize: KB,
+		Ordered:    true,
+		WorkerFunc: func(context.Context, *[]byte) {},
+		OnDone: func(_ context.Context, batch Batch) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			got = append(got, batch.Data...)
+		},
+	}
+
+	feeder := bread.NewFeeder(context.TODO())
+
+	if _, err := io.Copy(feeder, bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := feeder.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Writes after Close fail
+	if _, err := feeder.Write([]byte("late\n")); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	if !bytes.Equal(got, data) {
+		t.Fatal("the batches do not reproduce the input")
+	}
+
+	t.Log("Success!")
+}
@@ -0,0 +1,126 @@
+package bread
+
+import (
+	"errors"
+	"io"
+	"time"
+)
+
+// errIdle is returned by flushReader once the batch being read holds whole records and no more bytes arrived
+// within FlushInterval, see Bread.FlushInterval
+var errIdle = errors.New("idle reader")
+
+// flushReader runs the reads on their own goroutine, so the reading of a batch that holds whole records stops
+// once FlushInterval elapsed since the last batch was dispatched. The pending read is not abandoned, its bytes go to
+// the next Read.
+type flushReader struct {
+	reader   io.Reader
+	interval time.Duration
+	timer    *time.Timer
+
+	// armed reports that the batch being read holds whole records, owned by the reading goroutine
+	armed bool
+	// last is the time the last batch was dispatched
+	last time.Time
+
+	results chan readResult
+	// buffer belongs to the pending read, if any
+	buffer  []byte
+	pending bool
+	// rest and err are the part of the last read not returned yet
+	rest []byte
+	err  error
+}
+
+// flushable wraps the reader so the reads of the batches holding whole records stop at FlushInterval,
+// or returns nil when FlushInterval is not set
+func (e *eater) flushable(reader io.Reader) *flushReader {
+	interval := e.flushInterval()
+	if interval <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(interval)
+	timer.Stop()
+
+	return &flushReader{
+		reader:   reader,
+		interval: interval,
+		timer:    timer,
+		last:     time.Now(),
+		results:  make(chan readResult, 1),
+	}
+}
+
+// arm makes the reads stop with errIdle at the end of the interval, the batch being read holds whole records
+func (r *flushReader) arm(armed bool) {
+	if r != nil {
+		r.armed = armed
+	}
+}
+
+// dispatched starts the interval again
+func (r *flushReader) dispatched() {
+	if r != nil {
+		r.last = time.Now()
+	}
+}
+
+func (r *flushReader) Read(p []byte) (int, error) {
+	if len(r.rest) > 0 || r.err != nil {
+		return r.drain(p), r.takeErr()
+	}
+
+	if !r.pending {
+		if cap(r.buffer) < len(p) {
+			r.buffer = make([]byte, len(p))
+		}
+
+		buffer := r.buffer[:len(p)]
+		r.pending = true
+
+		go func() {
+			n, err := r.reader.Read(buffer)
+			r.results <- readResult{n: n, err: err}
+		}()
+	}
+
+	var expired <-chan time.Time
+
+	if r.armed {
+		r.timer.Reset(max(time.Until(r.last.Add(r.interval)), 0))
+		defer r.timer.Stop()
+
+		expired = r.timer.C
+	}
+
+	select {
+	case result := <-r.results:
+		r.pending = false
+		r.rest, r.err = r.buffer[:result.n], result.err
+
+		return r.drain(p), r.takeErr()
+	case <-expired:
+		return 0, errIdle
+	}
+}
+
+// drain copies to p the bytes of the last read not returned yet
+func (r *flushReader) drain(p []byte) int {
+	n := copy(p, r.rest)
+	r.rest = r.rest[n:]
+
+	return n
+}
+
+// takeErr returns the error of the last read once its bytes were returned
+func (r *flushReader) takeErr() error {
+	if len(r.rest) > 0 {
+		return nil
+	}
+
+	err := r.err
+	r.err = nil
+
+	return err
+}
@@ -0,0 +1,149 @@
+package bread_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yael-castro/bread"
+	"github.com/yael-castro/bread/breadtest"
+)
+
+func TestBread_FlushInterval(t *testing.T) {
+	lines := make([]byte, 0)
+	for i := range 24 {
+		lines = fmt.Appendf(lines, "line %09d\n", i)
+	}
+
+	// A record stays incomplete for a while, it starts in the first read
+	stalled := append([]byte("a\n"), bytes.Repeat([]byte("b"), 60)...)
+	stalled = append(stalled, '\n')
+
+	const (
+		interval = 20 * time.Millisecond
+		// delay is the time each read takes
+		delay = 10 * time.Millisecond
+	)
+
+	cases := [...]struct {
+		bread bread.Bread
+		data  []byte
+		// chunk is the size of each read
+		chunk int
+		// waits reports that the first batch is only dispatched at the end of the stream
+		waits bool
+		err   error
+	}{
+		{
+			bread: bread.Bread{FlushInterval: interval},
+			data:  lines,
+			chunk: 7,
+		},
+		{
+			bread: bread.Bread{FlushInterval: interval},
+			data:  stalled,
+			chunk: 3,
+		},
+		{
+			bread: bread.Bread{},
+			data:  stalled,
+			chunk: 3,
+			waits: true,
+		},
+		{
+			bread: bread.Bread{FlushInterval: interval, RecordSize: 15},
+			data:  lines,
+			chunk: 7,
+		},
+		{
+			bread: bread.Bread{RecordSize: 15},
+			data:  lines,
+			chunk: 7,
+			waits: true,
+		},
+		{
+			// No delimiter, see WithNoDelimiter
+			bread: bread.Bread{FlushInterval: interval, Framing: bread.FramingNone},
+			data:  lines,
+			chunk: 7,
+		},
+		{
+			bread: bread.Bread{FlushInterval: interval, SplitFunc: bufio.ScanLines},
+			data:  lines,
+			chunk: 7,
+		},
+		{
+			bread: bread.Bread{SplitFunc: bufio.ScanLines},
+			data:  lines,
+			chunk: 7,
+			waits: true,
+		},
+		{
+			bread: bread.Bread{FlushInterval: interval, Align: bread.AlignBackward},
+			data:  lines,
+			chunk: 7,
+		},
+		{
+			bread: bread.Bread{FlushInterval: interval, MaxBatchSize: 8 * bread.KB},
+			err:   bread.ErrFlushInterval,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			recorder := &breadtest.Recorder{}
+
+			once := sync.Once{}
+			first := time.Duration(0)
+
+			start := time.Now()
+
+			c.bread.BufferSize = 4 * bread.KB
+			c.bread.WorkerBatchFunc = func(ctx context.Context, batch bread.Batch) {
+				once.Do(func() {
+					first = time.Since(start)
+				})
+
+				recorder.WorkerBatchFunc(ctx, batch)
+			}
+
+			reader := breadtest.SlowReader{Reader: breadtest.Chunked(c.data, c.chunk), Delay: delay}
+
+			err := c.bread.Eat(context.TODO(), reader)
+			if c.err != nil {
+				if !errors.Is(err, c.err) {
+					t.Fatalf("expected error %v, got %v", c.err, err)
+				}
+
+				t.Logf("Success! %v", err)
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			breadtest.AssertData(t, recorder, c.data)
+
+			// Reading the whole stream takes a read for each chunk
+			total := time.Duration(len(c.data)/c.chunk) * delay
+
+			switch {
+			case c.waits && first < total*2/3:
+				t.Fatalf("the first batch was dispatched after %v, before the end of the stream", first)
+			case !c.waits && first > total/3:
+				t.Fatalf("the first batch was dispatched after %v, the stream takes %v", first, total)
+			case !c.waits && len(recorder.Batches()) < 2:
+				t.Fatal("the batches were not flushed")
+			}
+
+			t.Logf("Success! first batch after %v of %v", first, total)
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package bread
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// DefaultPollInterval is the default PollInterval
+const DefaultPollInterval = 250 * time.Millisecond
+
+// errFollowStopped ends the reading of a followed reader once the context is done, see Bread.Follow
+var errFollowStopped = errors.New("follow stopped")
+
+// followReader reads again at the end of the stream, so the bytes appended later are read too. See Bread.Follow
+type followReader struct {
+	ctx      context.Context
+	reader   io.Reader
+	interval time.Duration
+	// partial makes the cancellation end the stream, see Bread.AllowPartialOnCancel
+	partial bool
+}
+
+// follow wraps the reader so it is read past its end until the context is done
+func (e *eater) follow(ctx context.Context, reader io.Reader) io.Reader {
+	return &followReader{ctx: ctx, reader: reader, interval: e.pollInterval(), partial: e.AllowPartialOnCancel}
+}
+
+func (r *followReader) Read(p []byte) (int, error) {
+	for {
+		n, err := r.reader.Read(p)
+		if err != io.EOF {
+			return n, err
+		}
+
+		// The end of the stream moves further once the bytes read are returned
+		if n > 0 {
+			return n, nil
+		}
+
+		timer := time.NewTimer(r.interval)
+
+		select {
+		case <-r.ctx.Done():
+			timer.Stop()
+
+			if r.partial {
+				return 0, io.EOF
+			}
+
+			return 0, errFollowStopped
+		case <-timer.C:
+		}
+	}
+}
+
+// pollInterval returns the effective PollInterval
+func (b Bread) pollInterval() time.Duration {
+	if b.PollInterval <= 0 {
+		return DefaultPollInterval
+	}
+
+	return b.PollInterval
+}
+
+// flushInterval returns the effective FlushInterval, the followed readers flush at each poll by default
+func (b Bread) flushInterval() time.Duration {
+	if b.FlushInterval == 0 && b.Follow {
+		return b.pollInterval()
+	}
+
+	return b.FlushInterval
+}
@@ -0,0 +1,156 @@
+package bread_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/yael-castro/bread"
+)
+
+// appendRecords writes the records to the file one at a time, each one in two parts, like a process logging to it
+func appendRecords(t *testing.T, name string, records []string, tail string) {
+	file, err := os.OpenFile(name, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer file.Close()
+
+	for _, record := range records {
+		half := len(record) / 2
+
+		for _, part := range []string{record[:half], record[half:]} {
+			if _, err := file.WriteString(part); err != nil {
+				t.Error(err)
+				return
+			}
+
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	if _, err := file.WriteString(tail); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBread_Follow(t *testing.T) {
+	lines := make([]string, 40)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %03d\n", i)
+	}
+
+	cases := [...]struct {
+		bread bread.Bread
+		// tail is appended after the records, it is never terminated
+		tail     string
+		expected []string
+	}{
+		{
+			bread:    bread.Bread{},
+			expected: lines,
+		},
+		{
+			bread:    bread.Bread{RecordSize: 9},
+			expected: lines,
+		},
+		{
+			bread:    bread.Bread{},
+			tail:     "partial",
+			expected: lines,
+		},
+		{
+			bread:    bread.Bread{AllowPartialOnCancel: true, CancelMode: bread.CancelDrain},
+			tail:     "partial",
+			expected: append(slices.Clone(lines), "partial"),
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			name := filepath.Join(t.TempDir(), "log")
+			if err := os.WriteFile(name, []byte(lines[0]), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			file, err := os.Open(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			var (
+				mu         sync.Mutex
+				records    []string
+				checkpoint atomic.Int64
+			)
+
+			c.bread.BufferSize = 64
+			c.bread.Follow = true
+			c.bread.PollInterval = 2 * time.Millisecond
+			c.bread.CheckpointFunc = func(_ context.Context, offset int64) error {
+				checkpoint.Store(offset)
+				return nil
+			}
+			c.bread.RecordFunc = func(_ context.Context, batch [][]byte) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				for _, record := range batch {
+					records = append(records, string(record))
+				}
+			}
+
+			var wg sync.WaitGroup
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+				appendRecords(t, name, lines[1:], c.tail)
+
+				// The complete records are streamed before the context is done
+				for checkpoint.Load() < int64(len(lines)*len(lines[0])) && ctx.Err() == nil {
+					time.Sleep(time.Millisecond)
+				}
+
+				time.Sleep(10 * time.Millisecond)
+				cancel()
+			}()
+
+			err = c.bread.Eat(ctx, file)
+			wg.Wait()
+
+			if !errors.Is(err, context.Canceled) {
+				t.Fatalf("expected error %v, got %v", context.Canceled, err)
+			}
+
+			// The delimiters are excluded from the records
+			expected := make([]string, len(c.expected))
+			for j, record := range c.expected {
+				expected[j] = record
+				if c.bread.RecordSize == 0 {
+					expected[j] = strings.TrimSuffix(record, "\n")
+				}
+			}
+
+			if !slices.Equal(records, expected) {
+				t.Fatalf("expected records %q, got %q", expected, records)
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
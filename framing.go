@@ -0,0 +1,282 @@
+package bread
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// DefaultDocumentSeparator is the line that separates the documents of a YAML stream
+const DefaultDocumentSeparator = "---"
+
+// Framing selects how the stream is cut into records
+type Framing uint8
+
+// Supported values for Framing
+const (
+	// FramingDelimiter groups records terminated by the Delimiter into batches of about BufferSize bytes
+	FramingDelimiter Framing = iota
+	// FramingDocuments delivers one document per batch, documents are separated by lines consisting exactly
+	// of the DocumentSeparator, like in YAML streams. The separator lines are excluded from the documents
+	// and empty documents are skipped, so a leading separator does not produce an empty first document.
+	//
+	// NOTE: separator lines inside YAML block scalars are not distinguished from real separators
+	FramingDocuments
+	// FramingSyslog delivers one syslog message per batch, using the octet counting framing of RFC 6587:
+	// the message length in ASCII digits, a space and the message. Violations fail with a *FramingError.
+	// See SyslogNonTransparent
+	FramingSyslog
+	// FramingMbox delivers one mbox message per batch, each message starts with a "From " line at column zero
+	// that belongs to the message it begins. Quoted ">From " lines do not split messages and are delivered as they are.
+	// The bytes before the first "From " line are discarded as a preamble
+	FramingMbox
+	// FramingFASTA delivers one FASTA record per batch, each record starts with a ">" header line followed
+	// by any number of sequence lines. See FramingFASTQ
+	FramingFASTA
+	// FramingFASTQ delivers one FASTQ record per batch, each record is made of exactly four lines:
+	// the "@" header, the sequence, the "+" separator and the quality. Lines are counted rather than matched,
+	// so quality lines starting with "@" are not mistaken for headers.
+	//
+	// Records with a wrong number of lines, a missing header or separator, or a quality that does not match
+	// the sequence length fail with a *FramingError that reports the line number.
+	FramingFASTQ
+	// FramingWARC delivers one WARC record per batch, the header block followed by Content-Length bytes of payload.
+	// The blank lines between records are excluded, see ParseWARCRecord to access the header fields.
+	//
+	// Streams compressed with gzip, usually one member per record, are decompressed transparently.
+	// Offsets then refer to the decompressed stream.
+	FramingWARC
+	// FramingUvarint delivers one message per batch, each message is preceded by its length encoded as a uvarint,
+	// like the length-delimited protobuf streams. The length prefixes are excluded from the batches.
+	// Messages longer than MaxRecordSize fail with a *RecordTooLargeError, see DefaultMaxMessageLength
+	FramingUvarint
+	// FramingUint32BE works like FramingUvarint, with the length encoded as a big-endian uint32
+	FramingUint32BE
+	// FramingNone cuts the stream in batches of BufferSize bytes no matter their content, however short the reads
+	// are. The batches hold no records, so BatchMeta.Records is zero and the settings that work on records, like
+	// RecordFunc and MaxRecords, fail with ErrNoRecords
+	FramingNone
+)
+
+// DefaultMaxSyslogLength is the maximum length of a syslog message when MaxRecordSize is not set
+const DefaultMaxSyslogLength = 1 << 20
+
+// framer extracts one record at a time from the stream
+type framer interface {
+	// next appends the next record to dst, offset is the stream position of the frame.
+	// before and after are the bytes consumed from the stream that do not belong to the record.
+	// It returns io.EOF when there are no more records.
+	next(r *bufio.Reader, dst *[]byte, offset int64) (before, after int, err error)
+}
+
+// framer returns the framer selected by the Framing, or nil for FramingDelimiter
+func (b Bread) framer() framer {
+	switch b.Framing {
+	case FramingDocuments:
+		separator := b.DocumentSeparator
+		if separator == "" {
+			separator = DefaultDocumentSeparator
+		}
+
+		return documentFramer{separator: []byte(separator)}
+	case FramingSyslog:
+		maxLength := b.MaxRecordSize
+		if maxLength == 0 {
+			maxLength = DefaultMaxSyslogLength
+		}
+
+		return syslogFramer{maxLength: maxLength, nonTransparent: b.SyslogNonTransparent}
+	case FramingMbox:
+		return mboxFramer{}
+	case FramingFASTA:
+		return &fastaFramer{}
+	case FramingFASTQ:
+		return &fastqFramer{}
+	case FramingWARC:
+		return &warcFramer{}
+	case FramingUvarint, FramingUint32BE:
+		maxLength := b.MaxRecordSize
+		if maxLength == 0 {
+			maxLength = DefaultMaxMessageLength
+		}
+
+		return prefixFramer{maxLength: maxLength, uvarint: b.Framing == FramingUvarint}
+	}
+
+	return nil
+}
+
+// documentFramer splits the stream on separator lines
+type documentFramer struct {
+	separator []byte
+}
+
+func (f documentFramer) next(r *bufio.Reader, dst *[]byte, _ int64) (before, after int, err error) {
+	for {
+		start := len(*dst)
+
+		_, err = complete(r, dst, '\n')
+		if err != nil && err != io.EOF {
+			return
+		}
+
+		line := (*dst)[start:]
+
+		if len(line) == 0 {
+			if len(*dst) == 0 {
+				return before, 0, io.EOF
+			}
+
+			return before, 0, nil
+		}
+
+		if !bytes.Equal(bytes.TrimRight(line, "\r\n"), f.separator) {
+			if err == io.EOF {
+				return before, 0, nil
+			}
+
+			continue
+		}
+
+		*dst = (*dst)[:start]
+
+		// Empty documents are skipped
+		if start == 0 {
+			before += len(line)
+			continue
+		}
+
+		return before, len(line), nil
+	}
+}
+
+// mboxFrom is the beginning of the lines that start an mbox message
+var mboxFrom = []byte("From ")
+
+// mboxFramer splits the stream on the "From " lines of an mbox file
+type mboxFramer struct{}
+
+func (f mboxFramer) next(r *bufio.Reader, dst *[]byte, _ int64) (before, after int, err error) {
+	// Preamble: the lines before the first message are skipped
+	for !f.from(r) {
+		_, err = complete(r, dst, '\n')
+		before += len(*dst)
+		*dst = (*dst)[:0]
+
+		if err != nil {
+			return
+		}
+	}
+
+	for {
+		_, err = complete(r, dst, '\n')
+		if err == io.EOF {
+			return before, 0, nil
+		}
+
+		if err != nil || f.from(r) {
+			return
+		}
+	}
+}
+
+// from reports whether the next line starts an mbox message, it must be called at the beginning of a line
+func (mboxFramer) from(r *bufio.Reader) bool {
+	head, _ := r.Peek(len(mboxFrom))
+	return bytes.Equal(head, mboxFrom)
+}
+
+// syslogFramer splits the stream using the octet counting framing of RFC 6587
+type syslogFramer struct {
+	maxLength      int
+	nonTransparent bool
+}
+
+func (f syslogFramer) next(r *bufio.Reader, dst *[]byte, offset int64) (before, after int, err error) {
+	c, err := r.ReadByte()
+	if err != nil {
+		return
+	}
+
+	if c < '0' || c > '9' {
+		if !f.nonTransparent {
+			return 0, 0, &FramingError{Position: offset, Reason: fmt.Sprintf("unexpected byte %q in message length", c)}
+		}
+
+		// Non-transparent framing: the message is terminated by a line feed
+		_ = r.UnreadByte()
+
+		_, err = complete(r, dst, '\n')
+		if err != nil && err != io.EOF {
+			return
+		}
+
+		if last := len(*dst) - 1; (*dst)[last] == '\n' {
+			*dst = (*dst)[:last]
+			after = 1
+		}
+
+		return 0, after, nil
+	}
+
+	if c == '0' {
+		return 0, 0, &FramingError{Position: offset, Reason: "message length with leading zero"}
+	}
+
+	length := int(c - '0')
+
+	for before = 1; ; before++ {
+		c, err = r.ReadByte()
+		if err != nil {
+			return before, 0, &FramingError{Position: offset, Reason: "truncated message length", Err: unexpected(err)}
+		}
+
+		if c == ' ' {
+			before++
+			break
+		}
+
+		if c < '0' || c > '9' {
+			return before, 0, &FramingError{Position: offset, Reason: fmt.Sprintf("unexpected byte %q in message length", c)}
+		}
+
+		// The count is bounded while it is read, so it cannot overflow
+		if length = length*10 + int(c-'0'); length > f.maxLength {
+			break
+		}
+	}
+
+	if length > f.maxLength {
+		return before, 0, &FramingError{Position: offset, Reason: fmt.Sprintf("message length exceeds %d bytes", f.maxLength)}
+	}
+
+	*dst = grow(*dst, length)
+
+	n, err := io.ReadFull(r, *dst)
+	if err != nil {
+		*dst = (*dst)[:n]
+
+		return before, 0, &FramingError{Position: offset, Reason: "truncated message", Err: unexpected(err)}
+	}
+
+	return before, 0, nil
+}
+
+// grow returns a slice of length n, reusing the capacity of buffer when possible
+func grow(buffer []byte, n int) []byte {
+	if cap(buffer) >= n {
+		return buffer[:n]
+	}
+
+	return make([]byte, n)
+}
+
+// unexpected turns io.EOF into io.ErrUnexpectedEOF, for the streams that end in the middle of a frame
+func unexpected(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+
+	return err
+}
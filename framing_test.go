@@ -0,0 +1,404 @@
+package bread
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"testing/iotest"
+)
+
+// collect eats the reader and returns the batches sorted by index
+func collect(t *testing.T, bread Bread, reader io.Reader) ([]Batch, error) {
+	t.Helper()
+
+	mu := sync.Mutex{}
+	batches := make([]Batch, 0)
+
+	err := bread.EatBatches(context.TODO(), reader, func(_ context.Context, batch Batch) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		batches = append(batches, Batch{BatchMeta: batch.BatchMeta, Data: bytes.Clone(batch.Data)})
+	})
+
+	sort.Slice(batches, func(i, j int) bool {
+		return batches[i].Index < batches[j].Index
+	})
+
+	return batches, err
+}
+
+func TestBread_FramingDocuments(t *testing.T) {
+	longDocument := "key: " + strings.Repeat("v", 10_000) + "\n"
+
+	cases := [...]struct {
+		bread    Bread
+		data     string
+		reader   func(io.Reader) io.Reader
+		expected []string
+		offsets  []int64
+	}{
+		{
+			data:     "---\na: 1\n---\nb: 2\n",
+			expected: []string{"a: 1\n", "b: 2\n"},
+			offsets:  []int64{4, 13},
+		},
+		{
+			data:     "a: 1\n---\nb: 2",
+			expected: []string{"a: 1\n", "b: 2"},
+			offsets:  []int64{0, 9},
+		},
+		{
+			// Separators must match the whole line
+			data:     "a: |\n  --- not a separator\n----\n--- \n---\r\nb: 2\n---\n",
+			expected: []string{"a: |\n  --- not a separator\n----\n--- \n", "b: 2\n"},
+		},
+		{
+			data:     "---\n---\na: 1\n---\n---\n",
+			expected: []string{"a: 1\n"},
+		},
+		{
+			data: "",
+		},
+		{
+			bread:    Bread{DocumentSeparator: "%%"},
+			data:     "a\n%%\nb\n---\nc\n",
+			expected: []string{"a\n", "b\n---\nc\n"},
+		},
+		{
+			// Separator lines split across internal reads
+			data:     longDocument + "---\n" + longDocument,
+			reader:   iotest.OneByteReader,
+			expected: []string{longDocument, longDocument},
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			c.bread.Framing = FramingDocuments
+			c.bread.BufferSize = 16
+			c.bread.Workers = 4
+
+			var reader io.Reader = strings.NewReader(c.data)
+			if c.reader != nil {
+				reader = c.reader(reader)
+			}
+
+			batches, err := collect(t, c.bread, reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(batches) != len(c.expected) {
+				t.Fatalf("expected %d documents, got %d", len(c.expected), len(batches))
+			}
+
+			for j, batch := range batches {
+				if string(batch.Data) != c.expected[j] {
+					t.Fatalf("expected document %q, got %q", c.expected[j], batch.Data)
+				}
+
+				if batch.Records != 1 {
+					t.Fatalf("expected one record per batch, got %d", batch.Records)
+				}
+
+				if c.offsets != nil && batch.Offset != c.offsets[j] {
+					t.Fatalf("expected offset %d, got %d", c.offsets[j], batch.Offset)
+				}
+
+				if c.data[batch.Offset:batch.Offset+int64(batch.Size)] != c.expected[j] {
+					t.Fatalf("the offset %d does not point to the document", batch.Offset)
+				}
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+func TestBread_FramingSyslog(t *testing.T) {
+	longMessage := "<34>1 " + strings.Repeat("m", 10_000)
+	longFrame := strconv.Itoa(len(longMessage)) + " " + longMessage
+
+	cases := [...]struct {
+		bread    Bread
+		data     string
+		reader   func(io.Reader) io.Reader
+		expected []string
+		offsets  []int64
+		// position of the expected *FramingError, -1 means no error
+		position int64
+		// err is the error wrapped by the *FramingError
+		err error
+	}{
+		{
+			data:     "5 hello3 abc",
+			expected: []string{"hello", "abc"},
+			offsets:  []int64{2, 9},
+			position: -1,
+		},
+		{
+			// Line feeds are part of the message
+			data:     "6 a\nb\nc\n2 \n\n",
+			expected: []string{"a\nb\nc\n", "\n\n"},
+			position: -1,
+		},
+		{
+			data:     "",
+			position: -1,
+		},
+		{
+			// Messages spanning internal reads
+			data:     longFrame + longFrame,
+			reader:   iotest.OneByteReader,
+			expected: []string{longMessage, longMessage},
+			position: -1,
+		},
+		{
+			// Connection closed in the middle of the message
+			data:     "5 hello10 abc",
+			expected: []string{"hello"},
+			position: 7,
+			err:      io.ErrUnexpectedEOF,
+		},
+		{
+			// Connection closed in the middle of the count
+			data:     "5 hello12",
+			expected: []string{"hello"},
+			position: 7,
+			err:      io.ErrUnexpectedEOF,
+		},
+		{
+			data:     "5 hello5x hello",
+			expected: []string{"hello"},
+			position: 7,
+		},
+		{
+			data:     "05 hello",
+			position: 0,
+		},
+		{
+			data:     "<34>1 message\n",
+			position: 0,
+		},
+		{
+			bread:    Bread{MaxRecordSize: 8},
+			data:     "5 hello9 123456789",
+			expected: []string{"hello"},
+			position: 7,
+		},
+		{
+			// Non-transparent framing fallback
+			bread:    Bread{SyslogNonTransparent: true},
+			data:     "5 hello<34>1 first\n3 abc<34>1 last",
+			expected: []string{"hello", "<34>1 first", "abc", "<34>1 last"},
+			offsets:  []int64{2, 7, 21, 24},
+			position: -1,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			c.bread.Framing = FramingSyslog
+			c.bread.BufferSize = 16
+			c.bread.Workers = 4
+
+			var reader io.Reader = strings.NewReader(c.data)
+			if c.reader != nil {
+				reader = c.reader(reader)
+			}
+
+			batches, err := collect(t, c.bread, reader)
+
+			if c.position < 0 && err != nil {
+				t.Fatal(err)
+			}
+
+			if c.position >= 0 {
+				var framingErr *FramingError
+				if !errors.As(err, &framingErr) {
+					t.Fatalf("expected a *FramingError, got %v", err)
+				}
+
+				if framingErr.Position != c.position {
+					t.Fatalf("expected error at offset %d, got %d", c.position, framingErr.Position)
+				}
+
+				if c.err != nil && !errors.Is(err, c.err) {
+					t.Fatalf("expected error wrapping %v, got %v", c.err, err)
+				}
+			}
+
+			if len(batches) != len(c.expected) {
+				t.Fatalf("expected %d messages, got %d", len(c.expected), len(batches))
+			}
+
+			for j, batch := range batches {
+				if string(batch.Data) != c.expected[j] {
+					t.Fatalf("expected message %q, got %q", c.expected[j], batch.Data)
+				}
+
+				if c.offsets != nil && batch.Offset != c.offsets[j] {
+					t.Fatalf("expected offset %d, got %d", c.offsets[j], batch.Offset)
+				}
+
+				if c.data[batch.Offset:batch.Offset+int64(batch.Size)] != c.expected[j] {
+					t.Fatalf("the offset %d does not point to the message", batch.Offset)
+				}
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+func TestBread_FramingMbox(t *testing.T) {
+	first := "From alice@example.com Thu Jan  1 00:00:00 2026\n" +
+		"Subject: first\n" +
+		"\n" +
+		">From the quoted line, this is not a new message\n" +
+		">>From neither is this one\n" +
+		"Sent From my phone\n" +
+		"\n"
+	second := "From bob@example.com Fri Jan  2 00:00:00 2026\n" +
+		"Subject: second\n" +
+		"\n" +
+		strings.Repeat("a long body line\n", 1_000) +
+		"\n"
+	last := "From carol@example.com Sat Jan  3 00:00:00 2026\n" +
+		"Subject: last\n" +
+		"\n" +
+		"no trailing line feed"
+
+	cases := [...]struct {
+		data     string
+		reader   func(io.Reader) io.Reader
+		expected []string
+		offsets  []int64
+	}{
+		{
+			data:     first + second + last,
+			expected: []string{first, second, last},
+			offsets:  []int64{0, int64(len(first)), int64(len(first + second))},
+		},
+		{
+			// The preamble is discarded
+			data:     "preamble\nFrom: is not a From line\n" + first + last,
+			expected: []string{first, last},
+			offsets:  []int64{34, int64(34 + len(first))},
+		},
+		{
+			// Messages spanning internal reads
+			data:     second + second + first,
+			reader:   iotest.OneByteReader,
+			expected: []string{second, second, first},
+		},
+		{
+			data: "only a preamble\n",
+		},
+		{
+			data: "",
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			bread := Bread{
+				Framing:    FramingMbox,
+				BufferSize: 16,
+				Workers:    4,
+			}
+
+			var reader io.Reader = strings.NewReader(c.data)
+			if c.reader != nil {
+				reader = c.reader(reader)
+			}
+
+			batches, err := collect(t, bread, reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(batches) != len(c.expected) {
+				t.Fatalf("expected %d messages, got %d", len(c.expected), len(batches))
+			}
+
+			for j, batch := range batches {
+				if string(batch.Data) != c.expected[j] {
+					t.Fatalf("expected message %q, got %q", c.expected[j], batch.Data)
+				}
+
+				if c.offsets != nil && batch.Offset != c.offsets[j] {
+					t.Fatalf("expected offset %d, got %d", c.offsets[j], batch.Offset)
+				}
+
+				if c.data[batch.Offset:batch.Offset+int64(batch.Size)] != c.expected[j] {
+					t.Fatalf("the offset %d does not point to the message", batch.Offset)
+				}
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+func TestBread_FramingNone(t *testing.T) {
+	data := []byte(strings.Repeat("a\nbb\x00ccc\r\n", 100))
+
+	cases := [...]struct {
+		bread Bread
+		chunk int
+	}{
+		{
+			bread: Bread{BufferSize: 64, Framing: FramingNone, Workers: 4},
+			chunk: 7,
+		},
+		{
+			bread: Bread{BufferSize: 100, Framing: FramingNone},
+			chunk: 1000,
+		},
+		{
+			// The delimiters do not matter
+			bread: Bread{BufferSize: 10, Framing: FramingNone, Delimiter: '\r'},
+			chunk: 1,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			batches, err := collect(t, c.bread, &dataEOFReader{data: data, chunk: c.chunk})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := make([]byte, 0, len(data))
+
+			for _, batch := range batches {
+				last := batch.Offset+int64(batch.Size) == int64(len(data))
+
+				// Only the last batch is cut short, however short the reads are
+				if batch.Size != c.bread.BufferSize && !last {
+					t.Fatalf("batch %d of %d bytes is not %d bytes long", batch.Index, batch.Size, c.bread.BufferSize)
+				}
+
+				if batch.Records != 0 {
+					t.Fatalf("batch %d holds %d records", batch.Index, batch.Records)
+				}
+
+				got = append(got, batch.Data...)
+			}
+
+			if !bytes.Equal(got, data) {
+				t.Fatal("the batches do not match the data")
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package bread
+
+import (
+	"context"
+	"io/fs"
+	"os"
+
+	"github.com/yael-castro/bread/internal/semaphore"
+)
+
+// EatDir eats every regular file under dir, see EatFS
+func (b Bread) EatDir(ctx context.Context, dir string) error {
+	return b.EatFS(ctx, os.DirFS(dir), ".")
+}
+
+// EatFS eats every regular file under root, walking fsys in lexical order.
+//
+// The settings used for each file are resolved through ConfigFor, while the worker budget defined by
+// the base Workers and the MaxMemory budget of the base settings are shared by all the files, so the batches
+// retained by the workers of a file count against the budget of the next ones. The files eaten with the same
+// BufferSize share a buffer pool too, unless their settings have a Pool.
+func (b Bread) EatFS(ctx context.Context, fsys fs.FS, root string) error {
+	if fsys == nil {
+		return ErrNilReader
+	}
+
+	if b.Workers == 0 {
+		b.Workers = DefaultWorkers
+	}
+
+	if b.slots == nil {
+		b.slots = make(chan struct{}, b.Workers)
+	}
+
+	if b.memory == nil && b.MaxMemory > 0 {
+		b.memory = semaphore.NewWeighted(b.MaxMemory)
+	}
+
+	pools := make(map[int]BufferPool)
+
+	return fs.WalkDir(fsys, root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return &FileError{Path: path, Err: err}
+		}
+
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return &FileError{Path: path, Err: err}
+		}
+
+		config, err := b.configFor(path, info)
+		if err != nil {
+			return &FileError{Path: path, Err: err}
+		}
+
+		config = config.share(b, pools)
+		// The file is closed right after it is eaten
+		config.CloseReader = false
+
+		file, err := fsys.Open(path)
+		if err != nil {
+			return &FileError{Path: path, Err: err}
+		}
+
+		err = config.Eat(ctx, file)
+		_ = file.Close()
+
+		if err != nil {
+			return &FileError{Path: path, Err: err}
+		}
+
+		return ctx.Err()
+	})
+}
+
+// share returns the settings of a file using the worker and memory budgets of the base settings, along with the
+// pool of the files with the same BufferSize
+func (b Bread) share(base Bread, pools map[int]BufferPool) Bread {
+	b.slots = base.slots
+
+	if base.memory != nil {
+		// The weight of a batch is bounded by the budget it is taken from
+		b.memory, b.MaxMemory = base.memory, base.MaxMemory
+	}
+
+	if b.Pool != nil {
+		return b
+	}
+
+	if pools[b.BufferSize] == nil {
+		pools[b.BufferSize] = b.sharedPool()
+	}
+
+	b.Pool = pools[b.BufferSize]
+
+	return b
+}
+
+// configFor resolves and validates the settings for a file
+func (b Bread) configFor(path string, info fs.FileInfo) (Bread, error) {
+	if b.ConfigFor == nil {
+		return b, b.Validate()
+	}
+
+	config, err := b.ConfigFor(path, info)
+	if err != nil {
+		return Bread{}, err
+	}
+
+	if config.worker() == nil {
+		config.WorkerFunc, config.WorkerBatchFunc, config.RecordFunc = b.WorkerFunc, b.WorkerBatchFunc, b.RecordFunc
+		config.WorkerErrFunc, config.WorkerStateFunc, config.TransformFunc = b.WorkerErrFunc, b.WorkerStateFunc, b.TransformFunc
+		config.WorkerInit, config.WorkerClose = b.WorkerInit, b.WorkerClose
+		config.Sink = b.Sink
+	}
+
+	if config.worker() == nil {
+		return Bread{}, ErrMissingWorkerFunc
+	}
+
+	return config, config.Validate()
+}
@@ -0,0 +1,240 @@
+package bread
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestBread_EatFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.csv":          {Data: []byte("1;2;3;4;5;6;7;8;9")},
+		"b.ndjson":       {Data: []byte("{}\n{}\n{}\n")},
+		"nested/c.csv":   {Data: []byte("10;11;12")},
+		"nested/d.bogus": {Data: []byte("...")},
+	}
+
+	cases := [...]struct {
+		root  string
+		bread func(worker func(context.Context, *[]byte)) Bread
+		count map[string]int
+		err   string
+	}{
+		{
+			root: ".",
+			bread: func(worker func(context.Context, *[]byte)) Bread {
+				base := Bread{
+					Workers:    2,
+					BufferSize: 1,
+					WorkerFunc: worker,
+				}
+
+				base.ConfigFor = func(name string, _ fs.FileInfo) (Bread, error) {
+					switch path.Ext(name) {
+					case ".csv":
+						config := base
+						config.Delimiter = ';'
+						config.Workers = 16
+
+						return config, nil
+					case ".bogus":
+						return Bread{}, nil
+					}
+
+					return base, nil
+				}
+
+				return base
+			},
+			err: "nested/d.bogus",
+			count: map[string]int{
+				";":  10,
+				"\n": 3,
+			},
+		},
+		{
+			root: "nested",
+			bread: func(worker func(context.Context, *[]byte)) Bread {
+				return Bread{
+					BufferSize: 1,
+					Delimiter:  ';',
+					WorkerFunc: worker,
+				}
+			},
+			count: map[string]int{
+				";": 2,
+			},
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			mu := sync.Mutex{}
+			records := make(map[string]int)
+			running, peak := atomic.Int32{}, atomic.Int32{}
+
+			bread := c.bread(func(_ context.Context, buffer *[]byte) {
+				current := running.Add(1)
+				defer running.Add(-1)
+
+				for last := peak.Load(); current > last && !peak.CompareAndSwap(last, current); last = peak.Load() {
+				}
+
+				time.Sleep(time.Millisecond)
+
+				mu.Lock()
+				defer mu.Unlock()
+
+				for _, delimiter := range []string{";", "\n"} {
+					records[delimiter] += bytes.Count(*buffer, []byte(delimiter))
+				}
+			})
+
+			err := bread.EatFS(context.TODO(), fsys, c.root)
+
+			var fileErr *FileError
+
+			switch {
+			case c.err == "" && err != nil:
+				t.Fatal(err)
+			case c.err != "" && (!errors.As(err, &fileErr) || fileErr.Path != c.err):
+				t.Fatalf("expected an error naming %s, got %v", c.err, err)
+			}
+
+			for delimiter, count := range c.count {
+				if records[delimiter] != count {
+					t.Fatalf("expected %d records delimited by %q, got %d", count, delimiter, records[delimiter])
+				}
+			}
+
+			// The worker budget is shared between files
+			workers := bread.Workers
+			if workers == 0 {
+				workers = DefaultWorkers
+			}
+
+			if peak.Load() > int32(workers) {
+				t.Fatalf("%d concurrent workers exceeded the budget of %d", peak.Load(), workers)
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+func TestBread_EatFS_Sink(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.csv": {Data: []byte("header\n1\n2\n")},
+		"b.csv": {Data: []byte("header\n3\n")},
+	}
+
+	sink := &collectingSink{}
+
+	// The settings of each file do not have a worker, the Sink of the base settings is used
+	bread := Bread{
+		BufferSize: 16,
+		Sink:       sink,
+		ConfigFor: func(string, fs.FileInfo) (Bread, error) {
+			return Bread{BufferSize: 16, SkipLines: 1}, nil
+		},
+	}
+
+	if err := bread.EatFS(context.TODO(), fsys, "."); err != nil {
+		t.Fatal(err)
+	}
+
+	// The indexes of the batches restart with each file
+	batches := make([]string, len(sink.batches))
+	for i, batch := range sink.batches {
+		batches[i] = string(batch.Data)
+	}
+
+	sort.Strings(batches)
+
+	if data := strings.Join(batches, ""); data != "1\n2\n3\n" {
+		t.Fatalf("expected the records of both files, got %q", data)
+	}
+
+	t.Log("Success!")
+}
+
+func TestBread_EatFS_MaxMemory(t *testing.T) {
+	record := "xxxxxxxxxxxxxxx\n"
+
+	fsys := fstest.MapFS{
+		"a.log": {Data: []byte(strings.Repeat(record, 6))},
+		"b.log": {Data: []byte(strings.Repeat(record, 6))},
+		"c.log": {Data: []byte(strings.Repeat(record, 6))},
+	}
+
+	retained := make(chan Batch, 18)
+
+	bread := Bread{
+		Workers:    2,
+		BufferSize: len(record),
+		MaxMemory:  int64(4 * len(record)),
+		WorkerBatchFunc: func(_ context.Context, batch Batch) {
+			batch.Retain()
+			retained <- batch
+		},
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- bread.EatFS(context.TODO(), fsys, ".")
+	}()
+
+	// The batches are held until the whole budget is retained, the batches of the files eaten before included.
+	// Then no batch is dispatched until one of them is released.
+	held := make([]Batch, 0)
+	size := int64(0)
+
+loop:
+	for {
+		if size == bread.MaxMemory {
+			select {
+			case batch := <-retained:
+				t.Fatalf("batch %d dispatched with %d bytes retained", batch.Index, size)
+			case <-time.After(10 * time.Millisecond):
+			}
+
+			held[0].Release()
+			size -= int64(held[0].Size)
+			held = held[1:]
+		}
+
+		select {
+		case batch := <-retained:
+			held = append(held, batch)
+			size += int64(batch.Size)
+		case err := <-done:
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			break loop
+		}
+	}
+
+	close(retained)
+
+	for batch := range retained {
+		held = append(held, batch)
+	}
+
+	for _, batch := range held {
+		batch.Release()
+	}
+
+	t.Log("Success!")
+}
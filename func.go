@@ -0,0 +1,95 @@
+package bread
+
+import (
+	"context"
+	"io"
+)
+
+// EatFunc works like Eat, but the data is pulled from next one message at a time, until it returns io.EOF.
+// It suits the sources that do not implement io.Reader, like gRPC streams or queue consumers.
+//
+// Each message is copied into a pooled buffer, so next may reuse the returned slice on the following call.
+// By default each message is dispatched as its own batch, see CoalesceMessages.
+// The context is checked before each call to next.
+func (b Bread) EatFunc(ctx context.Context, next func(context.Context) ([]byte, error)) error {
+	if next == nil {
+		return ErrNilReader
+	}
+
+	return b.feed(ctx, b.worker(), func(ctx context.Context, e *eater) error {
+		return e.readFunc(ctx, next)
+	})
+}
+
+// readFunc dispatches the messages pulled from next
+func (e *eater) readFunc(ctx context.Context, next func(context.Context) ([]byte, error)) error {
+	var (
+		buffer  *[]byte
+		records int
+	)
+
+	// flush dispatches the pending messages
+	flush := func() {
+		if records > 0 {
+			e.emit(ctx, buffer, records)
+		}
+
+		buffer, records = nil, 0
+	}
+
+	defer func() {
+		if buffer != nil {
+			e.pool.Put(buffer)
+		}
+	}()
+
+	for !e.failed.Load() && !e.stopped {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+
+		message, err := next(ctx)
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			position := e.offset
+			if buffer != nil {
+				position += int64(len(*buffer))
+			}
+
+			return &ReadError{Position: position, Err: err}
+		}
+
+		if !e.CoalesceMessages {
+			buffer = e.pool.Get()
+			*buffer = append((*buffer)[:0], message...)
+			records = 1
+
+			flush()
+			continue
+		}
+
+		if buffer != nil && len(*buffer)+len(message)+1 > e.BufferSize {
+			flush()
+		}
+
+		if buffer == nil {
+			buffer = e.pool.Get()
+			*buffer = (*buffer)[:0]
+		}
+
+		*buffer = append(*buffer, message...)
+		*buffer = append(*buffer, e.separator...)
+		records++
+	}
+
+	if !e.failed.Load() && !e.stopped && ctx.Err() == nil {
+		flush()
+	}
+
+	return nil
+}
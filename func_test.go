@@ -0,0 +1,186 @@
+package bread
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// messages returns a pull function over the messages, reusing the same slice for every message
+func messages(msgs ...string) func(context.Context) ([]byte, error) {
+	scratch := make([]byte, 0, 64)
+
+	return func(context.Context) ([]byte, error) {
+		if len(msgs) == 0 {
+			return nil, io.EOF
+		}
+
+		scratch = append(scratch[:0], msgs[0]...)
+		msgs = msgs[1:]
+
+		return scratch, nil
+	}
+}
+
+// collectingSink keeps a copy of the batches it receives
+type collectingSink struct {
+	mu      sync.Mutex
+	batches []Batch
+}
+
+func (s *collectingSink) Write(_ context.Context, meta BatchMeta, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.batches = append(s.batches, Batch{BatchMeta: meta, Data: bytes.Clone(data)})
+	return nil
+}
+
+func (s *collectingSink) Flush(context.Context) error {
+	sort.Slice(s.batches, func(i, j int) bool {
+		return s.batches[i].Index < s.batches[j].Index
+	})
+
+	return nil
+}
+
+func TestBread_EatFunc(t *testing.T) {
+	errPull := errors.New("pull failure")
+
+	cases := [...]struct {
+		bread    Bread
+		next     func(context.Context) ([]byte, error)
+		expected []string
+		records  []uint32
+		// position of the expected *ReadError
+		position int64
+		err      error
+	}{
+		{
+			next:     messages("a", "bb", "ccc"),
+			expected: []string{"a", "bb", "ccc"},
+			records:  []uint32{1, 1, 1},
+		},
+		{
+			bread:    Bread{CoalesceMessages: true},
+			next:     messages("a", "bb", "ccc", "dddd", "eeeeeeeeeeeeeeeeeeee", "f"),
+			expected: []string{"a\nbb\nccc\n", "dddd\n", "eeeeeeeeeeeeeeeeeeee\n", "f\n"},
+			records:  []uint32{3, 1, 1, 1},
+		},
+		{
+			bread:    Bread{CoalesceMessages: true, Delimiter: ';'},
+			next:     messages("1", "2", "3"),
+			expected: []string{"1;2;3;"},
+			records:  []uint32{3},
+		},
+		{
+			next: messages(),
+		},
+		{
+			bread: Bread{CoalesceMessages: true},
+			next: func() func(context.Context) ([]byte, error) {
+				next := messages("a", "b")
+
+				return func(ctx context.Context) ([]byte, error) {
+					message, err := next(ctx)
+					if err == io.EOF {
+						return nil, errPull
+					}
+
+					return message, err
+				}
+			}(),
+			position: 4,
+			err:      errPull,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			sink := &collectingSink{}
+
+			c.bread.Sink = sink
+			c.bread.BufferSize = 10
+			c.bread.Workers = 4
+
+			err := c.bread.EatFunc(context.TODO(), c.next)
+			if !errors.Is(err, c.err) {
+				t.Fatalf("expected error %v, got %v", c.err, err)
+			}
+
+			if c.err != nil {
+				if offset, ok := OffsetOf(err); !ok || offset != c.position {
+					t.Fatalf("expected error at offset %d, got %v", c.position, err)
+				}
+
+				t.Log("Success!")
+				return
+			}
+
+			if len(sink.batches) != len(c.expected) {
+				t.Fatalf("expected %d batches, got %d", len(c.expected), len(sink.batches))
+			}
+
+			offset := int64(0)
+
+			for j, batch := range sink.batches {
+				if string(batch.Data) != c.expected[j] || batch.Records != c.records[j] {
+					t.Fatalf("expected batch %q with %d records, got %q with %d", c.expected[j], c.records[j], batch.Data, batch.Records)
+				}
+
+				if batch.Offset != offset {
+					t.Fatalf("expected offset %d, got %d", offset, batch.Offset)
+				}
+
+				offset += int64(batch.Size)
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+func TestBread_EatFunc_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	calls := 0
+
+	bread := Bread{
+		BufferSize: 10,
+		WorkerFunc: func(context.Context, *[]byte) {},
+	}
+
+	done := make(chan error)
+
+	go func() {
+		done <- bread.EatFunc(ctx, func(context.Context) ([]byte, error) {
+			calls++
+
+			// The source is canceled while the message is in flight
+			if calls == 3 {
+				cancel()
+			}
+
+			return []byte("message"), nil
+		})
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("EatFunc did not stop after the cancellation")
+	}
+
+	if calls != 3 {
+		t.Fatalf("expected next to be called 3 times, got %d", calls)
+	}
+
+	t.Log("Success!")
+}
@@ -0,0 +1,122 @@
+package bread
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"regexp"
+	"sync"
+)
+
+// ErrMultilinePattern indicates that the pattern given to Grep contains a line feed, so it can never match a line
+var ErrMultilinePattern = errors.New("pattern contains a line feed")
+
+// Grep reports the lines of the reader that contain the pattern, the batches are searched concurrently by the workers.
+//
+// Each batch is searched as a whole with bytes.Index, so the batches without matches are discarded at memory
+// bandwidth speed, and only the lines around each match are inspected.
+// Grep always eats lines (Delimiter '\n' without MaxBatchSize), so the lines are never split across batches
+// and no match is missed at the batch boundaries.
+//
+// onMatch receives the line without its line feed, the stream offset where the line starts and its line number
+// starting at 1. The calls are serialized, but the lines of different batches are not reported in order.
+// The line is only valid until onMatch returns. An error returned by onMatch stops the eating.
+//
+// NOTE: WorkerFunc, Sink, Framing, Delimiter, DelimiterBytes and MaxBatchSize are ignored by Grep
+func Grep(ctx context.Context, b Bread, r io.Reader, pattern []byte, onMatch func(line []byte, offset int64, lineNo uint64) error) error {
+	if bytes.IndexByte(pattern, '\n') >= 0 {
+		return ErrMultilinePattern
+	}
+
+	return grep(ctx, b, r, onMatch, func(data []byte, from int) int {
+		if i := bytes.Index(data[from:], pattern); i >= 0 {
+			return from + i
+		}
+
+		return -1
+	})
+}
+
+// GrepRegexp works like Grep, but reports the lines that match the regular expression.
+//
+// The lines are matched one by one. When the expression starts with a literal prefix, the batches that do not
+// contain it are discarded as a whole, like Grep does with the pattern.
+func GrepRegexp(ctx context.Context, b Bread, r io.Reader, re *regexp.Regexp, onMatch func(line []byte, offset int64, lineNo uint64) error) error {
+	prefix, _ := re.LiteralPrefix()
+
+	return grep(ctx, b, r, onMatch, func(data []byte, from int) int {
+		for from < len(data) {
+			// Skips to the line of the next occurrence of the prefix
+			if prefix != "" {
+				i := bytes.Index(data[from:], []byte(prefix))
+				if i < 0 {
+					return -1
+				}
+
+				from = max(from, bytes.LastIndexByte(data[:from+i], '\n')+1)
+			}
+
+			end := bytes.IndexByte(data[from:], '\n')
+			if end < 0 {
+				end = len(data)
+			} else {
+				end += from
+			}
+
+			if re.Match(data[from:end]) {
+				return from
+			}
+
+			from = end + 1
+		}
+
+		return -1
+	})
+}
+
+// grep eats the reader reporting the lines found by index, which returns the position of the next match
+// in data at or after from, or -1 when there are no more matches
+func grep(ctx context.Context, b Bread, r io.Reader, onMatch func([]byte, int64, uint64) error, index func(data []byte, from int) int) error {
+	if onMatch == nil {
+		return ErrMissingWorkerFunc
+	}
+
+	b.Sink, b.Framing, b.Delimiter, b.DelimiterBytes, b.MaxBatchSize = nil, FramingDelimiter, '\n', nil, 0
+
+	var mu sync.Mutex
+
+	return b.eat(ctx, r, func(ctx context.Context, batch Batch) error {
+		data, lineNo := batch.Data, batch.FirstRecord
+
+		for from := 0; from < len(data); {
+			at := index(data, from)
+			if at < 0 {
+				return nil
+			}
+
+			start := bytes.LastIndexByte(data[:at], '\n') + 1
+
+			end := bytes.IndexByte(data[at:], '\n')
+			if end < 0 {
+				end = len(data)
+			} else {
+				end += at
+			}
+
+			lineNo += uint64(bytes.Count(data[from:start], []byte{'\n'})) + 1
+
+			mu.Lock()
+			err := onMatch(data[start:end], batch.Offset+int64(start), lineNo)
+			mu.Unlock()
+
+			if err != nil {
+				return err
+			}
+
+			from = end + 1
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,182 @@
+package bread
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type grepMatch struct {
+	line   string
+	offset int64
+	lineNo uint64
+}
+
+// grepLines returns the expected matches of the lines of data that satisfy match
+func grepLines(data string, match func(line string) bool) (matches []grepMatch) {
+	offset := int64(0)
+
+	for i, line := range strings.SplitAfter(data, "\n") {
+		if trimmed := strings.TrimSuffix(line, "\n"); line != "" && match(trimmed) {
+			matches = append(matches, grepMatch{line: trimmed, offset: offset, lineNo: uint64(i + 1)})
+		}
+
+		offset += int64(len(line))
+	}
+
+	return
+}
+
+// grepCorpus builds lines of different lengths, some of them containing "needle"
+func grepCorpus(lines int) string {
+	builder := strings.Builder{}
+
+	for i := 0; i < lines; i++ {
+		builder.WriteString(strings.Repeat("hay", i%7))
+
+		if i%13 == 0 {
+			builder.WriteString("needle" + strconv.Itoa(i))
+		}
+
+		builder.WriteByte('\n')
+	}
+
+	return builder.String()
+}
+
+func TestGrep(t *testing.T) {
+	corpus := grepCorpus(1_000)
+	errStop := errors.New("stop")
+
+	cases := [...]struct {
+		data    string
+		pattern string
+		re      *regexp.Regexp
+		// stop is returned by onMatch
+		stop     error
+		expected []grepMatch
+		err      error
+	}{
+		{
+			data:     corpus,
+			pattern:  "needle",
+			expected: grepLines(corpus, func(line string) bool { return strings.Contains(line, "needle") }),
+		},
+		{
+			data:     corpus,
+			pattern:  "needle7",
+			expected: grepLines(corpus, func(line string) bool { return strings.Contains(line, "needle7") }),
+		},
+		{
+			data:    corpus,
+			pattern: "absent",
+		},
+		{
+			// Every line matches, the last one is not terminated
+			data:     "a\n\nb",
+			pattern:  "",
+			expected: []grepMatch{{"a", 0, 1}, {"", 2, 2}, {"b", 3, 3}},
+		},
+		{
+			// Several matches in the same line are reported once
+			data:     "aaaa\nbab\n",
+			pattern:  "a",
+			expected: []grepMatch{{"aaaa", 0, 1}, {"bab", 5, 2}},
+		},
+		{
+			data:     corpus,
+			re:       regexp.MustCompile(`needle\d*5$`),
+			expected: grepLines(corpus, regexp.MustCompile(`needle\d*5$`).MatchString),
+		},
+		{
+			data:     corpus,
+			re:       regexp.MustCompile(`^(hay){6}`),
+			expected: grepLines(corpus, regexp.MustCompile(`^(hay){6}`).MatchString),
+		},
+		{
+			data:    corpus,
+			pattern: "needle",
+			stop:    errStop,
+			err:     errStop,
+		},
+		{
+			data:    corpus,
+			pattern: "needle\nhay",
+			err:     ErrMultilinePattern,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			var matches []grepMatch
+
+			onMatch := func(line []byte, offset int64, lineNo uint64) error {
+				matches = append(matches, grepMatch{line: string(line), offset: offset, lineNo: lineNo})
+				return c.stop
+			}
+
+			bread := Bread{Workers: 4, BufferSize: 64}
+			reader := strings.NewReader(c.data)
+
+			var err error
+
+			if c.re != nil {
+				err = GrepRegexp(context.TODO(), bread, reader, c.re, onMatch)
+			} else {
+				err = Grep(context.TODO(), bread, reader, []byte(c.pattern), onMatch)
+			}
+
+			if !errors.Is(err, c.err) {
+				t.Fatalf("expected error %v, got %v", c.err, err)
+			}
+
+			if c.err != nil {
+				t.Log("Success!")
+				return
+			}
+
+			slices.SortFunc(matches, func(a, b grepMatch) int {
+				return int(a.lineNo) - int(b.lineNo)
+			})
+
+			if !slices.Equal(matches, c.expected) {
+				t.Fatalf("expected %v, got %v", c.expected, matches)
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+// BenchmarkGrep counts the lines that contain a rare pattern, compare it with grep over the same corpus:
+//
+//	grep -c needle1000 corpus.txt
+func BenchmarkGrep(b *testing.B) {
+	data := []byte(grepCorpus(1_000_000))
+
+	bread := Bread{Workers: 16, BufferSize: MB}
+
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+
+	for n := 0; n < b.N; n++ {
+		count := 0
+
+		err := Grep(context.TODO(), bread, bytes.NewReader(data), []byte("needle1000"), func([]byte, int64, uint64) error {
+			count++
+			return nil
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		if count == 0 {
+			b.Fatal("no matches")
+		}
+	}
+}
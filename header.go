@@ -0,0 +1,53 @@
+package bread
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+)
+
+// skipLines consumes the first SkipLines lines of the stream and passes them to HeaderFunc, the stream offset
+// advances past them
+func (e *eater) skipLines(ctx context.Context, r *bufio.Reader) error {
+	line := make([]byte, 0, 512)
+
+	for range e.SkipLines {
+		line = line[:0]
+
+		var err error
+
+		if e.MaxRecordSize > 0 {
+			limit := e.MaxRecordSize + 1
+
+			var found bool
+			if _, found, err = completeWithin(r, &line, '\n', limit); !found && len(line) >= limit {
+				return &RecordTooLargeError{Position: e.offset, Limit: e.MaxRecordSize, Preview: e.preview(line)}
+			}
+		} else {
+			_, err = complete(r, &line, '\n')
+		}
+
+		if err != nil && err != io.EOF {
+			return &ReadError{Position: e.offset + int64(len(line)), Err: err}
+		}
+
+		if len(line) == 0 {
+			return nil
+		}
+
+		if e.HeaderFunc != nil {
+			if headerErr := e.HeaderFunc(ctx, bytes.TrimRight(line, "\r\n")); headerErr != nil {
+				return headerErr
+			}
+		}
+
+		e.offset += int64(len(line))
+
+		if err == io.EOF {
+			return nil
+		}
+	}
+
+	return nil
+}
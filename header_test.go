@@ -0,0 +1,119 @@
+package bread
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestBread_SkipLines(t *testing.T) {
+	longHeader := strings.Repeat("column,", 100) + "last"
+
+	headerErr := errors.New("unexpected header")
+
+	cases := [...]struct {
+		bread   Bread
+		data    string
+		headers []string
+		// body is the data delivered to the workers
+		body string
+		err  error
+	}{
+		{
+			bread:   Bread{SkipLines: 1},
+			data:    "id,name\n1,a\n2,b\n",
+			headers: []string{"id,name"},
+			body:    "1,a\n2,b\n",
+		},
+		{
+			// Header longer than BufferSize, with CRLF line endings
+			bread:   Bread{SkipLines: 2},
+			data:    "# export\r\n" + longHeader + "\r\n1,a\r\n",
+			headers: []string{"# export", longHeader},
+			body:    "1,a\r\n",
+		},
+		{
+			// Only headers
+			bread:   Bread{SkipLines: 2},
+			data:    "id,name\n",
+			headers: []string{"id,name"},
+		},
+		{
+			bread:   Bread{SkipLines: 1},
+			data:    "id,name",
+			headers: []string{"id,name"},
+		},
+		{
+			bread: Bread{SkipLines: 1, MaxRecordSize: 100},
+			data:  longHeader + "\n1,a\n",
+			err:   ErrRecordTooLarge,
+		},
+		{
+			bread: Bread{SkipLines: 1, HeaderFunc: func(context.Context, []byte) error {
+				return headerErr
+			}},
+			data: "id,name\n1,a\n",
+			err:  headerErr,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			headers := make([]string, 0)
+
+			if c.bread.HeaderFunc == nil {
+				c.bread.HeaderFunc = func(_ context.Context, line []byte) error {
+					headers = append(headers, string(line))
+					return nil
+				}
+			}
+
+			c.bread.BufferSize = 16
+			c.bread.Workers = 4
+
+			batches, err := collect(t, c.bread, iotest.HalfReader(strings.NewReader(c.data)))
+			if c.err != nil {
+				if !errors.Is(err, c.err) {
+					t.Fatalf("expected error %v, got %v", c.err, err)
+				}
+
+				if len(batches) > 0 {
+					t.Fatalf("expected no batches, got %d", len(batches))
+				}
+
+				t.Logf("Success! %v", err)
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !slices.Equal(headers, c.headers) {
+				t.Fatalf("expected headers %q, got %q", c.headers, headers)
+			}
+
+			body := make([]byte, 0)
+
+			for _, batch := range batches {
+				// The offsets count the skipped lines
+				if !bytes.Equal(batch.Data, []byte(c.data[batch.Offset:batch.Offset+int64(batch.Size)])) {
+					t.Fatalf("the offset %d does not point to the batch", batch.Offset)
+				}
+
+				body = append(body, batch.Data...)
+			}
+
+			if string(body) != c.body {
+				t.Fatalf("expected body %q, got %q", c.body, body)
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package bread
+
+import (
+	"math/bits"
+	"sync/atomic"
+	"time"
+)
+
+// histogram summarizes durations with buckets of powers of two nanoseconds, without locks nor allocations
+type histogram struct {
+	buckets [64]atomic.Uint64
+	count   atomic.Uint64
+	sum     atomic.Int64
+	max     atomic.Int64
+}
+
+// observe adds a duration to the histogram
+func (h *histogram) observe(d time.Duration) {
+	d = max(d, 0)
+
+	h.buckets[bits.Len64(uint64(d))%64].Add(1)
+	h.count.Add(1)
+	h.sum.Add(int64(d))
+
+	for {
+		current := h.max.Load()
+		if int64(d) <= current || h.max.CompareAndSwap(current, int64(d)) {
+			return
+		}
+	}
+}
+
+// reset removes every duration
+func (h *histogram) reset() {
+	for i := range h.buckets {
+		h.buckets[i].Store(0)
+	}
+
+	h.count.Store(0)
+	h.sum.Store(0)
+	h.max.Store(0)
+}
+
+// stats summarizes the durations observed
+func (h *histogram) stats() QueueAgeStats {
+	count := h.count.Load()
+	if count == 0 {
+		return QueueAgeStats{}
+	}
+
+	stats := QueueAgeStats{
+		Mean: time.Duration(h.sum.Load() / int64(count)),
+		Max:  time.Duration(h.max.Load()),
+	}
+
+	// Upper bound of the bucket that holds the 95th percentile, bounded by the maximum
+	rank := (count*95 + 99) / 100
+
+	for i, seen := 0, uint64(0); i < len(h.buckets); i++ {
+		if seen += h.buckets[i].Load(); seen >= rank {
+			stats.P95 = min(time.Duration(1)<<i-1, stats.Max)
+			break
+		}
+	}
+
+	return stats
+}
@@ -0,0 +1,56 @@
+package bread
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestHistogram(t *testing.T) {
+	cases := [...]struct {
+		durations []time.Duration
+		expected  QueueAgeStats
+	}{
+		{},
+		{
+			durations: []time.Duration{100},
+			expected:  QueueAgeStats{Mean: 100, P95: 100, Max: 100},
+		},
+		{
+			// 19 of 20 durations fall in the bucket of 64 to 127
+			durations: append(repeat(100, 19), 1000),
+			expected:  QueueAgeStats{Mean: 145, P95: 127, Max: 1000},
+		},
+		{
+			durations: []time.Duration{-5, 0},
+			expected:  QueueAgeStats{},
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			h := &histogram{}
+
+			for _, d := range c.durations {
+				h.observe(d)
+			}
+
+			if stats := h.stats(); stats != c.expected {
+				t.Fatalf("expected %+v, got %+v", c.expected, stats)
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+// repeat returns n copies of d
+func repeat(d time.Duration, n int) []time.Duration {
+	durations := make([]time.Duration, n)
+
+	for i := range durations {
+		durations[i] = d
+	}
+
+	return durations
+}
@@ -0,0 +1,151 @@
+package bread
+
+import (
+	"bytes"
+	"context"
+	"io"
+)
+
+// head reads the input when it fits within SyncThreshold, whole is false when there is more data to read.
+// The size of the readers that report it, like *bytes.Reader, is checked before reading.
+func (e *eater) head(reader io.Reader) (data []byte, whole bool, err error) {
+	if sized, ok := reader.(interface{ Len() int }); ok {
+		if sized.Len() > e.SyncThreshold {
+			return nil, false, nil
+		}
+
+		data = make([]byte, sized.Len())
+
+		n, err := io.ReadFull(reader, data)
+		if err != nil {
+			return data[:n], false, err
+		}
+
+		return data, true, nil
+	}
+
+	data, err = io.ReadAll(io.LimitReader(progressReader{Reader: reader}, int64(e.SyncThreshold)+1))
+	if err != nil {
+		return data, false, err
+	}
+
+	return data, len(data) <= e.SyncThreshold, nil
+}
+
+// readInline splits the data in batches like readForward, running each worker on the calling goroutine
+func (e *eater) readInline(ctx context.Context, data []byte) error {
+	e.inline = true
+
+	return e.readSlices(ctx, data)
+}
+
+// readSlices splits the data in batches like readForward. The batches are slices of data,
+// so no buffer is taken from the pool.
+func (e *eater) readSlices(ctx context.Context, data []byte) error {
+	e.sliced = true
+
+	for len(data) > 0 && !e.failed.Load() && !e.stopped {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+
+		n := min(len(data), e.BufferSize)
+
+		records := bytes.Count(data[:n], e.separator)
+
+		// Complement up to the next delimiter, that may start before n when it has several bytes
+		complement := len(data) - n
+
+		if start := max(n-len(e.separator)+1, 0); start < len(data) {
+			if i := bytes.Index(data[start:], e.separator); i >= 0 {
+				complement = start + i + len(e.separator) - n
+			}
+		}
+
+		batch := data[: n+complement : n+complement]
+
+		if err := e.checkRecord(batch, n); err != nil {
+			return err
+		}
+
+		data = data[n+complement:]
+
+		if complement > 0 || !bytes.HasSuffix(batch, e.separator) {
+			records++
+		}
+
+		e.counters.complements.Add(int64(complement))
+		e.debugComplement(ctx, complement)
+
+		e.emit(ctx, &batch, records)
+	}
+
+	return nil
+}
+
+// checkRecord checks that the record completed past n, the last one of the batch, does not exceed MaxRecordSize
+func (e *eater) checkRecord(batch []byte, n int) error {
+	if e.MaxRecordSize == 0 {
+		return nil
+	}
+
+	start := bytes.LastIndex(batch[:n], e.separator) + len(e.separator)
+	if start < len(e.separator) {
+		start = 0
+	}
+
+	size := len(batch) - start
+	if bytes.HasSuffix(batch, e.separator) {
+		size -= len(e.separator)
+	}
+
+	if size <= e.MaxRecordSize {
+		return nil
+	}
+
+	return &RecordTooLargeError{Position: e.offset + int64(start), Limit: e.MaxRecordSize, Preview: e.preview(batch[start:])}
+}
+
+// workInline runs the worker on the calling goroutine, it is used instead of start for the inline batches
+func (e *eater) workInline(ctx context.Context, batch Batch) {
+	// The worker goroutines wait while the batches run inline, so the state of the first one is free
+	if e.states != nil {
+		batch.state = e.states[0]
+	}
+
+	// The batch runs with the context of the workers, see CancelMode
+	batchCtx := e.pickUp(e.workCtx, batch)
+
+	err := e.process(batchCtx, &batch)
+	if err != nil {
+		e.fail(err)
+	}
+
+	e.observer.OnBatchEnd(batchCtx, batch.BatchMeta, err)
+	e.debugProcessed(ctx, batch, err)
+
+	e.counters.completed.Add(1)
+	e.reporter.notify()
+
+	if err == nil {
+		e.checkpoints.complete(batch)
+	}
+
+	// The inline batches are processed in stream order
+	if e.order != nil {
+		e.deliver(ctx, batch)
+	}
+
+	e.recycle(batch)
+}
+
+// failedReader is an io.Reader that always fails
+type failedReader struct {
+	err error
+}
+
+func (r failedReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
@@ -0,0 +1,161 @@
+package bread
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestBread_SyncThreshold(t *testing.T) {
+	errRead := errors.New("read failure")
+
+	cases := [...]struct {
+		bread  Bread
+		data   string
+		reader func(string) io.Reader
+		err    error
+		// joined compares the joined batches, for inputs whose batches depend on the size of the reads
+		joined bool
+	}{
+		{
+			bread: Bread{BufferSize: 8},
+			data:  "a\nbb\nccc\ndddd\neeeee\nffffff\nggggggg\n",
+		},
+		{
+			bread: Bread{BufferSize: 8},
+			data:  "aaaaaaa\nbbbbbbbbbbbbbbbbbbbbbbb\nc\nno trailing delimiter",
+		},
+		{
+			bread: Bread{BufferSize: 4, Delimiter: ';', MaxRecords: 5},
+			data:  "1;2;3;4;5;6;7;8;9;",
+		},
+		{
+			bread: Bread{BufferSize: 1},
+			data:  "",
+		},
+		{
+			// Inputs that do not report their size
+			bread: Bread{BufferSize: 8},
+			data:  strings.Repeat("record\n", 100),
+			reader: func(data string) io.Reader {
+				r := strings.NewReader(data)
+
+				return readFunc(r.Read)
+			},
+		},
+		{
+			// Inputs larger than the threshold
+			bread: Bread{BufferSize: 1 << 10},
+			data:  strings.Repeat("record\n", 10_000),
+			reader: func(data string) io.Reader {
+				r := strings.NewReader(data)
+
+				return readFunc(r.Read)
+			},
+			joined: true,
+		},
+		{
+			// Read errors before reaching the threshold
+			bread: Bread{BufferSize: 8},
+			data:  "a\nb\n",
+			reader: func(data string) io.Reader {
+				return io.MultiReader(strings.NewReader(data), failedReader{err: errRead})
+			},
+			err: errRead,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			reader := func() io.Reader {
+				if c.reader != nil {
+					return c.reader(c.data)
+				}
+
+				return strings.NewReader(c.data)
+			}
+
+			expected, expectedErr := collect(t, c.bread, reader())
+
+			c.bread.SyncThreshold = 1 << 10
+
+			batches, err := collect(t, c.bread, reader())
+
+			if !errors.Is(err, c.err) || !errors.Is(expectedErr, c.err) {
+				t.Fatalf("expected error %v, got %v and %v", c.err, expectedErr, err)
+			}
+
+			if c.joined {
+				if join(batches) != join(expected) {
+					t.Fatal("the batches do not reproduce the input")
+				}
+
+				t.Log("Success!")
+				return
+			}
+
+			if len(batches) != len(expected) {
+				t.Fatalf("expected %d batches, got %d", len(expected), len(batches))
+			}
+
+			for j := range batches {
+				if batches[j].BatchMeta != expected[j].BatchMeta || string(batches[j].Data) != string(expected[j].Data) {
+					t.Fatalf("expected batch %+v %q, got %+v %q", expected[j].BatchMeta, expected[j].Data, batches[j].BatchMeta, batches[j].Data)
+				}
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+// join concatenates the data of the batches
+func join(batches []Batch) string {
+	builder := strings.Builder{}
+
+	for _, batch := range batches {
+		builder.Write(batch.Data)
+	}
+
+	return builder.String()
+}
+
+func BenchmarkBread_SyncThreshold(b *testing.B) {
+	data := strings.Repeat("{\"key\":\"value\"}\n", 256)
+
+	cases := [...]struct {
+		bread Bread
+	}{
+		{
+			bread: Bread{
+				Workers:    4,
+				BufferSize: 1 << 10,
+				WorkerFunc: func(context.Context, *[]byte) {},
+			},
+		},
+		{
+			bread: Bread{
+				Workers:       4,
+				BufferSize:    1 << 10,
+				SyncThreshold: 64 << 10,
+				WorkerFunc:    func(context.Context, *[]byte) {},
+			},
+		},
+	}
+
+	for i, c := range cases {
+		b.Run(strconv.Itoa(i), func(b *testing.B) {
+			b.ReportAllocs()
+
+			for n := 0; n < b.N; n++ {
+				err := c.bread.Eat(context.TODO(), strings.NewReader(data))
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
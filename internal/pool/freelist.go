@@ -0,0 +1,129 @@
+package pool
+
+import "sync/atomic"
+
+// NewFreelist builds a freelist that holds at most size buffers with length length and initial capacity capacity.
+//
+// When every buffer is in use, Get blocks until one is returned, unless allocate is true,
+// in which case Get allocates a new buffer and the freelist only bounds the number of idle buffers.
+func NewFreelist(size, length, capacity int, allocate bool) *Freelist {
+	return &Freelist{
+		free:     make(chan *[]byte, size),
+		tokens:   make(chan struct{}, size),
+		length:   length,
+		capacity: max(length, capacity),
+		allocate: allocate,
+	}
+}
+
+// Freelist is a fixed size pool of byte slices backed by a buffered channel.
+//
+// Unlike Buffers, the buffers of a Freelist survive the garbage collection cycles.
+type Freelist struct {
+	// free holds the idle buffers
+	free chan *[]byte
+	// tokens holds one token for each buffer in circulation, idle or in use. Unused when allocate is true
+	tokens   chan struct{}
+	length   int
+	capacity int
+	allocate bool
+	// MaxCap is the maximum capacity of the buffers kept by the freelist, the array of bigger buffers is released
+	// by Put and a new one is allocated by the next Get.
+	//
+	// Zero means no limit.
+	MaxCap int
+
+	allocations atomic.Uint64
+}
+
+// Get returns a buffer with length equal to the length of the freelist
+func (f *Freelist) Get() *[]byte {
+	var buffer *[]byte
+
+	select {
+	case buffer = <-f.free:
+	default:
+		buffer = f.acquire()
+	}
+
+	// The caller may have replaced the slice
+	if cap(*buffer) < f.length {
+		f.allocations.Add(1)
+		*buffer = make([]byte, f.length, f.capacity)
+	}
+
+	*buffer = (*buffer)[:f.length]
+
+	return buffer
+}
+
+// acquire allocates a new buffer if the freelist is not full, otherwise it waits for an idle one
+func (f *Freelist) acquire() *[]byte {
+	if f.allocate {
+		return f.newBuffer()
+	}
+
+	select {
+	case f.tokens <- struct{}{}:
+		return f.newBuffer()
+	case buffer := <-f.free:
+		return buffer
+	}
+}
+
+// Put returns the buffer to the freelist.
+//
+// A buffer whose slice is nil was kept by the caller, so the freelist releases its place for a new buffer.
+func (f *Freelist) Put(buffer *[]byte) {
+	if buffer == nil {
+		return
+	}
+
+	if *buffer == nil {
+		if !f.allocate {
+			<-f.tokens
+		}
+
+		return
+	}
+
+	if f.MaxCap > 0 && cap(*buffer) > f.MaxCap {
+		*buffer = (*buffer)[:0:0]
+	}
+
+	select {
+	case f.free <- buffer:
+	default:
+		// Only possible for the buffers allocated while the freelist was exhausted
+	}
+}
+
+// Seed allocates n buffers in advance, up to the size of the freelist
+func (f *Freelist) Seed(n int) {
+	n = min(n, cap(f.free)-len(f.free))
+
+	for ; n > 0; n-- {
+		if !f.allocate {
+			select {
+			case f.tokens <- struct{}{}:
+			default:
+				return
+			}
+		}
+
+		f.free <- f.newBuffer()
+	}
+}
+
+// Allocations returns the number of buffers allocated by the freelist
+func (f *Freelist) Allocations() uint64 {
+	return f.allocations.Load()
+}
+
+// newBuffer allocates a buffer
+func (f *Freelist) newBuffer() *[]byte {
+	f.allocations.Add(1)
+
+	buffer := make([]byte, f.length, f.capacity)
+	return &buffer
+}
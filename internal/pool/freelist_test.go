@@ -0,0 +1,136 @@
+package pool
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestFreelist_Block(t *testing.T) {
+	f := NewFreelist(2, 8, 16, false)
+
+	first, second := f.Get(), f.Get()
+
+	got := make(chan *[]byte)
+
+	go func() {
+		got <- f.Get()
+	}()
+
+	select {
+	case <-got:
+		t.Fatal("Get must block while every buffer is in use")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	*first = append(*first, 'x')
+	f.Put(first)
+
+	third := <-got
+	if third != first || len(*third) != 8 {
+		t.Fatal("expected the returned buffer with its length reset")
+	}
+
+	// A kept buffer releases its place
+	*second = nil
+	f.Put(second)
+
+	fourth := f.Get()
+	if fourth == second || len(*fourth) != 8 {
+		t.Fatal("expected a new buffer")
+	}
+
+	if f.Allocations() != 3 {
+		t.Fatalf("expected 3 allocations, got %d", f.Allocations())
+	}
+
+	t.Log("Success!")
+}
+
+func TestFreelist_Allocate(t *testing.T) {
+	f := NewFreelist(1, 8, 16, true)
+
+	first, second := f.Get(), f.Get()
+
+	f.Put(first)
+	f.Put(second)
+
+	// Only one idle buffer is kept
+	if len(f.free) != 1 {
+		t.Fatalf("expected 1 idle buffer, got %d", len(f.free))
+	}
+
+	*first = nil
+	f.Put(first)
+	f.Put(nil)
+
+	if f.Allocations() != 2 {
+		t.Fatalf("expected 2 allocations, got %d", f.Allocations())
+	}
+
+	t.Log("Success!")
+}
+
+func TestFreelist_Seed(t *testing.T) {
+	for _, allocate := range []bool{false, true} {
+		f := NewFreelist(3, 8, 16, allocate)
+		f.Seed(10)
+
+		if f.Allocations() != 3 || len(f.free) != 3 {
+			t.Fatalf("expected 3 seeded buffers, got %d", f.Allocations())
+		}
+
+		// Buffers survive the garbage collection
+		runtime.GC()
+
+		buffers := []*[]byte{f.Get(), f.Get(), f.Get()}
+
+		// The caller replaced the slice with a smaller one
+		*buffers[1] = make([]byte, 0, 1)
+
+		for _, buffer := range buffers {
+			f.Put(buffer)
+		}
+
+		for range buffers {
+			if buffer := f.Get(); len(*buffer) != 8 {
+				t.Fatalf("unexpected length %d", len(*buffer))
+			}
+		}
+
+		if f.Allocations() != 4 {
+			t.Fatalf("expected 4 allocations, got %d", f.Allocations())
+		}
+	}
+
+	t.Log("Success!")
+}
+
+func TestFreelist_MaxCap(t *testing.T) {
+	f := NewFreelist(1, 8, 16, false)
+	f.MaxCap = 32
+
+	buffer := f.Get()
+
+	// The caller grew the buffer beyond MaxCap
+	*buffer = append(*buffer, make([]byte, 64)...)
+	f.Put(buffer)
+
+	if buffer = f.Get(); len(*buffer) != 8 || cap(*buffer) != 16 {
+		t.Fatalf("expected a new buffer, got length %d and capacity %d", len(*buffer), cap(*buffer))
+	}
+
+	// Buffers within MaxCap are kept
+	*buffer = append(*buffer, make([]byte, 16)...)
+	f.Put(buffer)
+
+	if buffer = f.Get(); cap(*buffer) <= 16 {
+		t.Fatalf("expected the grown buffer, got capacity %d", cap(*buffer))
+	}
+
+	if f.Allocations() != 2 {
+		t.Fatalf("expected 2 allocations, got %d", f.Allocations())
+	}
+
+	t.Log("Success!")
+}
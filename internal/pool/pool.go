@@ -0,0 +1,113 @@
+// Package pool provides the statically typed object pools shared by the pooling features of bread
+package pool
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// New builds a Pool that calls newFunc when it is empty
+func New[T any](newFunc func() T) *Pool[T] {
+	p := &Pool[T]{}
+
+	p.pool.New = func() any {
+		return newFunc()
+	}
+
+	return p
+}
+
+// Pool is a statically typed wrapper over sync.Pool
+type Pool[T any] struct {
+	pool sync.Pool
+}
+
+// Get selects an arbitrary item from the Pool, removes it from the Pool and returns it to the caller
+func (p *Pool[T]) Get() T {
+	return p.pool.Get().(T)
+}
+
+// Put adds the item to the Pool
+func (p *Pool[T]) Put(item T) {
+	p.pool.Put(item)
+}
+
+// NewBuffers builds a pool of buffers with length size and initial capacity capacity.
+//
+// A capacity smaller than size is raised to size.
+func NewBuffers(size, capacity int) *Buffers {
+	b := &Buffers{
+		size:     size,
+		capacity: max(size, capacity),
+	}
+
+	b.pool = New(b.newBuffer)
+
+	return b
+}
+
+// Buffers is a pool of byte slices of a fixed length
+type Buffers struct {
+	pool     *Pool[*[]byte]
+	size     int
+	capacity int
+	// MaxCap is the maximum capacity of the buffers kept by the pool, bigger buffers are dropped by Put.
+	//
+	// Zero means no limit.
+	MaxCap int
+
+	allocations atomic.Uint64
+}
+
+// Get returns a buffer with length equal to the size of the pool
+func (b *Buffers) Get() *[]byte {
+	buffer := b.pool.Get()
+
+	// Buffers keep the length of their last use, and the caller may have replaced the slice
+	if cap(*buffer) < b.size {
+		b.allocations.Add(1)
+		*buffer = make([]byte, b.size, b.capacity)
+	}
+
+	*buffer = (*buffer)[:b.size]
+
+	return buffer
+}
+
+// Put returns the buffer to the pool, nil buffers and buffers bigger than MaxCap are dropped
+func (b *Buffers) Put(buffer *[]byte) {
+	if buffer == nil || *buffer == nil {
+		return
+	}
+
+	if b.MaxCap > 0 && cap(*buffer) > b.MaxCap {
+		return
+	}
+
+	b.pool.Put(buffer)
+}
+
+// Seed allocates n buffers in advance
+func (b *Buffers) Seed(n int) {
+	for ; n > 0; n-- {
+		b.pool.Put(b.newBuffer())
+	}
+}
+
+// newBuffer allocates a buffer
+func (b *Buffers) newBuffer() *[]byte {
+	b.allocations.Add(1)
+
+	buffer := make([]byte, b.size, b.capacity)
+	return &buffer
+}
+
+// Size returns the length of the buffers returned by Get
+func (b *Buffers) Size() int {
+	return b.size
+}
+
+// Allocations returns the number of buffers allocated by the pool
+func (b *Buffers) Allocations() uint64 {
+	return b.allocations.Load()
+}
@@ -0,0 +1,206 @@
+package pool
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestPool(t *testing.T) {
+	calls := 0
+
+	p := New(func() *int {
+		calls++
+
+		n := calls
+		return &n
+	})
+
+	first := p.Get()
+	if *first != 1 || calls != 1 {
+		t.Fatalf("expected a new item, got %d", *first)
+	}
+
+	p.Put(first)
+
+	// sync.Pool may drop items at any time, so only the type is guaranteed
+	second := p.Get()
+	if second == nil {
+		t.Fatal("unexpected nil item")
+	}
+
+	t.Log("Success!")
+}
+
+func TestBuffers_Get(t *testing.T) {
+	cases := [...]struct {
+		size     int
+		capacity int
+		reuse    func(*[]byte)
+	}{
+		{
+			size:     8,
+			capacity: 16,
+		},
+		{
+			size:     8,
+			capacity: 4,
+		},
+		{
+			size:     8,
+			capacity: 16,
+			reuse: func(buffer *[]byte) {
+				*buffer = (*buffer)[:2]
+			},
+		},
+		{
+			size:     8,
+			capacity: 16,
+			reuse: func(buffer *[]byte) {
+				*buffer = append(*buffer, make([]byte, 100)...)
+			},
+		},
+		{
+			size:     8,
+			capacity: 16,
+			reuse: func(buffer *[]byte) {
+				*buffer = make([]byte, 1)
+			},
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			b := NewBuffers(c.size, c.capacity)
+
+			buffer := b.Get()
+			if len(*buffer) != c.size || cap(*buffer) < c.size {
+				t.Fatalf("unexpected buffer len %d cap %d", len(*buffer), cap(*buffer))
+			}
+
+			if c.reuse != nil {
+				c.reuse(buffer)
+				b.Put(buffer)
+
+				buffer = b.Get()
+				if len(*buffer) != c.size || cap(*buffer) < c.size {
+					t.Fatalf("unexpected reused buffer len %d cap %d", len(*buffer), cap(*buffer))
+				}
+			}
+
+			if b.Size() != c.size {
+				t.Fatalf("unexpected size %d", b.Size())
+			}
+
+			if b.Allocations() == 0 {
+				t.Fatal("allocations were not counted")
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+func TestBuffers_Put(t *testing.T) {
+	cases := [...]struct {
+		maxCap   int
+		buffer   func() *[]byte
+		expected bool
+	}{
+		{
+			buffer: func() *[]byte {
+				return nil
+			},
+		},
+		{
+			buffer: func() *[]byte {
+				return new([]byte)
+			},
+		},
+		{
+			maxCap: 16,
+			buffer: func() *[]byte {
+				buffer := make([]byte, 8, 32)
+				return &buffer
+			},
+		},
+		{
+			maxCap: 16,
+			buffer: func() *[]byte {
+				buffer := make([]byte, 8, 16)
+				return &buffer
+			},
+			expected: true,
+		},
+		{
+			buffer: func() *[]byte {
+				buffer := make([]byte, 8, 1<<20)
+				return &buffer
+			},
+			expected: true,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			b := NewBuffers(8, 8)
+			b.MaxCap = c.maxCap
+
+			buffer := c.buffer()
+			b.Put(buffer)
+
+			kept := false
+
+			// sync.Pool is per P, so a dropped buffer can never come back while a kept one usually does
+			for n := 0; n < 10 && !kept; n++ {
+				got := b.Get()
+				kept = got == buffer
+			}
+
+			if kept && !c.expected {
+				t.Fatal("the buffer must be dropped")
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+func TestBuffers_Seed(t *testing.T) {
+	b := NewBuffers(8, 16)
+	b.Seed(4)
+
+	if b.Allocations() != 4 {
+		t.Fatalf("expected 4 allocations, got %d", b.Allocations())
+	}
+
+	t.Log("Success!")
+}
+
+func TestBuffers_Concurrency(t *testing.T) {
+	b := NewBuffers(64, 128)
+	wg := sync.WaitGroup{}
+
+	for worker := 0; worker < 8; worker++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for n := 0; n < 1_000; n++ {
+				buffer := b.Get()
+				if len(*buffer) != 64 {
+					t.Errorf("unexpected length %d", len(*buffer))
+					return
+				}
+
+				*buffer = append(*buffer, 'x')
+				b.Put(buffer)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	t.Log("Success!")
+}
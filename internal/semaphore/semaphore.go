@@ -0,0 +1,119 @@
+// Package semaphore provides the weighted semaphore that bounds the memory of the batches in flight,
+// with the semantics of golang.org/x/sync/semaphore
+package semaphore
+
+import (
+	"container/list"
+	"context"
+	"sync"
+)
+
+// NewWeighted builds a Weighted semaphore with the given maximum combined weight
+func NewWeighted(size int64) *Weighted {
+	return &Weighted{size: size}
+}
+
+// Weighted is a semaphore whose holders acquire different weights. The waiters are served in order,
+// so a large weight is not starved by the small ones acquired after it.
+type Weighted struct {
+	mu      sync.Mutex
+	size    int64
+	current int64
+	waiters list.List
+}
+
+// waiter is a blocked Acquire, ready is closed once its weight was acquired
+type waiter struct {
+	n     int64
+	ready chan struct{}
+}
+
+// Acquire acquires the weight n, blocking until it is available or the context is done.
+// On failure it returns the context error and leaves the semaphore unchanged.
+//
+// A weight larger than the size of the semaphore is never available.
+func (s *Weighted) Acquire(ctx context.Context, n int64) error {
+	done := ctx.Done()
+
+	s.mu.Lock()
+
+	select {
+	case <-done:
+		s.mu.Unlock()
+		return ctx.Err()
+	default:
+	}
+
+	if s.size-s.current >= n && s.waiters.Len() == 0 {
+		s.current += n
+		s.mu.Unlock()
+
+		return nil
+	}
+
+	if n > s.size {
+		s.mu.Unlock()
+		<-done
+
+		return ctx.Err()
+	}
+
+	ready := make(chan struct{})
+	element := s.waiters.PushBack(waiter{n: n, ready: ready})
+
+	s.mu.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-done:
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	select {
+	case <-ready:
+		// Acquired right after the cancellation, the weight is given back
+		s.current -= n
+	default:
+		s.waiters.Remove(element)
+	}
+
+	// The waiters behind may fit now
+	s.notify()
+
+	return ctx.Err()
+}
+
+// Release releases the weight n
+func (s *Weighted) Release(n int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.current -= n
+	if s.current < 0 {
+		panic("semaphore: released more than held")
+	}
+
+	s.notify()
+}
+
+// notify hands the available weight to the waiters in order, the first one that does not fit stops it
+func (s *Weighted) notify() {
+	for {
+		next := s.waiters.Front()
+		if next == nil {
+			return
+		}
+
+		w := next.Value.(waiter)
+		if s.size-s.current < w.n {
+			return
+		}
+
+		s.current += w.n
+		s.waiters.Remove(next)
+		close(w.ready)
+	}
+}
@@ -0,0 +1,130 @@
+package semaphore
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWeighted(t *testing.T) {
+	const size = 10
+
+	s := NewWeighted(size)
+
+	held, peak := atomic.Int64{}, atomic.Int64{}
+	wg := sync.WaitGroup{}
+
+	for i := range 100 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			n := int64(i%size + 1)
+
+			if err := s.Acquire(context.TODO(), n); err != nil {
+				t.Error(err)
+				return
+			}
+
+			current := held.Add(n)
+			for {
+				p := peak.Load()
+				if current <= p || peak.CompareAndSwap(p, current) {
+					break
+				}
+			}
+
+			time.Sleep(time.Millisecond)
+
+			held.Add(-n)
+			s.Release(n)
+		}()
+	}
+
+	wg.Wait()
+
+	if peak.Load() > size {
+		t.Fatalf("expected at most %d held, got %d", size, peak.Load())
+	}
+
+	t.Log("Success!")
+}
+
+func TestWeighted_Cancel(t *testing.T) {
+	s := NewWeighted(10)
+
+	if err := s.Acquire(context.TODO(), 8); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	// Blocked until the cancellation
+	if err := s.Acquire(ctx, 5); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected error %v, got %v", context.DeadlineExceeded, err)
+	}
+
+	// Larger than the semaphore
+	if err := s.Acquire(ctx, 11); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected error %v, got %v", context.DeadlineExceeded, err)
+	}
+
+	// The cancelled waiter does not hold any weight
+	s.Release(8)
+
+	if err := s.Acquire(context.TODO(), 10); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Log("Success!")
+}
+
+func TestWeighted_Order(t *testing.T) {
+	s := NewWeighted(10)
+
+	if err := s.Acquire(context.TODO(), 5); err != nil {
+		t.Fatal(err)
+	}
+
+	acquired := make(chan int64, 2)
+
+	go func() {
+		_ = s.Acquire(context.TODO(), 10)
+		acquired <- 10
+	}()
+
+	// The large waiter is queued first
+	time.Sleep(10 * time.Millisecond)
+
+	go func() {
+		_ = s.Acquire(context.TODO(), 1)
+		acquired <- 1
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+
+	select {
+	case n := <-acquired:
+		t.Fatalf("weight %d acquired ahead of the queue", n)
+	default:
+	}
+
+	s.Release(5)
+
+	if n := <-acquired; n != 10 {
+		t.Fatalf("expected the weight 10 first, got %d", n)
+	}
+
+	s.Release(10)
+
+	if n := <-acquired; n != 1 {
+		t.Fatalf("expected the weight 1, got %d", n)
+	}
+
+	t.Log("Success!")
+}
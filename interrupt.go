@@ -0,0 +1,91 @@
+package bread
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// deadliner is implemented by the readers whose blocked reads end at a deadline, like net.Conn and *os.File
+type deadliner interface {
+	SetReadDeadline(t time.Time) error
+}
+
+// readResult is the outcome of a read run by interruptibleReader
+type readResult struct {
+	n   int
+	err error
+}
+
+// interruptibleReader ends the blocked reads of r once the context is done, see Bread.InterruptibleRead
+type interruptibleReader struct {
+	ctx context.Context
+	r   io.Reader
+	// stop releases the resources of the reader
+	stop func() bool
+
+	// Only used for the readers without deadlines. buffer belongs to the pending read, if any
+	results chan readResult
+	buffer  []byte
+	pending bool
+}
+
+// interruptible wraps the reader so the cancellation of the context ends its blocked reads
+func (e *eater) interruptible(ctx context.Context, reader io.Reader) *interruptibleReader {
+	r := &interruptibleReader{ctx: ctx, r: reader}
+
+	if d, ok := reader.(deadliner); ok {
+		r.stop = context.AfterFunc(ctx, func() {
+			_ = d.SetReadDeadline(time.Now())
+		})
+
+		return r
+	}
+
+	r.stop = func() bool { return true }
+	r.results = make(chan readResult, 1)
+
+	return r
+}
+
+func (r *interruptibleReader) Read(p []byte) (int, error) {
+	if r.ctx.Err() != nil {
+		return 0, r.ctx.Err()
+	}
+
+	if r.results == nil {
+		n, err := r.r.Read(p)
+
+		// The deadline set on cancellation made the read fail
+		if err != nil && r.ctx.Err() != nil {
+			return n, r.ctx.Err()
+		}
+
+		return n, err
+	}
+
+	if !r.pending {
+		if cap(r.buffer) < len(p) {
+			r.buffer = make([]byte, len(p))
+		}
+
+		buffer := r.buffer[:len(p)]
+		r.pending = true
+
+		go func() {
+			n, err := r.r.Read(buffer)
+			r.results <- readResult{n: n, err: err}
+		}()
+	}
+
+	select {
+	case result := <-r.results:
+		r.pending = false
+		copy(p, r.buffer[:result.n])
+
+		return result.n, result.err
+	case <-r.ctx.Done():
+		// The buffer stays with the abandoned read
+		return 0, r.ctx.Err()
+	}
+}
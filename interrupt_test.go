@@ -0,0 +1,152 @@
+package bread
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// blockingReader returns its data and then blocks until release is closed, closing blocked once it does
+type blockingReader struct {
+	data    []byte
+	once    sync.Once
+	blocked chan struct{}
+	release chan struct{}
+}
+
+func newBlockingReader(data string) *blockingReader {
+	return &blockingReader{data: []byte(data), blocked: make(chan struct{}), release: make(chan struct{})}
+}
+
+func (r *blockingReader) Read(p []byte) (int, error) {
+	if len(r.data) > 0 {
+		n := copy(p, r.data)
+		r.data = r.data[n:]
+
+		return n, nil
+	}
+
+	r.once.Do(func() { close(r.blocked) })
+	<-r.release
+
+	return 0, io.EOF
+}
+
+// blockingConn closes blocked right before its first read, which blocks until the deadline of the connection
+type blockingConn struct {
+	net.Conn
+	once    sync.Once
+	blocked chan struct{}
+}
+
+func (c *blockingConn) Read(p []byte) (int, error) {
+	c.once.Do(func() { close(c.blocked) })
+	return c.Conn.Read(p)
+}
+
+func TestBread_InterruptibleRead(t *testing.T) {
+	cases := [...]struct {
+		// reader returns the reader to eat, a channel closed once the reader blocks and a function that ends it.
+		// The context is canceled as soon as the reader blocks.
+		reader     func() (io.Reader, <-chan struct{}, func())
+		bufferSize int
+		data       []byte
+		err        error
+	}{
+		{
+			// Deadlines
+			reader: func() (io.Reader, <-chan struct{}, func()) {
+				r, w := net.Pipe()
+				conn := &blockingConn{Conn: r, blocked: make(chan struct{})}
+
+				return conn, conn.blocked, func() {
+					_ = r.Close()
+					_ = w.Close()
+				}
+			},
+			err: context.Canceled,
+		},
+		{
+			// Helper goroutine
+			reader: func() (io.Reader, <-chan struct{}, func()) {
+				r := newBlockingReader("")
+				return r, r.blocked, func() { close(r.release) }
+			},
+			err: context.Canceled,
+		},
+		{
+			reader: func() (io.Reader, <-chan struct{}, func()) {
+				r := newBlockingReader("aaaa\nbbbb\n")
+				return r, r.blocked, func() { close(r.release) }
+			},
+			// The records are completed before the reader blocks
+			bufferSize: 4,
+			data:       []byte("aaaa\nbbbb\n"),
+			err:        context.Canceled,
+		},
+		{
+			reader: func() (io.Reader, <-chan struct{}, func()) {
+				data := memoryData(1 * MB)
+				return readFunc(bytes.NewReader(data).Read), nil, func() {}
+			},
+			bufferSize: 1 * KB,
+			data:       memoryData(1 * MB),
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			goroutines := runtime.NumGoroutine()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			bread := Bread{Workers: 4, BufferSize: max(c.bufferSize, 4), InterruptibleRead: true}
+
+			reader, blocked, end := c.reader()
+
+			go func() {
+				select {
+				case <-blocked:
+					cancel()
+				case <-ctx.Done():
+				}
+			}()
+
+			read := atomic.Int64{}
+
+			err := bread.EatBatches(ctx, reader, func(_ context.Context, batch Batch) {
+				read.Add(int64(len(batch.Data)))
+			})
+			if !errors.Is(err, c.err) {
+				t.Fatalf("expected error %v, got %v", c.err, err)
+			}
+
+			if read.Load() != int64(len(c.data)) {
+				t.Fatalf("expected %d bytes, got %d", len(c.data), read.Load())
+			}
+
+			// The abandoned read ends along with the reader
+			cancel()
+			end()
+
+			for deadline := time.Now().Add(time.Second); runtime.NumGoroutine() > goroutines; {
+				if time.Now().After(deadline) {
+					t.Fatalf("expected %d goroutines, got %d", goroutines, runtime.NumGoroutine())
+				}
+
+				time.Sleep(time.Millisecond)
+			}
+
+			t.Logf("Success! %v", err)
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package bread
+
+import (
+	"context"
+	"io"
+	"iter"
+)
+
+// Batches returns an iterator over the batches of the reader, read on the calling goroutine with the same
+// settings as Eat. The iteration ends with a pair holding the error that stopped the reading, if any.
+//
+// The batch data comes from the pool, so it is only valid until the next iteration. Breaking out of the loop
+// stops the reading.
+//
+// NOTE: the worker functions, Workers and Sink are ignored by Batches
+func (b Bread) Batches(ctx context.Context, reader io.Reader) iter.Seq2[[]byte, error] {
+	return func(yield func([]byte, error) bool) {
+		if reader == nil {
+			yield(nil, ErrNilReader)
+			return
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		stopped := false
+
+		b.Sink = nil
+
+		err := b.feed(ctx, func(_ context.Context, batch Batch) error {
+			if stopped {
+				return nil
+			}
+
+			// The reading stops before the next batch
+			if !yield(batch.Data, nil) {
+				stopped = true
+				cancel()
+			}
+
+			return nil
+		}, func(ctx context.Context, e *eater) error {
+			e.inline = true
+
+			return e.read(ctx, reader)
+		})
+		if err != nil && !stopped {
+			yield(nil, err)
+		}
+	}
+}
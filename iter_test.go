@@ -0,0 +1,106 @@
+package bread
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestBread_Batches(t *testing.T) {
+	errRead := errors.New("read failure")
+
+	cases := [...]struct {
+		bread Bread
+		data  []byte
+		err   error
+	}{
+		{
+			bread: Bread{BufferSize: KB},
+			data:  memoryData(MB),
+		},
+		{
+			bread: Bread{BufferSize: 7, Delimiter: ','},
+			data:  []byte("a,bb,ccc,dddd,eeeee,ffffff,ggggggg,hhhhhhhh"),
+		},
+		{
+			bread: Bread{BufferSize: 4, DelimiterBytes: []byte("\r\n")},
+			data:  []byte("a\r\nbb\r\nccccc\r\nd"),
+		},
+		{
+			bread: Bread{BufferSize: 8, Align: AlignBackward},
+			data:  []byte("one\ntwo\nthree\nfour\n"),
+		},
+		{
+			bread: Bread{BufferSize: 8},
+			data:  []byte("one\ntwo\n"),
+			err:   errRead,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			reader := io.Reader(bytes.NewReader(c.data))
+			if c.err != nil {
+				reader = io.MultiReader(reader, failedReader{err: c.err})
+			}
+
+			got := make([]byte, 0, len(c.data))
+
+			var err error
+
+			for data, batchErr := range c.bread.Batches(context.TODO(), reader) {
+				if batchErr != nil {
+					err = batchErr
+					continue
+				}
+
+				got = append(got, data...)
+			}
+
+			if !errors.Is(err, c.err) {
+				t.Fatalf("expected error %v, got %v", c.err, err)
+			}
+
+			if c.err == nil && !bytes.Equal(got, c.data) {
+				t.Fatalf("the batches do not reproduce the input: %q", got)
+			}
+
+			t.Logf("Success! %v", err)
+		})
+	}
+}
+
+func TestBread_BatchesBreak(t *testing.T) {
+	goroutines := runtime.NumGoroutine()
+
+	bread := Bread{BufferSize: 4, QueueDepth: 4}
+	reader := io.LimitReader(repeatReader('\n'), GB)
+
+	batches := 0
+
+	for range bread.Batches(context.TODO(), reader) {
+		if batches++; batches == 3 {
+			break
+		}
+	}
+
+	if rest, _ := io.Copy(io.Discard, io.LimitReader(reader, MB)); rest != MB {
+		t.Fatalf("expected the reading to stop, only %d bytes left", rest)
+	}
+
+	// Nothing keeps running after the loop
+	for deadline := time.Now().Add(time.Second); runtime.NumGoroutine() > goroutines; {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d goroutines, got %d", goroutines, runtime.NumGoroutine())
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	t.Log("Success!")
+}
@@ -0,0 +1,58 @@
+package bread
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+)
+
+// utf8BOM is the byte order mark that some tools write at the start of UTF-8 files
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// jsonLine is a line decoded by EatJSON, blank lines are not passed to the worker
+type jsonLine[T any] struct {
+	value T
+	blank bool
+}
+
+// EatJSON eats a JSON Lines stream, each line is decoded into a T with encoding/json and passed to the worker
+// concurrently, see EatTyped. The lines are split on the line feed whatever the Delimiter is, blank lines are
+// skipped and the last line does not need a line feed. A UTF-8 byte order mark at the start of a line is ignored,
+// like the one written at the start of the stream by some tools.
+//
+// A malformed line fails with a *JSONError wrapped in a *RecordError that holds its line number, unless
+// OnDecodeError is set.
+//
+// NOTE: Framing, SplitFunc, CSVMode and RecordSize are ignored by EatJSON
+func EatJSON[T any](ctx context.Context, b Bread, reader io.Reader, worker func(context.Context, T) error) error {
+	if worker == nil {
+		return ErrMissingWorkerFunc
+	}
+
+	b.Delimiter, b.DelimiterBytes = '\n', nil
+	b.Framing, b.SplitFunc, b.CSVMode, b.RecordSize = FramingDelimiter, nil, false, 0
+
+	unmarshal := func(data []byte) (line jsonLine[T], err error) {
+		data = bytes.TrimPrefix(data, utf8BOM)
+
+		if len(bytes.TrimSpace(data)) == 0 {
+			line.blank = true
+			return
+		}
+
+		if err = json.Unmarshal(data, &line.value); err != nil {
+			err = &JSONError{Preview: b.preview(data), Err: err}
+		}
+
+		return
+	}
+
+	return EatTyped(ctx, b, reader, unmarshal, func(ctx context.Context, line jsonLine[T]) error {
+		if line.blank {
+			return nil
+		}
+
+		return worker(ctx, line.value)
+	})
+}
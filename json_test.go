@@ -0,0 +1,146 @@
+package bread
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// jsonEvent is the record of the JSON Lines tests
+type jsonEvent struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestEatJSON(t *testing.T) {
+	cases := [...]struct {
+		data     string
+		expected []int
+		// line of the expected *JSONError, zero means no error
+		line uint64
+	}{
+		{
+			data:     "{\"id\": 1, \"name\": \"a\"}\n{\"id\": 2, \"name\": \"b\"}\n",
+			expected: []int{1, 2},
+		},
+		{
+			// Byte order mark, blank lines and no trailing line feed
+			data:     "\xef\xbb\xbf{\"id\": 1}\n\n  \r\n{\"id\": 2}\r\n\n{\"id\": 3}",
+			expected: []int{1, 2, 3},
+		},
+		{
+			data: "",
+		},
+		{
+			data: "{\"id\": 1}\n\n{\"id\": 2\n{\"id\": 3}\n",
+			line: 3,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			mu := sync.Mutex{}
+			ids := make(map[int]bool)
+
+			err := EatJSON(context.TODO(), Bread{BufferSize: 8, Workers: 4}, strings.NewReader(c.data), func(_ context.Context, e jsonEvent) error {
+				mu.Lock()
+				defer mu.Unlock()
+
+				ids[e.ID] = true
+				return nil
+			})
+
+			if c.line > 0 {
+				var recordErr *RecordError
+				if !errors.As(err, &recordErr) || recordErr.Record.Number != c.line {
+					t.Fatalf("expected the error of the line %d, got %v", c.line, err)
+				}
+
+				var jsonErr *JSONError
+				if !errors.As(err, &jsonErr) || jsonErr.Preview != `{\x22id\x22: 2` {
+					t.Fatalf("expected a *JSONError with the line preview, got %v", err)
+				}
+
+				t.Logf("Success! %v", err)
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(ids) != len(c.expected) {
+				t.Fatalf("expected %d events, got %d", len(c.expected), len(ids))
+			}
+
+			for _, id := range c.expected {
+				if !ids[id] {
+					t.Fatalf("missing event %d", id)
+				}
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+// jsonLines builds a JSON Lines stream of about size bytes
+func jsonLines(size int) []byte {
+	data := make([]byte, 0, size)
+
+	for i := 0; len(data) < size; i++ {
+		data = fmt.Appendf(data, "{\"id\": %d, \"name\": \"event %d\"}\n", i, i)
+	}
+
+	return data
+}
+
+// BenchmarkEatJSON compares EatJSON with a json.Decoder loop on a single goroutine
+func BenchmarkEatJSON(b *testing.B) {
+	data := jsonLines(16 * MB)
+
+	b.Run("EatJSON", func(b *testing.B) {
+		bread := Bread{Workers: 16, BufferSize: 64 * KB}
+
+		b.SetBytes(int64(len(data)))
+		b.ReportAllocs()
+
+		for n := 0; n < b.N; n++ {
+			count := atomic.Int64{}
+
+			err := EatJSON(context.TODO(), bread, bytes.NewReader(data), func(context.Context, jsonEvent) error {
+				count.Add(1)
+				return nil
+			})
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("json.Decoder", func(b *testing.B) {
+		b.SetBytes(int64(len(data)))
+		b.ReportAllocs()
+
+		for n := 0; n < b.N; n++ {
+			decoder := json.NewDecoder(bytes.NewReader(data))
+
+			for {
+				var e jsonEvent
+				if err := decoder.Decode(&e); err == io.EOF {
+					break
+				} else if err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,29 @@
+package bread
+
+import "context"
+
+// acquire waits until the memory of the batch is available, see MaxMemory.
+// It returns false when the context is done first.
+func (e *eater) acquire(ctx context.Context, batch *Batch) bool {
+	if e.memory == nil || batch.Size == 0 {
+		return true
+	}
+
+	// A batch larger than MaxMemory takes the whole budget
+	weight := min(int64(batch.Size), e.MaxMemory)
+
+	if e.memory.Acquire(ctx, weight) != nil {
+		return false
+	}
+
+	batch.weight = weight
+
+	return true
+}
+
+// free releases the memory of a batch that was processed or dropped
+func (e *eater) free(batch Batch) {
+	if batch.weight > 0 {
+		e.memory.Release(batch.weight)
+	}
+}
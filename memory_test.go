@@ -0,0 +1,105 @@
+package bread
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBread_MaxMemory(t *testing.T) {
+	// Many small records with a few huge ones in between
+	data := make([]byte, 0)
+
+	for i := range 2000 {
+		if i%200 == 0 {
+			data = append(data, strings.Repeat("H", 20<<10)...)
+			data = append(data, '\n')
+		}
+
+		data = append(data, "small record\n"...)
+	}
+
+	cases := [...]struct {
+		bread Bread
+		// cancel cancels the context once the workers are busy, the workers only return on cancellation
+		cancel bool
+		err    error
+	}{
+		{
+			bread: Bread{Workers: 16, BufferSize: 64, MaxMemory: 48 << 10},
+		},
+		{
+			// A huge batch takes the whole budget
+			bread: Bread{Workers: 16, BufferSize: 64, MaxMemory: 16 << 10},
+		},
+		{
+			bread: Bread{Workers: 16, BufferSize: 64, MaxMemory: 48 << 10, QueueDepth: 8},
+		},
+		{
+			bread: Bread{Workers: 16, BufferSize: 64, MaxMemory: 48 << 10, Ordered: true},
+		},
+		{
+			// The reading is blocked waiting for memory when the context is cancelled
+			bread:  Bread{Workers: 16, BufferSize: 64, MaxMemory: 1 << 10},
+			cancel: true,
+			err:    context.Canceled,
+		},
+		{
+			bread: Bread{BufferSize: 64, MaxMemory: 32},
+			err:   ErrMaxMemory,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			inFlight, peak, read := atomic.Int64{}, atomic.Int64{}, atomic.Int64{}
+
+			if c.cancel {
+				time.AfterFunc(50*time.Millisecond, cancel)
+			}
+
+			err := c.bread.EatBatches(ctx, bytes.NewReader(data), func(ctx context.Context, batch Batch) {
+				current := inFlight.Add(int64(len(batch.Data)))
+				defer inFlight.Add(-int64(len(batch.Data)))
+
+				for {
+					p := peak.Load()
+					if current <= p || peak.CompareAndSwap(p, current) {
+						break
+					}
+				}
+
+				read.Add(int64(len(batch.Data)))
+
+				if c.cancel {
+					<-ctx.Done()
+					return
+				}
+
+				time.Sleep(50 * time.Microsecond)
+			})
+			if !errors.Is(err, c.err) {
+				t.Fatalf("expected error %v, got %v", c.err, err)
+			}
+
+			// Only a batch larger than MaxMemory can exceed it, alone
+			if limit := max(c.bread.MaxMemory, 20<<10+64); peak.Load() > limit {
+				t.Fatalf("%d bytes in flight, expected at most %d", peak.Load(), limit)
+			}
+
+			if c.err == nil && read.Load() != int64(len(data)) {
+				t.Fatalf("%d bytes processed, expected %d", read.Load(), len(data))
+			}
+
+			t.Logf("Success! peak of %d bytes, %v", peak.Load(), err)
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package bread
+
+import (
+	"bufio"
+	"bytes"
+	"container/heap"
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrMissingLess indicates that Merge was called without a comparator
+var ErrMissingLess = errors.New("missing less function")
+
+// Merge writes the records of the readers to w as a single stream sorted by less, each record followed by the
+// delimiter, see DelimiterBytes. The records of each reader must already be sorted by less, records that compare
+// equal are written in the order of the readers, see MergeUnique.
+//
+// Each reader is buffered with BufferSize bytes, only records larger than that take extra memory.
+// Read errors are returned as a *ReadError with the offset in the failing reader.
+func (b Bread) Merge(ctx context.Context, w io.Writer, less func(a, b []byte) bool, readers ...io.Reader) error {
+	if less == nil {
+		return ErrMissingLess
+	}
+
+	if err := b.Validate(); err != nil {
+		return err
+	}
+
+	b = b.delimiters()
+	separator := b.separator()
+
+	m := &merger{
+		less:    less,
+		sources: make([]*mergeSource, 0, len(readers)),
+	}
+
+	for i, reader := range readers {
+		if reader == nil {
+			return ErrNilReader
+		}
+
+		source := &mergeSource{
+			reader:    bufio.NewReaderSize(reader, b.BufferSize),
+			separator: separator,
+			order:     i,
+		}
+
+		if err := source.next(); err != nil {
+			if err == io.EOF {
+				continue
+			}
+
+			return err
+		}
+
+		m.sources = append(m.sources, source)
+	}
+
+	heap.Init(m)
+
+	out := bufio.NewWriter(w)
+
+	var last []byte
+
+	for written := false; m.Len() > 0; {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		source := m.sources[0]
+
+		// Equal records are next to each other in the merged stream
+		if !b.MergeUnique || !written || less(last, source.record) || less(source.record, last) {
+			_, _ = out.Write(source.record)
+
+			if _, err := out.Write(separator); err != nil {
+				return err
+			}
+
+			if b.MergeUnique {
+				last = append(last[:0], source.record...)
+				written = true
+			}
+		}
+
+		switch err := source.next(); err {
+		case nil:
+			heap.Fix(m, 0)
+		case io.EOF:
+			heap.Pop(m)
+		default:
+			return err
+		}
+	}
+
+	return out.Flush()
+}
+
+// mergeSource holds the current record of a reader
+type mergeSource struct {
+	reader    *bufio.Reader
+	separator []byte
+	// order is the position of the reader in the arguments of Merge
+	order int
+	// record is the current record without the delimiter, it may point to the internal buffer of the reader
+	record []byte
+	// scratch holds the records larger than the buffer of the reader
+	scratch []byte
+	offset  int64
+	size    int
+}
+
+// next moves to the following record, it returns io.EOF when the reader has no more records
+func (s *mergeSource) next() error {
+	s.offset += int64(s.size)
+
+	last := s.separator[len(s.separator)-1]
+	slice, err := s.reader.ReadSlice(last)
+
+	// Records larger than the buffer, or holding the last byte of a longer separator, are accumulated in the
+	// scratch slice
+	if s.partial(slice, err) {
+		s.scratch = append(s.scratch[:0], slice...)
+
+		for s.partial(s.scratch, err) {
+			slice, err = s.reader.ReadSlice(last)
+			s.scratch = append(s.scratch, slice...)
+		}
+
+		slice = s.scratch
+	}
+
+	s.size = len(slice)
+
+	if err != nil && err != io.EOF {
+		return &ReadError{Position: s.offset + int64(len(slice)), Err: err}
+	}
+
+	if len(slice) == 0 {
+		return io.EOF
+	}
+
+	s.record = bytes.TrimSuffix(slice, s.separator)
+
+	return nil
+}
+
+// partial reports whether the record read so far still misses the rest of its bytes or its separator
+func (s *mergeSource) partial(record []byte, err error) bool {
+	return err == bufio.ErrBufferFull || err == nil && !bytes.HasSuffix(record, s.separator)
+}
+
+// merger is a heap of sources ordered by their current record
+type merger struct {
+	less    func(a, b []byte) bool
+	sources []*mergeSource
+}
+
+func (m *merger) Len() int {
+	return len(m.sources)
+}
+
+func (m *merger) Less(i, j int) bool {
+	a, b := m.sources[i], m.sources[j]
+
+	if m.less(a.record, b.record) {
+		return true
+	}
+
+	if m.less(b.record, a.record) {
+		return false
+	}
+
+	return a.order < b.order
+}
+
+func (m *merger) Swap(i, j int) {
+	m.sources[i], m.sources[j] = m.sources[j], m.sources[i]
+}
+
+func (m *merger) Push(x any) {
+	m.sources = append(m.sources, x.(*mergeSource))
+}
+
+func (m *merger) Pop() any {
+	last := m.sources[len(m.sources)-1]
+	m.sources = m.sources[:len(m.sources)-1]
+
+	return last
+}
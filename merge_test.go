@@ -0,0 +1,158 @@
+package bread
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestBread_Merge(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+
+	// sortedRun returns n random sorted records, long records exceed the buffer of the readers
+	sortedRun := func(n int) []string {
+		run := make([]string, n)
+
+		for i := range run {
+			run[i] = strconv.Itoa(random.Intn(100)) + strings.Repeat("z", random.Intn(3)*10)
+		}
+
+		sort.Strings(run)
+		return run
+	}
+
+	less := func(a, b []byte) bool {
+		return bytes.Compare(a, b) < 0
+	}
+
+	cases := [...]struct {
+		runs   [][]string
+		unique bool
+		// trim removes the trailing delimiter of each input
+		trim bool
+		// delimiter defaults to a line feed
+		delimiter string
+	}{
+		{
+			runs: [][]string{sortedRun(100), sortedRun(50), sortedRun(200)},
+		},
+		{
+			runs:   [][]string{sortedRun(100), sortedRun(50), sortedRun(200)},
+			unique: true,
+		},
+		{
+			runs: [][]string{sortedRun(10), {}, sortedRun(1), sortedRun(30)},
+			trim: true,
+		},
+		{
+			runs: [][]string{sortedRun(300)},
+		},
+		{
+			runs:      [][]string{sortedRun(100), sortedRun(50), sortedRun(200)},
+			delimiter: "\r\n",
+		},
+		{
+			runs:      [][]string{sortedRun(10), {}, sortedRun(1), sortedRun(30)},
+			trim:      true,
+			delimiter: "\r\n",
+		},
+		{
+			runs:      [][]string{sortedRun(100), sortedRun(50)},
+			unique:    true,
+			delimiter: "\x00",
+		},
+		{},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			if c.delimiter == "" {
+				c.delimiter = "\n"
+			}
+
+			readers := make([]io.Reader, len(c.runs))
+			expected := make([]string, 0)
+
+			for j, run := range c.runs {
+				data := strings.Join(run, c.delimiter) + c.delimiter
+				if c.trim || len(run) == 0 {
+					data = strings.TrimSuffix(data, c.delimiter)
+				}
+
+				readers[j] = iotest.HalfReader(strings.NewReader(data))
+				expected = append(expected, run...)
+			}
+
+			sort.Strings(expected)
+
+			if c.unique {
+				unique := expected[:0]
+
+				for j, record := range expected {
+					if j == 0 || record != expected[j-1] {
+						unique = append(unique, record)
+					}
+				}
+
+				expected = unique
+			}
+
+			output := bytes.Buffer{}
+
+			bread := Bread{BufferSize: 16, MergeUnique: c.unique, DelimiterBytes: []byte(c.delimiter)}
+
+			if err := bread.Merge(context.TODO(), &output, less, readers...); err != nil {
+				t.Fatal(err)
+			}
+
+			want := ""
+			if len(expected) > 0 {
+				want = strings.Join(expected, c.delimiter) + c.delimiter
+			}
+
+			if output.String() != want {
+				t.Fatalf("expected %q, got %q", want, output.String())
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+func TestBread_Merge_Validate(t *testing.T) {
+	less := func(a, b []byte) bool {
+		return bytes.Compare(a, b) < 0
+	}
+
+	cases := [...]struct {
+		bread Bread
+		err   error
+	}{
+		{
+			bread: Bread{},
+			err:   ErrMissingBufferSize,
+		},
+		{
+			bread: Bread{BufferSize: -16},
+			err:   ErrNegativeSize,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			err := c.bread.Merge(context.TODO(), io.Discard, less, strings.NewReader("a\n"))
+			if !errors.Is(err, c.err) {
+				t.Fatalf("expected error %v, got %v", c.err, err)
+			}
+
+			t.Logf("Success! %v", err)
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package bread
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/textproto"
+)
+
+// Part describes a part of a multipart body
+type Part struct {
+	// Index is the position of the part in the body, starting at 0
+	Index int
+	// Name is the form name of the part, taken from its Content-Disposition header
+	Name string
+	// FileName is the file name of the part, taken from its Content-Disposition header
+	FileName string
+	// Header holds the headers of the part
+	Header textproto.MIMEHeader
+}
+
+// EatMultipart eats the body of each part of a multipart stream delimited by boundary,
+// the preamble and the epilogue of the stream are discarded.
+//
+// Each batch is passed to the worker along with the part it belongs to, batch offsets are relative to the part body.
+// The part bodies are passed as they are, no Content-Transfer-Encoding is decoded.
+// The worker budget defined by Workers is shared by all the parts.
+//
+// NOTE: WorkerFunc is ignored by EatMultipart
+func (b Bread) EatMultipart(ctx context.Context, reader io.Reader, boundary string, worker func(context.Context, Part, Batch)) (err error) {
+	switch {
+	case reader == nil:
+		return ErrNilReader
+	case boundary == "":
+		return ErrMissingBoundary
+	case worker == nil:
+		return ErrMissingWorkerFunc
+	}
+
+	if closer, ok := reader.(io.Closer); ok && b.CloseReader {
+		defer func() {
+			err = errors.Join(err, closer.Close())
+		}()
+	}
+
+	// The parts are closed right after they are eaten
+	b.CloseReader = false
+
+	if err := b.Validate(); err != nil {
+		return err
+	}
+
+	if b.Workers == 0 {
+		b.Workers = DefaultWorkers
+	}
+
+	if b.slots == nil {
+		b.slots = make(chan struct{}, b.Workers)
+	}
+
+	body := multipart.NewReader(reader, boundary)
+
+	for index := 0; ; index++ {
+		part, err := body.NextRawPart()
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return &PartError{Index: index, Err: err}
+		}
+
+		meta := Part{
+			Index:    index,
+			Name:     part.FormName(),
+			FileName: part.FileName(),
+			Header:   part.Header,
+		}
+
+		err = b.eat(ctx, part, func(ctx context.Context, batch Batch) error {
+			worker(ctx, meta, batch)
+			return nil
+		})
+		_ = part.Close()
+
+		if err != nil {
+			return &PartError{Index: index, Name: meta.Name, Err: err}
+		}
+
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+	}
+}
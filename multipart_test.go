@@ -0,0 +1,178 @@
+package bread
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"mime/multipart"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"testing/iotest"
+)
+
+func TestBread_EatMultipart(t *testing.T) {
+	const boundary = "bread-boundary"
+
+	// body builds a multipart body with the given parts
+	body := func(preamble, epilogue string, parts ...string) string {
+		buffer := bytes.NewBufferString(preamble)
+
+		writer := multipart.NewWriter(buffer)
+		_ = writer.SetBoundary(boundary)
+
+		for i, part := range parts {
+			w, _ := writer.CreateFormFile("file"+strconv.Itoa(i), "file"+strconv.Itoa(i)+".ndjson")
+			_, _ = io.WriteString(w, part)
+		}
+
+		_ = writer.Close()
+
+		return buffer.String() + epilogue
+	}
+
+	longPart := strings.Repeat("{\"key\":\"value\"}\n", 1_000)
+
+	cases := [...]struct {
+		data   string
+		reader func(io.Reader) io.Reader
+		err    bool
+	}{
+		{
+			data: body("", "", "1\n2\n3\n", "4\n5\n"),
+		},
+		{
+			// Preamble and epilogue junk
+			data: body("preamble junk\r\n--not-the-boundary\r\n", "\r\nepilogue junk\r\n", "1\n2\n", "3"),
+		},
+		{
+			// Boundaries split across reads
+			data:   body("", "", longPart, longPart, "\r\n--"+boundary+"-not-a-boundary\n"),
+			reader: iotest.OneByteReader,
+		},
+		{
+			data: body("", "", "", "1\n"),
+		},
+		{
+			data: body("only a preamble", ""),
+		},
+		{
+			// Missing closing boundary
+			data: strings.TrimSuffix(body("", "", "1\n2\n"), "--\r\n"),
+			err:  true,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			// Expected parts, according to mime/multipart
+			expected := make([]string, 0)
+			expectedErr := error(nil)
+
+			reader := multipart.NewReader(strings.NewReader(c.data), boundary)
+
+			for {
+				part, err := reader.NextRawPart()
+				if err != nil {
+					if err != io.EOF {
+						expectedErr = err
+					}
+
+					break
+				}
+
+				data, err := io.ReadAll(part)
+				if err != nil {
+					expectedErr = err
+					break
+				}
+
+				expected = append(expected, string(data))
+			}
+
+			if (expectedErr != nil) != c.err {
+				t.Fatalf("unexpected mime/multipart result: %v", expectedErr)
+			}
+
+			var input io.Reader = strings.NewReader(c.data)
+			if c.reader != nil {
+				input = c.reader(input)
+			}
+
+			mu := sync.Mutex{}
+			parts := make(map[int]Part)
+			batches := make(map[int][]Batch)
+
+			bread := Bread{
+				BufferSize: 16,
+				Workers:    4,
+			}
+
+			err := bread.EatMultipart(context.TODO(), input, boundary, func(_ context.Context, part Part, batch Batch) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				parts[part.Index] = part
+				batches[part.Index] = append(batches[part.Index], Batch{BatchMeta: batch.BatchMeta, Data: bytes.Clone(batch.Data)})
+			})
+
+			if c.err {
+				var partErr *PartError
+				if !errors.As(err, &partErr) {
+					t.Fatalf("expected a *PartError, got %v", err)
+				}
+
+				t.Log("Success!")
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for index, data := range expected {
+				sort.Slice(batches[index], func(i, j int) bool {
+					return batches[index][i].Index < batches[index][j].Index
+				})
+
+				got := make([]byte, 0, len(data))
+
+				for _, batch := range batches[index] {
+					if batch.Offset != int64(len(got)) {
+						t.Fatalf("part %d: expected offset %d, got %d", index, len(got), batch.Offset)
+					}
+
+					got = append(got, batch.Data...)
+				}
+
+				if string(got) != data {
+					t.Fatalf("part %d: expected %q, got %q", index, data, got)
+				}
+
+				if data == "" {
+					continue
+				}
+
+				part := parts[index]
+				name := "file" + strconv.Itoa(index)
+
+				if part.Name != name || part.FileName != name+".ndjson" {
+					t.Fatalf("part %d: unexpected name %q and file name %q", index, part.Name, part.FileName)
+				}
+
+				if part.Header.Get("Content-Type") != "application/octet-stream" {
+					t.Fatalf("part %d: unexpected headers %v", index, part.Header)
+				}
+			}
+
+			if len(batches) > len(expected) {
+				t.Fatalf("expected %d parts, got %d", len(expected), len(batches))
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
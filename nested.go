@@ -0,0 +1,88 @@
+package bread
+
+import (
+	"bytes"
+	"context"
+	"io"
+)
+
+// Record describes the record of the parent stream that a child batch belongs to, see EatNested
+type Record struct {
+	// Number is the position of the record in the parent stream, starting from 1
+	Number uint64
+	// Offset is the position in the parent stream of the first byte of the record
+	Offset int64
+	// Parent describes the parent batch holding the record
+	Parent BatchMeta
+}
+
+// EatNested splits each record of the reader in batches using the child settings, so the sub-fields of a record
+// are processed like a stream of their own. The delimiter that ends the parent record is not passed to the child.
+//
+// Each child batch is passed to the worker along with the record it belongs to, child batch offsets are relative
+// to the record. The child batches run on the goroutine of the parent worker that holds the record, so the
+// concurrency is bounded by the parent Workers and the child batches are slices of the parent buffer.
+//
+// Only the FramingDelimiter batches hold several records, with any other Framing each parent batch is a record.
+//
+// NOTE: WorkerFunc and Sink are ignored by EatNested, as well as the child Tracker, WorkerInit and WorkerClose
+func (b Bread) EatNested(ctx context.Context, reader io.Reader, child Bread, worker func(context.Context, Record, Batch)) error {
+	if worker == nil {
+		return ErrMissingWorkerFunc
+	}
+
+	if err := child.Validate(); err != nil {
+		return err
+	}
+
+	b = b.delimiters()
+	separator := b.separator()
+
+	// The records are eaten concurrently, the progress is only tracked for the parent stream
+	child.Tracker = nil
+	child.Sink = nil
+	child.WorkerInit, child.WorkerClose = nil, nil
+
+	b.Sink = nil
+
+	return b.eat(ctx, reader, func(ctx context.Context, batch Batch) error {
+		data, offset := batch.Data, batch.Offset
+
+		for number := batch.FirstRecord + 1; len(data) > 0 && ctx.Err() == nil; number++ {
+			end := len(data)
+
+			if b.Framing == FramingDelimiter {
+				if i := bytes.Index(data, separator); i >= 0 {
+					end = i + len(separator)
+				}
+			}
+
+			record := Record{Number: number, Offset: offset, Parent: batch.BatchMeta}
+
+			err := child.eatRecord(ctx, record, bytes.TrimSuffix(data[:end], separator), worker)
+			if err != nil {
+				return &RecordError{Record: record, Err: err}
+			}
+
+			data, offset = data[end:], offset+int64(end)
+		}
+
+		return nil
+	})
+}
+
+// eatRecord eats the record on the calling goroutine
+func (b Bread) eatRecord(ctx context.Context, record Record, data []byte, worker func(context.Context, Record, Batch)) error {
+	return b.feed(ctx, func(ctx context.Context, batch Batch) error {
+		worker(ctx, record, batch)
+		return nil
+	}, func(ctx context.Context, e *eater) error {
+		if e.sliceable() {
+			return e.readInline(ctx, data)
+		}
+
+		e.inline = true
+
+		return e.read(ctx, bytes.NewReader(data))
+	})
+}
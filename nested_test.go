@@ -0,0 +1,144 @@
+package bread
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBread_EatNested(t *testing.T) {
+	cases := [...]struct {
+		parent Bread
+		child  Bread
+		data   string
+		err    error
+	}{
+		{
+			parent: Bread{Workers: 2, BufferSize: 4},
+			child:  Bread{Delimiter: ',', BufferSize: 1},
+			data:   "a,b,c\nd,ee,f\n\ng,h",
+		},
+		{
+			parent: Bread{Workers: 4, BufferSize: 64},
+			child:  Bread{Delimiter: ',', BufferSize: 8, MaxBatchSize: 8},
+			data:   strings.Repeat("id,name,description of the record,0\n", 200),
+		},
+		{
+			parent: Bread{Workers: 1, BufferSize: 16, Framing: FramingDocuments},
+			child:  Bread{BufferSize: 4},
+			data:   "---\nkey: value\nother: value\n---\nlast: value\n",
+		},
+		{
+			parent: Bread{Workers: 2, BufferSize: 8},
+			child:  Bread{Delimiter: ',', BufferSize: 2, Align: AlignBackward},
+			data:   "a,b\nc,too long\n",
+			err:    ErrRecordTooLarge,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			mu := sync.Mutex{}
+			records := make(map[int64][]Batch)
+
+			running, peak := atomic.Int32{}, atomic.Int32{}
+
+			err := c.parent.EatNested(context.TODO(), strings.NewReader(c.data), c.child, func(_ context.Context, record Record, batch Batch) {
+				peak.Store(max(peak.Load(), running.Add(1)))
+				defer running.Add(-1)
+
+				mu.Lock()
+				defer mu.Unlock()
+
+				records[record.Offset] = append(records[record.Offset], Batch{BatchMeta: batch.BatchMeta, Data: bytes.Clone(batch.Data)})
+			})
+
+			if c.err != nil {
+				var recordErr *RecordError
+
+				if !errors.Is(err, c.err) || !errors.As(err, &recordErr) {
+					t.Fatalf("expected a *RecordError wrapping %v, got %v", c.err, err)
+				}
+
+				t.Logf("Success! %v", err)
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// The child batches never run on more goroutines than the parent workers
+			if peak.Load() > int32(max(c.parent.Workers, 1)) {
+				t.Fatalf("expected at most %d concurrent workers, got %d", c.parent.Workers, peak.Load())
+			}
+
+			for offset, batches := range records {
+				sort.Slice(batches, func(i, j int) bool {
+					return batches[i].Index < batches[j].Index
+				})
+
+				got := make([]byte, 0)
+
+				for _, batch := range batches {
+					if batch.Offset != int64(len(got)) {
+						t.Fatalf("record at %d: batch %d starts at %d, expected %d", offset, batch.Index, batch.Offset, len(got))
+					}
+
+					got = append(got, batch.Data...)
+				}
+
+				if !strings.HasPrefix(c.data[offset:], string(got)) {
+					t.Fatalf("record at %d: %q is not the record in the parent stream", offset, got)
+				}
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+func TestBread_EatNestedCancel(t *testing.T) {
+	goroutines := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	parent := Bread{Workers: 4, BufferSize: 64}
+	child := Bread{Delimiter: ',', BufferSize: 4}
+
+	data := strings.Repeat("a,b,c,d,e,f,g,h\n", 10_000)
+	calls := atomic.Int64{}
+
+	err := parent.EatNested(ctx, strings.NewReader(data), child, func(context.Context, Record, Batch) {
+		if calls.Add(1) == 100 {
+			cancel()
+		}
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected %v, got %v", context.Canceled, err)
+	}
+
+	if calls.Load() >= int64(strings.Count(data, ",")) {
+		t.Fatal("the cancellation did not stop the child batches")
+	}
+
+	// The parent workers exit right after Eat returns
+	for deadline := time.Now().Add(time.Second); runtime.NumGoroutine() > goroutines; {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d goroutines, got %d", goroutines, runtime.NumGoroutine())
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	t.Log("Success!")
+}
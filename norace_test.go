@@ -0,0 +1,6 @@
+//go:build !race
+
+package bread
+
+// raceEnabled indicates that the race detector is on, it makes sync.Pool drop buffers at random
+const raceEnabled = false
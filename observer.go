@@ -0,0 +1,127 @@
+package bread
+
+import (
+	"context"
+	"time"
+)
+
+// Observer receives the events of an Eat call, see Bread.Observer.
+//
+// Embed NoopObserver to implement only the events of interest. The batch events are called from the worker
+// goroutines, so the implementations must be safe for concurrent use.
+type Observer interface {
+	// OnStart is called exactly once before the first read, with the effective settings. With DetectDelimiter
+	// it is called once the delimiter was detected.
+	OnStart(ctx context.Context, effective Config)
+	// OnBatchStart is called before the worker processes a batch, once no matter how many retries it takes.
+	// queueAge is the time the batch waited since it was read, in the queue and for a free worker
+	OnBatchStart(ctx context.Context, meta BatchMeta, queueAge time.Duration)
+	// OnBatchEnd is called after the last attempt to process a batch, with the error it failed with
+	OnBatchEnd(ctx context.Context, meta BatchMeta, err error)
+	// OnBackpressure is called when a batch waited for a free worker, with the time it waited.
+	// It is not called for the batches that wait in the queue, see QueueDepth
+	OnBackpressure(ctx context.Context, meta BatchMeta, wait time.Duration)
+	// OnEpoch is called at the end of each pass over the reader, only when Epochs is greater than 1
+	OnEpoch(ctx context.Context, epoch uint32, stats EpochStats)
+	// OnComplete is called exactly once when the processing ends, after every worker finished,
+	// with the final statistics and the error that Eat is going to return
+	OnComplete(ctx context.Context, stats Stats, err error)
+}
+
+// NoopObserver is an Observer that ignores every event, it is meant to be embedded
+type NoopObserver struct{}
+
+func (NoopObserver) OnStart(context.Context, Config)                          {}
+func (NoopObserver) OnBatchStart(context.Context, BatchMeta, time.Duration)   {}
+func (NoopObserver) OnBatchEnd(context.Context, BatchMeta, error)             {}
+func (NoopObserver) OnBackpressure(context.Context, BatchMeta, time.Duration) {}
+func (NoopObserver) OnEpoch(context.Context, uint32, EpochStats)              {}
+func (NoopObserver) OnComplete(context.Context, Stats, error)                 {}
+
+// observer returns the Observer that receives the events, along with the hook fields of the Bread
+func (b Bread) observer() Observer {
+	hooks := hookObserver{
+		onStart:    b.OnStart,
+		onBatchEnd: b.OnBatchEnd,
+		onEpoch:    b.OnEpoch,
+		onComplete: b.OnComplete,
+	}
+
+	if b.Observer == nil {
+		return hooks
+	}
+
+	return observers{b.Observer, hooks}
+}
+
+// hookObserver adapts the hook fields of the Bread to Observer
+type hookObserver struct {
+	NoopObserver
+	onStart    func(context.Context, Config)
+	onBatchEnd func(context.Context, BatchMeta, error)
+	onEpoch    func(context.Context, uint32, EpochStats)
+	onComplete func(context.Context, Stats, error)
+}
+
+func (h hookObserver) OnStart(ctx context.Context, effective Config) {
+	if h.onStart != nil {
+		h.onStart(ctx, effective)
+	}
+}
+
+func (h hookObserver) OnBatchEnd(ctx context.Context, meta BatchMeta, err error) {
+	if h.onBatchEnd != nil {
+		h.onBatchEnd(ctx, meta, err)
+	}
+}
+
+func (h hookObserver) OnEpoch(ctx context.Context, epoch uint32, stats EpochStats) {
+	if h.onEpoch != nil {
+		h.onEpoch(ctx, epoch, stats)
+	}
+}
+
+func (h hookObserver) OnComplete(ctx context.Context, stats Stats, err error) {
+	if h.onComplete != nil {
+		h.onComplete(ctx, stats, err)
+	}
+}
+
+// observers passes every event to each Observer in order
+type observers []Observer
+
+func (o observers) OnStart(ctx context.Context, effective Config) {
+	for _, observer := range o {
+		observer.OnStart(ctx, effective)
+	}
+}
+
+func (o observers) OnBatchStart(ctx context.Context, meta BatchMeta, queueAge time.Duration) {
+	for _, observer := range o {
+		observer.OnBatchStart(ctx, meta, queueAge)
+	}
+}
+
+func (o observers) OnBatchEnd(ctx context.Context, meta BatchMeta, err error) {
+	for _, observer := range o {
+		observer.OnBatchEnd(ctx, meta, err)
+	}
+}
+
+func (o observers) OnBackpressure(ctx context.Context, meta BatchMeta, wait time.Duration) {
+	for _, observer := range o {
+		observer.OnBackpressure(ctx, meta, wait)
+	}
+}
+
+func (o observers) OnEpoch(ctx context.Context, epoch uint32, stats EpochStats) {
+	for _, observer := range o {
+		observer.OnEpoch(ctx, epoch, stats)
+	}
+}
+
+func (o observers) OnComplete(ctx context.Context, stats Stats, err error) {
+	for _, observer := range o {
+		observer.OnComplete(ctx, stats, err)
+	}
+}
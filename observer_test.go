@@ -0,0 +1,365 @@
+package bread
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// countingObserver counts the events it receives
+type countingObserver struct {
+	mu     sync.Mutex
+	events map[string]int
+	// failed counts the OnBatchEnd events with an error
+	failed int
+}
+
+func (o *countingObserver) count(event string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.events == nil {
+		o.events = make(map[string]int)
+	}
+
+	o.events[event]++
+}
+
+func (o *countingObserver) OnStart(context.Context, Config) { o.count("start") }
+func (o *countingObserver) OnBatchStart(context.Context, BatchMeta, time.Duration) {
+	o.count("batch start")
+}
+func (o *countingObserver) OnEpoch(context.Context, uint32, EpochStats) {
+	o.count("epoch")
+}
+func (o *countingObserver) OnComplete(context.Context, Stats, error) { o.count("complete") }
+
+func (o *countingObserver) OnBatchEnd(_ context.Context, _ BatchMeta, err error) {
+	o.count("batch end")
+
+	if err != nil {
+		o.mu.Lock()
+		o.failed++
+		o.mu.Unlock()
+	}
+}
+
+func (o *countingObserver) OnBackpressure(context.Context, BatchMeta, time.Duration) {
+	o.count("backpressure")
+}
+
+func TestBread_Observer(t *testing.T) {
+	errSink := errors.New("sink failure")
+
+	cases := [...]struct {
+		bread Bread
+		data  string
+		// fail is the index of the batch that fails
+		fail     int
+		expected map[string]int
+		failed   int
+	}{
+		{
+			bread:    Bread{},
+			data:     "a\nb\nc\n",
+			fail:     -1,
+			expected: map[string]int{"start": 1, "batch start": 3, "batch end": 3, "complete": 1},
+		},
+		{
+			bread:    Bread{Epochs: 2},
+			data:     "a\nb\nc\n",
+			fail:     -1,
+			expected: map[string]int{"start": 1, "batch start": 6, "batch end": 6, "epoch": 2, "complete": 1},
+		},
+		{
+			// Retries do not repeat the batch events
+			bread:    Bread{BatchRetries: 2},
+			data:     "a\n",
+			fail:     0,
+			expected: map[string]int{"start": 1, "batch start": 1, "batch end": 1, "complete": 1},
+			failed:   1,
+		},
+		{
+			bread:    Bread{SyncThreshold: 1 * KB},
+			data:     "a\nb\n",
+			fail:     -1,
+			expected: map[string]int{"start": 1, "batch start": 2, "batch end": 2, "complete": 1},
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			observer := &countingObserver{}
+			hooks := 0
+
+			c.bread.BufferSize = 1
+			c.bread.Observer = observer
+			c.bread.OnStart = func(context.Context, Config) { hooks++ }
+			c.bread.OnComplete = func(context.Context, Stats, error) { hooks++ }
+			c.bread.Sink = sinkFunc(func(_ context.Context, meta BatchMeta, _ []byte) error {
+				if int(meta.Index) == c.fail {
+					return errSink
+				}
+
+				return nil
+			})
+
+			_ = c.bread.Eat(context.TODO(), strings.NewReader(c.data))
+
+			// Backpressure depends on the timing of the workers
+			delete(observer.events, "backpressure")
+
+			if len(observer.events) != len(c.expected) {
+				t.Fatalf("expected events %v, got %v", c.expected, observer.events)
+			}
+
+			for event, count := range c.expected {
+				if observer.events[event] != count {
+					t.Fatalf("expected events %v, got %v", c.expected, observer.events)
+				}
+			}
+
+			if observer.failed != c.failed {
+				t.Fatalf("expected %d failed batches, got %d", c.failed, observer.failed)
+			}
+
+			// The hook fields are still called
+			if hooks != 2 {
+				t.Fatalf("expected 2 hook calls, got %d", hooks)
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+func TestBread_Observer_Backpressure(t *testing.T) {
+	observer := &countingObserver{}
+
+	bread := Bread{
+		BufferSize: 1,
+		Observer:   observer,
+		WorkerFunc: func(context.Context, *[]byte) {
+			time.Sleep(time.Millisecond)
+		},
+	}
+
+	if err := bread.Eat(context.TODO(), strings.NewReader("a\nb\nc\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	// The single worker is busy when the second and third batches are read
+	if count := observer.events["backpressure"]; count != 2 {
+		t.Fatalf("expected 2 backpressure events, got %d", count)
+	}
+
+	t.Log("Success!")
+}
+
+// sinkFunc is a Sink made of its Write function
+type sinkFunc func(ctx context.Context, meta BatchMeta, data []byte) error
+
+func (f sinkFunc) Write(ctx context.Context, meta BatchMeta, data []byte) error {
+	return f(ctx, meta, data)
+}
+
+func (sinkFunc) Flush(context.Context) error {
+	return nil
+}
+
+// queueAgeObserver keeps the queue age of each batch
+type queueAgeObserver struct {
+	NoopObserver
+	mu   sync.Mutex
+	ages map[uint64]time.Duration
+}
+
+func (o *queueAgeObserver) OnBatchStart(_ context.Context, meta BatchMeta, queueAge time.Duration) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.ages[meta.Index] = queueAge
+}
+
+// TestBread_QueueAge saturates a single worker, the batches wait longer and longer while the worker
+// takes the same time for each one
+func TestBread_QueueAge(t *testing.T) {
+	const (
+		batches = 10
+		work    = 2 * time.Millisecond
+	)
+
+	observer := &queueAgeObserver{ages: make(map[uint64]time.Duration)}
+
+	var stats Stats
+
+	bread := Bread{
+		BufferSize: 1,
+		QueueDepth: batches,
+		Observer:   observer,
+		WorkerFunc: func(context.Context, *[]byte) {
+			time.Sleep(work)
+		},
+		OnComplete: func(_ context.Context, s Stats, _ error) {
+			stats = s
+		},
+	}
+
+	if err := bread.Eat(context.TODO(), strings.NewReader(strings.Repeat("a\n", batches))); err != nil {
+		t.Fatal(err)
+	}
+
+	// Every batch is read at once, so the last one waits for all the others
+	if last := observer.ages[batches-1]; last < (batches-1)*work {
+		t.Fatalf("expected the last batch to wait at least %s, got %s", (batches-1)*work, last)
+	}
+
+	if first := observer.ages[0]; first >= work {
+		t.Fatalf("expected the first batch to wait less than %s, got %s", work, first)
+	}
+
+	if stats.QueueAge.Max != observer.ages[batches-1] || stats.QueueAge.Mean <= observer.ages[0] || stats.QueueAge.P95 > stats.QueueAge.Max {
+		t.Fatalf("unexpected queue age stats %+v", stats.QueueAge)
+	}
+
+	t.Log("Success!")
+}
+
+// spanKey carries the span of a batch in the context, like a tracer would
+type spanKey struct{}
+
+// span is the trace of a single batch
+type span struct {
+	index uint64
+	ended int
+	err   error
+}
+
+// tracer is a fake tracer that starts a span for each batch
+type tracer struct {
+	mu    sync.Mutex
+	spans []*span
+}
+
+func (t *tracer) start(ctx context.Context, meta BatchMeta) context.Context {
+	s := &span{index: meta.Index}
+
+	t.mu.Lock()
+	t.spans = append(t.spans, s)
+	t.mu.Unlock()
+
+	return context.WithValue(ctx, spanKey{}, s)
+}
+
+func (t *tracer) end(ctx context.Context, meta BatchMeta, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s, ok := ctx.Value(spanKey{}).(*span)
+	if !ok || s.index != meta.Index {
+		panic("the span of the batch was not propagated")
+	}
+
+	s.ended++
+	s.err = err
+}
+
+func TestBread_OnBatchStart(t *testing.T) {
+	errWorker := errors.New("worker failure")
+
+	cases := [...]struct {
+		bread Bread
+		data  string
+		// fail is the index of the batch that fails
+		fail uint64
+		// cancel cancels the context once the batch with this index started
+		cancel uint64
+	}{
+		{
+			bread:  Bread{Workers: 4},
+			data:   strings.Repeat("a\n", 100),
+			fail:   math.MaxUint64,
+			cancel: math.MaxUint64,
+		},
+		{
+			bread:  Bread{Workers: 4, BatchRetries: 2},
+			data:   strings.Repeat("a\n", 100),
+			fail:   10,
+			cancel: math.MaxUint64,
+		},
+		{
+			bread:  Bread{Workers: 4},
+			data:   strings.Repeat("a\n", 100),
+			fail:   math.MaxUint64,
+			cancel: 10,
+		},
+		{
+			bread:  Bread{SyncThreshold: 1 * KB},
+			data:   strings.Repeat("a\n", 100),
+			fail:   math.MaxUint64,
+			cancel: math.MaxUint64,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			tr := &tracer{}
+
+			c.bread.BufferSize = 1
+			c.bread.OnBatchStart = tr.start
+			c.bread.OnBatchEnd = tr.end
+
+			c.bread.WorkerErrFunc = func(ctx context.Context, _ *[]byte) error {
+				s, ok := ctx.Value(spanKey{}).(*span)
+				if !ok {
+					return errors.New("the worker did not receive the context of OnBatchStart")
+				}
+
+				if s.index == c.cancel {
+					cancel()
+				}
+
+				if s.index == c.fail {
+					return errWorker
+				}
+
+				return nil
+			}
+
+			err := c.bread.Eat(ctx, strings.NewReader(c.data))
+
+			switch {
+			case c.fail != math.MaxUint64 && !errors.Is(err, errWorker):
+				t.Fatalf("expected error %v, got %v", errWorker, err)
+			case c.cancel != math.MaxUint64 && !errors.Is(err, context.Canceled):
+				t.Fatalf("expected error %v, got %v", context.Canceled, err)
+			case c.fail == math.MaxUint64 && c.cancel == math.MaxUint64 && err != nil:
+				t.Fatal(err)
+			}
+
+			if len(tr.spans) == 0 {
+				t.Fatal("no span was started")
+			}
+
+			for _, s := range tr.spans {
+				if s.ended != 1 {
+					t.Fatalf("the span of batch %d ended %d times", s.index, s.ended)
+				}
+
+				if (s.index == c.fail) != (s.err != nil && errors.Is(s.err, errWorker)) {
+					t.Fatalf("unexpected error %v for the span of batch %d", s.err, s.index)
+				}
+			}
+
+			t.Logf("Success! %d spans, %v", len(tr.spans), err)
+		})
+	}
+}
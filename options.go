@@ -0,0 +1,63 @@
+package bread
+
+import "context"
+
+// Option sets up a Bread built by New, it is the same as setting the field it names
+type Option func(*Bread)
+
+// WithWorkers sets the number of concurrent workers, see Bread.Workers
+func WithWorkers(n int) Option {
+	return func(b *Bread) {
+		b.Workers = n
+	}
+}
+
+// WithBufferSize sets the size of the batches, see Bread.BufferSize
+func WithBufferSize(n int) Option {
+	return func(b *Bread) {
+		b.BufferSize = n
+	}
+}
+
+// WithBufferSeed sets the number of buffers reserved in advance, see Bread.BufferSeed
+func WithBufferSeed(n int) Option {
+	return func(b *Bread) {
+		b.BufferSeed = n
+	}
+}
+
+// WithQueueDepth sets the number of batches read in advance, see Bread.QueueDepth
+func WithQueueDepth(n uint32) Option {
+	return func(b *Bread) {
+		b.QueueDepth = n
+	}
+}
+
+// WithDelimiter sets the byte that ends the records, it undoes WithNoDelimiter. See Bread.Delimiter
+func WithDelimiter(delimiter byte) Option {
+	return func(b *Bread) {
+		b.Delimiter, b.DelimiterBytes, b.RecordSize, b.Framing = 0, nil, 0, FramingDelimiter
+
+		if delimiter == 0 {
+			b.DelimiterBytes = DelimiterNUL
+			return
+		}
+
+		b.Delimiter = delimiter
+	}
+}
+
+// WithNoDelimiter cuts the stream in batches of BufferSize bytes no matter their content, without records.
+// See FramingNone
+func WithNoDelimiter() Option {
+	return func(b *Bread) {
+		b.Delimiter, b.DelimiterBytes, b.RecordSize, b.Framing = 0, nil, 0, FramingNone
+	}
+}
+
+// WithWorkerFunc sets the function that processes the batches, see Bread.WorkerFunc
+func WithWorkerFunc(worker func(context.Context, *[]byte)) Option {
+	return func(b *Bread) {
+		b.WorkerFunc = worker
+	}
+}
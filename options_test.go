@@ -0,0 +1,218 @@
+package bread
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestNew_Options(t *testing.T) {
+	data := []byte("aaa\nbbb\x00ccc\nddd\x00")
+
+	cases := [...]struct {
+		opts []Option
+		// expected holds the batches
+		expected []string
+		err      error
+	}{
+		{
+			opts:     []Option{WithBufferSize(2), WithWorkers(1)},
+			expected: []string{"aaa\n", "bbb\x00ccc\n", "ddd\x00"},
+		},
+		{
+			opts:     []Option{WithBufferSize(2), WithDelimiter(0)},
+			expected: []string{"aaa\nbbb\x00", "ccc\nddd\x00"},
+		},
+		{
+			opts:     []Option{WithBufferSize(8), WithNoDelimiter()},
+			expected: []string{"aaa\nbbb\x00", "ccc\nddd\x00"},
+		},
+		{
+			// The last option wins
+			opts:     []Option{WithBufferSize(2), WithNoDelimiter(), WithDelimiter('\n')},
+			expected: []string{"aaa\n", "bbb\x00ccc\n", "ddd\x00"},
+		},
+		{
+			// Only the bytes count, the records are not split on the line feeds
+			opts:     []Option{WithBufferSize(6), WithNoDelimiter()},
+			expected: []string{"aaa\nbb", "b\x00ccc\n", "ddd\x00"},
+		},
+		{
+			opts: []Option{WithWorkers(4)},
+			err:  ErrMissingBufferSize,
+		},
+		{
+			opts: []Option{WithBufferSize(2), WithWorkers(MaxWorkers + 1)},
+			err:  ErrTooManyWorkers,
+		},
+		{
+			opts: []Option{WithBufferSize(2), WithWorkers(2), WithBufferSeed(MaxSeedPerBuffer*3 + 1)},
+			err:  ErrBufferSeed,
+		},
+		{
+			opts:     []Option{WithBufferSize(2), WithWorkers(2), WithQueueDepth(1), WithBufferSeed(MaxSeedPerBuffer * 4)},
+			expected: []string{"aaa\n", "bbb\x00ccc\n", "ddd\x00"},
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			mu := sync.Mutex{}
+			batches := make(map[int]string)
+
+			worker := WithWorkerFunc(func(_ context.Context, buffer *[]byte) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				batches[bytes.Index(data, *buffer)] = string(*buffer)
+			})
+
+			bread, err := New(Bread{}, append(c.opts, worker)...)
+			if !errors.Is(err, c.err) {
+				t.Fatalf("expected error %v, got %v", c.err, err)
+			}
+
+			if c.err != nil {
+				t.Logf("Success! %v", err)
+				return
+			}
+
+			if err = bread.Eat(context.TODO(), bytes.NewReader(data)); err != nil {
+				t.Fatal(err)
+			}
+
+			got := make([]string, 0, len(batches))
+			for offset := 0; offset < len(data); offset += len(batches[offset]) {
+				if batches[offset] == "" {
+					t.Fatalf("missing batch at %d", offset)
+				}
+
+				got = append(got, batches[offset])
+			}
+
+			if strings.Join(got, "|") != strings.Join(c.expected, "|") {
+				t.Fatalf("expected batches %q, got %q", c.expected, got)
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+func TestBread_Validate(t *testing.T) {
+	cases := [...]struct {
+		bread Bread
+		err   error
+	}{
+		{
+			bread: Bread{BufferSize: 16, Workers: MaxWorkers},
+		},
+		{
+			bread: Bread{Workers: 1},
+			err:   ErrMissingBufferSize,
+		},
+		{
+			bread: Bread{BufferSize: 16, Workers: MaxWorkers + 1},
+			err:   ErrTooManyWorkers,
+		},
+		{
+			// One worker by default, and the batch being read
+			bread: Bread{BufferSize: 16, BufferSeed: 2*MaxSeedPerBuffer + 1},
+			err:   ErrBufferSeed,
+		},
+		{
+			bread: Bread{BufferSize: 16, MaxBatchSize: 8},
+			err:   ErrMaxBatchSize,
+		},
+		{
+			bread: Bread{BufferSize: -16},
+			err:   ErrNegativeSize,
+		},
+		{
+			bread: Bread{BufferSize: 16, Workers: -1},
+			err:   ErrNegativeSize,
+		},
+		{
+			bread: Bread{BufferSize: 16, BufferSeed: -1},
+			err:   ErrNegativeSize,
+		},
+		{
+			bread: Bread{BufferSize: 16, ReaderBufferSize: -1},
+			err:   ErrNegativeSize,
+		},
+		{
+			bread: Bread{BufferSize: 16, StartOffset: -1},
+			err:   ErrNegativeSize,
+		},
+		{
+			bread: Bread{BufferSize: 16, MaxRecordSize: -1},
+			err:   ErrNegativeSize,
+		},
+		{
+			bread: Bread{BufferSize: 16, MinFill: -1},
+			err:   ErrNegativeSize,
+		},
+		{
+			bread: Bread{BufferSize: 16, MaxBufferCap: -1},
+			err:   ErrNegativeSize,
+		},
+		{
+			bread: Bread{BufferSize: 16, Framing: FramingNone, MaxRecords: 1},
+			err:   ErrNoRecords,
+		},
+		{
+			bread: Bread{BufferSize: 16, Framing: FramingNone, RecordFunc: func(context.Context, [][]byte) {}},
+			err:   ErrNoRecords,
+		},
+		{
+			bread: Bread{BufferSize: 16, StartOffset: 10, SkipLines: 1},
+			err:   ErrStartOffset,
+		},
+		{
+			bread: Bread{BufferSize: 16, StartOffset: 10, AlignToDelimiter: true, RecordSize: 4},
+			err:   ErrStartOffset,
+		},
+		{
+			// The pooled buffers grow up to 4 times BufferSize
+			bread: Bread{BufferSize: math.MaxInt/2 + 1},
+			err:   ErrSizeOverflow,
+		},
+		{
+			// The record is completed past the limit
+			bread: Bread{BufferSize: 16, MaxRecordSize: math.MaxInt},
+			err:   ErrSizeOverflow,
+		},
+		{
+			bread: Bread{BufferSize: 16, MaxBatchSize: math.MaxInt/2 + 1},
+			err:   ErrSizeOverflow,
+		},
+		{
+			// The default MaxBufferSize is 64 times BufferSize
+			bread: Bread{BufferSize: math.MaxInt / 64, AdaptiveBuffer: true},
+			err:   ErrSizeOverflow,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			err := c.bread.Validate()
+			if !errors.Is(err, c.err) {
+				t.Fatalf("expected error %v, got %v", c.err, err)
+			}
+
+			// Eat returns the same errors
+			c.bread.WorkerFunc = func(context.Context, *[]byte) {}
+
+			if c.err != nil && !errors.Is(c.bread.Eat(context.TODO(), strings.NewReader("a\n")), c.err) {
+				t.Fatalf("Eat does not return %v", c.err)
+			}
+
+			t.Logf("Success! %v", err)
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package bread
+
+import (
+	"context"
+	"sync"
+)
+
+// orderer holds the processed batches until the previous ones were delivered, see Bread.Ordered
+type orderer struct {
+	mu      sync.Mutex
+	next    uint64
+	pending map[uint64]Batch
+}
+
+// newOrderer builds an orderer that delivers the batches starting from index 0
+func newOrderer() *orderer {
+	return &orderer{
+		pending: make(map[uint64]Batch),
+	}
+}
+
+// done delivers the processed batch along with the batches it unblocks, or holds it until the previous ones
+// were delivered. A held batch keeps its buffer and its worker slot, so at most Workers batches are held.
+func (e *eater) done(ctx context.Context, batch Batch) {
+	o := e.order
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if batch.Index != o.next {
+		o.pending[batch.Index] = batch
+		return
+	}
+
+	for ok := true; ok; batch, ok = o.pending[o.next] {
+		delete(o.pending, o.next)
+		o.next++
+
+		e.deliver(ctx, batch)
+
+		e.recycle(batch)
+		<-e.workerCh
+	}
+}
+
+// deliver passes a batch to OnDone and writes it to Output, unless a previous batch failed
+func (e *eater) deliver(ctx context.Context, batch Batch) {
+	if e.failed.Load() {
+		return
+	}
+
+	// The worker may have replaced the slice of the buffer
+	batch.Data = *batch.buffer
+
+	if e.OnDone != nil {
+		e.OnDone(ctx, batch)
+	}
+
+	if e.Output == nil {
+		return
+	}
+
+	if _, err := e.Output.Write(batch.Data); err != nil {
+		e.fail(err)
+	}
+}
+
+// discard releases the batches still held once every worker finished, their previous batches were dropped
+func (e *eater) discard() {
+	o := e.order
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	for index, batch := range o.pending {
+		delete(o.pending, index)
+
+		e.recycle(batch)
+		<-e.workerCh
+	}
+}
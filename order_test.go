@@ -0,0 +1,157 @@
+package bread
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBread_Ordered(t *testing.T) {
+	cases := [...]struct {
+		bread   Bread
+		batches int
+	}{
+		{
+			bread:   Bread{Workers: 8, BufferSize: 1},
+			batches: 10_000,
+		},
+		{
+			bread:   Bread{Workers: 4, BufferSize: 1, QueueDepth: 16},
+			batches: 1_000,
+		},
+		{
+			bread:   Bread{Workers: 4, BufferSize: 1, SyncThreshold: KB},
+			batches: 100,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			processed, delivered, peak := atomic.Int64{}, atomic.Int64{}, atomic.Int64{}
+			next := uint64(0)
+
+			c.bread.Ordered = true
+
+			c.bread.WorkerFunc = func(context.Context, *[]byte) {
+				time.Sleep(time.Duration(rand.Intn(50)) * time.Microsecond)
+
+				peak.Store(max(peak.Load(), processed.Add(1)-delivered.Load()))
+			}
+
+			c.bread.OnDone = func(_ context.Context, batch Batch) {
+				if batch.Index != next {
+					t.Errorf("expected batch %d, got %d", next, batch.Index)
+				}
+
+				if string(batch.Data) != "x\n" {
+					t.Errorf("the buffer of batch %d was released before its delivery: %q", batch.Index, batch.Data)
+				}
+
+				next = batch.Index + 1
+				delivered.Add(1)
+			}
+
+			err := c.bread.Eat(context.TODO(), strings.NewReader(strings.Repeat("x\n", c.batches)))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if next != uint64(c.batches) {
+				t.Fatalf("expected %d batches, got %d", c.batches, next)
+			}
+
+			// The batches waiting for the previous ones are bounded by the workers
+			if peak.Load() > int64(c.bread.Workers) {
+				t.Fatalf("expected at most %d held batches, got %d", c.bread.Workers, peak.Load())
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+func TestBread_OrderedFailure(t *testing.T) {
+	errBad := errors.New("bad record")
+
+	bread := Bread{
+		Workers:    4,
+		BufferSize: 1,
+		Ordered:    true,
+		WorkerErrFunc: func(_ context.Context, buffer *[]byte) error {
+			if string(*buffer) == "bad\n" {
+				return errBad
+			}
+
+			return nil
+		},
+	}
+
+	delivered := make([]string, 0)
+
+	bread.OnDone = func(_ context.Context, batch Batch) {
+		delivered = append(delivered, string(batch.Data))
+	}
+
+	err := bread.Eat(context.TODO(), strings.NewReader("a\nb\nbad\n"+strings.Repeat("c\n", 100)))
+	if !errors.Is(err, errBad) {
+		t.Fatalf("expected %v, got %v", errBad, err)
+	}
+
+	// The failed batch and the following ones are not delivered
+	if !strings.HasPrefix("a\nb\n", strings.Join(delivered, "")) {
+		t.Fatalf("unexpected batches %q", delivered)
+	}
+
+	bread.PriorityFunc = func(BatchMeta, []byte) int { return 0 }
+
+	if err = bread.Eat(context.TODO(), strings.NewReader("a\n")); !errors.Is(err, ErrOrderedPriority) {
+		t.Fatalf("expected %v, got %v", ErrOrderedPriority, err)
+	}
+
+	t.Log("Success!")
+}
+
+func TestBread_Output(t *testing.T) {
+	data := bytes.ToLower(memoryData(100 * MB))
+
+	output := bytes.NewBuffer(make([]byte, 0, len(data)))
+
+	bread := Bread{
+		Workers:    8,
+		BufferSize: 64 * KB,
+		Output:     output,
+		TransformFunc: func(_ context.Context, buffer *[]byte) ([]byte, error) {
+			return bytes.ToUpper(*buffer), nil
+		},
+	}
+
+	if err := bread.Eat(context.TODO(), bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(output.Bytes(), bytes.ToUpper(data)) {
+		t.Fatal("the output is not the uppercase input")
+	}
+
+	// A failed write stops the reading
+	errWrite := errors.New("write failure")
+	reader := &countingReader{Reader: bytes.NewReader(data)}
+
+	bread.Output = failingWriter{err: errWrite}
+
+	if err := bread.Eat(context.TODO(), reader); !errors.Is(err, errWrite) {
+		t.Fatalf("expected %v, got %v", errWrite, err)
+	}
+
+	if reader.n.Load() == int64(len(data)) {
+		t.Fatal("expected the reading to stop")
+	}
+
+	t.Log("Success!")
+}
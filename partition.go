@@ -0,0 +1,142 @@
+package bread
+
+import (
+	"context"
+	"sync"
+)
+
+// partitioner passes the records to the partition of their key, in stream order, see Bread.PartitionFunc
+type partitioner struct {
+	key    func([]byte) uint64
+	queues []chan partition
+	wg     sync.WaitGroup
+	// next is the index of the batch whose records are queued next
+	mu   sync.Mutex
+	cond *sync.Cond
+	next uint64
+}
+
+// partition holds the records of a batch with the keys of the same partition
+type partition struct {
+	ctx     context.Context
+	records [][]byte
+	done    *sync.WaitGroup
+}
+
+// startPartitions starts a goroutine for each partition, see Bread.PartitionFunc
+func (e *eater) startPartitions() {
+	if e.PartitionFunc == nil || e.RecordFunc == nil {
+		return
+	}
+
+	p := &partitioner{key: e.PartitionFunc, queues: make([]chan partition, e.Workers)}
+	p.cond = sync.NewCond(&p.mu)
+
+	for i := range p.queues {
+		queue := make(chan partition, 1)
+		p.queues[i] = queue
+
+		p.wg.Add(1)
+
+		go func() {
+			defer p.wg.Done()
+
+			for partition := range queue {
+				e.partition(partition)
+			}
+		}()
+	}
+
+	e.partitions = p
+}
+
+// partition passes the records to RecordFunc, the queued records are dropped once their batch is cancelled
+func (e *eater) partition(p partition) {
+	defer p.done.Done()
+
+	if e.OnPanic != nil {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				e.OnPanic(p.ctx, recovered, nil)
+			}
+		}()
+	}
+
+	if p.ctx.Err() == nil {
+		e.RecordFunc(p.ctx, p.records)
+	}
+}
+
+// stopPartitions waits until the partitions processed their queues, once every worker finished
+func (e *eater) stopPartitions() {
+	if e.partitions == nil {
+		return
+	}
+
+	for _, queue := range e.partitions.queues {
+		close(queue)
+	}
+
+	e.partitions.wg.Wait()
+}
+
+// deliver queues the records of the batch in their partitions after the ones of the previous batches, and waits
+// until they were processed, so they stay valid
+func (p *partitioner) deliver(ctx context.Context, index uint64, records [][]byte) {
+	partitions := make([][][]byte, len(p.queues))
+
+	for _, record := range records {
+		i := p.key(record) % uint64(len(p.queues))
+		partitions[i] = append(partitions[i], record)
+	}
+
+	if !p.wait(ctx, index) {
+		return
+	}
+
+	done := sync.WaitGroup{}
+
+queue:
+	for i, records := range partitions {
+		if len(records) == 0 {
+			continue
+		}
+
+		done.Add(1)
+
+		select {
+		case p.queues[i] <- partition{ctx: ctx, records: records, done: &done}:
+		case <-ctx.Done():
+			done.Done()
+			break queue
+		}
+	}
+
+	p.mu.Lock()
+	p.next++
+	p.cond.Broadcast()
+	p.mu.Unlock()
+
+	done.Wait()
+}
+
+// wait blocks until the previous batches queued their records, it reports false when the context is done before
+// or when the batch already queued its records, like a retried batch
+func (p *partitioner) wait(ctx context.Context, index uint64) bool {
+	stop := context.AfterFunc(ctx, func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		p.cond.Broadcast()
+	})
+	defer stop()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for p.next < index && ctx.Err() == nil {
+		p.cond.Wait()
+	}
+
+	return p.next == index
+}
@@ -0,0 +1,151 @@
+package bread
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// recordKey returns the key of the records "key,sequence"
+func recordKey(record []byte) uint64 {
+	key, _ := strconv.ParseUint(string(record[:bytes.IndexByte(record, ',')]), 10, 64)
+	return key
+}
+
+func TestBread_PartitionFunc(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+
+	const (
+		records = 20_000
+		keys    = 16
+	)
+
+	// The key 0 has 90% of the records
+	data := make([]byte, 0)
+	sequences := make([]int, keys)
+
+	for range records {
+		key := 0
+		if random.Intn(10) == 0 {
+			key = 1 + random.Intn(keys-1)
+		}
+
+		data = fmt.Appendf(data, "%d,%d\n", key, sequences[key])
+		sequences[key]++
+	}
+
+	cases := [...]struct {
+		bread Bread
+		// cancel is the number of records processed before the context is cancelled
+		cancel int64
+		err    error
+	}{
+		{
+			bread: Bread{BufferSize: 64, Workers: 4},
+		},
+		{
+			bread: Bread{BufferSize: 1024, Workers: 8, QueueDepth: 4},
+		},
+		{
+			bread: Bread{BufferSize: 64, Workers: 4, MaxRecords: 1000},
+		},
+		{
+			bread:  Bread{BufferSize: 64, Workers: 4},
+			cancel: 5000,
+			err:    context.Canceled,
+		},
+		{
+			bread: Bread{BufferSize: 64, Workers: 4, PriorityFunc: func(BatchMeta, []byte) int { return 0 }},
+			err:   ErrPartitionFunc,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			goroutines := runtime.NumGoroutine()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var (
+				mu sync.Mutex
+				// next is the sequence expected for each key
+				next      = make([]int, keys)
+				running   = make([]atomic.Int32, keys)
+				processed atomic.Int64
+			)
+
+			c.bread.PartitionFunc = recordKey
+			c.bread.RecordFunc = func(_ context.Context, records [][]byte) {
+				for _, record := range records {
+					key := recordKey(record)
+
+					// The records of a key are never processed concurrently
+					if running[key].Add(1) > 1 {
+						t.Errorf("key %d processed concurrently", key)
+					}
+
+					sequence, _ := strconv.Atoi(string(record[bytes.IndexByte(record, ',')+1:]))
+
+					mu.Lock()
+					if sequence != next[key] {
+						t.Errorf("expected sequence %d of key %d, got %d", next[key], key, sequence)
+					}
+
+					next[key]++
+					mu.Unlock()
+
+					running[key].Add(-1)
+
+					if processed.Add(1) == c.cancel {
+						cancel()
+					}
+				}
+			}
+
+			stats, err := c.bread.EatStats(ctx, bytes.NewReader(data))
+			if !errors.Is(err, c.err) {
+				t.Fatalf("expected error %v, got %v", c.err, err)
+			}
+
+			// The partitions are drained
+			for deadline := time.Now().Add(time.Second); runtime.NumGoroutine() > goroutines; {
+				if time.Now().After(deadline) {
+					t.Fatalf("expected %d goroutines, got %d", goroutines, runtime.NumGoroutine())
+				}
+
+				time.Sleep(time.Millisecond)
+			}
+
+			if c.err != nil {
+				t.Logf("Success! %v", err)
+				return
+			}
+
+			expected := int64(records)
+			if c.bread.MaxRecords > 0 {
+				expected = int64(c.bread.MaxRecords)
+			}
+
+			if processed.Load() != expected {
+				t.Fatalf("expected %d records, got %d", expected, processed.Load())
+			}
+
+			// The hot key holds back the reading instead of buffering its records, the race detector drops
+			// pooled buffers
+			if limit := uint64(c.bread.Workers+int(c.bread.QueueDepth)) * 2; stats.Allocations > limit && !raceEnabled {
+				t.Fatalf("expected at most %d buffers, got %d", limit, stats.Allocations)
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
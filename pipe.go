@@ -0,0 +1,111 @@
+package bread
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+)
+
+// ErrMissingStages is returned by Pipe without any stage
+var ErrMissingStages = errors.New("missing pipeline stages")
+
+// errStageFailed is the cause of the cancellation of the stages stopped by a failed stage
+var errStageFailed = errors.New("another stage failed")
+
+// Stage is a step of a pipeline, see Pipe
+type Stage struct {
+	// Bread holds the settings of the stage, with its own Workers and BufferSize
+	Bread Bread
+	// Transform replaces each batch with the slice it returns, see Bread.TransformFunc. The output must keep the
+	// records delimited, so the next stage splits them again.
+	//
+	// This member is optional. By default the TransformFunc of the settings is used
+	Transform func(ctx context.Context, in *[]byte) ([]byte, error)
+}
+
+// Pipe runs the stages concurrently, each one on its own goroutine, so every stage eats the output of the
+// previous one, written in stream order, and the first stage eats the reader. The output of the last stage
+// is written to its Output, if any.
+//
+// The stages are connected by an io.Pipe, so a stage waits until the next one reads its output, and each one
+// takes its buffers from its own pool. The first stage that fails stops the others, and its error is returned
+// wrapped in a *StageError.
+//
+// NOTE: the Output of every stage but the last one is ignored
+func Pipe(ctx context.Context, r io.Reader, stages ...Stage) error {
+	if r == nil {
+		return ErrNilReader
+	}
+
+	if len(stages) == 0 {
+		return ErrMissingStages
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	var (
+		wg   sync.WaitGroup
+		errs = make([]error, len(stages))
+		// readers and writers connect each stage to the next one
+		readers = make([]*io.PipeReader, len(stages))
+		writers = make([]*io.PipeWriter, len(stages))
+	)
+
+	for i := range stages[1:] {
+		readers[i+1], writers[i] = io.Pipe()
+	}
+
+	for i, stage := range stages {
+		config := stage.Bread
+		if stage.Transform != nil {
+			config.TransformFunc = stage.Transform
+		}
+
+		if writers[i] != nil {
+			config.Output = writers[i]
+		}
+
+		var reader io.Reader = r
+		if readers[i] != nil {
+			reader = readers[i]
+			// The pipes are closed with the failure of the stage, see below
+			config.CloseReader = false
+		}
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			err := config.Eat(ctx, reader)
+
+			if err != nil && !errors.Is(err, errStageFailed) && context.Cause(ctx) != errStageFailed {
+				errs[i] = &StageError{Index: i, Err: err}
+				cancel(errStageFailed)
+			}
+
+			// The previous stage stops writing, and the next one reads the end of the stream or the failure
+			if readers[i] != nil {
+				readers[i].CloseWithError(errStageFailed)
+			}
+
+			if writers[i] == nil {
+				return
+			}
+
+			// A nil error closes the pipe with io.EOF
+			closeErr := error(nil)
+			if err != nil {
+				closeErr = errStageFailed
+			}
+
+			writers[i].CloseWithError(closeErr)
+		}()
+	}
+
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,136 @@
+package bread
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"hash"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestPipe(t *testing.T) {
+	errStage := errors.New("stage failure")
+
+	size := 100 * MB
+	if testing.Short() || raceEnabled {
+		size = 8 * MB
+	}
+
+	// Lines of lowercase words
+	data := make([]byte, 0, size+64)
+	for i := 0; len(data) < size; i++ {
+		data = append(data, "record "...)
+		data = strconv.AppendInt(data, int64(i), 36)
+		data = append(data, '\n')
+	}
+
+	upper := func(_ context.Context, in *[]byte) ([]byte, error) {
+		return bytes.ToUpper(*in), nil
+	}
+
+	suffix := func(_ context.Context, in *[]byte) ([]byte, error) {
+		return bytes.ReplaceAll(*in, []byte{'\n'}, []byte(" ok\n")), nil
+	}
+
+	identity := func(_ context.Context, in *[]byte) ([]byte, error) {
+		return *in, nil
+	}
+
+	expected := sha256.Sum256(bytes.ReplaceAll(bytes.ToUpper(data), []byte{'\n'}, []byte(" ok\n")))
+
+	cases := [...]struct {
+		stages []Stage
+		// cancel is the time the context of the pipeline is cancelled after, zero means never
+		cancel time.Duration
+		err    error
+		// stage is the index of the failed stage
+		stage int
+	}{
+		{
+			stages: []Stage{
+				{Bread: Bread{Workers: 4, BufferSize: 64 * KB}, Transform: upper},
+				{Bread: Bread{Workers: 2, BufferSize: 4 * KB}, Transform: suffix},
+				{Bread: Bread{Workers: 8, BufferSize: 16 * KB}, Transform: identity},
+			},
+		},
+		{
+			stages: []Stage{
+				{Bread: Bread{Workers: 4, BufferSize: 64 * KB}, Transform: upper},
+				{Bread: Bread{Workers: 2, BufferSize: 4 * KB}, Transform: func(ctx context.Context, in *[]byte) ([]byte, error) {
+					if bytes.Contains(*in, []byte("RECORD 2S0\n")) {
+						return nil, errStage
+					}
+
+					return suffix(ctx, in)
+				}},
+				{Bread: Bread{Workers: 8, BufferSize: 16 * KB}, Transform: identity},
+			},
+			err:   errStage,
+			stage: 1,
+		},
+		{
+			stages: []Stage{
+				{Bread: Bread{Workers: 4, BufferSize: 64 * KB}, Transform: upper},
+				{Bread: Bread{Workers: 2, BufferSize: 4 * KB}, Transform: suffix},
+				{Bread: Bread{Workers: 8, BufferSize: 16 * KB}, Transform: func(context.Context, *[]byte) ([]byte, error) {
+					time.Sleep(time.Millisecond)
+					return nil, nil
+				}},
+			},
+			cancel: 20 * time.Millisecond,
+			err:    context.Canceled,
+			stage:  -1,
+		},
+		{
+			err: ErrMissingStages,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			goroutines := runtime.NumGoroutine()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			if c.cancel > 0 {
+				time.AfterFunc(c.cancel, cancel)
+			}
+
+			var sum hash.Hash
+			if len(c.stages) > 0 {
+				sum = sha256.New()
+				c.stages[len(c.stages)-1].Bread.Output = sum
+			}
+
+			err := Pipe(ctx, bytes.NewReader(data), c.stages...)
+			if !errors.Is(err, c.err) {
+				t.Fatalf("expected error %v, got %v", c.err, err)
+			}
+
+			var stageErr *StageError
+
+			switch {
+			case c.err == nil && !bytes.Equal(sum.Sum(nil), expected[:]):
+				t.Fatal("the output of the pipeline does not match the transformed data")
+			case c.stage >= 0 && c.err != nil && len(c.stages) > 0 && (!errors.As(err, &stageErr) || stageErr.Index != c.stage):
+				t.Fatalf("expected a *StageError of the stage %d, got %v", c.stage, err)
+			}
+
+			deadline := time.Now().Add(time.Second)
+			for runtime.NumGoroutine() > goroutines && time.Now().Before(deadline) {
+				time.Sleep(10 * time.Millisecond)
+			}
+
+			if runtime.NumGoroutine() > goroutines {
+				t.Fatalf("%d goroutines leaked", runtime.NumGoroutine()-goroutines)
+			}
+
+			t.Logf("Success! %v", err)
+		})
+	}
+}
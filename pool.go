@@ -0,0 +1,234 @@
+package bread
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+
+	"github.com/yael-castro/bread/internal/pool"
+)
+
+// PoolStrategy selects the implementation of the buffer pool
+type PoolStrategy uint8
+
+// Supported values for PoolStrategy
+const (
+	// PoolSync uses a sync.Pool, the buffers may be released by the garbage collector
+	PoolSync PoolStrategy = iota
+	// PoolFreelist uses a fixed size freelist, the buffers survive the garbage collection cycles
+	// and the memory used by buffers is bounded by FreelistSize. See FreelistAllocate
+	PoolFreelist
+)
+
+// bufferPool is implemented by the buffer pools of internal/pool
+type bufferPool interface {
+	Get() *[]byte
+	Put(*[]byte)
+	Seed(n int)
+	Allocations() uint64
+}
+
+// BufferPool provides the buffers of the batches, like a pool shared by a whole service or buffers backed by
+// an arena or a memory mapping. Its methods are called concurrently.
+type BufferPool interface {
+	// Get returns a buffer with length size
+	Get(size int) *[]byte
+	// Put takes back a buffer returned by Get, once for each Get. Its length and capacity may have changed,
+	// and the slice is nil once Detach handed it to a worker.
+	Put(buffer *[]byte)
+}
+
+// NewBufferPool builds a SharedPool of buffers with length size, capHint is the initial capacity of the buffers
+func NewBufferPool(size, capHint int) *SharedPool {
+	return &SharedPool{
+		buffers: pool.NewBuffers(size, capHint),
+		size:    size,
+	}
+}
+
+// SharedPool is a BufferPool backed by a sync.Pool, that can be shared by many Bread instances and Eat calls
+// with the same BufferSize
+type SharedPool struct {
+	buffers bufferPool
+	size    int
+}
+
+// Get implements BufferPool
+func (p *SharedPool) Get(size int) *[]byte {
+	buffer := p.buffers.Get()
+
+	if cap(*buffer) < size {
+		*buffer = make([]byte, size)
+	}
+
+	*buffer = (*buffer)[:size]
+
+	return buffer
+}
+
+// Put implements BufferPool
+func (p *SharedPool) Put(buffer *[]byte) {
+	p.buffers.Put(buffer)
+}
+
+// Size returns the length of the buffers
+func (p *SharedPool) Size() int {
+	return p.size
+}
+
+// seed keeps at least n buffers in the pool, see pluggedPool.Seed. A freelist is seeded up to its size instead,
+// since taking more buffers than it holds would block.
+func (p *SharedPool) seed(n int) {
+	if freelist, ok := p.buffers.(*pool.Freelist); ok {
+		freelist.Seed(n)
+		return
+	}
+
+	buffers := make([]*[]byte, n)
+
+	for i := range buffers {
+		buffers[i] = p.Get(p.size)
+	}
+
+	for _, buffer := range buffers {
+		p.Put(buffer)
+	}
+}
+
+// Allocations returns the number of buffers allocated by the pool
+func (p *SharedPool) Allocations() uint64 {
+	return p.buffers.Allocations()
+}
+
+// pluggedPool adapts the BufferPool of the settings, see Bread.Pool
+type pluggedPool struct {
+	pool BufferPool
+	size int
+}
+
+// Get implements bufferPool
+func (p pluggedPool) Get() *[]byte {
+	return p.pool.Get(p.size)
+}
+
+// Put implements bufferPool
+func (p pluggedPool) Put(buffer *[]byte) {
+	if buffer == nil {
+		return
+	}
+
+	p.pool.Put(buffer)
+}
+
+// Seed implements bufferPool, the buffers are taken and then returned, so the pool keeps at least n of them
+func (p pluggedPool) Seed(n int) {
+	if shared, ok := p.pool.(*SharedPool); ok {
+		shared.seed(n)
+		return
+	}
+
+	buffers := make([]*[]byte, n)
+
+	for i := range buffers {
+		buffers[i] = p.pool.Get(p.size)
+	}
+
+	for _, buffer := range buffers {
+		p.pool.Put(buffer)
+	}
+}
+
+// Allocations implements bufferPool, it is zero unless the pool counts its allocations like SharedPool
+func (p pluggedPool) Allocations() uint64 {
+	if counted, ok := p.pool.(interface{ Allocations() uint64 }); ok {
+		return counted.Allocations()
+	}
+
+	return 0
+}
+
+// newPool builds the buffer pool selected by the PoolStrategy, unless there is a Pool
+func (b Bread) newPool() bufferPool {
+	if b.Pool != nil {
+		return pluggedPool{pool: b.Pool, size: b.BufferSize}
+	}
+
+	size, capacity, maxCap := b.BufferSize, b.BufferSize*2, b.maxBufferCap()
+
+	if b.PoolStrategy != PoolFreelist {
+		buffers := pool.NewBuffers(size, capacity)
+		buffers.MaxCap = maxCap
+
+		return buffers
+	}
+
+	freelist := pool.NewFreelist(b.freelistSize(), size, capacity, b.FreelistAllocate)
+	freelist.MaxCap = maxCap
+
+	return freelist
+}
+
+// sharedPool builds a SharedPool selected by the PoolStrategy, for the Eat calls that share their buffers like the
+// files of EatFS. Its buffers are bounded by the MaxBufferCap of the settings.
+func (b Bread) sharedPool() *SharedPool {
+	b.Pool = nil
+
+	return &SharedPool{buffers: b.newPool(), size: b.BufferSize}
+}
+
+// freelistSize returns the number of buffers held by the PoolFreelist strategy, see FreelistSize
+func (b Bread) freelistSize() int {
+	if b.FreelistSize > 0 {
+		return int(b.FreelistSize)
+	}
+
+	// One buffer for each worker, each queued batch and the batch being read
+	return b.Workers + int(b.QueueDepth) + 1
+}
+
+// poolSize returns the buffer size reported by the Pool, or BufferSize when it does not report any
+func (b Bread) poolSize() int {
+	if sized, ok := b.Pool.(interface{ Size() int }); ok {
+		return sized.Size()
+	}
+
+	return b.BufferSize
+}
+
+// maxBufferCap returns the maximum capacity of the pooled buffers, see MaxBufferCap
+func (b Bread) maxBufferCap() int {
+	switch {
+	case b.MaxBufferCap > 0:
+		return b.MaxBufferCap
+	case b.AdaptiveBuffer:
+		// The buffers grown for the adaptive size are kept
+		return b.maxBufferSize() * maxBufferGrowth
+	default:
+		return b.BufferSize * maxBufferGrowth
+	}
+}
+
+// countedPool counts the buffers taken from the pool, see Stats.Reuses, and logs them along with the debug events
+type countedPool struct {
+	bufferPool
+	gets   *atomic.Uint64
+	ctx    context.Context
+	logger *slog.Logger
+}
+
+// Get implements bufferPool
+func (p countedPool) Get() *[]byte {
+	p.gets.Add(1)
+
+	if p.logger == nil || !p.logger.Enabled(p.ctx, slog.LevelDebug) {
+		return p.bufferPool.Get()
+	}
+
+	// The allocations of concurrent Eat calls sharing the Pool may be attributed to this buffer
+	allocations := p.bufferPool.Allocations()
+	buffer := p.bufferPool.Get()
+
+	p.logger.LogAttrs(p.ctx, slog.LevelDebug, "buffer taken", slog.Bool("allocated", p.bufferPool.Allocations() != allocations))
+
+	return buffer
+}
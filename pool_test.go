@@ -0,0 +1,432 @@
+package bread
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/yael-castro/bread/internal/pool"
+)
+
+func TestBread_Pool(t *testing.T) {
+	shared := NewBufferPool(1<<10, 2<<10)
+
+	cases := [...]struct {
+		bread Bread
+		err   error
+	}{
+		{
+			bread: Bread{
+				Workers:    4,
+				BufferSize: 1 << 10,
+				Pool:       shared,
+			},
+		},
+		{
+			bread: Bread{
+				Workers:    2,
+				BufferSize: 1 << 10,
+				Delimiter:  'O',
+				Pool:       shared,
+			},
+		},
+		{
+			bread: Bread{
+				BufferSize: 1 << 11,
+				Pool:       shared,
+			},
+			err: ErrPoolSizeMismatch,
+		},
+	}
+
+	data := memoryData(1 * MB)
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			wg := sync.WaitGroup{}
+			errs := make([]error, 8)
+
+			// Concurrent Eat calls sharing the pool
+			for n := range errs {
+				wg.Add(1)
+
+				go func(n int) {
+					defer wg.Done()
+
+					size := atomic.Int64{}
+
+					errs[n] = c.bread.EatBatches(context.TODO(), bytes.NewReader(data), func(_ context.Context, batch Batch) {
+						size.Add(int64(len(batch.Data)))
+
+						// Scribble on the buffer to detect sharing between batches
+						for j := range batch.Data {
+							batch.Data[j] = 0
+						}
+					})
+
+					if errs[n] == nil && size.Load() != int64(len(data)) {
+						errs[n] = errors.New("missing bytes")
+					}
+				}(n)
+			}
+
+			wg.Wait()
+
+			for _, err := range errs {
+				if !errors.Is(err, c.err) {
+					t.Fatalf("expected %v, got %v", c.err, err)
+				}
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	cases := [...]struct {
+		bread Bread
+		err   error
+	}{
+		{
+			bread: Bread{Workers: 4, BufferSize: 1 << 10, BufferSeed: 4},
+		},
+		{
+			bread: Bread{Workers: 4, BufferSize: 1 << 10, Pool: NewBufferPool(1<<10, 2<<10)},
+		},
+		{
+			bread: Bread{Workers: 4},
+			err:   ErrMissingBufferSize,
+		},
+		{
+			bread: Bread{BufferSize: 1 << 11, Pool: NewBufferPool(1<<10, 2<<10)},
+			err:   ErrPoolSizeMismatch,
+		},
+	}
+
+	data := memoryData(1 * MB)
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			bread, err := New(c.bread)
+			if !errors.Is(err, c.err) {
+				t.Fatalf("expected error %v, got %v", c.err, err)
+			}
+
+			if c.err != nil {
+				t.Logf("Success! %v", err)
+				return
+			}
+
+			if c.bread.Pool != nil && bread.Pool != c.bread.Pool {
+				t.Fatal("the pool of the settings was replaced")
+			}
+
+			wg := sync.WaitGroup{}
+			errs := make([]error, 8)
+
+			// Concurrent Eat calls of the same Bread
+			for n := range errs {
+				wg.Add(1)
+
+				go func(n int) {
+					defer wg.Done()
+
+					size := atomic.Int64{}
+
+					errs[n] = bread.EatBatches(context.TODO(), bytes.NewReader(data), func(_ context.Context, batch Batch) {
+						size.Add(int64(len(batch.Data)))
+
+						// Scribble on the buffer to detect sharing between batches
+						for j := range batch.Data {
+							batch.Data[j] = 0
+						}
+					})
+
+					if errs[n] == nil && size.Load() != int64(len(data)) {
+						errs[n] = errors.New("missing bytes")
+					}
+				}(n)
+			}
+
+			wg.Wait()
+
+			if err := errors.Join(errs...); err != nil {
+				t.Fatal(err)
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+func TestNew_Reuse(t *testing.T) {
+	// The race detector makes sync.Pool drop items at random
+	if raceEnabled {
+		t.Skip("sync.Pool drops items under the race detector")
+	}
+
+	bread, err := New(Bread{Workers: 1, BufferSize: 1 << 10, BufferSeed: 2, WorkerFunc: func(context.Context, *[]byte) {}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := memoryData(64 * KB)
+
+	for n := range 3 {
+		stats, err := bread.EatStats(context.TODO(), bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The buffers seeded by New and released by the previous calls are reused
+		if stats.Allocations != 0 {
+			t.Fatalf("call %d allocated %d buffers", n, stats.Allocations)
+		}
+	}
+
+	t.Log("Success!")
+}
+
+// BenchmarkNew eats many small inputs, the Bread built by New keeps its pool between the Eat calls
+func BenchmarkNew(b *testing.B) {
+	settings := Bread{
+		Workers:    4,
+		BufferSize: 4 * KB,
+		BufferSeed: 4,
+		WorkerFunc: func(context.Context, *[]byte) {},
+	}
+
+	built, err := New(settings)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	data := memoryData(16 * KB)
+
+	cases := [...]struct {
+		name  string
+		bread *Bread
+	}{
+		{name: "zero", bread: &settings},
+		{name: "new", bread: built},
+	}
+
+	for _, c := range cases {
+		b.Run(c.name, func(b *testing.B) {
+			b.ReportAllocs()
+
+			for n := 0; n < b.N; n++ {
+				for range 1000 {
+					if err := c.bread.Eat(context.TODO(), bytes.NewReader(data)); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestBread_sharedPool(t *testing.T) {
+	cases := [...]struct {
+		bread  Bread
+		maxCap int
+	}{
+		{
+			bread:  Bread{BufferSize: 1 << 10, Workers: 2},
+			maxCap: 4 << 10,
+		},
+		{
+			bread:  Bread{BufferSize: 1 << 10, Workers: 2, MaxBufferCap: 8 << 10},
+			maxCap: 8 << 10,
+		},
+		{
+			bread:  Bread{BufferSize: 1 << 10, Workers: 2, PoolStrategy: PoolFreelist},
+			maxCap: 4 << 10,
+		},
+		{
+			bread:  Bread{BufferSize: 1 << 10, Workers: 2, PoolStrategy: PoolFreelist, MaxBufferCap: 2 << 10},
+			maxCap: 2 << 10,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			shared := c.bread.sharedPool()
+
+			if size := shared.Size(); size != c.bread.BufferSize {
+				t.Fatalf("expected size %d, got %d", c.bread.BufferSize, size)
+			}
+
+			var maxCap int
+
+			switch buffers := shared.buffers.(type) {
+			case *pool.Buffers:
+				if c.bread.PoolStrategy == PoolFreelist {
+					t.Fatal("expected a freelist")
+				}
+
+				maxCap = buffers.MaxCap
+			case *pool.Freelist:
+				if c.bread.PoolStrategy != PoolFreelist {
+					t.Fatal("unexpected freelist")
+				}
+
+				maxCap = buffers.MaxCap
+			default:
+				t.Fatalf("unexpected pool %T", buffers)
+			}
+
+			if maxCap != c.maxCap {
+				t.Fatalf("expected max capacity %d, got %d", c.maxCap, maxCap)
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+// countingPool is a BufferPool that tracks the buffers taken from it
+type countingPool struct {
+	mu    sync.Mutex
+	taken map[*[]byte]bool
+	free  []*[]byte
+	gets  int
+	// errs holds the buffers returned twice or not taken from the pool
+	errs []error
+}
+
+func newCountingPool() *countingPool {
+	return &countingPool{taken: make(map[*[]byte]bool)}
+}
+
+func (p *countingPool) Get(size int) *[]byte {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.gets++
+
+	buffer := new([]byte)
+	if len(p.free) > 0 {
+		buffer, p.free = p.free[len(p.free)-1], p.free[:len(p.free)-1]
+	}
+
+	if cap(*buffer) < size {
+		*buffer = make([]byte, size)
+	}
+
+	*buffer = (*buffer)[:size]
+	p.taken[buffer] = true
+
+	return buffer
+}
+
+func (p *countingPool) Put(buffer *[]byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.taken[buffer] {
+		p.errs = append(p.errs, errors.New("buffer returned without being taken"))
+		return
+	}
+
+	delete(p.taken, buffer)
+	p.free = append(p.free, buffer)
+}
+
+func TestBread_PoolInterface(t *testing.T) {
+	errWorker := errors.New("worker failure")
+
+	cases := [...]struct {
+		bread Bread
+		// worker processes the batch, cancel cancels the context of Eat
+		worker func(ctx context.Context, cancel context.CancelFunc, batch Batch) error
+	}{
+		{
+			bread: Bread{Workers: 4, BufferSeed: 8},
+		},
+		{
+			bread: Bread{Workers: 4, QueueDepth: 8},
+			worker: func(_ context.Context, cancel context.CancelFunc, batch Batch) error {
+				if batch.Index == 10 {
+					cancel()
+				}
+
+				return nil
+			},
+		},
+		{
+			bread: Bread{Workers: 4, OnPanic: func(context.Context, any, *[]byte) {}},
+			worker: func(_ context.Context, _ context.CancelFunc, batch Batch) error {
+				if batch.Index%3 == 0 {
+					panic(errWorker)
+				}
+
+				return nil
+			},
+		},
+		{
+			bread: Bread{Workers: 4, Delimiter: 'O', Align: AlignBackward, BatchRetries: 2, RereadOnRetry: true},
+			worker: func(_ context.Context, _ context.CancelFunc, batch Batch) error {
+				if batch.Index == 5 {
+					return errWorker
+				}
+
+				return nil
+			},
+		},
+		{
+			bread: Bread{Workers: 4, Ordered: true, OnDone: func(context.Context, Batch) {}},
+			worker: func(_ context.Context, _ context.CancelFunc, batch Batch) error {
+				if batch.Index%2 == 0 {
+					batch.Detach()
+				}
+
+				return nil
+			},
+		},
+	}
+
+	data := memoryData(64 * KB)
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			pool := newCountingPool()
+
+			c.bread.BufferSize = 256
+			c.bread.Pool = pool
+
+			err := c.bread.eat(ctx, bytes.NewReader(data), func(ctx context.Context, batch Batch) error {
+				if c.worker == nil {
+					return nil
+				}
+
+				return c.worker(ctx, cancel, batch)
+			})
+			if err != nil && !errors.Is(err, context.Canceled) && !errors.Is(err, errWorker) {
+				t.Fatal(err)
+			}
+
+			if err := errors.Join(pool.errs...); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(pool.taken) > 0 {
+				t.Fatalf("%d of %d buffers were not returned", len(pool.taken), pool.gets)
+			}
+
+			if pool.gets < c.bread.BufferSeed {
+				t.Fatalf("the pool was not seeded, %d buffers taken", pool.gets)
+			}
+
+			t.Logf("Success! %d buffers taken, %v", pool.gets, err)
+		})
+	}
+}
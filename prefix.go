@@ -0,0 +1,93 @@
+package bread
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// DefaultMaxMessageLength is the maximum length of a length-prefixed message when MaxRecordSize is not set
+const DefaultMaxMessageLength = 64 << 20
+
+// prefixFramer splits the stream on the length prefixes of FramingUvarint and FramingUint32BE
+type prefixFramer struct {
+	maxLength int
+	uvarint   bool
+}
+
+func (f prefixFramer) next(r *bufio.Reader, dst *[]byte, offset int64) (before, after int, err error) {
+	length, before, err := f.length(r, offset)
+	if err != nil {
+		return
+	}
+
+	if length > uint64(f.maxLength) {
+		return before, 0, &RecordTooLargeError{Position: offset + int64(before), Limit: f.maxLength}
+	}
+
+	// The buffer grows up to the message length
+	*dst = grow(*dst, int(length))
+
+	n, err := io.ReadFull(r, *dst)
+	if err != nil {
+		*dst = (*dst)[:n]
+
+		reason := fmt.Sprintf("truncated message of %d bytes, got %d", length, n)
+
+		return before, 0, &FramingError{Position: offset, Reason: reason, Err: unexpected(err)}
+	}
+
+	return before, 0, nil
+}
+
+// length reads the length prefix, n is the size of the prefix
+func (f prefixFramer) length(r *bufio.Reader, offset int64) (length uint64, n int, err error) {
+	if !f.uvarint {
+		prefix := [4]byte{}
+
+		n, err = io.ReadFull(r, prefix[:])
+		if err == io.ErrUnexpectedEOF {
+			return 0, n, &FramingError{Position: offset, Reason: "truncated length prefix", Err: err}
+		}
+
+		if err != nil {
+			return
+		}
+
+		return uint64(binary.BigEndian.Uint32(prefix[:])), n, nil
+	}
+
+	counter := &byteCounter{r: r}
+
+	length, err = binary.ReadUvarint(counter)
+
+	switch {
+	case err == io.EOF:
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		err = &FramingError{Position: offset, Reason: "truncated length prefix", Err: err}
+	case err != nil && counter.err == nil:
+		err = &FramingError{Position: offset, Reason: "invalid uvarint length prefix", Err: err}
+	}
+
+	return length, counter.n, err
+}
+
+// byteCounter counts the bytes read from a bufio.Reader, err holds the error of the reader
+type byteCounter struct {
+	r   *bufio.Reader
+	n   int
+	err error
+}
+
+func (c *byteCounter) ReadByte() (byte, error) {
+	b, err := c.r.ReadByte()
+	if err == nil {
+		c.n++
+	} else if err != io.EOF {
+		c.err = err
+	}
+
+	return b, err
+}
@@ -0,0 +1,156 @@
+package bread
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// prefixed encodes the messages with the length prefix of the framing
+func prefixed(framing Framing, messages ...string) []byte {
+	data := make([]byte, 0)
+
+	for _, message := range messages {
+		if framing == FramingUvarint {
+			data = binary.AppendUvarint(data, uint64(len(message)))
+		} else {
+			data = binary.BigEndian.AppendUint32(data, uint32(len(message)))
+		}
+
+		data = append(data, message...)
+	}
+
+	return data
+}
+
+func TestBread_FramingLengthPrefixed(t *testing.T) {
+	largeMessage := strings.Repeat("m", 10_000)
+
+	cases := [...]struct {
+		bread    Bread
+		data     []byte
+		reader   func(io.Reader) io.Reader
+		expected []string
+		// position of the expected error, -1 means no error
+		position int64
+		// err is the error expected with errors.Is
+		err error
+	}{
+		{
+			bread:    Bread{Framing: FramingUvarint},
+			data:     prefixed(FramingUvarint, "a", "", "bc", largeMessage, "d"),
+			expected: []string{"a", "", "bc", largeMessage, "d"},
+			position: -1,
+		},
+		{
+			bread:    Bread{Framing: FramingUint32BE},
+			data:     prefixed(FramingUint32BE, "a", "", "bc", largeMessage, "d"),
+			expected: []string{"a", "", "bc", largeMessage, "d"},
+			position: -1,
+		},
+		{
+			// Prefixes and messages split across internal reads
+			bread:    Bread{Framing: FramingUvarint},
+			data:     prefixed(FramingUvarint, largeMessage, "a", largeMessage),
+			reader:   iotest.OneByteReader,
+			expected: []string{largeMessage, "a", largeMessage},
+			position: -1,
+		},
+		{
+			bread:    Bread{Framing: FramingUvarint},
+			position: -1,
+		},
+		{
+			// The message exceeds MaxRecordSize, the position is the start of the message
+			bread:    Bread{Framing: FramingUint32BE, MaxRecordSize: 100},
+			data:     prefixed(FramingUint32BE, "a", largeMessage),
+			expected: []string{"a"},
+			position: 9,
+			err:      ErrRecordTooLarge,
+		},
+		{
+			// Truncated uvarint prefix
+			bread:    Bread{Framing: FramingUvarint},
+			data:     append(prefixed(FramingUvarint, "a"), 0x80),
+			expected: []string{"a"},
+			position: 2,
+			err:      io.ErrUnexpectedEOF,
+		},
+		{
+			// Truncated uint32 prefix
+			bread:    Bread{Framing: FramingUint32BE},
+			data:     append(prefixed(FramingUint32BE, "a"), 0, 0),
+			expected: []string{"a"},
+			position: 5,
+			err:      io.ErrUnexpectedEOF,
+		},
+		{
+			// Truncated message
+			bread:    Bread{Framing: FramingUvarint},
+			data:     prefixed(FramingUvarint, "a", largeMessage)[:100],
+			expected: []string{"a"},
+			position: 2,
+			err:      io.ErrUnexpectedEOF,
+		},
+		{
+			// The uvarint prefix overflows 64 bits
+			bread:    Bread{Framing: FramingUvarint},
+			data:     bytes.Repeat([]byte{0xff}, 11),
+			position: 0,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			c.bread.BufferSize = 16
+			c.bread.Workers = 4
+
+			var reader io.Reader = bytes.NewReader(c.data)
+			if c.reader != nil {
+				reader = c.reader(reader)
+			}
+
+			batches, err := collect(t, c.bread, reader)
+
+			if c.position < 0 && err != nil {
+				t.Fatal(err)
+			}
+
+			if c.position >= 0 {
+				var positioned Positioned
+				if !errors.As(err, &positioned) {
+					t.Fatalf("expected an error with a position, got %v", err)
+				}
+
+				if positioned.Offset() != c.position {
+					t.Fatalf("expected error at offset %d, got %d", c.position, positioned.Offset())
+				}
+
+				if c.err != nil && !errors.Is(err, c.err) {
+					t.Fatalf("expected error wrapping %v, got %v", c.err, err)
+				}
+			}
+
+			if len(batches) != len(c.expected) {
+				t.Fatalf("expected %d messages, got %d", len(c.expected), len(batches))
+			}
+
+			for j, batch := range batches {
+				if string(batch.Data) != c.expected[j] {
+					t.Fatalf("expected message %d of %d bytes, got %d bytes", j, len(c.expected[j]), len(batch.Data))
+				}
+
+				if batch.Records != 1 {
+					t.Fatalf("expected one record per batch, got %d", batch.Records)
+				}
+			}
+
+			t.Logf("Success! %v", err)
+		})
+	}
+}
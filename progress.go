@@ -0,0 +1,247 @@
+package bread
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Progress is a snapshot of the progress of an Eat call
+type Progress struct {
+	// BytesRead is the number of bytes read from the io.Reader
+	BytesRead ByteSize
+	// BatchesDispatched is the number of batches read and handed to the workers
+	BatchesDispatched uint64
+	// BatchesCompleted is the number of batches the workers finished processing
+	BatchesCompleted uint64
+	// Records is the number of records in the dispatched batches
+	Records uint64
+	// Errors is the number of batches that failed
+	Errors uint64
+	// Workers is the number of active workers, it only changes with ScaleWorkers
+	Workers int
+	// Elapsed is the time since the Eat call started
+	Elapsed time.Duration
+	// Fraction is the part of the input already read, from 0 to 1. It is only set when the size is known,
+	// see Bread.SizeHint
+	Fraction float64
+	// ETA is the estimated time until the whole input is read, from the recent throughput.
+	// It is only set when the size is known
+	ETA time.Duration
+	// SizeExceeded indicates that more bytes than the size hint were read, like for a growing file.
+	// Fraction stays at 1 and ETA at 0 then
+	SizeExceeded bool
+}
+
+// Tracker holds the counters of an Eat call, so its progress can be polled from any goroutine while it runs.
+//
+// A Tracker must not be shared by concurrent Eat calls, its counters are reset when Eat starts.
+type Tracker struct {
+	started     atomic.Int64
+	bytesRead   atomic.Int64
+	batches     atomic.Uint64
+	completed   atomic.Uint64
+	records     atomic.Uint64
+	failures    atomic.Uint64
+	complements atomic.Int64
+	truncated   atomic.Uint64
+	delivered   atomic.Uint64
+	workers     atomic.Int64
+	queueAges   histogram
+	// Sizes of the dispatched batches, see Stats.BatchSize
+	batchBytes atomic.Int64
+	minBatch   atomic.Int64
+	maxBatch   atomic.Int64
+	// size is the expected number of bytes to read, zero when it is unknown
+	size atomic.Int64
+
+	// throughput is the rolling estimate used for the ETA
+	throughput throughput
+}
+
+// Progress takes a snapshot of the counters, it never blocks the Eat call
+func (t *Tracker) Progress() Progress {
+	return t.progress(time.Now())
+}
+
+// progress takes a snapshot of the counters at the given time
+func (t *Tracker) progress(now time.Time) Progress {
+	p := Progress{
+		BytesRead:         ByteSize(t.bytesRead.Load()),
+		BatchesDispatched: t.batches.Load(),
+		BatchesCompleted:  t.completed.Load(),
+		Records:           t.records.Load(),
+		Errors:            t.failures.Load(),
+		Workers:           int(t.workers.Load()),
+	}
+
+	if started := t.started.Load(); started != 0 {
+		p.Elapsed = now.Sub(time.Unix(0, started))
+	}
+
+	size := t.size.Load()
+	if size <= 0 {
+		return p
+	}
+
+	read := int64(p.BytesRead)
+	perSecond := t.throughput.update(now, time.Unix(0, t.started.Load()), read)
+
+	switch {
+	case read > size:
+		p.Fraction, p.SizeExceeded = 1, true
+	case read == size:
+		p.Fraction = 1
+	default:
+		p.Fraction = float64(read) / float64(size)
+
+		if perSecond > 0 {
+			p.ETA = time.Duration(float64(size-read) / perSecond * float64(time.Second))
+		}
+	}
+
+	return p
+}
+
+// throughputWindow is the time it takes for the throughput estimate to forget a sample
+const throughputWindow = 10 * time.Second
+
+// throughput is an exponentially weighted estimate of the bytes read per second
+type throughput struct {
+	mu        sync.Mutex
+	at        time.Time
+	bytes     int64
+	perSecond float64
+}
+
+// update adds a sample of the bytes read until now and returns the estimate
+func (r *throughput) update(now, started time.Time, bytes int64) float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// The first sample covers the time since the start
+	if r.at.IsZero() || r.at.Before(started) {
+		r.at, r.bytes, r.perSecond = started, 0, 0
+	}
+
+	elapsed := now.Sub(r.at)
+	if elapsed <= 0 {
+		return r.perSecond
+	}
+
+	sample := float64(bytes-r.bytes) / elapsed.Seconds()
+
+	if r.perSecond == 0 {
+		r.perSecond = sample
+	} else {
+		weight := 1 - math.Exp(-float64(elapsed)/float64(throughputWindow))
+		r.perSecond += weight * (sample - r.perSecond)
+	}
+
+	r.at, r.bytes = now, bytes
+
+	return r.perSecond
+}
+
+// reset sets every counter to zero and marks the start of an Eat call
+func (t *Tracker) reset() {
+	t.started.Store(time.Now().UnixNano())
+	t.bytesRead.Store(0)
+	t.batches.Store(0)
+	t.completed.Store(0)
+	t.records.Store(0)
+	t.failures.Store(0)
+	t.complements.Store(0)
+	t.truncated.Store(0)
+	t.delivered.Store(0)
+	t.workers.Store(0)
+	t.queueAges.reset()
+	t.batchBytes.Store(0)
+	t.minBatch.Store(0)
+	t.maxBatch.Store(0)
+	t.size.Store(0)
+}
+
+// elapsed returns the time since the Eat call started
+func (t *Tracker) elapsed() time.Duration {
+	return time.Since(time.Unix(0, t.started.Load()))
+}
+
+// reporter calls OnProgress from its own goroutine, see Bread.OnProgress
+type reporter struct {
+	report func(Progress)
+	// completed signals that a batch completed, it holds at most one pending signal. It is nil with a ProgressInterval
+	completed chan struct{}
+	stop      chan struct{}
+	stopped   chan struct{}
+}
+
+// startReporter starts the goroutine that calls OnProgress, if any
+func (e *eater) startReporter() {
+	if e.OnProgress == nil {
+		return
+	}
+
+	r := &reporter{
+		report:  e.OnProgress,
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+
+	var tick <-chan time.Time
+	var ticker *time.Ticker
+
+	if e.ProgressInterval > 0 {
+		ticker = time.NewTicker(e.ProgressInterval)
+		tick = ticker.C
+	} else {
+		r.completed = make(chan struct{}, 1)
+	}
+
+	e.reporter = r
+
+	go func() {
+		defer close(r.stopped)
+
+		if ticker != nil {
+			defer ticker.Stop()
+		}
+
+		for {
+			select {
+			case <-r.stop:
+				return
+			case <-tick:
+			case <-r.completed:
+			}
+
+			r.report(e.counters.progress(time.Now()))
+		}
+	}()
+}
+
+// notify signals a completed batch without blocking, the signals not taken yet are merged
+func (r *reporter) notify() {
+	if r == nil || r.completed == nil {
+		return
+	}
+
+	select {
+	case r.completed <- struct{}{}:
+	default:
+	}
+}
+
+// stopReporter waits for the goroutine of the reporter and reports the final counters
+func (e *eater) stopReporter() {
+	r := e.reporter
+	if r == nil {
+		return
+	}
+
+	close(r.stop)
+	<-r.stopped
+
+	r.report(e.counters.progress(time.Now()))
+}
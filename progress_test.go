@@ -0,0 +1,315 @@
+package bread
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTracker_Progress(t *testing.T) {
+	tracker := new(Tracker)
+
+	bread := Bread{
+		Workers:    8,
+		BufferSize: 1 << 10,
+		Tracker:    tracker,
+	}
+
+	data := memoryData(8 * MB)
+
+	done := make(chan struct{})
+	wg := sync.WaitGroup{}
+
+	// Concurrent polling while eating
+	for poller := 0; poller < 4; poller++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			last := Progress{}
+
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				p := tracker.Progress()
+
+				if p.BytesRead < last.BytesRead || p.BatchesDispatched < last.BatchesDispatched ||
+					p.BatchesCompleted < last.BatchesCompleted || p.Records < last.Records {
+					t.Errorf("the progress went backwards: %+v after %+v", p, last)
+					return
+				}
+
+				last = p
+			}
+		}()
+	}
+
+	err := bread.EatBatches(context.TODO(), bytes.NewReader(data), func(context.Context, Batch) {})
+
+	close(done)
+	wg.Wait()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := tracker.Progress()
+
+	if p.BytesRead != ByteSize(len(data)) || p.BatchesCompleted != p.BatchesDispatched || p.Errors != 0 {
+		t.Fatalf("unexpected final progress %+v", p)
+	}
+
+	if p.Records != uint64(bytes.Count(data, []byte{DefaultDelimiter})+1) {
+		t.Fatalf("unexpected number of records %d", p.Records)
+	}
+
+	t.Log("Success!")
+}
+
+func TestBread_OnProgress(t *testing.T) {
+	cases := [...]struct {
+		bread Bread
+		data  []byte
+		// min is the minimum number of calls, including the final one
+		min int
+	}{
+		{
+			bread: Bread{Workers: 8, BufferSize: 1 << 10},
+			data:  memoryData(1 * MB),
+			min:   2,
+		},
+		{
+			// The inline batches may not leave the reporter any chance to run before the end
+			bread: Bread{Workers: 8, BufferSize: 1 << 10, SyncThreshold: 2 << 20},
+			data:  memoryData(1 * MB),
+			min:   1,
+		},
+		{
+			bread: Bread{Workers: 2, BufferSize: 1 << 10, ProgressInterval: 5 * time.Millisecond},
+			data:  memoryData(64 * KB),
+			min:   3,
+		},
+		{
+			bread: Bread{Workers: 2, BufferSize: 1 << 10},
+			min:   1,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			var calls []Progress
+			// running detects concurrent calls, no locking is needed otherwise
+			running := atomic.Int32{}
+
+			c.bread.OnProgress = func(p Progress) {
+				if running.Add(1) > 1 {
+					t.Error("concurrent progress calls")
+				}
+				defer running.Add(-1)
+
+				calls = append(calls, p)
+			}
+
+			stats := Stats{}
+			c.bread.OnComplete = func(_ context.Context, s Stats, _ error) {
+				stats = s
+			}
+
+			err := c.bread.EatBatches(context.TODO(), bytes.NewReader(c.data), func(context.Context, Batch) {
+				if c.bread.ProgressInterval > 0 {
+					time.Sleep(time.Millisecond)
+				}
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(calls) < c.min {
+				t.Fatalf("expected at least %d calls, got %d", c.min, len(calls))
+			}
+
+			for j := 1; j < len(calls); j++ {
+				p, last := calls[j], calls[j-1]
+
+				if p.BytesRead < last.BytesRead || p.BatchesDispatched < last.BatchesDispatched ||
+					p.BatchesCompleted < last.BatchesCompleted || p.Records < last.Records || p.Elapsed < last.Elapsed {
+					t.Fatalf("the progress went backwards: %+v after %+v", p, last)
+				}
+			}
+
+			final := calls[len(calls)-1]
+
+			if final.BytesRead != stats.BytesRead || final.BatchesDispatched != stats.Batches ||
+				final.BatchesCompleted != stats.Batches || final.Records != stats.Records || final.BytesRead != ByteSize(len(c.data)) {
+				t.Fatalf("the final progress %+v does not match the stats %+v", final, stats)
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+func TestTracker_ProgressETA(t *testing.T) {
+	started := time.Unix(1_000, 0)
+
+	cases := [...]struct {
+		size int64
+		// samples are the bytes read after each second
+		samples  []int64
+		expected Progress
+	}{
+		{
+			samples:  []int64{100},
+			expected: Progress{BytesRead: 100},
+		},
+		{
+			size:     1000,
+			samples:  []int64{100, 200, 300, 400},
+			expected: Progress{BytesRead: 400, Fraction: 0.4, ETA: 6 * time.Second},
+		},
+		{
+			size:     1000,
+			samples:  []int64{500, 1000},
+			expected: Progress{BytesRead: 1000, Fraction: 1},
+		},
+		{
+			// Growing input
+			size:     1000,
+			samples:  []int64{900, 1500},
+			expected: Progress{BytesRead: 1500, Fraction: 1, SizeExceeded: true},
+		},
+		{
+			// Nothing read yet
+			size:     1000,
+			samples:  []int64{0},
+			expected: Progress{},
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			tracker := new(Tracker)
+			tracker.started.Store(started.UnixNano())
+			tracker.size.Store(c.size)
+
+			var p Progress
+
+			for second, read := range c.samples {
+				tracker.bytesRead.Store(read)
+				p = tracker.progress(started.Add(time.Duration(second+1) * time.Second))
+			}
+
+			c.expected.Elapsed = time.Duration(len(c.samples)) * time.Second
+
+			if p != c.expected {
+				t.Fatalf("expected %+v, got %+v", c.expected, p)
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+func TestBread_DetectSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data")
+
+	if err := os.WriteFile(path, []byte("0123456789"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := [...]struct {
+		reader   func(t *testing.T) io.Reader
+		expected int64
+	}{
+		{
+			reader: func(*testing.T) io.Reader {
+				r := strings.NewReader("0123456789")
+				_, _ = r.Seek(4, io.SeekStart)
+
+				return r
+			},
+			expected: 6,
+		},
+		{
+			reader: func(t *testing.T) io.Reader {
+				f, err := os.Open(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				t.Cleanup(func() {
+					_ = f.Close()
+				})
+
+				_, _ = f.Seek(3, io.SeekStart)
+
+				return f
+			},
+			expected: 7,
+		},
+		{
+			reader: func(t *testing.T) io.Reader {
+				r, w, err := os.Pipe()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				_, _ = w.WriteString("0123456789")
+				_ = w.Close()
+
+				t.Cleanup(func() {
+					_ = r.Close()
+				})
+
+				return r
+			},
+		},
+		{
+			reader: func(*testing.T) io.Reader {
+				return readFunc(strings.NewReader("0123456789").Read)
+			},
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			reader := c.reader(t)
+
+			if size := detectSize(reader); size != c.expected {
+				t.Fatalf("expected size %d, got %d", c.expected, size)
+			}
+
+			tracker := new(Tracker)
+
+			bread := Bread{
+				BufferSize: 4,
+				DetectSize: true,
+				Tracker:    tracker,
+			}
+
+			if err := bread.EatBatches(context.TODO(), reader, func(context.Context, Batch) {}); err != nil {
+				t.Fatal(err)
+			}
+
+			// The size is detected from the position of the reader
+			if p := tracker.Progress(); c.expected > 0 && p.Fraction != 1 {
+				t.Fatalf("expected the whole input to be read, got %+v", p)
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
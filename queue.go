@@ -0,0 +1,140 @@
+package bread
+
+import (
+	"container/heap"
+	"sync"
+)
+
+// newBatchQueue builds a queue of at most depth batches, ordered by priority when priority is not nil
+func newBatchQueue(depth int, priority func(BatchMeta, []byte) int) *batchQueue {
+	q := &batchQueue{
+		depth:    depth,
+		priority: priority,
+	}
+
+	if priority == nil {
+		q.fifo = make(chan Batch, depth)
+		return q
+	}
+
+	q.notFull = sync.NewCond(&q.mu)
+	q.notEmpty = sync.NewCond(&q.mu)
+
+	return q
+}
+
+// batchQueue holds the batches waiting for a worker
+type batchQueue struct {
+	depth    int
+	priority func(BatchMeta, []byte) int
+
+	// fifo is used when there is no priority function
+	fifo chan Batch
+
+	mu       sync.Mutex
+	notFull  *sync.Cond
+	notEmpty *sync.Cond
+	pending  batchHeap
+	sequence uint64
+	closed   bool
+	once     sync.Once
+}
+
+// push adds a batch to the queue, blocking while the queue is full
+func (q *batchQueue) push(batch Batch) {
+	if q.fifo != nil {
+		q.fifo <- batch
+		return
+	}
+
+	item := prioritized{
+		Batch:    batch,
+		priority: q.priority(batch.BatchMeta, batch.Data),
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for len(q.pending) >= q.depth {
+		q.notFull.Wait()
+	}
+
+	item.sequence = q.sequence
+	q.sequence++
+
+	heap.Push(&q.pending, item)
+	q.notEmpty.Signal()
+}
+
+// pop removes the next batch from the queue, blocking while the queue is empty.
+// It returns false once the queue is closed and drained.
+func (q *batchQueue) pop() (Batch, bool) {
+	if q.fifo != nil {
+		batch, ok := <-q.fifo
+		return batch, ok
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for len(q.pending) == 0 {
+		if q.closed {
+			return Batch{}, false
+		}
+
+		q.notEmpty.Wait()
+	}
+
+	item := heap.Pop(&q.pending).(prioritized)
+	q.notFull.Signal()
+
+	return item.Batch, true
+}
+
+// close stops accepting batches, the pending ones can still be popped
+func (q *batchQueue) close() {
+	q.once.Do(func() {
+		if q.fifo != nil {
+			close(q.fifo)
+			return
+		}
+
+		q.mu.Lock()
+		q.closed = true
+		q.notEmpty.Broadcast()
+		q.mu.Unlock()
+	})
+}
+
+// prioritized is a queued batch along with its ordering keys
+type prioritized struct {
+	Batch
+	priority int
+	sequence uint64
+}
+
+// batchHeap implements heap.Interface, higher priorities first and FIFO among equal priorities
+type batchHeap []prioritized
+
+func (h batchHeap) Len() int { return len(h) }
+
+func (h batchHeap) Less(i, j int) bool {
+	if h[i].priority != h[j].priority {
+		return h[i].priority > h[j].priority
+	}
+
+	return h[i].sequence < h[j].sequence
+}
+
+func (h batchHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *batchHeap) Push(x any) { *h = append(*h, x.(prioritized)) }
+
+func (h *batchHeap) Pop() any {
+	old := *h
+	item := old[len(old)-1]
+	old[len(old)-1] = prioritized{}
+	*h = old[:len(old)-1]
+
+	return item
+}
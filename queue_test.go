@@ -0,0 +1,141 @@
+package bread
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBatchQueue(t *testing.T) {
+	cases := [...]struct {
+		priority   func(BatchMeta, []byte) int
+		priorities []int
+		expected   []uint64
+	}{
+		{
+			priorities: []int{0, 0, 0, 0},
+			expected:   []uint64{0, 1, 2, 3},
+		},
+		{
+			priority: func(meta BatchMeta, _ []byte) int {
+				return []int{1, 3, 1, 3, 2}[meta.Index]
+			},
+			expected: []uint64{1, 3, 4, 0, 2},
+		},
+		{
+			priority: func(meta BatchMeta, _ []byte) int {
+				return -int(meta.Index)
+			},
+			expected: []uint64{0, 1, 2},
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			q := newBatchQueue(len(c.expected), c.priority)
+
+			for index := range c.expected {
+				q.push(Batch{BatchMeta: BatchMeta{Index: uint64(index)}})
+			}
+
+			q.close()
+
+			for _, expected := range c.expected {
+				batch, ok := q.pop()
+				if !ok {
+					t.Fatal("unexpected end of the queue")
+				}
+
+				if batch.Index != expected {
+					t.Fatalf("expected batch %d, got %d", expected, batch.Index)
+				}
+			}
+
+			if _, ok := q.pop(); ok {
+				t.Fatal("the queue must be drained")
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+func TestBread_QueueDepthCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	bread := Bread{
+		Workers:      1,
+		BufferSize:   16,
+		QueueDepth:   8,
+		PoolStrategy: PoolFreelist,
+	}
+
+	calls := atomic.Uint64{}
+	stats := Stats{}
+
+	bread.OnComplete = func(_ context.Context, s Stats, _ error) {
+		stats = s
+	}
+
+	err := bread.EatBatches(ctx, bytes.NewReader(memoryData(MB)), func(ctx context.Context, _ Batch) {
+		calls.Add(1)
+
+		// The queue fills up while the first batch is processed
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected error %v, got %v", context.Canceled, err)
+	}
+
+	// The queued batches were dropped
+	if calls.Load() != 1 || stats.Batches != 1 {
+		t.Fatalf("%d worker calls for %d batches", calls.Load(), stats.Batches)
+	}
+
+	// Every buffer was returned to the freelist, so none was allocated past its size
+	if stats.Allocations > uint64(bread.Workers+int(bread.QueueDepth)+1) {
+		t.Fatalf("unexpected allocations %d", stats.Allocations)
+	}
+
+	t.Log("Success!")
+}
+
+// BenchmarkBread_QueueDepth eats a reader that takes 1ms per read with a worker that takes 8ms every 8 batches,
+// the queue lets the reading go on during the slow batches
+func BenchmarkBread_QueueDepth(b *testing.B) {
+	data := memoryData(64 * KB)
+
+	for _, depth := range []uint32{0, 8} {
+		b.Run("QueueDepth="+strconv.Itoa(int(depth)), func(b *testing.B) {
+			bread := Bread{
+				Workers:    1,
+				BufferSize: 4 * KB,
+				QueueDepth: depth,
+			}
+
+			b.SetBytes(int64(len(data)))
+
+			for n := 0; n < b.N; n++ {
+				reader := bytes.NewReader(data)
+
+				err := bread.EatBatches(context.TODO(), readFunc(func(p []byte) (int, error) {
+					time.Sleep(time.Millisecond)
+					return reader.Read(p)
+				}), func(_ context.Context, batch Batch) {
+					if batch.Index%8 == 0 {
+						time.Sleep(8 * time.Millisecond)
+					}
+				})
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
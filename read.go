@@ -0,0 +1,845 @@
+package bread
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+)
+
+// read splits the reader into batches and dispatches them
+func (e *eater) read(ctx context.Context, reader io.Reader) (err error) {
+	// The batches read the rest of the same bufio.Reader, see newReader
+	if e.StartOffset > 0 {
+		// Eat positions the reader before wrapping it
+		if !e.positioned {
+			if err := e.seekStart(reader); err != nil {
+				return err
+			}
+		}
+
+		r := e.newReader(reader)
+
+		if err := e.skipStart(r); err != nil {
+			return err
+		}
+
+		reader = r
+	}
+
+	// The leading bytes are read again by the batches, a followed stream is sampled up to its current end
+	if e.DetectDelimiter {
+		r := e.newReader(reader)
+		e.detectDelimiter(r)
+		e.notifyStart(ctx)
+
+		reader = r
+	}
+
+	if e.Follow {
+		reader = e.follow(ctx, reader)
+
+		// The reading ends with the context, the partial record is dropped
+		defer func() {
+			if errors.Is(err, errFollowStopped) {
+				err = nil
+			}
+		}()
+	}
+
+	if e.ContinueOnError {
+		resilient := e.resilient(ctx, reader)
+		reader = resilient
+
+		defer func() {
+			err = resilient.result(err)
+		}()
+	}
+
+	// The reads of the batches holding whole records stop at FlushInterval
+	if f := e.flushable(reader); f != nil {
+		e.flusher = f
+		reader = f
+	}
+
+	// The batches read the rest of the same bufio.Reader, see newReader
+	if e.SkipLines > 0 {
+		r := e.newReader(reader)
+
+		if err := e.skipLines(ctx, r); err != nil {
+			return err
+		}
+
+		reader = r
+	}
+
+	if e.RecordSize > 0 {
+		return e.readFixed(ctx, e.newReader(reader))
+	}
+
+	if e.Framing == FramingNone {
+		return e.readRaw(ctx, e.newReader(reader))
+	}
+
+	if e.SplitFunc != nil {
+		return e.readSplit(ctx, e.newReader(reader))
+	}
+
+	// The contents of a bytes.Buffer are already in memory
+	if buffer, ok := reader.(*bytes.Buffer); ok && e.sliceable() {
+		return e.readBytes(ctx, buffer.Next(buffer.Len()))
+	}
+
+	if e.SyncThreshold > 0 && e.sliceable() && e.flusher == nil {
+		data, whole, err := e.head(reader)
+		if whole {
+			return e.readInline(ctx, data)
+		}
+
+		// The bytes already read go first, followed by the rest of the input or the read error
+		if err != nil {
+			reader = failedReader{err: err}
+		}
+
+		reader = io.MultiReader(bytes.NewReader(data), reader)
+	}
+
+	r := e.newReader(reader)
+
+	if f := e.framer(); f != nil {
+		return e.readFramed(ctx, r, f)
+	}
+
+	if e.TruncateRecordsAt > 0 {
+		return e.readTruncated(ctx, r)
+	}
+
+	if e.Align == AlignBackward {
+		return e.readBackward(ctx, r)
+	}
+
+	if e.MaxBatchSize > 0 {
+		return e.readHybrid(ctx, r)
+	}
+
+	return e.readForward(ctx, r)
+}
+
+// maxEmptyReads is the number of consecutive reads without data nor error tolerated before failing
+// with io.ErrNoProgress, like bufio.Reader does
+const maxEmptyReads = 100
+
+// readerBufferSize returns the size of the bufio.Reader of the stream, see ReaderBufferSize
+func (b Bread) readerBufferSize() int {
+	if b.ReaderBufferSize == 0 {
+		return max(b.BufferSize, DefaultReaderBufferSize)
+	}
+
+	return b.ReaderBufferSize
+}
+
+// newReader buffers the reader with ReaderBufferSize, so the records can be completed, after retrying the empty
+// reads. A *bufio.Reader large enough is kept as it is, it retries the empty reads by itself.
+func (e *eater) newReader(reader io.Reader) *bufio.Reader {
+	size := e.readerBufferSize()
+
+	if r, ok := reader.(*bufio.Reader); ok && r.Size() >= size {
+		return r
+	}
+
+	return bufio.NewReaderSize(progressReader{Reader: reader}, size)
+}
+
+// progressReader retries the reads that return neither data nor error, allowed by io.Reader but discouraged,
+// so the batches are never empty and the reading does not spin. See maxEmptyReads
+type progressReader struct {
+	io.Reader
+}
+
+// Read implements io.Reader
+func (r progressReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	for range maxEmptyReads {
+		n, err := r.Reader.Read(p)
+		if n > 0 || err != nil {
+			return n, err
+		}
+	}
+
+	return 0, io.ErrNoProgress
+}
+
+// readForward completes each buffer with the bytes up to the next delimiter
+func (e *eater) readForward(ctx context.Context, r *bufio.Reader) (err error) {
+	n, complement := 0, 0
+
+	detector := e.newDetector(ctx)
+
+	// carry holds the start of the record left by the last flush, see FlushInterval
+	var carry []byte
+	if e.flusher != nil {
+		carry = e.scratch(e.BufferSize)
+	}
+
+	for !e.failed.Load() && !e.stopped {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+
+		allocations := e.pool.Allocations()
+		buffer := e.pool.Get()
+		e.adapt(buffer)
+
+		// The record left by the last flush starts the buffer
+		filled := copy(*buffer, carry)
+		*buffer = append(*buffer, carry[filled:]...)
+		filled, carry = len(carry), carry[:0]
+
+		// The bufio.Reader returns the bytes read along with an error first, and the error on the next call,
+		// so the last bytes of the stream are never dropped
+		n, err = 0, nil
+		if filled < len(*buffer) {
+			n, err = e.readMin(ctx, r, (*buffer)[filled:])
+		}
+
+		// The batch being filled is dropped like the next ones, see MinFill
+		if e.MinFill > 0 && ctx.Err() != nil {
+			e.pool.Put(buffer)
+			return nil
+		}
+
+		// The record left by the last flush, or the bytes accumulated for MinFill, end the stream
+		if err != nil && (filled+n == 0 || err != io.EOF) {
+			e.pool.Put(buffer)
+
+			if err == io.EOF {
+				break
+			}
+
+			return &ReadError{Position: e.offset + int64(filled+n), Err: err}
+		}
+
+		*buffer = (*buffer)[:filled+n]
+		n += filled
+
+		var records int
+
+		complement, records, err = e.completeRecord(r, buffer)
+
+		if err == errIdle {
+			cut := bytes.LastIndex(*buffer, e.separator) + len(e.separator)
+
+			if carry, err = e.carry(carry, (*buffer)[cut:], e.offset+int64(cut)); err != nil {
+				e.pool.Put(buffer)
+				return err
+			}
+
+			*buffer = (*buffer)[:cut]
+			e.emit(ctx, buffer, records)
+
+			continue
+		}
+
+		if err != nil && err != io.EOF {
+			e.pool.Put(buffer)
+
+			if _, ok := err.(*RecordTooLargeError); ok {
+				return err
+			}
+
+			return &ReadError{Position: e.offset + int64(n+complement), Err: err}
+		}
+
+		// The complement ends with the delimiter, unless the stream ended before it
+		if complement > 0 || (len(*buffer) > 0 && !bytes.HasSuffix(*buffer, e.separator)) {
+			records++
+		}
+
+		e.counters.complements.Add(int64(complement))
+		e.debugComplement(ctx, complement)
+
+		if detector != nil {
+			detector.observe(complement, e.pool.Allocations() != allocations)
+		}
+
+		e.observeRecords(len(*buffer), records)
+
+		e.emit(ctx, buffer, records)
+	}
+
+	return nil
+}
+
+// readBackward cuts each buffer after its last delimiter and carries the remaining bytes to the next buffer,
+// so batches never exceed BufferSize
+func (e *eater) readBackward(ctx context.Context, r *bufio.Reader) (err error) {
+	carry := e.scratch(e.BufferSize)
+
+	for eof := false; !eof && !e.failed.Load() && !e.stopped; {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+
+		buffer := e.pool.Get()
+		filled := copy(*buffer, carry)
+
+		// Reads until the buffer holds a delimiter and MinFill bytes, is full or the stream ends
+		for scanned, found := 0, false; filled < len(*buffer); {
+			n, err := r.Read((*buffer)[filled:])
+			filled += n
+
+			if err == io.EOF {
+				eof = true
+				break
+			}
+
+			if err != nil {
+				e.pool.Put(buffer)
+				return &ReadError{Position: e.offset + int64(filled), Err: err}
+			}
+
+			if found = found || bytes.IndexByte((*buffer)[scanned:filled], e.Delimiter) >= 0; found && filled >= e.MinFill {
+				break
+			}
+
+			// The batch being filled is dropped like the next ones, see MinFill
+			if e.MinFill > 0 && ctx.Err() != nil {
+				e.pool.Put(buffer)
+				return nil
+			}
+
+			scanned = filled
+		}
+
+		// The pass that finds the last delimiter counts the records too
+		records, cut := scanDelimiters((*buffer)[:filled], []byte{e.Delimiter})
+
+		switch {
+		case eof:
+			cut = filled
+		case cut == 0:
+			err = &RecordTooLargeError{Position: e.offset, Limit: e.BufferSize, Preview: e.preview(*buffer)}
+			e.pool.Put(buffer)
+
+			return err
+		}
+
+		if carry, err = e.carry(carry[:0], (*buffer)[cut:filled], e.offset+int64(cut)); err != nil {
+			e.pool.Put(buffer)
+			return
+		}
+
+		if cut == 0 {
+			e.pool.Put(buffer)
+			continue
+		}
+
+		*buffer = (*buffer)[:cut]
+
+		if (*buffer)[cut-1] != e.Delimiter {
+			records++
+		}
+
+		e.emit(ctx, buffer, records)
+	}
+
+	return nil
+}
+
+// readFixed fills each buffer with whole records of RecordSize bytes, however short the reads are
+func (e *eater) readFixed(ctx context.Context, r *bufio.Reader) error {
+	size := e.RecordSize
+	limit := e.BufferSize - e.BufferSize%size
+
+	// carry holds the start of the record left by the last flush, see FlushInterval
+	carry := make([]byte, 0, size)
+
+	for !e.failed.Load() && !e.stopped {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+
+		buffer := e.pool.Get()
+
+		n, err := e.readFull(r, (*buffer)[:limit], copy(*buffer, carry), size)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF && err != errIdle {
+			e.pool.Put(buffer)
+			return &ReadError{Position: e.offset + int64(n), Err: err}
+		}
+
+		carry = carry[:0]
+
+		if n == 0 {
+			e.pool.Put(buffer)
+			return nil
+		}
+
+		*buffer = (*buffer)[:n]
+		records := n / size
+
+		// The whole records go once the reader is idle
+		trailing := n % size
+		if err == errIdle && trailing > 0 {
+			carry = append(carry, (*buffer)[n-trailing:]...)
+			*buffer = (*buffer)[:n-trailing]
+			trailing = 0
+		}
+
+		if trailing == 0 || e.AllowTrailing {
+			if trailing > 0 {
+				records++
+			}
+
+			e.emit(ctx, buffer, records)
+			continue
+		}
+
+		trailingErr := &TrailingRecordError{Position: e.offset + int64(n-trailing), Size: trailing, RecordSize: size}
+
+		// The whole records go first
+		if records == 0 {
+			e.pool.Put(buffer)
+		} else {
+			*buffer = (*buffer)[:n-trailing]
+			e.emit(ctx, buffer, records)
+		}
+
+		return trailingErr
+	}
+
+	return nil
+}
+
+// readRaw fills each buffer with BufferSize bytes, however short the reads are, see FramingNone
+func (e *eater) readRaw(ctx context.Context, r *bufio.Reader) error {
+	for !e.failed.Load() && !e.stopped {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+
+		buffer := e.pool.Get()
+
+		// Any byte read goes once the reader is idle, see FlushInterval
+		n, err := e.readFull(r, (*buffer)[:e.BufferSize], 0, 1)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF && err != errIdle {
+			e.pool.Put(buffer)
+			return &ReadError{Position: e.offset + int64(n), Err: err}
+		}
+
+		if n == 0 {
+			e.pool.Put(buffer)
+			return nil
+		}
+
+		*buffer = (*buffer)[:n]
+		e.emit(ctx, buffer, 0)
+	}
+
+	return nil
+}
+
+// readMin reads into p like a single Read, or until p holds MinFill bytes when it is set. The bytes read before an
+// error are returned along with it. It stops early once the context is done, and with FlushInterval once the bytes
+// read hold a whole record and the reader is idle, the idle error is left for the completion of the record.
+func (e *eater) readMin(ctx context.Context, r io.Reader, p []byte) (n int, err error) {
+	fill := min(e.MinFill, len(p))
+
+	defer e.flusher.arm(false)
+
+	for {
+		read, err := r.Read(p[n:])
+		n += read
+
+		if err == errIdle {
+			return n, nil
+		}
+
+		if err != nil || n >= fill || ctx.Err() != nil {
+			return n, err
+		}
+
+		// The whole records go once the reader is idle, see FlushInterval
+		if e.flusher != nil && !e.flusher.armed {
+			e.flusher.arm(bytes.Contains(p[max(n-read-len(e.separator)+1, 0):n], e.separator))
+		}
+	}
+}
+
+// readFull works like io.ReadFull over p from its first n bytes. With FlushInterval it stops with errIdle once p
+// holds a whole record of the given size and the reader is idle.
+func (e *eater) readFull(r io.Reader, p []byte, n int, size int) (int, error) {
+	if e.flusher == nil {
+		read, err := io.ReadFull(r, p[n:])
+		return n + read, err
+	}
+
+	defer e.flusher.arm(false)
+
+	for n < len(p) {
+		e.flusher.arm(n >= size)
+
+		read, err := r.Read(p[n:])
+		n += read
+
+		if err != nil {
+			return n, err
+		}
+	}
+
+	return n, nil
+}
+
+// readFramed dispatches each record extracted by the framer as a batch
+func (e *eater) readFramed(ctx context.Context, r *bufio.Reader, f framer) error {
+	for !e.failed.Load() && !e.stopped {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+
+		buffer := e.pool.Get()
+		*buffer = (*buffer)[:0]
+
+		before, after, err := f.next(r, buffer, e.offset)
+
+		// Bytes consumed from the stream that are not part of the record
+		e.offset += int64(before)
+
+		if err == io.EOF {
+			e.pool.Put(buffer)
+			return nil
+		}
+
+		if err != nil {
+			if framingErr, ok := err.(*FramingError); ok {
+				framingErr.Preview = e.preview(*buffer)
+			}
+
+			e.pool.Put(buffer)
+
+			if _, ok := err.(Positioned); ok {
+				return err
+			}
+
+			return &ReadError{Position: e.offset, Err: err}
+		}
+
+		e.emit(ctx, buffer, 1)
+		e.offset += int64(after)
+	}
+
+	return nil
+}
+
+// readHybrid completes each buffer up to the next delimiter like readForward, but batches never exceed MaxBatchSize.
+// When the delimiter is further, the batch is cut after its last delimiter carrying the remaining bytes to the
+// next batch, or it is force-split at MaxBatchSize if it has no delimiter at all.
+func (e *eater) readHybrid(ctx context.Context, r *bufio.Reader) error {
+	limit := e.MaxBatchSize
+	carry := e.scratch(limit)
+
+	for !e.failed.Load() && !e.stopped {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+
+		buffer := e.pool.Get()
+		*buffer = append((*buffer)[:0], carry...)
+		carry = carry[:0]
+
+		eof := false
+
+		if filled := len(*buffer); filled < e.BufferSize {
+			n, err := e.readMin(ctx, r, (*buffer)[filled:e.BufferSize])
+			if e.MinFill > 0 && ctx.Err() != nil {
+				e.pool.Put(buffer)
+				return nil
+			}
+
+			if err != nil && err != io.EOF {
+				e.pool.Put(buffer)
+				return &ReadError{Position: e.offset + int64(filled+n), Err: err}
+			}
+
+			*buffer = (*buffer)[:filled+n]
+			eof = err == io.EOF
+		}
+
+		if len(*buffer) == 0 {
+			e.pool.Put(buffer)
+			return nil
+		}
+
+		// The delimiter is searched up to MaxBatchSize
+		complement, found, err := completeWithin(r, buffer, e.Delimiter, limit-len(*buffer))
+		if err != nil && err != io.EOF {
+			e.pool.Put(buffer)
+			return &ReadError{Position: e.offset + int64(len(*buffer)), Err: err}
+		}
+
+		eof = eof || err == io.EOF
+		e.counters.complements.Add(int64(complement))
+		e.debugComplement(ctx, complement)
+
+		if !found && !eof && len(*buffer) > 0 && (*buffer)[len(*buffer)-1] != e.Delimiter {
+			if cut := bytes.LastIndexByte(*buffer, e.Delimiter) + 1; cut > 0 {
+				if carry, err = e.carry(carry, (*buffer)[cut:], e.offset+int64(cut)); err != nil {
+					e.pool.Put(buffer)
+					return err
+				}
+
+				*buffer = (*buffer)[:cut]
+			} else {
+				e.forceSplit = true
+			}
+		}
+
+		records := bytes.Count(*buffer, []byte{e.Delimiter})
+
+		// Unterminated record at the end of the stream
+		if eof && (*buffer)[len(*buffer)-1] != e.Delimiter {
+			records++
+		}
+
+		e.emit(ctx, buffer, records)
+	}
+
+	return nil
+}
+
+// completeRecord appends to the buffer the bytes up to the next delimiter, the record completed must not exceed
+// MaxRecordSize. It returns the number of bytes appended and the number of delimiters of the buffer before them,
+// counted before the completion starts, so the whole records go once the reader is idle, see FlushInterval.
+func (e *eater) completeRecord(r *bufio.Reader, buffer *[]byte) (n, delimiters int, err error) {
+	defer e.flusher.arm(false)
+
+	if e.MaxRecordSize == 0 {
+		delimiters = bytes.Count(*buffer, e.separator)
+		e.flusher.arm(delimiters > 0)
+
+		if e.DelimiterBytes != nil {
+			n, _, err = completeBytes(r, buffer, e.DelimiterBytes, 0)
+			return
+		}
+
+		n, err = complete(r, buffer, e.Delimiter)
+		return
+	}
+
+	// The record being completed starts after the last delimiter of the buffer, found by the same pass that counts
+	// the delimiters
+	delimiters, start := scanDelimiters(*buffer, e.separator)
+	e.flusher.arm(delimiters > 0)
+
+	// The delimiter does not count towards the size of the record
+	limit := e.MaxRecordSize - (len(*buffer) - start) + len(e.separator)
+
+	found := false
+
+	if limit > 0 {
+		if e.DelimiterBytes != nil {
+			n, found, err = completeBytes(r, buffer, e.DelimiterBytes, limit)
+		} else {
+			n, found, err = completeWithin(r, buffer, e.Delimiter, limit)
+		}
+	}
+
+	// completeBytes may read past the limit
+	if (!found && err == nil) || n > limit {
+		err = &RecordTooLargeError{Position: e.offset + int64(start), Limit: e.MaxRecordSize, Preview: e.preview((*buffer)[start:])}
+	}
+
+	return
+}
+
+// scanDelimiters returns the number of delimiters in data and the position right after the last one, zero when
+// there is none
+func scanDelimiters(data, delimiter []byte) (count, end int) {
+	for i := bytes.Index(data, delimiter); i >= 0; i = bytes.Index(data[end:], delimiter) {
+		count++
+		end += i + len(delimiter)
+	}
+
+	return
+}
+
+// completeBytes works like complete for a delimiter of several bytes, reading about limit bytes at most when it is
+// positive. The delimiter may start in the buffer, when the buffer ends in the middle of it.
+func completeBytes(r *bufio.Reader, buffer *[]byte, delimiter []byte, limit int) (n int, found bool, err error) {
+	var slice []byte
+
+	for limit <= 0 || n < limit {
+		// Every occurrence of the delimiter ends with its last byte
+		slice, err = r.ReadSlice(delimiter[len(delimiter)-1])
+		*buffer = append(*buffer, slice...)
+		n += len(slice)
+
+		if bytes.HasSuffix(*buffer, delimiter) {
+			return n, true, nil
+		}
+
+		if err != nil && err != bufio.ErrBufferFull {
+			return
+		}
+	}
+
+	return n, false, nil
+}
+
+// completeWithin appends to the buffer the bytes up to the next delimiter, delimiter included, reading at most
+// limit bytes. found reports whether the delimiter was reached.
+func completeWithin(r *bufio.Reader, buffer *[]byte, delimiter byte, limit int) (n int, found bool, err error) {
+	for n < limit {
+		var peeked []byte
+
+		peeked, err = r.Peek(min(limit-n, r.Size()))
+
+		if i := bytes.IndexByte(peeked, delimiter); i >= 0 {
+			peeked, found, err = peeked[:i+1], true, nil
+		}
+
+		*buffer = append(*buffer, peeked...)
+		n += len(peeked)
+		_, _ = r.Discard(len(peeked))
+
+		if found || err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// scratch returns the staging area for the carried bytes, see Bread.Scratch
+func (e *eater) scratch(size int) []byte {
+	if e.Scratch != nil {
+		return e.Scratch[:0]
+	}
+
+	return make([]byte, 0, size)
+}
+
+// carry appends the bytes carried to the next batch, position is their stream offset
+func (e *eater) carry(carry, data []byte, position int64) ([]byte, error) {
+	if e.Scratch != nil && len(carry)+len(data) > cap(carry) {
+		return carry, &ScratchTooSmallError{Position: position, Size: len(carry) + len(data), Capacity: cap(carry)}
+	}
+
+	return append(carry, data...), nil
+}
+
+// emit dispatches the buffer as the next batch of the stream
+func (e *eater) emit(ctx context.Context, buffer *[]byte, records int) {
+	// The batch that reaches MaxRecords is truncated right after the last allowed record
+	if e.MaxRecords > 0 && e.counters.records.Load()+uint64(records) >= e.MaxRecords {
+		if allowed := int(e.MaxRecords - e.counters.records.Load()); records > allowed {
+			end := recordsEnd(*buffer, e.separator, allowed)
+			if e.RecordSize > 0 {
+				end = allowed * e.RecordSize
+			}
+
+			if e.FinalFunc != nil {
+				e.leftover = bytes.Clone((*buffer)[end:])
+			}
+
+			records = allowed
+			*buffer = (*buffer)[:end]
+		}
+
+		e.stopped = true
+	}
+
+	batch := Batch{
+		BatchMeta: BatchMeta{
+			Index:   e.index,
+			Offset:  e.offset,
+			Size:    len(*buffer),
+			Records: uint32(records),
+			// The counter is updated after the batch is built
+			FirstRecord: e.counters.records.Load() - e.epochBase.Records,
+			Epoch:       e.epoch,
+			Source:      e.sourceIndex,
+			// Only readHybrid splits records
+			ForceSplit: e.forceSplit,
+		},
+		Data:      *buffer,
+		buffer:    buffer,
+		separator: e.detected,
+	}
+
+	e.index++
+	e.offset += int64(len(*buffer))
+	e.forceSplit = false
+
+	e.counters.bytesRead.Store(int64(e.epochBase.BytesRead) + e.offset - e.startOffset)
+	e.counters.observeBatch(len(*buffer))
+	e.counters.batches.Add(1)
+	e.counters.records.Add(uint64(records))
+
+	if e.LimitBytes > 0 && e.counters.bytesRead.Load() >= e.LimitBytes {
+		e.stopped, e.limited = true, true
+	}
+
+	if e.MaxBatches > 0 && e.counters.batches.Load() >= e.MaxBatches {
+		e.stopped = true
+	}
+
+	e.flusher.dispatched()
+	e.debugDispatched(ctx, batch)
+
+	if e.shuffle != nil {
+		e.reserve(ctx, batch)
+		return
+	}
+
+	e.dispatch(ctx, batch)
+}
+
+// recordsEnd returns the position right after the first n records of data
+func recordsEnd(data []byte, delimiter []byte, n int) (end int) {
+	for ; n > 0; n-- {
+		i := bytes.Index(data[end:], delimiter)
+		if i < 0 {
+			return len(data)
+		}
+
+		end += i + len(delimiter)
+	}
+
+	return end
+}
+
+// complete appends to the buffer the bytes up to the next delimiter, delimiter included,
+// copying them straight from the bufio.Reader. It returns the number of bytes appended.
+//
+// ReadSlice scans the buffered bytes with bytes.IndexByte, so the delimiter is searched a chunk at a time
+// rather than byte by byte, see BenchmarkScan.
+func complete(r *bufio.Reader, buffer *[]byte, delimiter byte) (n int, err error) {
+	var slice []byte
+
+	for {
+		slice, err = r.ReadSlice(delimiter)
+		*buffer = append(*buffer, slice...)
+		n += len(slice)
+
+		// The delimiter is further than the internal buffer of the bufio.Reader
+		if err != bufio.ErrBufferFull {
+			return
+		}
+	}
+}
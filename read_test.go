@@ -0,0 +1,1230 @@
+package bread
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+func TestBread_AlignBackward(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+
+	// Records of random length up to maxRecord bytes, delimiter included
+	records := func(n, maxRecord int) []byte {
+		data := make([]byte, 0)
+
+		for i := 0; i < n; i++ {
+			data = append(data, bytes.Repeat([]byte{'a' + byte(i%26)}, random.Intn(maxRecord))...)
+			data = append(data, DefaultDelimiter)
+		}
+
+		return data
+	}
+
+	cases := [...]struct {
+		bread  Bread
+		data   []byte
+		reader func(io.Reader) io.Reader
+		err    error
+	}{
+		{
+			bread: Bread{Workers: 8, BufferSize: 64},
+			data:  records(10_000, 64),
+		},
+		{
+			bread:  Bread{Workers: 8, BufferSize: 100},
+			data:   records(5_000, 100),
+			reader: iotest.HalfReader,
+		},
+		{
+			bread:  Bread{Workers: 2, BufferSize: 16},
+			data:   append(records(100, 16), "unterminated"...),
+			reader: iotest.OneByteReader,
+		},
+		{
+			bread: Bread{BufferSize: 4},
+			data:  []byte("\n\n\n\n\n\n\n\n\n"),
+		},
+		{
+			bread: Bread{BufferSize: 8},
+			data:  []byte("short\nthis record is too large\nshort\n"),
+			err:   ErrRecordTooLarge,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			c.bread.Align = AlignBackward
+
+			var reader io.Reader = bytes.NewReader(c.data)
+			if c.reader != nil {
+				reader = c.reader(reader)
+			}
+
+			mu := sync.Mutex{}
+			batches := make([]Batch, 0)
+
+			err := c.bread.EatBatches(context.TODO(), reader, func(_ context.Context, batch Batch) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				batches = append(batches, Batch{BatchMeta: batch.BatchMeta, Data: bytes.Clone(batch.Data)})
+			})
+
+			if c.err != nil {
+				if !errors.Is(err, c.err) {
+					t.Fatalf("expected %v, got %v", c.err, err)
+				}
+
+				offset, _ := OffsetOf(err)
+				t.Logf("Success! %v at offset %d", err, offset)
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			sort.Slice(batches, func(i, j int) bool {
+				return batches[i].Index < batches[j].Index
+			})
+
+			got := make([]byte, 0, len(c.data))
+
+			for j, batch := range batches {
+				if len(batch.Data) > c.bread.BufferSize {
+					t.Fatalf("batch %d has %d bytes, more than BufferSize", j, len(batch.Data))
+				}
+
+				if batch.Offset != int64(len(got)) {
+					t.Fatalf("batch %d starts at %d, expected %d", j, batch.Offset, len(got))
+				}
+
+				if j < len(batches)-1 && !bytes.HasSuffix(batch.Data, []byte{DefaultDelimiter}) {
+					t.Fatalf("batch %d does not end with the delimiter", j)
+				}
+
+				got = append(got, batch.Data...)
+			}
+
+			// No bytes lost or duplicated across the carry
+			if !bytes.Equal(got, c.data) {
+				t.Fatal("the batches do not reproduce the input")
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+func TestBread_MaxRecords(t *testing.T) {
+	// Ten records of five bytes: "r000\n", "r001\n", ...
+	data := make([]byte, 0)
+	for i := 0; i < 10; i++ {
+		data = append(data, []byte("r00"+strconv.Itoa(i)+"\n")...)
+	}
+
+	cases := [...]struct {
+		bread    Bread
+		data     []byte
+		expected int
+	}{
+		{
+			// Limit in the middle of a batch
+			bread:    Bread{BufferSize: 12, MaxRecords: 4},
+			data:     data,
+			expected: 4,
+		},
+		{
+			// Limit at the exact end of a batch
+			bread:    Bread{BufferSize: 10, MaxRecords: 4},
+			data:     data,
+			expected: 4,
+		},
+		{
+			bread:    Bread{BufferSize: 1, MaxRecords: 1},
+			data:     data,
+			expected: 1,
+		},
+		{
+			bread:    Bread{BufferSize: 12, MaxRecords: 7, Align: AlignBackward, Workers: 4},
+			data:     data,
+			expected: 7,
+		},
+		{
+			bread:    Bread{BufferSize: 12, MaxRecords: 100},
+			data:     data,
+			expected: 10,
+		},
+		{
+			// The last record is not terminated
+			bread:    Bread{BufferSize: 32, MaxRecords: 3},
+			data:     []byte("a\nb\nc"),
+			expected: 3,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			mu := sync.Mutex{}
+			got := make([]byte, len(c.data))
+			records, size := 0, 0
+
+			err := c.bread.EatBatches(context.TODO(), bytes.NewReader(c.data), func(_ context.Context, batch Batch) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				records += int(batch.Records)
+				size += len(batch.Data)
+				copy(got[batch.Offset:], batch.Data)
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if records != c.expected {
+				t.Fatalf("expected %d records, got %d", c.expected, records)
+			}
+
+			// Exactly the first records of the stream
+			expected := c.data[:recordsEnd(c.data, []byte{DefaultDelimiter}, c.expected)]
+
+			if !bytes.Equal(got[:size], expected) {
+				t.Fatalf("expected %q, got %q", expected, got[:size])
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+func TestBread_MaxBatches(t *testing.T) {
+	// Far more records than the limit
+	data := memoryData(10 * MB)
+
+	cases := [...]struct {
+		bread Bread
+		// cancel cancels the context from the batch that reaches the limit
+		cancel bool
+	}{
+		{
+			bread: Bread{BufferSize: 1, Workers: 1, MaxBatches: 1000},
+		},
+		{
+			bread: Bread{BufferSize: 1, Workers: 8, MaxBatches: 1000},
+		},
+		{
+			bread: Bread{BufferSize: 1, Workers: 8, MaxBatches: 1000, QueueDepth: 16},
+		},
+		{
+			bread: Bread{BufferSize: 512, Workers: 8, MaxBatches: 1000, Align: AlignBackward},
+		},
+		{
+			bread:  Bread{BufferSize: 1, Workers: 8, MaxBatches: 1000},
+			cancel: true,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			goroutines := runtime.NumGoroutine()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			calls := atomic.Uint64{}
+
+			c.bread.WorkerBatchFunc = func(_ context.Context, batch Batch) {
+				calls.Add(1)
+
+				if c.cancel && batch.Index+1 == c.bread.MaxBatches {
+					cancel()
+				}
+			}
+
+			err := c.bread.Eat(ctx, bytes.NewReader(data))
+
+			switch {
+			case c.cancel:
+				if !errors.Is(err, context.Canceled) {
+					t.Fatalf("expected error %v, got %v", context.Canceled, err)
+				}
+			case err != nil:
+				t.Fatal(err)
+			case calls.Load() != c.bread.MaxBatches:
+				t.Fatalf("expected %d calls, got %d", c.bread.MaxBatches, calls.Load())
+			}
+
+			for deadline := time.Now().Add(time.Second); runtime.NumGoroutine() > goroutines; {
+				if time.Now().After(deadline) {
+					t.Fatalf("expected %d goroutines, got %d", goroutines, runtime.NumGoroutine())
+				}
+
+				time.Sleep(time.Millisecond)
+			}
+
+			t.Logf("Success! %v", err)
+		})
+	}
+}
+
+func TestBread_MaxBatchSize(t *testing.T) {
+	type expected struct {
+		data    string
+		records uint32
+		split   bool
+	}
+
+	cases := [...]struct {
+		bread    Bread
+		data     string
+		reader   func(io.Reader) io.Reader
+		expected []expected
+		err      error
+	}{
+		{
+			// Delimiter right at MaxBatchSize
+			bread:    Bread{BufferSize: 4, MaxBatchSize: 8},
+			data:     "aaaaaaa\nb\n",
+			expected: []expected{{"aaaaaaa\n", 1, false}, {"b\n", 1, false}},
+		},
+		{
+			// Delimiter right after MaxBatchSize
+			bread:    Bread{BufferSize: 4, MaxBatchSize: 8},
+			data:     "aaaaaaaa\nb\n",
+			expected: []expected{{"aaaaaaaa", 0, true}, {"\nb\n", 2, false}},
+		},
+		{
+			// The batch is cut after its last delimiter, the remaining bytes are carried
+			bread:    Bread{BufferSize: 4, MaxBatchSize: 8},
+			data:     "ab\ncdefghij\nk",
+			expected: []expected{{"ab\n", 1, false}, {"cdefghij", 0, true}, {"\nk", 2, false}},
+		},
+		{
+			// Blob spanning several batches
+			bread:    Bread{BufferSize: 2, MaxBatchSize: 4},
+			data:     "x\n0123456789\ny\n",
+			expected: []expected{{"x\n", 1, false}, {"0123", 0, true}, {"4567", 0, true}, {"89\n", 1, false}, {"y\n", 1, false}},
+		},
+		{
+			bread:    Bread{BufferSize: 4, MaxBatchSize: 8},
+			data:     "aaaaaaaa\nb\n",
+			reader:   iotest.OneByteReader,
+			expected: []expected{{"aaaaaaaa", 0, true}, {"\nb\n", 2, false}},
+		},
+		{
+			bread:    Bread{BufferSize: 4, MaxBatchSize: 8},
+			data:     "unterminated",
+			expected: []expected{{"unterminated"[:8], 0, true}, {"ated", 1, false}},
+		},
+		{
+			bread: Bread{BufferSize: 8, MaxBatchSize: 4},
+			err:   ErrMaxBatchSize,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			var reader io.Reader = bytes.NewReader([]byte(c.data))
+			if c.reader != nil {
+				reader = c.reader(reader)
+			}
+
+			batches, err := collect(t, c.bread, reader)
+			if !errors.Is(err, c.err) {
+				t.Fatalf("expected error %v, got %v", c.err, err)
+			}
+
+			if len(batches) != len(c.expected) {
+				t.Fatalf("expected %d batches, got %d", len(c.expected), len(batches))
+			}
+
+			offset := int64(0)
+
+			for j, batch := range batches {
+				e := c.expected[j]
+
+				if string(batch.Data) != e.data || batch.Records != e.records || batch.ForceSplit != e.split {
+					t.Fatalf("expected batch %q with %d records (split %v), got %q with %d records (split %v)",
+						e.data, e.records, e.split, batch.Data, batch.Records, batch.ForceSplit)
+				}
+
+				if batch.Offset != offset {
+					t.Fatalf("expected offset %d, got %d", offset, batch.Offset)
+				}
+
+				offset += int64(batch.Size)
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+func TestBread_DelimiterBytes(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+
+	// Records of random length up to maxRecord bytes, each one followed by delimiter
+	records := func(n, maxRecord int, delimiter string) string {
+		data := make([]byte, 0)
+
+		for i := 0; i < n; i++ {
+			data = append(data, bytes.Repeat([]byte{'a' + byte(i%26)}, random.Intn(maxRecord))...)
+			data = append(data, delimiter...)
+		}
+
+		return string(data)
+	}
+
+	cases := [...]struct {
+		bread  Bread
+		data   string
+		reader func(io.Reader) io.Reader
+		err    error
+	}{
+		{
+			bread: Bread{Workers: 4, BufferSize: 7, DelimiterBytes: []byte("\r\n")},
+			data:  records(1_000, 16, "\r\n"),
+		},
+		{
+			// The delimiter straddles the end of the buffer on every read
+			bread:  Bread{BufferSize: 1, DelimiterBytes: []byte("\r\n")},
+			data:   "a\r\nb\r\n\r\nc\rd\r\nunterminated\r",
+			reader: iotest.OneByteReader,
+		},
+		{
+			bread: Bread{Workers: 2, BufferSize: 5, DelimiterBytes: []byte("||")},
+			data:  records(500, 8, "||") + "last|",
+		},
+		{
+			bread:  Bread{Workers: 2, BufferSize: 3, DelimiterBytes: []byte("\x1e\n")},
+			data:   records(500, 8, "\x1e\n"),
+			reader: iotest.HalfReader,
+		},
+		{
+			bread: Bread{BufferSize: 3, SyncThreshold: 1 * KB, DelimiterBytes: []byte("\r\n")},
+			data:  records(50, 8, "\r\n"),
+		},
+		{
+			bread: Bread{BufferSize: 4, DelimiterBytes: []byte("\r\n"), Align: AlignBackward},
+			err:   ErrDelimiterBytes,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			var reader io.Reader = strings.NewReader(c.data)
+			if c.reader != nil {
+				reader = c.reader(reader)
+			}
+
+			batches, err := collect(t, c.bread, reader)
+			if !errors.Is(err, c.err) {
+				t.Fatalf("expected error %v, got %v", c.err, err)
+			}
+
+			delimiter := string(c.bread.DelimiterBytes)
+			got, records := "", 0
+
+			for j, batch := range batches {
+				if j < len(batches)-1 && !strings.HasSuffix(string(batch.Data), delimiter) {
+					t.Fatalf("batch %d %q does not end with the delimiter", j, batch.Data)
+				}
+
+				got += string(batch.Data)
+				records += int(batch.Records)
+			}
+
+			if got != c.data {
+				t.Fatal("the batches do not reproduce the input")
+			}
+
+			expected := len(strings.Split(c.data, delimiter)) - 1
+			if c.data != "" && !strings.HasSuffix(c.data, delimiter) {
+				expected++
+			}
+
+			if records != expected {
+				t.Fatalf("expected %d records, got %d", expected, records)
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+func TestBread_DelimiterNUL(t *testing.T) {
+	cases := [...]struct {
+		bread    Bread
+		data     string
+		expected []string
+	}{
+		{
+			bread:    Bread{BufferSize: 2, DelimiterBytes: DelimiterNUL},
+			data:     "a\x00bb\x00ccc",
+			expected: []string{"a\x00bb\x00", "ccc"},
+		},
+		{
+			bread:    Bread{BufferSize: 2, DelimiterBytes: DelimiterNUL, Align: AlignBackward},
+			data:     "a\x00b\x00c\x00",
+			expected: []string{"a\x00", "b\x00", "c\x00"},
+		},
+		{
+			// Line feeds are regular bytes
+			bread:    Bread{BufferSize: 2, DelimiterBytes: DelimiterNUL, SyncThreshold: KB},
+			data:     "a\nb\x00c\n",
+			expected: []string{"a\nb\x00", "c\n"},
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			batches, err := collect(t, c.bread, strings.NewReader(c.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := make([]string, 0, len(batches))
+			for _, batch := range batches {
+				got = append(got, string(batch.Data))
+			}
+
+			if !slices.Equal(got, c.expected) {
+				t.Fatalf("expected %q, got %q", c.expected, got)
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+func TestBread_MaxRecordSize(t *testing.T) {
+	cases := [...]struct {
+		bread  Bread
+		reader io.Reader
+		offset int64
+		err    error
+	}{
+		{
+			// No delimiter at all in an endless stream
+			bread:  Bread{BufferSize: 4, MaxRecordSize: 64},
+			reader: repeatReader('x'),
+			err:    ErrRecordTooLarge,
+		},
+		{
+			bread:  Bread{BufferSize: 4, MaxRecordSize: 8},
+			reader: strings.NewReader("short\nshort\nrecord too large\nshort\n"),
+			offset: 12,
+			err:    ErrRecordTooLarge,
+		},
+		{
+			// Records of exactly MaxRecordSize bytes, the last one unterminated
+			bread:  Bread{BufferSize: 2, MaxRecordSize: 4},
+			reader: strings.NewReader("aaaa\nbbbb\ncccc"),
+		},
+		{
+			bread:  Bread{BufferSize: 2, MaxRecordSize: 4},
+			reader: strings.NewReader("aaaa\nbbbbb"),
+			offset: 5,
+			err:    ErrRecordTooLarge,
+		},
+		{
+			bread:  Bread{BufferSize: 4, MaxRecordSize: 4, DelimiterBytes: []byte("\r\n")},
+			reader: strings.NewReader("aaaa\r\nbbbb\r\nccccc\r\n"),
+			offset: 12,
+			err:    ErrRecordTooLarge,
+		},
+		{
+			bread:  Bread{BufferSize: 4, MaxRecordSize: 4, SyncThreshold: KB},
+			reader: strings.NewReader("aaaa\nbbbb\nccccc\n"),
+			offset: 10,
+			err:    ErrRecordTooLarge,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			_, err := collect(t, c.bread, c.reader)
+			if !errors.Is(err, c.err) {
+				t.Fatalf("expected error %v, got %v", c.err, err)
+			}
+
+			if offset, _ := OffsetOf(err); offset != c.offset {
+				t.Fatalf("expected offset %d, got %d", c.offset, offset)
+			}
+
+			t.Logf("Success! %v", err)
+		})
+	}
+}
+
+// dataEOFReader returns io.EOF along with the last bytes of the data, as io.Reader allows
+type dataEOFReader struct {
+	data  []byte
+	chunk int
+}
+
+func (r *dataEOFReader) Read(p []byte) (int, error) {
+	n := copy(p, r.data[:min(len(r.data), r.chunk)])
+	r.data = r.data[n:]
+
+	if len(r.data) == 0 {
+		return n, io.EOF
+	}
+
+	return n, nil
+}
+
+// emptyReader returns empty reads without error between the chunks of the data, as io.Reader allows
+type emptyReader struct {
+	data  []byte
+	chunk int
+	// empty is the number of empty reads before each chunk
+	empty int
+	reads int
+}
+
+func (r *emptyReader) Read(p []byte) (int, error) {
+	r.reads++
+
+	if r.reads%(r.empty+1) != 0 {
+		return 0, nil
+	}
+
+	if len(r.data) == 0 {
+		return 0, io.EOF
+	}
+
+	n := copy(p, r.data[:min(len(r.data), r.chunk)])
+	r.data = r.data[n:]
+
+	return n, nil
+}
+
+func TestBread_EmptyReads(t *testing.T) {
+	data := memoryData(64 * KB)
+
+	cases := [...]struct {
+		bread Bread
+		// empty is the number of empty reads before each chunk, -1 means that the reader never returns any data
+		empty int
+		err   error
+	}{
+		{
+			bread: Bread{BufferSize: 64},
+			empty: 1,
+		},
+		{
+			// The reads larger than the buffer of the bufio.Reader reach the reader directly
+			bread: Bread{BufferSize: 8 * KB, Workers: 4},
+			empty: maxEmptyReads - 1,
+		},
+		{
+			bread: Bread{BufferSize: 64, Align: AlignBackward, Delimiter: 'O'},
+			empty: 3,
+		},
+		{
+			bread: Bread{BufferSize: 64, RecordSize: 16},
+			empty: 3,
+		},
+		{
+			bread: Bread{BufferSize: 64, SplitFunc: bufio.ScanLines},
+			empty: 3,
+		},
+		{
+			bread: Bread{BufferSize: 64, SyncThreshold: MB},
+			empty: 3,
+		},
+		{
+			bread: Bread{BufferSize: 64},
+			empty: maxEmptyReads,
+			err:   io.ErrNoProgress,
+		},
+		{
+			bread: Bread{BufferSize: 8 * KB},
+			empty: -1,
+			err:   io.ErrNoProgress,
+		},
+		{
+			bread: Bread{BufferSize: 64, Align: AlignBackward},
+			empty: -1,
+			err:   io.ErrNoProgress,
+		},
+		{
+			bread: Bread{BufferSize: 64, Framing: FramingUvarint},
+			empty: -1,
+			err:   io.ErrNoProgress,
+		},
+		{
+			bread: Bread{BufferSize: 64, SyncThreshold: MB},
+			empty: -1,
+			err:   io.ErrNoProgress,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			var reader io.Reader = &emptyReader{data: data, chunk: 100, empty: c.empty}
+			if c.empty < 0 {
+				reader = readFunc(func([]byte) (int, error) {
+					return 0, nil
+				})
+			}
+
+			batches, err := collect(t, c.bread, reader)
+			if !errors.Is(err, c.err) {
+				t.Fatalf("expected error %v, got %v", c.err, err)
+			}
+
+			got := make([]byte, 0, len(data))
+
+			for _, batch := range batches {
+				if len(batch.Data) == 0 {
+					t.Fatalf("empty batch %d", batch.Index)
+				}
+
+				got = append(got, batch.Data...)
+			}
+
+			if c.err == nil && !bytes.Equal(got, data) {
+				t.Fatalf("expected %d bytes, got %d", len(data), len(got))
+			}
+
+			t.Logf("Success! %d batches, %v", len(batches), err)
+		})
+	}
+}
+
+func TestBread_RecordSize(t *testing.T) {
+	// Records of 64 bytes numbered by their first byte
+	records := make([]byte, 0, 100*64)
+	for i := range 100 {
+		records = append(records, bytes.Repeat([]byte{byte(i)}, 64)...)
+	}
+
+	cases := [...]struct {
+		bread Bread
+		data  []byte
+		chunk int
+		// expected is the number of records delivered
+		expected int
+		// position of the expected *TrailingRecordError, -1 means no error
+		position int64
+		err      error
+	}{
+		{
+			// BufferSize is rounded down to 3 records
+			bread:    Bread{BufferSize: 200, RecordSize: 64, Workers: 4},
+			data:     records,
+			chunk:    7,
+			expected: 100,
+			position: -1,
+		},
+		{
+			bread:    Bread{BufferSize: 64, RecordSize: 64, Workers: 4, Delimiter: 0x01},
+			data:     records,
+			chunk:    1000,
+			expected: 100,
+			position: -1,
+		},
+		{
+			bread:    Bread{BufferSize: 200, RecordSize: 64},
+			data:     records[:10*64+5],
+			chunk:    7,
+			expected: 10,
+			position: 10 * 64,
+		},
+		{
+			bread:    Bread{BufferSize: 200, RecordSize: 64},
+			data:     records[:5],
+			chunk:    7,
+			position: 0,
+		},
+		{
+			// The trailing record is delivered at the end of the last batch
+			bread:    Bread{BufferSize: 200, RecordSize: 64, AllowTrailing: true},
+			data:     records[:10*64+5],
+			chunk:    7,
+			expected: 11,
+			position: -1,
+		},
+		{
+			bread:    Bread{BufferSize: 200, RecordSize: 64, MaxRecords: 5},
+			data:     records,
+			chunk:    7,
+			expected: 5,
+			position: -1,
+		},
+		{
+			bread:    Bread{BufferSize: 32, RecordSize: 64},
+			data:     records,
+			position: -1,
+			err:      ErrRecordSize,
+		},
+		{
+			bread:    Bread{BufferSize: 64, RecordSize: 64, Align: AlignBackward},
+			data:     records,
+			position: -1,
+			err:      ErrRecordSize,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			batches, err := collect(t, c.bread, &dataEOFReader{data: c.data, chunk: max(c.chunk, 1)})
+
+			switch {
+			case c.err != nil:
+				if !errors.Is(err, c.err) {
+					t.Fatalf("expected error %v, got %v", c.err, err)
+				}
+			case c.position >= 0:
+				var trailingErr *TrailingRecordError
+				if !errors.As(err, &trailingErr) {
+					t.Fatalf("expected a *TrailingRecordError, got %v", err)
+				}
+
+				if trailingErr.Position != c.position {
+					t.Fatalf("expected error at offset %d, got %d", c.position, trailingErr.Position)
+				}
+			case err != nil:
+				t.Fatal(err)
+			}
+
+			got := make([]byte, 0, len(c.data))
+			delivered := 0
+
+			for _, batch := range batches {
+				last := batch.Offset+int64(batch.Size) == int64(len(c.data))
+
+				if batch.Size%64 != 0 && !(c.bread.AllowTrailing && last) {
+					t.Fatalf("batch %d of %d bytes is not a multiple of the record size", batch.Index, batch.Size)
+				}
+
+				if batch.Size > 192 {
+					t.Fatalf("batch %d of %d bytes exceeds the rounded buffer size", batch.Index, batch.Size)
+				}
+
+				got = append(got, batch.Data...)
+				delivered += int(batch.Records)
+			}
+
+			if delivered != c.expected {
+				t.Fatalf("expected %d records, got %d", c.expected, delivered)
+			}
+
+			if !bytes.Equal(got, c.data[:len(got)]) {
+				t.Fatal("the batches do not match the data")
+			}
+
+			t.Logf("Success! %v", err)
+		})
+	}
+}
+
+func TestBread_LimitBytes(t *testing.T) {
+	data := memoryData(MB)
+
+	cases := [...]struct {
+		bread Bread
+		err   error
+	}{
+		{
+			bread: Bread{BufferSize: 64 * KB, Workers: 4, LimitBytes: 10 * MB},
+		},
+		{
+			bread: Bread{BufferSize: 64 * KB, Workers: 4, LimitBytes: 10 * MB, FailOnLimit: true},
+			err:   ErrLimitReached,
+		},
+		{
+			bread: Bread{BufferSize: 64 * KB, Workers: 4, LimitBytes: 10 * MB, Align: AlignBackward},
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			// 1 GB made of the same megabyte
+			readers := make([]io.Reader, GB/len(data))
+			for j := range readers {
+				readers[j] = bytes.NewReader(data)
+			}
+
+			reader := &countingReader{Reader: io.MultiReader(readers...)}
+
+			batches, err := collect(t, c.bread, reader)
+			if !errors.Is(err, c.err) {
+				t.Fatalf("expected error %v, got %v", c.err, err)
+			}
+
+			size := int64(0)
+
+			for _, batch := range batches {
+				// Every batch ends on a record boundary
+				if batch.Data[len(batch.Data)-1] != DefaultDelimiter {
+					t.Fatalf("batch %d does not end with the delimiter", batch.Index)
+				}
+
+				size += int64(batch.Size)
+			}
+
+			// About a batch more than the limit
+			if size < c.bread.LimitBytes || size > c.bread.LimitBytes+2*int64(c.bread.BufferSize) {
+				t.Fatalf("expected about %d bytes, got %d", c.bread.LimitBytes, size)
+			}
+
+			if read := reader.n.Load(); read > 2*c.bread.LimitBytes {
+				t.Fatalf("expected about %d bytes read, got %d", c.bread.LimitBytes, read)
+			}
+
+			t.Logf("Success! %v", err)
+		})
+	}
+}
+
+// shortReads returns at most size bytes per read, like the network readers
+func shortReads(reader io.Reader, size int) io.Reader {
+	return readFunc(func(p []byte) (int, error) {
+		return reader.Read(p[:min(len(p), size)])
+	})
+}
+
+func TestBread_MinFill(t *testing.T) {
+	data := memoryData(256 * KB)
+
+	cases := [...]struct {
+		bread Bread
+		// batches is the maximum number of batches expected
+		batches int
+	}{
+		{
+			bread:   Bread{BufferSize: 16 * KB, MinFill: 16 * KB},
+			batches: len(data)/(16*KB) + 1,
+		},
+		{
+			// Values above BufferSize fill the whole buffer
+			bread:   Bread{BufferSize: 16 * KB, MinFill: MB},
+			batches: len(data)/(16*KB) + 1,
+		},
+		{
+			bread:   Bread{BufferSize: 16 * KB, MinFill: 16 * KB, Align: AlignBackward},
+			batches: len(data)/(15*KB) + 1,
+		},
+		{
+			bread:   Bread{BufferSize: 16 * KB, MinFill: 16 * KB, MaxBatchSize: 32 * KB},
+			batches: len(data)/(16*KB) + 1,
+		},
+		{
+			bread:   Bread{BufferSize: 16 * KB, MinFill: 16 * KB, FlushInterval: time.Hour},
+			batches: len(data)/(16*KB) + 1,
+		},
+		{
+			// Without MinFill, a batch for each read
+			bread:   Bread{BufferSize: 16 * KB},
+			batches: len(data) / 512,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			c.bread.Workers = 4
+
+			batches, err := collect(t, c.bread, shortReads(bytes.NewReader(data), 512))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(batches) > c.batches {
+				t.Fatalf("expected at most %d batches, got %d", c.batches, len(batches))
+			}
+
+			got := make([]byte, 0, len(data))
+			for _, batch := range batches {
+				got = append(got, batch.Data...)
+			}
+
+			if !bytes.Equal(got, data) {
+				t.Fatal("the batches do not reproduce the input")
+			}
+
+			t.Logf("Success! %d batches", len(batches))
+		})
+	}
+}
+
+func TestBread_MinFill_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	reads := atomic.Int64{}
+
+	// An endless stream without delimiters, the first buffer is never filled
+	reader := readFunc(func(p []byte) (int, error) {
+		if reads.Add(1) == 100 {
+			cancel()
+		}
+
+		return repeatReader('x').Read(p[:min(len(p), 512)])
+	})
+
+	bread := Bread{BufferSize: MB, MinFill: MB}
+
+	err := bread.EatBatches(ctx, reader, func(context.Context, Batch) {
+		t.Error("unexpected batch")
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected error %v, got %v", context.Canceled, err)
+	}
+
+	// The reading stops at the next read
+	if n := reads.Load(); n > 101 {
+		t.Fatalf("expected 100 reads, got %d", n)
+	}
+
+	t.Logf("Success! %v", err)
+}
+
+// BenchmarkBread_MinFill eats a reader that returns 512 bytes per read, MinFill accumulates them up to BufferSize
+func BenchmarkBread_MinFill(b *testing.B) {
+	data := memoryData(16 * MB)
+
+	for _, fill := range [...]int{0, MB} {
+		b.Run("MinFill="+ByteSize(fill).String(), func(b *testing.B) {
+			bread := Bread{
+				Workers:    4,
+				BufferSize: MB,
+				MinFill:    fill,
+			}
+
+			b.SetBytes(int64(len(data)))
+
+			batches := atomic.Int64{}
+
+			for n := 0; n < b.N; n++ {
+				err := bread.EatBatches(context.TODO(), shortReads(bytes.NewReader(data), 512), func(context.Context, Batch) {
+					batches.Add(1)
+				})
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+
+			b.ReportMetric(float64(batches.Load())/float64(b.N), "batches/op")
+		})
+	}
+}
+
+func TestBread_ReaderBufferSize(t *testing.T) {
+	data := memoryData(4 * MB)
+
+	cases := [...]struct {
+		bread Bread
+		// batches is the maximum number of batches expected
+		batches int
+	}{
+		{
+			// The batches keep BufferSize after the first complement
+			bread:   Bread{BufferSize: MB},
+			batches: 6,
+		},
+		{
+			bread:   Bread{BufferSize: MB, ReaderBufferSize: 2 * MB},
+			batches: 6,
+		},
+		{
+			// The next batches start with the few bytes held by the bufio.Reader
+			bread:   Bread{BufferSize: MB, ReaderBufferSize: 4 * KB},
+			batches: len(data) / (2 * KB),
+		},
+		{
+			bread:   Bread{BufferSize: MB, Align: AlignBackward},
+			batches: 6,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			batches, err := collect(t, c.bread, readFunc(bytes.NewReader(data).Read))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(batches) > c.batches {
+				t.Fatalf("expected at most %d batches, got %d", c.batches, len(batches))
+			}
+
+			got := make([]byte, 0, len(data))
+			for _, batch := range batches {
+				got = append(got, batch.Data...)
+			}
+
+			if !bytes.Equal(got, data) {
+				t.Fatal("the batches do not reproduce the input")
+			}
+
+			t.Logf("Success! %d batches", len(batches))
+		})
+	}
+}
+
+func TestEater_newReader(t *testing.T) {
+	e := &eater{Bread: Bread{BufferSize: 16 * KB}}
+
+	cases := [...]struct {
+		reader io.Reader
+		// kept reports that the reader is read directly
+		kept bool
+		size int
+	}{
+		{
+			reader: bufio.NewReaderSize(strings.NewReader("a\n"), 64*KB),
+			kept:   true,
+			size:   64 * KB,
+		},
+		{
+			reader: bufio.NewReader(strings.NewReader("a\n")),
+			size:   16 * KB,
+		},
+		{
+			reader: strings.NewReader("a\n"),
+			size:   16 * KB,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			r := e.newReader(c.reader)
+
+			if kept := io.Reader(r) == c.reader; kept != c.kept {
+				t.Fatalf("expected the reader to be kept %v, got %v", c.kept, kept)
+			}
+
+			if r.Size() != c.size {
+				t.Fatalf("expected a bufio.Reader of %d bytes, got %d", c.size, r.Size())
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+// BenchmarkBread_ReaderBufferSize eats a file with large batches, the default ReaderBufferSize keeps them large
+// while the size of bufio.NewReader cuts them in pieces of 4 KB, with a read for each one
+func BenchmarkBread_ReaderBufferSize(b *testing.B) {
+	path := filepath.Join(b.TempDir(), "data")
+	data := memoryData(64 * MB)
+
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		b.Fatal(err)
+	}
+
+	for _, size := range [...]int{DefaultReaderBufferSize, 0} {
+		name := "ReaderBufferSize=" + ByteSize(size).String()
+		if size == 0 {
+			name = "ReaderBufferSize=default"
+		}
+
+		b.Run(name, func(b *testing.B) {
+			bread := Bread{
+				Workers:          4,
+				BufferSize:       8 * MB,
+				ReaderBufferSize: size,
+			}
+
+			b.SetBytes(int64(len(data)))
+
+			var reads, batches atomic.Int64
+
+			for n := 0; n < b.N; n++ {
+				file, err := os.Open(path)
+				if err != nil {
+					b.Fatal(err)
+				}
+
+				err = bread.EatBatches(context.TODO(), readFunc(func(p []byte) (int, error) {
+					reads.Add(1)
+					return file.Read(p)
+				}), func(context.Context, Batch) {
+					batches.Add(1)
+				})
+
+				_ = file.Close()
+
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+
+			b.ReportMetric(float64(reads.Load())/float64(b.N), "reads/op")
+			b.ReportMetric(float64(batches.Load())/float64(b.N), "batches/op")
+		})
+	}
+}
+
+// BenchmarkScan isolates the search of the delimiter at the end of batches of different sizes:
+// complete, bytes.IndexByte over the filled buffer and a byte by byte loop as a baseline
+func BenchmarkScan(b *testing.B) {
+	for _, size := range [...]int{KB, MB, 16 * MB} {
+		data := bytes.Repeat([]byte{'x'}, size)
+		data[size-1] = '\n'
+
+		name := ByteSize(size).String()
+
+		b.Run(name+"/complete", func(b *testing.B) {
+			buffer := make([]byte, 0, size)
+			reader := bytes.NewReader(data)
+			r := bufio.NewReader(reader)
+
+			b.SetBytes(int64(size))
+
+			for n := 0; n < b.N; n++ {
+				reader.Reset(data)
+				r.Reset(reader)
+				buffer = buffer[:0]
+
+				if _, err := complete(r, &buffer, '\n'); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+
+		b.Run(name+"/IndexByte", func(b *testing.B) {
+			b.SetBytes(int64(size))
+
+			for n := 0; n < b.N; n++ {
+				if bytes.IndexByte(data, '\n') != size-1 {
+					b.Fatal("delimiter not found")
+				}
+			}
+		})
+
+		b.Run(name+"/loop", func(b *testing.B) {
+			b.SetBytes(int64(size))
+
+			for n := 0; n < b.N; n++ {
+				i := 0
+				for data[i] != '\n' {
+					i++
+				}
+
+				if i != size-1 {
+					b.Fatal("delimiter not found")
+				}
+			}
+		})
+	}
+}
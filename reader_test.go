@@ -0,0 +1,207 @@
+package bread_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/yael-castro/bread"
+	"github.com/yael-castro/bread/breadtest"
+)
+
+func TestBread_DataWithEOF(t *testing.T) {
+	cases := [...]struct {
+		bread bread.Bread
+		data  string
+		chunk int
+	}{
+		{
+			bread: bread.Bread{BufferSize: 4},
+			data:  "aaaa\nbbbb\ncc",
+			chunk: 3,
+		},
+		{
+			// The complement reaches the end of the stream in the middle of the record
+			bread: bread.Bread{BufferSize: 4, Workers: 2},
+			data:  "aa\nbbbbbbbbbbbbbbbbbbbbbbb",
+			chunk: 100,
+		},
+		{
+			// The whole stream in a single read
+			bread: bread.Bread{BufferSize: 64},
+			data:  "a\nb\nc\n",
+			chunk: 100,
+		},
+		{
+			bread: bread.Bread{BufferSize: 4, Align: bread.AlignBackward},
+			data:  "aa\nbb\ncc",
+			chunk: 5,
+		},
+		{
+			bread: bread.Bread{BufferSize: 4, MaxBatchSize: 6},
+			data:  "aa\nbbbbbbbbbb\ncc",
+			chunk: 7,
+		},
+		{
+			bread: bread.Bread{BufferSize: 4, TruncateRecordsAt: 64},
+			data:  "aa\nbbbbbb\ncc",
+			chunk: 4,
+		},
+		{
+			bread: bread.Bread{BufferSize: 4, SyncThreshold: 1 * bread.KB},
+			data:  "aa\nbbbbbb\ncc",
+			chunk: 4,
+		},
+		{
+			bread: bread.Bread{BufferSize: 4, DelimiterBytes: []byte("\r\n")},
+			data:  "aa\r\nbbbbbb\r\ncc\r",
+			chunk: 6,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			recorder := &breadtest.Recorder{}
+
+			// io.EOF comes along with the last bytes of the data, as io.Reader allows
+			err := c.bread.EatBatches(context.TODO(), breadtest.Chunked([]byte(c.data), c.chunk), recorder.WorkerBatchFunc)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Every byte of the source is delivered
+			breadtest.AssertData(t, recorder, []byte(c.data))
+
+			t.Log("Success!")
+		})
+	}
+}
+
+// closeCounter is an io.ReadCloser that counts the calls to Close
+type closeCounter struct {
+	io.Reader
+	closes atomic.Int32
+	err    error
+}
+
+func (c *closeCounter) Close() error {
+	c.closes.Add(1)
+	return c.err
+}
+
+func TestBread_CloseReader(t *testing.T) {
+	errClose := errors.New("close failed")
+	errRead := errors.New("read failed")
+
+	cancelled, cancel := context.WithCancel(context.TODO())
+	cancel()
+
+	cases := [...]struct {
+		ctx    context.Context
+		bread  bread.Bread
+		reader io.Reader
+		// closes is the number of expected calls to Close
+		closes   int32
+		closeErr error
+		err      error
+	}{
+		{
+			ctx:    context.TODO(),
+			bread:  bread.Bread{BufferSize: 8, CloseReader: true},
+			reader: strings.NewReader("a\nb\nc\n"),
+			closes: 1,
+		},
+		{
+			// The reader keeps its ownership
+			ctx:    context.TODO(),
+			bread:  bread.Bread{BufferSize: 8},
+			reader: strings.NewReader("a\nb\nc\n"),
+		},
+		{
+			ctx:    context.TODO(),
+			bread:  bread.Bread{BufferSize: 8, CloseReader: true},
+			reader: breadtest.ErrAfter(strings.NewReader(strings.Repeat("a\n", 100)), 10, errRead),
+			closes: 1,
+			err:    errRead,
+		},
+		{
+			ctx:    cancelled,
+			bread:  bread.Bread{BufferSize: 8, CloseReader: true},
+			reader: strings.NewReader(strings.Repeat("a\n", 100)),
+			closes: 1,
+			err:    context.Canceled,
+		},
+		{
+			// The settings are not valid
+			ctx:    context.TODO(),
+			bread:  bread.Bread{CloseReader: true},
+			reader: strings.NewReader("a\n"),
+			closes: 1,
+			err:    bread.ErrMissingBufferSize,
+		},
+		{
+			ctx:    context.TODO(),
+			bread:  bread.Bread{BufferSize: 8, CloseReader: true, MaxRecordSize: 4},
+			reader: strings.NewReader("a\n" + strings.Repeat("b", 100)),
+			closes: 1,
+			err:    bread.ErrRecordTooLarge,
+		},
+		{
+			// The error of Close is joined with the error of Eat
+			ctx:      context.TODO(),
+			bread:    bread.Bread{BufferSize: 8, CloseReader: true, MaxRecordSize: 4},
+			reader:   strings.NewReader("a\n" + strings.Repeat("b", 100)),
+			closes:   1,
+			closeErr: errClose,
+			err:      bread.ErrRecordTooLarge,
+		},
+		{
+			ctx:      context.TODO(),
+			bread:    bread.Bread{BufferSize: 8, CloseReader: true},
+			reader:   strings.NewReader("a\n"),
+			closes:   1,
+			closeErr: errClose,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			reader := &closeCounter{Reader: c.reader, err: c.closeErr}
+
+			c.bread.WorkerFunc = func(context.Context, *[]byte) {}
+
+			// OnComplete runs once the reader is closed, with the error Eat returns
+			completed, completeErr := false, error(nil)
+
+			c.bread.OnComplete = func(_ context.Context, _ bread.Stats, err error) {
+				completed, completeErr = true, err
+
+				if closes := reader.closes.Load(); closes != c.closes {
+					t.Errorf("expected %d calls to Close before OnComplete, got %d", c.closes, closes)
+				}
+			}
+
+			err := c.bread.Eat(c.ctx, reader)
+			if completed && completeErr != err {
+				t.Fatalf("expected OnComplete to receive %v, got %v", err, completeErr)
+			}
+			if c.err != nil && !errors.Is(err, c.err) || c.closeErr != nil && !errors.Is(err, c.closeErr) {
+				t.Fatalf("expected errors %v and %v, got %v", c.err, c.closeErr, err)
+			}
+
+			if c.err == nil && c.closeErr == nil && err != nil {
+				t.Fatal(err)
+			}
+
+			if closes := reader.closes.Load(); closes != c.closes {
+				t.Fatalf("expected %d calls to Close, got %d", c.closes, closes)
+			}
+
+			t.Logf("Success! %v", err)
+		})
+	}
+}
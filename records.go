@@ -0,0 +1,130 @@
+package bread
+
+import (
+	"bytes"
+	"context"
+	"sync"
+)
+
+// recordSlices pools the slices of records passed to RecordFunc
+var recordSlices = sync.Pool{
+	New: func() any {
+		return new([][]byte)
+	},
+}
+
+// recordWorker splits each batch into its records for RecordFunc, the slices alias the batch data
+func (b Bread) recordWorker() func(context.Context, Batch) error {
+	if b.RecordFunc == nil {
+		return nil
+	}
+
+	b = b.delimiters()
+
+	return func(ctx context.Context, batch Batch) error {
+		records := recordSlices.Get().(*[][]byte)
+		*records = b.batchRecords((*records)[:0], batch)
+
+		if b.sampling() {
+			*records = b.sampleRecords(*records, batch)
+		}
+
+		switch {
+		case batch.partitions != nil:
+			// Every batch takes its turn, even without records
+			batch.partitions.deliver(ctx, batch.Index, *records)
+		case len(*records) > 0 || !b.sampling():
+			b.RecordFunc(ctx, *records)
+		}
+
+		// The pooled slice must not keep the buffer of the batch alive
+		clear(*records)
+		recordSlices.Put(records)
+
+		return nil
+	}
+}
+
+// batchRecords appends to dst the records of the batch, including the empty record that ends the batches trimmed
+// by TrimDelimiter: the data of a trimmed batch only ends with the delimiter when its last record is empty
+func (b Bread) batchRecords(dst [][]byte, batch Batch) [][]byte {
+	if batch.separator != "" {
+		b = b.delimitedBy([]byte(batch.separator))
+	}
+
+	dst = b.records(dst, batch.Data)
+
+	if b.trimsDelimiter() && bytes.HasSuffix(batch.Data, b.separator()) {
+		dst = append(dst, batch.Data[len(batch.Data):])
+	}
+
+	return dst
+}
+
+// records appends to dst the records of data, as they are delimited by the settings
+func (b Bread) records(dst [][]byte, data []byte) [][]byte {
+	switch {
+	case b.Framing == FramingNone:
+		return dst
+	case b.Framing != FramingDelimiter:
+		return append(dst, data)
+	case b.RecordSize > 0:
+		for size := b.RecordSize; len(data) > 0; data = data[min(size, len(data)):] {
+			dst = append(dst, data[:min(size, len(data))])
+		}
+
+		return dst
+	case b.CSVMode:
+		splitter := csvSplitter{}
+		return splitRecords(dst, data, splitter.split)
+	case b.SplitFunc != nil:
+		return splitRecords(dst, data, b.SplitFunc)
+	}
+
+	separator := b.separator()
+
+	for len(data) > 0 {
+		i := bytes.Index(data, separator)
+		if i < 0 {
+			return append(dst, data)
+		}
+
+		dst = append(dst, data[:i])
+		data = data[i+len(separator):]
+	}
+
+	return dst
+}
+
+// splitRecords appends to dst the records of data found by split, data holds whole records
+func splitRecords(dst [][]byte, data []byte, split func([]byte, bool) (int, []byte, error)) [][]byte {
+	for len(data) > 0 {
+		advance, _, err := split(data, true)
+		if err != nil || advance <= 0 || advance > len(data) {
+			return append(dst, data)
+		}
+
+		dst = append(dst, data[:advance])
+		data = data[advance:]
+	}
+
+	return dst
+}
+
+// trimsDelimiter reports whether TrimDelimiter applies to the settings
+func (b Bread) trimsDelimiter() bool {
+	return b.TrimDelimiter && b.Framing == FramingDelimiter && b.SplitFunc == nil && !b.CSVMode && b.RecordSize == 0
+}
+
+// trim excludes the delimiter that ends the batch data, see TrimDelimiter
+func (e *eater) trim(batch *Batch) {
+	if !e.trimsDelimiter() || !bytes.HasSuffix(batch.Data, e.separator) {
+		return
+	}
+
+	batch.Data = batch.Data[:len(batch.Data)-len(e.separator)]
+
+	if batch.buffer != nil {
+		*batch.buffer = batch.Data
+	}
+}
@@ -0,0 +1,216 @@
+package bread
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestBread_RecordFunc(t *testing.T) {
+	cases := [...]struct {
+		bread    Bread
+		data     string
+		expected []string
+	}{
+		{
+			bread:    Bread{BufferSize: 8},
+			data:     "a\nbb\n\nccc\ndddd\neeeee",
+			expected: []string{"a", "bb", "", "ccc", "dddd", "eeeee"},
+		},
+		{
+			bread:    Bread{BufferSize: 8, DelimiterBytes: []byte("\r\n")},
+			data:     "a\r\nbb\r\nccc\r\n",
+			expected: []string{"a", "bb", "ccc"},
+		},
+		{
+			bread:    Bread{BufferSize: 8, RecordSize: 3, AllowTrailing: true},
+			data:     "aaabbbcccdddee",
+			expected: []string{"aaa", "bbb", "ccc", "ddd", "ee"},
+		},
+		{
+			bread:    Bread{BufferSize: 8, CSVMode: true},
+			data:     "a,\"b\nc\"\nd,e\n",
+			expected: []string{"a,\"b\nc\"\n", "d,e\n"},
+		},
+		{
+			bread:    Bread{BufferSize: 8, Framing: FramingDocuments},
+			data:     "a: 1\n---\nb: 2\n",
+			expected: []string{"a: 1\n", "b: 2\n"},
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			mu := sync.Mutex{}
+			records := make([]string, 0)
+
+			c.bread.RecordFunc = func(_ context.Context, batch [][]byte) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				for _, record := range batch {
+					records = append(records, string(record))
+				}
+			}
+
+			// A single worker delivers the records in stream order
+			c.bread.Workers = 1
+
+			err := c.bread.Eat(context.TODO(), strings.NewReader(c.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !slices.Equal(records, c.expected) {
+				t.Fatalf("expected records %q, got %q", c.expected, records)
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+// BenchmarkBread_RecordFunc compares RecordFunc with a WorkerFunc that splits each batch with bytes.Split
+func BenchmarkBread_RecordFunc(b *testing.B) {
+	data := memoryData(64 * MB)
+
+	cases := map[string]Bread{
+		"RecordFunc": {
+			RecordFunc: func(context.Context, [][]byte) {},
+		},
+		"bytes.Split": {
+			WorkerFunc: func(_ context.Context, buffer *[]byte) {
+				_ = bytes.Split(*buffer, []byte{DefaultDelimiter})
+			},
+		},
+	}
+
+	for name, bread := range cases {
+		b.Run(name, func(b *testing.B) {
+			bread.Workers = 16
+			bread.BufferSize = 64 * KB
+
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+
+			for n := 0; n < b.N; n++ {
+				err := bread.Eat(context.TODO(), bytes.NewReader(data))
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestBread_TrimDelimiter(t *testing.T) {
+	cases := [...]struct {
+		bread Bread
+		data  string
+	}{
+		{
+			bread: Bread{BufferSize: 4},
+			data:  "aaa\nbbbbbb\ncc\n\n\ndd\n",
+		},
+		{
+			// The last record has no delimiter
+			bread: Bread{BufferSize: 4},
+			data:  "aaa\nbbbbbb\ncc",
+		},
+		{
+			bread: Bread{BufferSize: 4, DelimiterBytes: []byte("\r\n")},
+			data:  "aaa\r\nbbbbbb\r\ncc\r\n",
+		},
+		{
+			bread: Bread{BufferSize: 4, Align: AlignBackward},
+			data:  "aaa\nbb\ncc\n",
+		},
+		{
+			bread: Bread{BufferSize: 4, ShuffleBuffer: 2},
+			data:  "aaa\nbb\ncc\n",
+		},
+		{
+			// Documents are not trimmed
+			bread: Bread{BufferSize: 4, Framing: FramingDocuments},
+			data:  "a: 1\n---\nb: 2\n",
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			c.bread.Workers = 2
+
+			expected, err := collect(t, c.bread, strings.NewReader(c.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			c.bread.TrimDelimiter = true
+
+			trimmed, err := collect(t, c.bread, strings.NewReader(c.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(trimmed) != len(expected) {
+				t.Fatalf("expected %d batches, got %d", len(expected), len(trimmed))
+			}
+
+			separator := c.bread.delimiters().separator()
+
+			for j, batch := range trimmed {
+				data := expected[j].Data
+				if c.bread.trimsDelimiter() {
+					data = bytes.TrimSuffix(data, separator)
+				}
+
+				if !bytes.Equal(batch.Data, data) {
+					t.Fatalf("expected batch %q, got %q", data, batch.Data)
+				}
+
+				// The metadata still describes the bytes read
+				if batch.BatchMeta != expected[j].BatchMeta {
+					t.Fatalf("expected meta %+v, got %+v", expected[j].BatchMeta, batch.BatchMeta)
+				}
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+func TestBread_TrimDelimiterRecords(t *testing.T) {
+	data := "a\n\nb\n\n"
+
+	for _, trim := range []bool{false, true} {
+		t.Run(strconv.FormatBool(trim), func(t *testing.T) {
+			records := make([]string, 0)
+
+			bread := Bread{
+				BufferSize:    8,
+				TrimDelimiter: trim,
+				RecordFunc: func(_ context.Context, batch [][]byte) {
+					for _, record := range batch {
+						records = append(records, string(record))
+					}
+				},
+			}
+
+			err := bread.Eat(context.TODO(), strings.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// The trailing empty record is kept
+			if expected := []string{"a", "", "b", ""}; !slices.Equal(records, expected) {
+				t.Fatalf("expected records %q, got %q", expected, records)
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package bread
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"slices"
+)
+
+// resilientReader only returns the whole records of the reader, so the records around a read error or a record
+// larger than MaxRecordSize are skipped and the reading goes on from the next delimiter. See Bread.ContinueOnError
+type resilientReader struct {
+	ctx    context.Context
+	e      *eater
+	reader io.Reader
+	// buffer holds the bytes not returned yet from head, the whole records end at ready
+	buffer []byte
+	head   int
+	ready  int
+	// scanned is the position up to which the delimiters were searched
+	scanned int
+	// position is the stream offset of the record starting at ready
+	position int64
+	// resync drops the bytes up to the next delimiter
+	resync bool
+	// failures counts the read errors in a row, a segment is reported once
+	failures int
+	// err ends the stream once the whole records are returned
+	err     error
+	skipped SkipError
+}
+
+// resilient wraps the reader so the corrupt segments are skipped
+func (e *eater) resilient(ctx context.Context, reader io.Reader) *resilientReader {
+	return &resilientReader{ctx: ctx, e: e, reader: reader, buffer: make([]byte, 0, max(e.BufferSize, 4096))}
+}
+
+func (r *resilientReader) Read(p []byte) (int, error) {
+	for r.head == r.ready {
+		if r.err == nil {
+			if err := r.fill(); err != nil {
+				return 0, err
+			}
+
+			continue
+		}
+
+		if r.resync {
+			r.drop(len(r.buffer))
+		}
+
+		// The last record does not need a delimiter, unless the stream did not end
+		if r.err != io.EOF || r.head == len(r.buffer) {
+			return 0, r.err
+		}
+
+		r.ready = len(r.buffer)
+	}
+
+	n := copy(p, r.buffer[r.head:r.ready])
+	r.head += n
+
+	return n, nil
+}
+
+// fill reads the next bytes and finds the records that end in them
+func (r *resilientReader) fill() error {
+	if r.head > 0 {
+		r.buffer = r.buffer[:copy(r.buffer, r.buffer[r.head:])]
+		r.ready -= r.head
+		r.scanned -= r.head
+		r.head = 0
+	}
+
+	if len(r.buffer) == cap(r.buffer) {
+		r.buffer = slices.Grow(r.buffer, len(r.buffer))
+	}
+
+	n, err := r.reader.Read(r.buffer[len(r.buffer):cap(r.buffer)])
+	r.buffer = r.buffer[:len(r.buffer)+n]
+
+	if n > 0 {
+		r.failures = 0
+	}
+
+	if err := r.scan(); err != nil {
+		return err
+	}
+
+	switch {
+	case err == nil:
+		return nil
+	case err == io.EOF || err == errFollowStopped || r.ctx.Err() != nil:
+		r.err = err
+		return nil
+	}
+
+	// The reader does not recover
+	if r.failures++; r.failures > maxEmptyReads {
+		return err
+	}
+
+	if r.failures > 1 {
+		return nil
+	}
+
+	// The record read before the error may be missing bytes, and so may the next one. The restarted Decompressor
+	// starts with a whole record instead, see decompressReader.restart
+	var decompress *DecompressError
+	r.resync = len(r.buffer) > r.ready && !errors.As(err, &decompress)
+
+	return r.skip(len(r.buffer), err)
+}
+
+// scan moves ready past the whole records, dropping the bytes up to the next delimiter after a skipped segment
+// and the records larger than MaxRecordSize
+func (r *resilientReader) scan() error {
+	separator := r.e.separator
+	limit := r.e.MaxRecordSize
+
+	for {
+		i := bytes.Index(r.buffer[r.scanned:], separator)
+		if i < 0 {
+			break
+		}
+
+		end := r.scanned + i + len(separator)
+
+		switch {
+		case r.resync:
+			r.drop(end)
+			r.resync = false
+		case limit > 0 && end-len(separator)-r.ready > limit:
+			err := &RecordTooLargeError{Position: r.position, Limit: limit, Preview: r.e.preview(r.buffer[r.ready : end-len(separator)])}
+
+			if err := r.skip(end, err); err != nil {
+				return err
+			}
+		default:
+			r.position += int64(end - r.ready)
+			r.ready = end
+		}
+
+		r.scanned = r.ready
+	}
+
+	// The delimiter may be split across reads
+	end := len(r.buffer) - len(separator) + 1
+
+	switch {
+	case r.resync && end > r.ready:
+		r.drop(end)
+	case limit > 0 && end-r.ready > limit:
+		err := &RecordTooLargeError{Position: r.position, Limit: limit, Preview: r.e.preview(r.buffer[r.ready:])}
+
+		if err := r.skip(len(r.buffer), err); err != nil {
+			return err
+		}
+
+		r.resync = true
+	}
+
+	r.scanned = max(r.ready, len(r.buffer)-len(separator)+1)
+
+	return nil
+}
+
+// skip reports the segment up to end to OnError and drops it, unless OnError stops the reading
+func (r *resilientReader) skip(end int, err error) error {
+	if r.e.OnError != nil {
+		if err := r.e.OnError(r.ctx, r.position, r.buffer[r.ready:end], err); err != nil {
+			return err
+		}
+	}
+
+	r.skipped.Segments++
+	if r.skipped.Err == nil {
+		r.skipped.Err = err
+	}
+
+	r.drop(end)
+
+	return nil
+}
+
+// drop removes the bytes from ready up to end
+func (r *resilientReader) drop(end int) {
+	r.skipped.Bytes += int64(end - r.ready)
+	r.position += int64(end - r.ready)
+	r.buffer = append(r.buffer[:r.ready], r.buffer[end:]...)
+	r.scanned = r.ready
+}
+
+// result returns the error of the reading, or a *SkipError when it succeeded after skipping segments
+func (r *resilientReader) result(err error) error {
+	if err != nil || r.skipped.Segments == 0 {
+		return err
+	}
+
+	skipped := r.skipped
+	return &skipped
+}
@@ -0,0 +1,159 @@
+package bread_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/yael-castro/bread"
+	"github.com/yael-castro/bread/breadtest"
+)
+
+// gzipMembers compresses each part as a gzip member of its own, the offsets tell where each member starts
+func gzipMembers(t *testing.T, parts ...string) (data []byte, offsets []int) {
+	buffer := &bytes.Buffer{}
+
+	for _, part := range parts {
+		offsets = append(offsets, buffer.Len())
+
+		writer := gzip.NewWriter(buffer)
+		if _, err := writer.Write([]byte(part)); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := writer.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return buffer.Bytes(), offsets
+}
+
+func TestBread_ContinueOnError(t *testing.T) {
+	lines := func(prefix string, n int) string {
+		builder := strings.Builder{}
+		for i := range n {
+			fmt.Fprintf(&builder, "%s %04d\n", prefix, i)
+		}
+
+		return builder.String()
+	}
+
+	errCorrupt := errors.New("corrupt")
+	errStop := errors.New("stop")
+
+	// The checksum of the second member and the header of the third one are corrupt
+	members, offsets := gzipMembers(t, lines("first", 500), lines("second", 500), lines("third", 500), lines("fourth", 500))
+	corrupt := bytes.Clone(members)
+	corrupt[offsets[2]-8] ^= 0xff
+	corrupt[offsets[2]+3] = 0xff
+
+	cases := [...]struct {
+		bread  bread.Bread
+		reader io.Reader
+		// expected are the records delivered, the ones of contains only need to be among them
+		expected string
+		contains []string
+		// offsets are the ones received by OnError
+		offsets []int64
+		// onError is the error returned by OnError
+		onError error
+		err     error
+	}{
+		{
+			bread:    bread.Bread{MaxRecordSize: 10},
+			reader:   strings.NewReader("a\nlonger than ten\nb\n" + strings.Repeat("x", 100) + "\nc"),
+			expected: "a\nb\nc",
+			offsets:  []int64{2, 20},
+			err:      bread.ErrRecordTooLarge,
+		},
+		{
+			// The record cut by the error and the rest of the next one are dropped
+			bread: bread.Bread{},
+			reader: breadtest.NewChunkReader(
+				breadtest.Chunk{Data: []byte("a\nb\nc"), Err: errCorrupt},
+				breadtest.Chunk{Data: []byte("cc\nd\n"), Err: io.EOF},
+			),
+			expected: "a\nb\nd\n",
+			offsets:  []int64{4},
+			err:      errCorrupt,
+		},
+		{
+			// The error comes at the end of a record
+			bread: bread.Bread{DelimiterBytes: []byte("\r\n")},
+			reader: breadtest.NewChunkReader(
+				breadtest.Chunk{Data: []byte("a\r\nb\r\n"), Err: errCorrupt},
+				breadtest.Chunk{Data: []byte("c\r\n"), Err: io.EOF},
+			),
+			expected: "a\r\nb\r\nc\r\n",
+			offsets:  []int64{6},
+			err:      errCorrupt,
+		},
+		{
+			bread:    bread.Bread{Decompressor: func(r io.Reader) (io.ReadCloser, error) { return gzip.NewReader(r) }},
+			reader:   bytes.NewReader(corrupt),
+			contains: []string{lines("first", 500), lines("second", 500), lines("fourth", 500)},
+			err:      bread.ErrSkipped,
+		},
+		{
+			bread:   bread.Bread{MaxRecordSize: 10},
+			reader:  strings.NewReader("a\nlonger than ten\nb\n"),
+			offsets: []int64{2},
+			onError: errStop,
+			err:     errStop,
+		},
+		{
+			bread:  bread.Bread{RecordSize: 4},
+			reader: strings.NewReader("abcd"),
+			err:    bread.ErrContinueOnError,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			offsets := make([]int64, 0)
+
+			c.bread.BufferSize = 8
+			c.bread.ContinueOnError = true
+			c.bread.OnError = func(_ context.Context, offset int64, _ []byte, _ error) error {
+				offsets = append(offsets, offset)
+				return c.onError
+			}
+
+			recorder := &breadtest.Recorder{}
+
+			err := c.bread.EatBatches(context.TODO(), c.reader, recorder.WorkerBatchFunc)
+			if !errors.Is(err, c.err) {
+				t.Fatalf("expected error %v, got %v", c.err, err)
+			}
+
+			// The skipped segments are summarized once the reading succeeded
+			var skipped *bread.SkipError
+			if c.onError == nil && errors.As(err, &skipped) && skipped.Segments != uint64(len(offsets)) {
+				t.Fatalf("expected %d segments, got %d", len(offsets), skipped.Segments)
+			}
+
+			for _, records := range c.contains {
+				if !bytes.Contains(recorder.Data(), []byte(records)) {
+					t.Fatalf("the records around the corrupt segment were not delivered: %q", records[:16])
+				}
+			}
+
+			if c.contains == nil {
+				breadtest.AssertData(t, recorder, []byte(c.expected))
+
+				if fmt.Sprint(offsets) != fmt.Sprint(c.offsets) {
+					t.Fatalf("expected offsets %v, got %v", c.offsets, offsets)
+				}
+			}
+
+			t.Logf("Success! %v", err)
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package bread
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+)
+
+// seekStart moves a seekable reader to where the reading starts, see Bread.StartOffset. The bytes before it are
+// discarded by skipBytes or skipStart otherwise, along with the decompressed or decoded data whose offsets do not match
+// the source.
+func (e *eater) seekStart(reader io.Reader) error {
+	e.positioned = true
+
+	begin := e.StartOffset
+	if e.AlignToDelimiter {
+		// A delimiter right before StartOffset is found, so a record starting at StartOffset is kept
+		begin = max(begin-int64(len(e.separator)), 0)
+	}
+
+	seeker, ok := reader.(io.Seeker)
+	if begin == 0 || !ok || e.Decompressor != nil || e.WrapReader != nil {
+		e.skip = begin
+		return nil
+	}
+
+	// StartOffset counts from the position of the reader, like the offsets of the batches
+	if _, err := seeker.Seek(begin, io.SeekCurrent); err != nil {
+		return &ReadError{Position: begin, Err: err}
+	}
+
+	e.offset, e.startOffset = begin, begin
+
+	return nil
+}
+
+// skipStart discards the bytes left by seekStart and, with AlignToDelimiter, the bytes up to the end of the next
+// delimiter. The offset where the first batch starts is kept in startOffset, see Stats.StartOffset.
+func (e *eater) skipStart(r *bufio.Reader) error {
+	if err := e.skipBytes(r); err != nil {
+		return err
+	}
+
+	if e.AlignToDelimiter && e.StartOffset > 0 {
+		if err := e.skipRecord(r); err != nil {
+			return err
+		}
+	}
+
+	e.startOffset = e.offset
+
+	return nil
+}
+
+// skipBytes reads and discards the bytes left by seekStart
+func (e *eater) skipBytes(reader io.Reader) error {
+	if e.skip == 0 {
+		return nil
+	}
+
+	n, err := io.CopyN(io.Discard, reader, e.skip)
+	e.offset += n
+	e.skip = 0
+
+	if err != nil && err != io.EOF {
+		return &ReadError{Position: e.offset, Err: err}
+	}
+
+	return nil
+}
+
+// skipRecord discards the bytes up to the end of the next delimiter, or the rest of the stream without any
+func (e *eater) skipRecord(r *bufio.Reader) error {
+	// tail holds the last bytes discarded, so the delimiters of several bytes split across slices are found
+	tail := make([]byte, 0, 2*len(e.separator))
+
+	for {
+		slice, err := r.ReadSlice(e.separator[len(e.separator)-1])
+		e.offset += int64(len(slice))
+
+		tail = append(tail, slice[max(len(slice)-len(e.separator), 0):]...)
+		tail = tail[max(len(tail)-len(e.separator), 0):]
+
+		switch {
+		case err == nil && bytes.HasSuffix(tail, e.separator):
+			return nil
+		case err == io.EOF:
+			return nil
+		case err != nil && err != bufio.ErrBufferFull:
+			return &ReadError{Position: e.offset, Err: err}
+		}
+	}
+}
@@ -0,0 +1,350 @@
+package bread
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestBread_StartOffset(t *testing.T) {
+	lines, crlf := make([]string, 500), make([]string, 500)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("record %d %s\n", i, strings.Repeat("x", i%37))
+		crlf[i] = strings.TrimSuffix(lines[i], "\n") + "\r\n"
+	}
+
+	data, crlfData := strings.Join(lines, ""), strings.Join(crlf, "")
+
+	// next returns the offset of the first record starting at offset or after it
+	next := func(data, separator string, offset int) int {
+		if offset == 0 {
+			return 0
+		}
+
+		i := strings.Index(data[offset-len(separator):], separator)
+		if i < 0 {
+			return len(data)
+		}
+
+		return offset - len(separator) + i + len(separator)
+	}
+
+	recordStart := len(lines[0]) + len(lines[1])
+
+	cases := [...]struct {
+		bread Bread
+		data  string
+		// seekable tells whether the reader is an io.Seeker
+		seekable bool
+		// prefix is the number of bytes read before the reading starts
+		prefix int
+		// expected is the offset of the first batch
+		expected int
+	}{
+		{
+			bread:    Bread{StartOffset: 1000},
+			data:     data,
+			seekable: true,
+			expected: 1000,
+		},
+		{
+			bread:    Bread{StartOffset: 1000},
+			data:     data,
+			expected: 1000,
+		},
+		{
+			bread:    Bread{StartOffset: 1000, AlignToDelimiter: true},
+			data:     data,
+			seekable: true,
+			expected: next(data, "\n", 1000),
+		},
+		{
+			bread:    Bread{StartOffset: 1000, AlignToDelimiter: true},
+			data:     data,
+			expected: next(data, "\n", 1000),
+		},
+		{
+			// The record starting at StartOffset is kept
+			bread:    Bread{StartOffset: int64(recordStart), AlignToDelimiter: true},
+			data:     data,
+			seekable: true,
+			expected: recordStart,
+		},
+		{
+			// StartOffset at the delimiter, the next record starts right after it
+			bread:    Bread{StartOffset: int64(recordStart - 1), AlignToDelimiter: true},
+			data:     data,
+			expected: recordStart,
+		},
+		{
+			bread:    Bread{StartOffset: 7777, AlignToDelimiter: true, DelimiterBytes: []byte("\r\n")},
+			data:     crlfData,
+			seekable: true,
+			expected: next(crlfData, "\r\n", 7777),
+		},
+		{
+			// StartOffset between the bytes of the delimiter
+			bread:    Bread{StartOffset: int64(len(crlf[0]) - 1), AlignToDelimiter: true, DelimiterBytes: []byte("\r\n")},
+			data:     crlfData,
+			expected: len(crlf[0]),
+		},
+		{
+			// StartOffset counts from the position of the reader
+			bread:    Bread{StartOffset: 1000},
+			data:     data,
+			seekable: true,
+			prefix:   333,
+			expected: 1000,
+		},
+		{
+			bread:    Bread{StartOffset: 1000, AlignToDelimiter: true},
+			data:     data,
+			prefix:   333,
+			expected: next(data, "\n", 1000),
+		},
+		{
+			bread:    Bread{StartOffset: int64(len(data) + 10), AlignToDelimiter: true},
+			data:     data,
+			expected: len(data),
+		},
+		{
+			bread:    Bread{StartOffset: int64(len(data) - 3), AlignToDelimiter: true},
+			data:     data,
+			seekable: true,
+			expected: len(data),
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			var reader io.Reader = strings.NewReader(strings.Repeat("p", c.prefix) + c.data)
+			if _, err := io.CopyN(io.Discard, reader, int64(c.prefix)); err != nil {
+				t.Fatal(err)
+			}
+
+			if !c.seekable {
+				reader = readFunc(reader.Read)
+			}
+
+			mu := sync.Mutex{}
+			batches := make([]Batch, 0)
+
+			c.bread.BufferSize = 256
+			c.bread.Workers = 4
+			c.bread.WorkerBatchFunc = func(_ context.Context, batch Batch) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				batches = append(batches, Batch{BatchMeta: batch.BatchMeta, Data: bytes.Clone(batch.Data)})
+			}
+
+			stats, err := c.bread.EatStats(context.TODO(), reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if stats.StartOffset != int64(c.expected) {
+				t.Fatalf("expected start offset %d, got %d", c.expected, stats.StartOffset)
+			}
+
+			sort.Slice(batches, func(i, j int) bool {
+				return batches[i].Index < batches[j].Index
+			})
+
+			// The batches are the suffix of the stream, with offsets of the whole stream
+			got := make([]byte, 0, len(c.data))
+			offset := int64(c.expected)
+
+			for _, batch := range batches {
+				if batch.Offset != offset {
+					t.Fatalf("expected batch %d at offset %d, got %d", batch.Index, offset, batch.Offset)
+				}
+
+				got = append(got, batch.Data...)
+				offset += int64(batch.Size)
+			}
+
+			if string(got) != c.data[min(c.expected, len(c.data)):] {
+				t.Fatalf("expected the stream from offset %d, got %d bytes starting with %.20q", c.expected, len(got), got)
+			}
+
+			if int64(stats.BytesRead) != int64(len(got)) {
+				t.Fatalf("expected %d bytes read, got %d", len(got), stats.BytesRead)
+			}
+
+			t.Logf("Success! %d batches from offset %d", len(batches), stats.StartOffset)
+		})
+	}
+}
+
+func TestBread_StartOffset_Digest(t *testing.T) {
+	data := strings.Repeat("record\n", 1000)
+	sum := sha256.Sum256([]byte(data[1000:]))
+
+	for i, seekable := range [...]bool{true, false} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			var reader io.Reader = strings.NewReader(data)
+			if !seekable {
+				reader = readFunc(reader.Read)
+			}
+
+			bread := Bread{
+				BufferSize:      256,
+				Workers:         4,
+				StartOffset:     1000,
+				ExpectedDigest:  sum[:],
+				WorkerBatchFunc: func(context.Context, Batch) {},
+			}
+
+			// The bytes before StartOffset are not hashed
+			if err := bread.Eat(context.TODO(), reader); err != nil {
+				t.Fatal(err)
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+func TestBread_StartOffset_EntryPoints(t *testing.T) {
+	lines := make([]string, 500)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("record %d %s\n", i, strings.Repeat("x", i%37))
+	}
+
+	data := strings.Join(lines, "")
+
+	// start is the offset of the first record after offset 1000
+	start := 1000 + strings.IndexByte(data[1000:], '\n') + 1
+
+	cases := [...]struct {
+		name string
+		// eat returns the offset of the first batch and the data of the batches
+		eat func(bread Bread) (int64, []byte, error)
+	}{
+		{
+			name: "EatBytes",
+			eat: func(bread Bread) (int64, []byte, error) {
+				mu := sync.Mutex{}
+				batches := make([]Batch, 0)
+
+				bread.WorkerBatchFunc = func(_ context.Context, batch Batch) {
+					mu.Lock()
+					defer mu.Unlock()
+
+					batches = append(batches, Batch{BatchMeta: batch.BatchMeta, Data: bytes.Clone(batch.Data)})
+				}
+
+				if err := bread.EatBytes(context.TODO(), []byte(data)); err != nil {
+					return 0, nil, err
+				}
+
+				sort.Slice(batches, func(i, j int) bool {
+					return batches[i].Index < batches[j].Index
+				})
+
+				got := make([]byte, 0, len(data))
+				for _, batch := range batches {
+					got = append(got, batch.Data...)
+				}
+
+				return batches[0].Offset, got, nil
+			},
+		},
+		{
+			name: "EatAt",
+			eat: func(bread Bread) (int64, []byte, error) {
+				mu := sync.Mutex{}
+				batches := make([]Batch, 0)
+
+				bread.WorkerBatchFunc = func(_ context.Context, batch Batch) {
+					mu.Lock()
+					defer mu.Unlock()
+
+					batches = append(batches, Batch{BatchMeta: batch.BatchMeta, Data: bytes.Clone(batch.Data)})
+				}
+
+				if err := bread.EatAt(context.TODO(), strings.NewReader(data), int64(len(data))); err != nil {
+					return 0, nil, err
+				}
+
+				// The indexes do not follow the stream order across the ranges, unlike the offsets
+				sort.Slice(batches, func(i, j int) bool {
+					return batches[i].Offset < batches[j].Offset
+				})
+
+				got := make([]byte, 0, len(data))
+				for _, batch := range batches {
+					got = append(got, batch.Data...)
+				}
+
+				return batches[0].Offset, got, nil
+			},
+		},
+		{
+			name: "Batches",
+			eat: func(bread Bread) (int64, []byte, error) {
+				got := make([]byte, 0, len(data))
+
+				for batch, err := range bread.Batches(context.TODO(), readFunc(strings.NewReader(data).Read)) {
+					if err != nil {
+						return 0, nil, err
+					}
+
+					got = append(got, batch...)
+				}
+
+				// The batches carry no offset
+				return int64(start), got, nil
+			},
+		},
+		{
+			name: "EatChan",
+			eat: func(bread Bread) (int64, []byte, error) {
+				batches, errs := bread.EatChan(context.TODO(), strings.NewReader(data))
+
+				offset := int64(-1)
+				got := make([]byte, 0, len(data))
+
+				for batch := range batches {
+					if offset < 0 {
+						offset = batch.Offset
+					}
+
+					got = append(got, batch.Data...)
+					batch.Release()
+				}
+
+				return offset, got, <-errs
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			bread := Bread{BufferSize: 256, Workers: 4, StartOffset: 1000, AlignToDelimiter: true}
+
+			offset, got, err := c.eat(bread)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if offset != int64(start) {
+				t.Fatalf("expected the first batch at offset %d, got %d", start, offset)
+			}
+
+			if string(got) != data[start:] {
+				t.Fatalf("expected the stream from offset %d, got %d bytes starting with %.20q", start, len(got), got)
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package bread
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/yael-castro/bread/internal/semaphore"
+)
+
+// retention holds the buffers retained by the workers until they are released, see Batch.Retain
+type retention struct {
+	pool   bufferPool
+	memory *semaphore.Weighted
+
+	mu sync.Mutex
+	// buffers holds the memory acquired for each retained buffer, see Bread.MaxMemory
+	buffers map[*[]byte]int64
+	// held is the number of buffers retained and not released yet, see Stats.Retained
+	held atomic.Int64
+}
+
+// retain keeps the buffer out of the pool until it is released
+func (r *retention) retain(buffer *[]byte, weight int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.buffers[buffer]; ok {
+		return
+	}
+
+	if r.buffers == nil {
+		r.buffers = make(map[*[]byte]int64)
+	}
+
+	r.buffers[buffer] = weight
+	r.held.Add(1)
+}
+
+// retained tells whether the buffer was retained
+func (r *retention) retained(buffer *[]byte) bool {
+	// The workers retain their buffer before returning, so there is nothing to look up unless a buffer is held
+	if r == nil || r.held.Load() == 0 {
+		return false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, ok := r.buffers[buffer]
+
+	return ok
+}
+
+// release returns a retained buffer to the pool along with its memory, the buffers not retained are ignored
+func (r *retention) release(buffer *[]byte) {
+	r.mu.Lock()
+
+	weight, ok := r.buffers[buffer]
+	delete(r.buffers, buffer)
+
+	r.mu.Unlock()
+
+	if !ok {
+		return
+	}
+
+	r.held.Add(-1)
+	r.pool.Put(buffer)
+
+	if weight > 0 {
+		r.memory.Release(weight)
+	}
+}
+
+// recycle returns the buffer and the memory of a processed batch, unless the worker retained them
+func (e *eater) recycle(batch Batch) {
+	if e.retention.retained(batch.buffer) {
+		return
+	}
+
+	e.release(batch.buffer)
+	e.free(batch)
+}
@@ -0,0 +1,163 @@
+package bread
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBread_Retain(t *testing.T) {
+	cases := [...]struct {
+		bread Bread
+		// pooled tells whether the batches come from the pool, so they are counted by Stats.Retained
+		pooled bool
+	}{
+		{
+			bread:  Bread{Workers: 4},
+			pooled: true,
+		},
+		{
+			bread:  Bread{Workers: 4, PoolStrategy: PoolFreelist, FreelistAllocate: true},
+			pooled: true,
+		},
+		{
+			bread:  Bread{Workers: 4, Ordered: true, OnDone: func(context.Context, Batch) {}},
+			pooled: true,
+		},
+		{
+			bread:  Bread{Workers: 4, BatchRetries: 1, RereadOnRetry: true},
+			pooled: true,
+		},
+		{
+			// The batches are slices of the input
+			bread: Bread{SyncThreshold: 4 * MB},
+		},
+	}
+
+	// Every record is distinct, so the reuse of a retained buffer is noticed
+	data := make([]byte, 0, 2*MB)
+	for i := 0; len(data) < 2*MB; i++ {
+		data = strconv.AppendInt(data, int64(i), 10)
+		data = append(data, '\n')
+	}
+
+	const every = 1000
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			c.bread.BufferSize = 128
+
+			stats := Stats{}
+
+			c.bread.OnComplete = func(_ context.Context, s Stats, _ error) {
+				stats = s
+			}
+
+			mu := sync.Mutex{}
+			retained := make([]Batch, 0)
+
+			err := c.bread.EatBatches(context.TODO(), bytes.NewReader(data), func(_ context.Context, batch Batch) {
+				if batch.Index%every != 0 {
+					return
+				}
+
+				batch.Retain()
+
+				mu.Lock()
+				retained = append(retained, batch)
+				mu.Unlock()
+
+				// The first retained batch is released while the reading goes on
+				if batch.Index == 0 {
+					go batch.Release()
+				}
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if stats.Batches < 10*every {
+				t.Fatalf("only %d batches were processed", stats.Batches)
+			}
+
+			leaked := uint64(0)
+			if c.pooled {
+				leaked = uint64(len(retained) - 1)
+			}
+
+			if stats.Retained != leaked && stats.Retained != leaked+1 {
+				t.Fatalf("expected %d retained buffers, got %d", leaked, stats.Retained)
+			}
+
+			// The data of the buffers still retained was not overwritten by the later batches
+			for _, batch := range retained[1:] {
+				if !bytes.Equal(batch.Data, data[batch.Offset:batch.Offset+int64(batch.Size)]) {
+					t.Fatalf("the retained batch %d was overwritten", batch.Index)
+				}
+
+				batch.Release()
+			}
+
+			t.Logf("Success! %d retained batches", len(retained))
+		})
+	}
+}
+
+func TestBread_RetainMaxMemory(t *testing.T) {
+	const buffers = 4
+
+	bread := Bread{
+		Workers:    2,
+		BufferSize: 128,
+		MaxMemory:  buffers * 128,
+		RecordSize: 128,
+	}
+
+	mu := sync.Mutex{}
+	held, peak := 0, 0
+	released := make(chan Batch, 64)
+
+	// The retained batches are released later by another goroutine
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		for batch := range released {
+			time.Sleep(time.Millisecond)
+
+			mu.Lock()
+			held--
+			mu.Unlock()
+
+			batch.Release()
+		}
+	}()
+
+	err := bread.EatBatches(context.TODO(), bytes.NewReader(memoryData(64*KB)), func(_ context.Context, batch Batch) {
+		batch.Retain()
+
+		mu.Lock()
+		held++
+		peak = max(peak, held)
+		mu.Unlock()
+
+		released <- batch
+	})
+
+	close(released)
+	<-done
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The retained buffers count against MaxMemory
+	if peak > buffers {
+		t.Fatalf("%d buffers retained at once, the budget is %d", peak, buffers)
+	}
+
+	t.Logf("Success! %d buffers retained at once", peak)
+}
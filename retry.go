@@ -0,0 +1,168 @@
+package bread
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"time"
+)
+
+// ErrSourceChanged indicates that the bytes of a batch changed in the source between the first read and a retry
+var ErrSourceChanged = errors.New("source changed")
+
+// SourceChangedError indicates which batch could not be re-read as it was, it matches ErrSourceChanged with errors.Is
+type SourceChangedError struct {
+	Meta BatchMeta
+	// Reason describes the difference
+	Reason string
+}
+
+func (e *SourceChangedError) Error() string {
+	return fmt.Sprintf("%v: batch %d at offset %d: %s", ErrSourceChanged, e.Meta.Index, e.Meta.Offset, e.Reason)
+}
+
+func (e *SourceChangedError) Unwrap() error {
+	return ErrSourceChanged
+}
+
+// Offset implements Positioned
+func (e *SourceChangedError) Offset() int64 {
+	return e.Meta.Offset
+}
+
+// rereadSource returns the source used to re-read the batches and the position where the stream starts in it,
+// or nil when the reader is not an io.ReaderAt
+func rereadSource(reader io.Reader) (io.ReaderAt, int64) {
+	source, ok := reader.(io.ReaderAt)
+	if !ok {
+		return nil, 0
+	}
+
+	// Readers already advanced, like an *os.File after a Seek
+	base := int64(0)
+
+	if seeker, ok := reader.(io.Seeker); ok {
+		position, err := seeker.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, 0
+		}
+
+		base = position
+	}
+
+	return source, base
+}
+
+// process runs the worker over the batch, retrying it up to BatchRetries times. When the batch is re-read
+// from the source, see RereadOnRetry, the buffer of the batch is replaced.
+func (e *eater) process(ctx context.Context, batch *Batch) error {
+	reread := e.BatchRetries > 0 && e.source != nil && !e.sliced && e.shuffle == nil && batch.buffer != nil
+
+	// The checksum is taken before the worker has a chance to modify the data
+	var checksum *uint32
+
+	if reread && e.RereadChecksum {
+		sum := crc32.ChecksumIEEE(batch.Data)
+		checksum = &sum
+	}
+
+	e.normalize(batch)
+	e.trim(batch)
+
+	// Only the pooled buffers are retained, the data of the other batches stays valid anyway
+	if e.pooled() {
+		batch.retention = e.retention
+	}
+
+	if e.sampling() {
+		batch.delivered = &e.counters.delivered
+	}
+
+	batch.partitions = e.partitions
+
+	err := e.attempt(ctx, batch)
+	backoff := e.RetryBackoff
+
+	// The abandoned calls may still be running, see FailOnBatchTimeout
+	for retry := uint32(0); err != nil && !batch.abandoned && retry < e.BatchRetries; retry++ {
+		// Detached and retained batches belong to the worker
+		reread := reread && *batch.buffer != nil && !e.retention.retained(batch.buffer)
+
+		// The bytes are not kept alive during the backoff
+		if reread {
+			e.pool.Put(batch.buffer)
+			batch.buffer, batch.Data = nil, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+
+		if reread {
+			if rereadErr := e.reread(batch, checksum); rereadErr != nil {
+				return rereadErr
+			}
+
+			e.normalize(batch)
+			e.trim(batch)
+		}
+
+		err = e.attempt(ctx, batch)
+	}
+
+	return err
+}
+
+// attempt runs the worker over the batch once, its error carries a preview of the batch while the data is valid
+func (e *eater) attempt(ctx context.Context, batch *Batch) (err error) {
+	if e.OnPanic != nil {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				e.OnPanic(ctx, recovered, batch.buffer)
+			}
+		}()
+	}
+
+	err = e.work(ctx, e.worker, *batch)
+	if err == nil {
+		return nil
+	}
+
+	// The buffer stays with the abandoned call, see FailOnBatchTimeout
+	var deadlineErr *DeadlineError
+	if errors.As(err, &deadlineErr) && deadlineErr.Abandoned {
+		batch.buffer, batch.Data, batch.abandoned = nil, nil, true
+	}
+
+	return &WorkerError{Meta: batch.BatchMeta, Preview: e.preview(batch.Data), Err: err}
+}
+
+// reread reads the batch again from the source into a pooled buffer
+func (e *eater) reread(batch *Batch, checksum *uint32) error {
+	buffer := e.pool.Get()
+	*buffer = grow(*buffer, batch.Size)
+
+	n, err := e.source.ReadAt(*buffer, e.base+batch.Offset)
+	if n == batch.Size {
+		err = nil
+	}
+
+	batch.buffer, batch.Data = buffer, (*buffer)[:n]
+
+	switch {
+	case err == io.EOF:
+		return &SourceChangedError{Meta: batch.BatchMeta, Reason: fmt.Sprintf("only %d of %d bytes left", n, batch.Size)}
+	case err != nil:
+		return &ReadError{Position: batch.Offset + int64(n), Err: err}
+	case checksum != nil && crc32.ChecksumIEEE(batch.Data) != *checksum:
+		return &SourceChangedError{Meta: batch.BatchMeta, Reason: "checksum mismatch"}
+	}
+
+	return nil
+}
@@ -0,0 +1,187 @@
+package bread
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// flakySink fails the first attempts of each batch, after running before
+type flakySink struct {
+	failures int
+	before   func(meta BatchMeta, data []byte)
+
+	mu       sync.Mutex
+	attempts map[uint64]int
+	// seen keeps the data received by the last attempt of each batch
+	seen map[uint64]string
+}
+
+var errFlaky = errors.New("flaky failure")
+
+func (s *flakySink) Write(_ context.Context, meta BatchMeta, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.attempts == nil {
+		s.attempts, s.seen = make(map[uint64]int), make(map[uint64]string)
+	}
+
+	s.seen[meta.Index] = string(data)
+
+	s.attempts[meta.Index]++
+	if s.attempts[meta.Index] > s.failures {
+		return nil
+	}
+
+	if s.before != nil {
+		s.before(meta, data)
+	}
+
+	return errFlaky
+}
+
+func (s *flakySink) Flush(context.Context) error {
+	return nil
+}
+
+func TestBread_BatchRetries(t *testing.T) {
+	data := strings.Repeat("some record\n", 100)
+
+	// file writes the data to a new file
+	file := func(t *testing.T) *os.File {
+		path := filepath.Join(t.TempDir(), "data")
+
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		t.Cleanup(func() {
+			_ = f.Close()
+		})
+
+		return f
+	}
+
+	// corrupt modifies the data received by the worker
+	corrupt := func(_ BatchMeta, data []byte) {
+		data[0] = 'X'
+	}
+
+	cases := [...]struct {
+		bread    Bread
+		failures int
+		before   func(t *testing.T, f *os.File) func(BatchMeta, []byte)
+		// reader returns the reader to eat, by default the file
+		reader func(f *os.File) io.Reader
+		// corrupted indicates that the retries receive the data modified by the worker
+		corrupted bool
+		err       error
+	}{
+		{
+			bread:    Bread{BatchRetries: 2},
+			failures: 2,
+		},
+		{
+			bread:    Bread{BatchRetries: 1},
+			failures: 2,
+			err:      errFlaky,
+		},
+		{
+			// In-memory retries keep the bytes modified by the worker
+			bread:     Bread{BatchRetries: 1},
+			failures:  1,
+			before:    func(*testing.T, *os.File) func(BatchMeta, []byte) { return corrupt },
+			corrupted: true,
+		},
+		{
+			bread:    Bread{BatchRetries: 1, RereadOnRetry: true, RereadChecksum: true},
+			failures: 1,
+			before:   func(*testing.T, *os.File) func(BatchMeta, []byte) { return corrupt },
+		},
+		{
+			// Sources that are not an io.ReaderAt are retried in memory
+			bread:     Bread{BatchRetries: 1, RereadOnRetry: true},
+			failures:  1,
+			before:    func(*testing.T, *os.File) func(BatchMeta, []byte) { return corrupt },
+			reader:    func(f *os.File) io.Reader { return readFunc(f.Read) },
+			corrupted: true,
+		},
+		{
+			bread:    Bread{BatchRetries: 1, RereadOnRetry: true, RereadChecksum: true},
+			failures: 1,
+			before: func(t *testing.T, f *os.File) func(BatchMeta, []byte) {
+				return func(meta BatchMeta, _ []byte) {
+					if err := os.WriteFile(f.Name(), []byte(strings.ToUpper(data)), 0o600); err != nil {
+						t.Error(err)
+					}
+				}
+			},
+			err: ErrSourceChanged,
+		},
+		{
+			bread:    Bread{BatchRetries: 1, RereadOnRetry: true},
+			failures: 1,
+			before: func(t *testing.T, f *os.File) func(BatchMeta, []byte) {
+				return func(BatchMeta, []byte) {
+					if err := os.Truncate(f.Name(), 0); err != nil {
+						t.Error(err)
+					}
+				}
+			},
+			err: ErrSourceChanged,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			f := file(t)
+
+			sink := &flakySink{failures: c.failures}
+			if c.before != nil {
+				sink.before = c.before(t, f)
+			}
+
+			var reader io.Reader = f
+			if c.reader != nil {
+				reader = c.reader(f)
+			}
+
+			c.bread.Sink = sink
+			c.bread.BufferSize = 64
+
+			err := c.bread.Eat(context.TODO(), reader)
+			if !errors.Is(err, c.err) {
+				t.Fatalf("expected error %v, got %v", c.err, err)
+			}
+
+			if c.err != nil {
+				t.Log("Success!")
+				return
+			}
+
+			joined := strings.Builder{}
+
+			for index := uint64(0); index < uint64(len(sink.seen)); index++ {
+				joined.WriteString(sink.seen[index])
+			}
+
+			if corrupted := joined.String() != data; corrupted != c.corrupted {
+				t.Fatalf("expected corrupted data %v, got %q", c.corrupted, joined.String())
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
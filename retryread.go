@@ -0,0 +1,57 @@
+package bread
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// RetryReads builds a RetryRead that retries each read up to retries times, the backoff starts at backoff
+// and doubles on each attempt
+func RetryReads(retries int, backoff time.Duration) func(error, int) (bool, time.Duration) {
+	return func(_ error, attempt int) (bool, time.Duration) {
+		if attempt > retries {
+			return false, 0
+		}
+
+		return true, backoff << (attempt - 1)
+	}
+}
+
+// retryReader retries the failed reads of r, see Bread.RetryRead
+type retryReader struct {
+	ctx   context.Context
+	r     io.Reader
+	retry func(err error, attempt int) (bool, time.Duration)
+}
+
+func (r *retryReader) Read(p []byte) (int, error) {
+	for attempt := 1; ; attempt++ {
+		n, err := r.r.Read(p)
+
+		switch {
+		case err == nil || err == io.EOF || errors.Is(err, io.ErrUnexpectedEOF):
+			return n, err
+		case r.ctx.Err() != nil:
+			return n, r.ctx.Err()
+		case n > 0:
+			// The bytes are kept, the next read retries
+			return n, nil
+		}
+
+		retry, backoff := r.retry(err, attempt)
+		if !retry {
+			return 0, err
+		}
+
+		timer := time.NewTimer(backoff)
+
+		select {
+		case <-r.ctx.Done():
+			timer.Stop()
+			return 0, r.ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
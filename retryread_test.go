@@ -0,0 +1,120 @@
+package bread
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// errTransient is the error of the reads that succeed when they are retried
+var errTransient = errors.New("connection reset")
+
+// flakyReader fails every third read, partial makes the failed reads return half of the bytes along with the error
+type flakyReader struct {
+	r       io.Reader
+	partial bool
+	calls   int
+}
+
+func (f *flakyReader) Read(p []byte) (int, error) {
+	f.calls++
+
+	if f.calls%3 != 0 {
+		return f.r.Read(p)
+	}
+
+	if f.partial && len(p) > 1 {
+		n, _ := f.r.Read(p[:len(p)/2])
+		return n, errTransient
+	}
+
+	return 0, errTransient
+}
+
+func TestBread_RetryRead(t *testing.T) {
+	data := memoryData(256 * KB)
+
+	cases := [...]struct {
+		reader io.Reader
+		retry  func(error, int) (bool, time.Duration)
+		// timeout of the context, zero means none
+		timeout time.Duration
+		// attempts is the expected number of calls to retry, -1 means any
+		attempts int
+		err      error
+	}{
+		{
+			reader:   &flakyReader{r: bytes.NewReader(data)},
+			retry:    RetryReads(1, 0),
+			attempts: -1,
+		},
+		{
+			reader:   &flakyReader{r: bytes.NewReader(data), partial: true},
+			retry:    RetryReads(1, 0),
+			attempts: -1,
+		},
+		{
+			reader:   failedReader{err: errTransient},
+			retry:    RetryReads(3, time.Millisecond),
+			attempts: 4,
+			err:      errTransient,
+		},
+		{
+			reader:   failedReader{err: io.ErrUnexpectedEOF},
+			retry:    RetryReads(3, 0),
+			attempts: 0,
+			err:      io.ErrUnexpectedEOF,
+		},
+		{
+			// The backoff ends with the context
+			reader:   failedReader{err: errTransient},
+			retry:    RetryReads(1, time.Hour),
+			timeout:  50 * time.Millisecond,
+			attempts: 1,
+			err:      context.DeadlineExceeded,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			if c.timeout > 0 {
+				ctx, cancel = context.WithTimeout(context.Background(), c.timeout)
+			}
+			defer cancel()
+
+			attempts := 0
+
+			bread := Bread{
+				BufferSize: 1 * KB,
+				Ordered:    true,
+				Output:     &bytes.Buffer{},
+				RetryRead: func(err error, attempt int) (bool, time.Duration) {
+					attempts++
+					return c.retry(err, attempt)
+				},
+				WorkerFunc: func(context.Context, *[]byte) {},
+			}
+
+			err := bread.Eat(ctx, c.reader)
+			if !errors.Is(err, c.err) {
+				t.Fatalf("expected error %v, got %v", c.err, err)
+			}
+
+			if c.attempts >= 0 && attempts != c.attempts {
+				t.Fatalf("expected %d attempts, got %d", c.attempts, attempts)
+			}
+
+			// Nothing was lost nor duplicated
+			if output := bread.Output.(*bytes.Buffer).Bytes(); c.err == nil && !bytes.Equal(output, data) {
+				t.Fatalf("expected %d bytes, got %d", len(data), len(output))
+			}
+
+			t.Logf("Success! %d attempts, %v", attempts, err)
+		})
+	}
+}
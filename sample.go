@@ -0,0 +1,84 @@
+package bread
+
+import (
+	"context"
+	"math"
+)
+
+// sampling reports whether SampleEvery or SampleRate leave out part of the records
+func (b Bread) sampling() bool {
+	return b.SampleEvery > 1 || (b.SampleRate > 0 && b.SampleRate < 1)
+}
+
+// sampled reports whether the record or batch with the given number, counting from 1, is delivered.
+// See SampleEvery and SampleRate
+func (b Bread) sampled(n uint64) bool {
+	switch {
+	case b.SampleEvery > 1:
+		return n%b.SampleEvery == 0
+	case b.SampleRate > 0 && b.SampleRate < 1:
+		// The 53 high bits of the hash make a uniform float64 in [0, 1)
+		return float64(splitmix64(uint64(b.SampleSeed)+n*0x9e3779b97f4a7c15)>>11)/(1<<53) < b.SampleRate
+	}
+
+	return true
+}
+
+// splitmix64 is the finalizer of the SplitMix64 generator, it spreads consecutive numbers over the 64 bits
+func splitmix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+
+	return x
+}
+
+// validSampling reports whether the sampling settings are consistent
+func (b Bread) validSampling() bool {
+	if math.IsNaN(b.SampleRate) || b.SampleRate < 0 || b.SampleRate > 1 {
+		return false
+	}
+
+	return b.SampleEvery == 0 || b.SampleRate == 0
+}
+
+// sampleBatches makes the worker skip the batches left out by the sampling, they are consumed without calling it
+func (b Bread) sampleBatches(worker func(context.Context, Batch) error) func(context.Context, Batch) error {
+	if !b.sampling() {
+		return worker
+	}
+
+	return func(ctx context.Context, batch Batch) error {
+		if !b.sampled(batch.Index + 1) {
+			return nil
+		}
+
+		if batch.delivered != nil {
+			batch.delivered.Add(uint64(batch.Records))
+		}
+
+		return worker(ctx, batch)
+	}
+}
+
+// sampleRecords keeps in place the records left by the sampling, numbered like the records of the stream
+func (b Bread) sampleRecords(records [][]byte, batch Batch) [][]byte {
+	kept := records[:0]
+
+	for j, record := range records {
+		if b.sampled(batch.FirstRecord + uint64(j) + 1) {
+			kept = append(kept, record)
+		}
+	}
+
+	// The records left out must not keep the buffer of the batch alive
+	clear(records[len(kept):])
+
+	if batch.delivered != nil {
+		batch.delivered.Add(uint64(len(kept)))
+	}
+
+	return kept
+}
@@ -0,0 +1,155 @@
+package bread
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestBread_Sample(t *testing.T) {
+	lines := make([]string, 1000)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("record %d\n", i+1)
+	}
+
+	data := strings.Join(lines, "")
+
+	cases := [...]struct {
+		bread Bread
+		// records reports whether the records are delivered one by one to RecordFunc
+		records bool
+		// expected is the number of records delivered, -1 when it is random
+		expected int
+		err      error
+	}{
+		{
+			bread:    Bread{BufferSize: 64, Workers: 4, SampleEvery: 10},
+			records:  true,
+			expected: 100,
+		},
+		{
+			bread:    Bread{BufferSize: 64, Workers: 4, SampleRate: 0.25, SampleSeed: 7},
+			records:  true,
+			expected: -1,
+		},
+		{
+			bread:    Bread{BufferSize: 64, Workers: 4, SampleRate: 1},
+			records:  true,
+			expected: len(lines),
+		},
+		{
+			bread:    Bread{BufferSize: 64, Workers: 4, SampleEvery: 3},
+			expected: -1,
+		},
+		{
+			bread: Bread{BufferSize: 64, SampleRate: 1.5},
+			err:   ErrSampling,
+		},
+		{
+			bread: Bread{BufferSize: 64, SampleEvery: 2, SampleRate: 0.5},
+			err:   ErrSampling,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			run := func() ([]string, Stats, int64, error) {
+				mu := sync.Mutex{}
+				delivered := make([]string, 0)
+				checkpoint := int64(0)
+
+				b := c.bread
+				b.CheckpointFunc = func(_ context.Context, offset int64) error {
+					checkpoint = offset
+					return nil
+				}
+
+				if c.records {
+					b.RecordFunc = func(_ context.Context, records [][]byte) {
+						mu.Lock()
+						defer mu.Unlock()
+
+						for _, record := range records {
+							delivered = append(delivered, string(record))
+						}
+					}
+				} else {
+					b.WorkerBatchFunc = func(_ context.Context, batch Batch) {
+						if (batch.Index+1)%b.SampleEvery != 0 {
+							t.Errorf("batch %d was not sampled", batch.Index)
+						}
+
+						mu.Lock()
+						defer mu.Unlock()
+
+						delivered = append(delivered, strings.SplitAfter(strings.TrimSuffix(string(batch.Data), "\n"), "\n")...)
+					}
+				}
+
+				stats, err := b.EatStats(context.Background(), strings.NewReader(data))
+				slices.Sort(delivered)
+
+				return delivered, stats, checkpoint, err
+			}
+
+			delivered, stats, checkpoint, err := run()
+			if c.err != nil {
+				if !errors.Is(err, c.err) {
+					t.Fatalf("expected error %v, got %v", c.err, err)
+				}
+
+				t.Logf("Success! %v", err)
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if c.expected >= 0 && len(delivered) != c.expected {
+				t.Fatalf("expected %d records, got %d", c.expected, len(delivered))
+			}
+
+			if len(delivered) == 0 || len(delivered) == len(lines) && c.expected != len(lines) {
+				t.Fatalf("unexpected number of records %d", len(delivered))
+			}
+
+			// The records left out are still read
+			if stats.Records != uint64(len(lines)) || stats.DeliveredRecords != uint64(len(delivered)) {
+				t.Fatalf("expected %d records seen and %d delivered, got %d and %d", len(lines), len(delivered), stats.Records, stats.DeliveredRecords)
+			}
+
+			if int(stats.BytesRead) != len(data) || checkpoint != int64(len(data)) {
+				t.Fatalf("expected %d bytes read and checkpointed, got %d and %d", len(data), stats.BytesRead, checkpoint)
+			}
+
+			if c.bread.SampleEvery > 0 && c.records {
+				for _, record := range delivered {
+					var n uint64
+					fmt.Sscanf(record, "record %d", &n)
+
+					if n%c.bread.SampleEvery != 0 {
+						t.Fatalf("record %d was not sampled", n)
+					}
+				}
+			}
+
+			// The same seed samples the same records
+			again, _, _, err := run()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !slices.Equal(delivered, again) {
+				t.Fatal("the sampling is not reproducible")
+			}
+
+			t.Logf("Success! %d of %d records", len(delivered), len(lines))
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package bread
+
+import (
+	"runtime"
+	"time"
+)
+
+// DefaultScaleWindow is the default ScaleWindow
+const DefaultScaleWindow = 100 * time.Millisecond
+
+// ScaledWorkersPerCPU is the maximum number of workers for each CPU when ScaleWorkers is set without Workers
+const ScaledWorkersPerCPU = 8
+
+const (
+	// idleWindows is the number of consecutive idle windows that deactivate a worker
+	idleWindows = 4
+	// holdWindows is the number of windows without growing after the first growth that did not pay off
+	holdWindows = 8
+	// maxHoldWindows bounds the windows without growing after the growths that did not pay off
+	maxHoldWindows = 64
+	// minGain is the increase of the throughput expected from an additional worker
+	minGain = 1.1
+)
+
+// scaler adjusts the number of active workers while eating, see Bread.ScaleWorkers.
+//
+// The inactive workers are the slots of workerCh held by the scaler, so the dispatch needs nothing else:
+// a worker is deactivated by taking a free slot, and activated by giving it back.
+type scaler struct {
+	stop    chan struct{}
+	stopped chan struct{}
+
+	// The fields below are owned by the goroutine of the scaler until it stops
+	held      int
+	waits     uint64
+	completed uint64
+	// rate is the number of batches completed during the window that followed the last growth, zero when
+	// the last change was not a growth
+	rate uint64
+	// idle is the number of consecutive windows with free workers and batches that did not wait
+	idle int
+	// hold is the number of windows left before growing again, and backoff the next hold
+	hold       int
+	backoff    int
+	scaleUps   uint64
+	scaleDowns uint64
+}
+
+// initialWorkers returns the number of active workers when ScaleWorkers starts
+func (b Bread) initialWorkers() int {
+	return min(b.Workers, runtime.GOMAXPROCS(0))
+}
+
+// scaleWindow returns the effective ScaleWindow
+func (b Bread) scaleWindow() time.Duration {
+	if b.ScaleWindow <= 0 {
+		return DefaultScaleWindow
+	}
+
+	return b.ScaleWindow
+}
+
+// startScaler starts the goroutine in charge of ScaleWorkers
+func (e *eater) startScaler() {
+	e.counters.workers.Store(int64(e.Workers))
+
+	// The slots shared with other readers are not scaled, see EatFS
+	if !e.ScaleWorkers || e.Bread.slots != nil {
+		return
+	}
+
+	s := &scaler{
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+		held:    e.Workers - e.initialWorkers(),
+		backoff: holdWindows,
+	}
+
+	// Nothing was dispatched yet, so every slot is free
+	for range s.held {
+		e.workerCh <- struct{}{}
+	}
+
+	e.counters.workers.Store(int64(e.initialWorkers()))
+	e.scaler = s
+
+	go func() {
+		defer close(s.stopped)
+
+		ticker := time.NewTicker(e.scaleWindow())
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+			}
+
+			e.scale(s)
+		}
+	}()
+}
+
+// scale decides the active workers of the next window from the last one. A worker is activated when the batches
+// waited for a free worker, and deactivated again when the throughput did not grow with it. A worker is also
+// deactivated once the workers were idle during several windows, so a short pause does not undo the growth.
+func (e *eater) scale(s *scaler) {
+	waits, completed := e.waits.Load(), e.counters.completed.Load()
+	rate := completed - s.completed
+	saturated := waits > s.waits
+	idle := !saturated && len(e.workerCh) < cap(e.workerCh)
+
+	s.waits, s.completed = waits, completed
+
+	switch {
+	case s.rate > 0:
+		// The window that follows a growth tells whether the worker paid off, it does not for CPU-bound workers.
+		// The next attempt waits twice as long each time.
+		if saturated && float64(rate) < float64(s.rate)*minGain {
+			if e.deactivate(s) {
+				s.hold, s.backoff = s.backoff, min(s.backoff*2, maxHoldWindows)
+			}
+		} else {
+			s.backoff = holdWindows
+		}
+
+		s.rate = 0
+	case saturated:
+		s.idle = 0
+
+		if s.hold == 0 && s.held > 0 {
+			<-e.workerCh
+			s.held--
+			s.scaleUps++
+			s.rate = max(rate, 1)
+		}
+	case idle:
+		s.idle++
+
+		if s.idle >= idleWindows && e.deactivate(s) {
+			s.idle = 0
+		}
+	default:
+		s.idle = 0
+	}
+
+	s.hold = max(s.hold-1, 0)
+	e.counters.workers.Store(int64(cap(e.workerCh) - s.held))
+}
+
+// deactivate takes the slot of a worker once it is free, there is always one active worker left
+func (e *eater) deactivate(s *scaler) bool {
+	if s.held >= cap(e.workerCh)-1 {
+		return false
+	}
+
+	select {
+	case e.workerCh <- struct{}{}:
+		s.held++
+		s.scaleDowns++
+		return true
+	case <-s.stop:
+		return false
+	}
+}
+
+// stopScaler waits for the goroutine of the scaler and gives back the slots of the inactive workers
+func (e *eater) stopScaler() {
+	s := e.scaler
+	if s == nil {
+		return
+	}
+
+	close(s.stop)
+	<-s.stopped
+
+	for range s.held {
+		<-e.workerCh
+	}
+}
+
+// ups returns the number of workers activated, the scaler must be stopped
+func (s *scaler) ups() uint64 {
+	if s == nil {
+		return 0
+	}
+
+	return s.scaleUps
+}
+
+// downs returns the number of workers deactivated, the scaler must be stopped
+func (s *scaler) downs() uint64 {
+	if s == nil {
+		return 0
+	}
+
+	return s.scaleDowns
+}
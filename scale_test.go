@@ -0,0 +1,207 @@
+package bread
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestBread_Scale(t *testing.T) {
+	e := &eater{
+		Bread:    Bread{Workers: 8},
+		workerCh: make(chan struct{}, 8),
+		counters: new(Tracker),
+	}
+
+	s := &scaler{stop: make(chan struct{}), held: 7, backoff: holdWindows}
+	for range s.held {
+		e.workerCh <- struct{}{}
+	}
+
+	// Each window is described by whether a batch waited and the batches completed during it
+	windows := make([]struct {
+		waited    bool
+		completed uint64
+		// active is the number of active workers expected after the window
+		active int
+	}, 0)
+
+	window := func(waited bool, completed uint64, active int) {
+		windows = append(windows, struct {
+			waited    bool
+			completed uint64
+			active    int
+		}{waited, completed, active})
+	}
+
+	window(true, 10, 2)
+	// The worker paid off
+	window(true, 20, 2)
+	window(true, 20, 3)
+	// The worker did not pay off, the growth waits holdWindows
+	window(true, 21, 2)
+	for range holdWindows - 1 {
+		window(true, 20, 2)
+	}
+	window(true, 20, 3)
+	// The worker did not pay off again, the growth waits twice as long
+	window(true, 20, 2)
+	for range 2*holdWindows - 1 {
+		window(true, 20, 2)
+	}
+	window(true, 20, 3)
+	window(false, 20, 3)
+	// The idle workers are deactivated after idleWindows
+	for range idleWindows - 1 {
+		window(false, 5, 3)
+	}
+	window(false, 5, 2)
+	for range idleWindows - 1 {
+		window(false, 5, 2)
+	}
+	window(false, 5, 1)
+	// There is always a worker
+	for range idleWindows {
+		window(false, 5, 1)
+	}
+
+	for i, w := range windows {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			if w.waited {
+				e.waits.Add(1)
+			}
+
+			e.counters.completed.Add(w.completed)
+			e.scale(s)
+
+			if active := int(e.counters.workers.Load()); active != w.active {
+				t.Fatalf("expected %d active workers, got %d", w.active, active)
+			}
+		})
+	}
+
+	t.Logf("Success! %d up, %d down", s.scaleUps, s.scaleDowns)
+}
+
+func TestBread_ScaleWorkers(t *testing.T) {
+	cases := [...]struct {
+		bread Bread
+		// work is the time spent by the worker on each batch
+		work time.Duration
+		// min and max bound the active workers when the processing ends
+		min, max int
+	}{
+		{
+			bread: Bread{AutoWorkers: true},
+			min:   runtime.GOMAXPROCS(0),
+			max:   runtime.GOMAXPROCS(0),
+		},
+		{
+			bread: Bread{AutoWorkers: true, Workers: 3},
+			min:   3,
+			max:   3,
+		},
+		{
+			// The workers wait, so they pay off
+			bread: Bread{ScaleWorkers: true, Workers: 16, ScaleWindow: 10 * time.Millisecond},
+			work:  2 * time.Millisecond,
+			min:   runtime.GOMAXPROCS(0) + 2,
+			max:   16,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			goroutines := runtime.NumGoroutine()
+
+			c.bread.BufferSize = 16
+			c.bread.WorkerFunc = func(context.Context, *[]byte) {
+				time.Sleep(c.work)
+			}
+
+			progress := make([]int, 0)
+			c.bread.Tracker = new(Tracker)
+			c.bread.OnProgress = func(p Progress) {
+				progress = append(progress, p.Workers)
+			}
+
+			stats, err := c.bread.EatStats(context.Background(), bytes.NewReader(bytes.Repeat([]byte("0123456789abcdef\n"), 1000)))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if stats.Workers < c.min || stats.Workers > c.max {
+				t.Fatalf("expected between %d and %d active workers, got %d", c.min, c.max, stats.Workers)
+			}
+
+			if c.bread.ScaleWorkers && int(stats.ScaleUps-stats.ScaleDowns) != stats.Workers-runtime.GOMAXPROCS(0) {
+				t.Fatalf("%d workers activated and %d deactivated do not end with %d", stats.ScaleUps, stats.ScaleDowns, stats.Workers)
+			}
+
+			if last := progress[len(progress)-1]; last != stats.Workers {
+				t.Fatalf("expected %d workers in the last progress, got %d", stats.Workers, last)
+			}
+
+			deadline := time.Now().Add(time.Second)
+			for runtime.NumGoroutine() > goroutines && time.Now().Before(deadline) {
+				time.Sleep(10 * time.Millisecond)
+			}
+
+			if runtime.NumGoroutine() > goroutines {
+				t.Fatalf("%d goroutines leaked", runtime.NumGoroutine()-goroutines)
+			}
+
+			t.Logf("Success! %d workers", stats.Workers)
+		})
+	}
+}
+
+// BenchmarkBread_ScaleWorkers compares the workers chosen for CPU-bound and IO-bound worker functions
+func BenchmarkBread_ScaleWorkers(b *testing.B) {
+	data := bytes.Repeat([]byte("0123456789abcdef\n"), 64*KB/17)
+
+	workers := map[string]func(context.Context, *[]byte){
+		"cpu": func(_ context.Context, buffer *[]byte) {
+			for range 64 {
+				sha256.Sum256(*buffer)
+			}
+		},
+		"io": func(context.Context, *[]byte) {
+			time.Sleep(100 * time.Microsecond)
+		},
+	}
+
+	modes := map[string]Bread{
+		"default": {},
+		"auto":    {AutoWorkers: true},
+		"scale":   {ScaleWorkers: true, ScaleWindow: 10 * time.Millisecond},
+	}
+
+	for name, worker := range workers {
+		for mode, bread := range modes {
+			b.Run(name+"/"+mode, func(b *testing.B) {
+				bread.BufferSize = 512
+				bread.WorkerFunc = worker
+
+				b.SetBytes(int64(len(data)))
+
+				active := 0
+
+				for n := 0; n < b.N; n++ {
+					stats, err := bread.EatStats(context.Background(), bytes.NewReader(data))
+					if err != nil {
+						b.Fatal(err)
+					}
+
+					active = max(active, stats.Workers)
+				}
+
+				b.ReportMetric(float64(active), "workers")
+			})
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package bread
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestBread_Scratch(t *testing.T) {
+	data := "aaaaa\nbbbbb\nccccc\nddddd\n"
+
+	cases := [...]struct {
+		bread    Bread
+		expected []string
+		err      error
+	}{
+		{
+			bread:    Bread{Align: AlignBackward, Scratch: make([]byte, 0, 8)},
+			expected: []string{"aaaaa\n", "bbbbb\n", "ccccc\n", "ddddd\n"},
+		},
+		{
+			// The bytes after the first delimiter do not fit
+			bread: Bread{Align: AlignBackward, Scratch: make([]byte, 1)},
+			err:   ErrScratchTooSmall,
+		},
+		{
+			bread:    Bread{MaxBatchSize: 8, Scratch: make([]byte, 0, 8)},
+			expected: []string{"aaaaa\n", "bbbbb\n", "ccccc\n", "ddddd\n"},
+		},
+		{
+			bread: Bread{MaxBatchSize: 8, Scratch: make([]byte, 1)},
+			err:   ErrScratchTooSmall,
+		},
+		{
+			bread: Bread{MaxRecordSize: 16, Scratch: make([]byte, 8)},
+			err:   ErrScratchTooSmall,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			sink := &collectingSink{}
+
+			c.bread.BufferSize = 8
+			c.bread.Sink = sink
+
+			err := c.bread.Eat(context.TODO(), strings.NewReader(data))
+			if !errors.Is(err, c.err) {
+				t.Fatalf("expected error %v, got %v", c.err, err)
+			}
+
+			if c.err != nil {
+				t.Log("Success!")
+				return
+			}
+
+			batches := make([]string, len(sink.batches))
+			for j, batch := range sink.batches {
+				batches[j] = string(batch.Data)
+			}
+
+			if strings.Join(batches, "|") != strings.Join(c.expected, "|") {
+				t.Fatalf("expected batches %q, got %q", c.expected, batches)
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+// TestBread_Allocs checks that the allocations of an Eat call with a shared Pool and a Scratch
+// do not depend on the number of batches
+func TestBread_Allocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("the pool drops buffers under the race detector")
+	}
+
+	cases := [...]struct {
+		bread Bread
+	}{
+		{
+			bread: Bread{},
+		},
+		{
+			bread: Bread{Workers: 4},
+		},
+		{
+			bread: Bread{Align: AlignBackward, Scratch: make([]byte, 0, 64)},
+		},
+		{
+			bread: Bread{MaxBatchSize: 64, Scratch: make([]byte, 0, 64)},
+		},
+	}
+
+	// allocs returns the allocations of an Eat call over the given number of records
+	allocs := func(t *testing.T, bread Bread, records int) float64 {
+		data := bytes.Repeat([]byte("0123456789abcde\n"), records)
+		reader := bytes.NewReader(data)
+
+		return testing.AllocsPerRun(10, func() {
+			reader.Reset(data)
+
+			// The reader is wrapped to take the generic path
+			if err := bread.Eat(context.TODO(), readFunc(reader.Read)); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			c.bread.BufferSize = 64
+			c.bread.Pool = NewBufferPool(64, 128)
+			c.bread.WorkerFunc = func(context.Context, *[]byte) {}
+
+			few, many := allocs(t, c.bread, 100), allocs(t, c.bread, 10_000)
+
+			// A few worker goroutines may be started late
+			if many > few+float64(c.bread.Workers)+1 {
+				t.Fatalf("expected about %v allocations, got %v", few, many)
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
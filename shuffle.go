@@ -0,0 +1,95 @@
+package bread
+
+import (
+	"bytes"
+	"context"
+	"math/rand"
+)
+
+// shuffler is the reservoir of records of ShuffleBuffer.
+//
+// The records are copied out of the pooled buffers, so the memory held is bounded by the size of the records
+// instead of BufferSize. Their buffers are never returned to the pool.
+type shuffler struct {
+	random  *rand.Rand
+	records []Batch
+	// bytes is the size of the records held
+	bytes uint64
+	// index is the position of the next dispatched record
+	index uint64
+}
+
+func newShuffler(seed int64) *shuffler {
+	return &shuffler{
+		random: rand.New(rand.NewSource(seed)),
+	}
+}
+
+// pop removes a random record from the reservoir
+func (s *shuffler) pop() Batch {
+	last := len(s.records) - 1
+
+	i := s.random.Intn(len(s.records))
+	s.records[i], s.records[last] = s.records[last], s.records[i]
+
+	record := s.records[last]
+	s.records[last] = Batch{}
+	s.records = s.records[:last]
+
+	s.bytes -= uint64(record.Size)
+
+	record.Index = s.index
+	s.index++
+
+	return record
+}
+
+// reserve adds the records of the batch to the reservoir, dispatching random records to make room for them
+func (e *eater) reserve(ctx context.Context, batch Batch) {
+	data := batch.Data
+
+	for record := batch.FirstRecord; len(data) > 0; record++ {
+		// Only FramingDelimiter batches hold several records
+		end := len(data)
+		if e.Framing == FramingDelimiter {
+			if i := bytes.IndexByte(data, e.Delimiter); i >= 0 {
+				end = i + 1
+			}
+		}
+
+		for len(e.shuffle.records) > 0 && (len(e.shuffle.records) >= int(e.ShuffleBuffer) ||
+			e.ShuffleMaxBytes > 0 && e.shuffle.bytes+uint64(end) > e.ShuffleMaxBytes) {
+			e.dispatch(ctx, e.shuffle.pop())
+		}
+
+		copied := bytes.Clone(data[:end])
+
+		e.shuffle.records = append(e.shuffle.records, Batch{
+			BatchMeta: BatchMeta{
+				Offset:      batch.Offset + int64(batch.Size-len(data)),
+				Size:        end,
+				Records:     1,
+				FirstRecord: record,
+				Epoch:       batch.Epoch,
+				Source:      batch.Source,
+			},
+			Data:   copied,
+			buffer: &copied,
+		})
+
+		e.shuffle.bytes += uint64(end)
+		data = data[end:]
+	}
+
+	// Sliced batches are not pooled
+	if !e.sliced {
+		e.pool.Put(batch.buffer)
+	}
+}
+
+// drain dispatches the records left in the reservoir at the end of the stream
+func (e *eater) drain(ctx context.Context) {
+	for len(e.shuffle.records) > 0 && !e.failed.Load() && ctx.Err() == nil {
+		e.dispatch(ctx, e.shuffle.pop())
+	}
+}
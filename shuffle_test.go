@@ -0,0 +1,140 @@
+package bread
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// shuffled eats data returning the records in the order given by BatchMeta.Index
+func shuffled(t *testing.T, bread Bread, data string) []string {
+	var (
+		mu      sync.Mutex
+		records = make(map[uint64]string)
+	)
+
+	err := bread.EatBatches(context.TODO(), strings.NewReader(data), func(_ context.Context, batch Batch) {
+		mu.Lock()
+		records[batch.Index] = string(batch.Data)
+		mu.Unlock()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	order := make([]string, len(records))
+
+	for index, record := range records {
+		order[index] = record
+	}
+
+	return order
+}
+
+func TestBread_ShuffleBuffer(t *testing.T) {
+	records := make([]string, 100)
+
+	for i := range records {
+		records[i] = strconv.Itoa(i) + "\n"
+	}
+
+	data := strings.Join(records, "")
+
+	cases := [...]struct {
+		bread Bread
+		// ordered indicates that the records keep the stream order
+		ordered bool
+	}{
+		{
+			bread: Bread{Workers: 4, BufferSize: 16, ShuffleBuffer: 10},
+		},
+		{
+			bread: Bread{Workers: 4, BufferSize: 16, ShuffleBuffer: 1000, ShuffleSeed: 7},
+		},
+		{
+			bread: Bread{Workers: 4, BufferSize: 16, ShuffleBuffer: 10, SyncThreshold: 1 * KB},
+		},
+		{
+			bread:   Bread{Workers: 4, BufferSize: 16, ShuffleBuffer: 1},
+			ordered: true,
+		},
+		{
+			// A single record fits in the reservoir
+			bread:   Bread{Workers: 4, BufferSize: 16, ShuffleBuffer: 10, ShuffleMaxBytes: 1},
+			ordered: true,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			order := shuffled(t, c.bread, data)
+
+			if ordered := slices.Equal(order, records); ordered != c.ordered {
+				t.Fatalf("expected ordered %v, got %q", c.ordered, order)
+			}
+
+			// The same seed produces the same order
+			if again := shuffled(t, c.bread, data); !slices.Equal(order, again) {
+				t.Fatalf("expected order %q, got %q", order, again)
+			}
+
+			slices.SortFunc(order, func(a, b string) int {
+				x, _ := strconv.Atoi(strings.TrimSpace(a))
+				y, _ := strconv.Atoi(strings.TrimSpace(b))
+
+				return x - y
+			})
+
+			if !slices.Equal(order, records) {
+				t.Fatalf("expected records %q, got %q", records, order)
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+// TestBread_ShuffleBias checks that every record lands in every position with about the same frequency,
+// when the reservoir holds the whole stream
+func TestBread_ShuffleBias(t *testing.T) {
+	const (
+		records = 8
+		trials  = 4_000
+	)
+
+	data := strings.Repeat("x\n", records)
+
+	var counts [records][records]int
+
+	for trial := 0; trial < trials; trial++ {
+		bread := Bread{
+			BufferSize:    4,
+			ShuffleBuffer: records,
+			ShuffleSeed:   int64(trial),
+		}
+
+		err := bread.EatBatches(context.TODO(), strings.NewReader(data), func(_ context.Context, batch Batch) {
+			counts[batch.FirstRecord][batch.Index]++
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The standard deviation of each count is about 21, the tolerance is almost five of them
+	expected := trials / records
+	tolerance := expected / 5
+
+	for record, positions := range counts {
+		for position, count := range positions {
+			if count < expected-tolerance || count > expected+tolerance {
+				t.Fatalf("record %d landed %d times in position %d, expected %d±%d", record, count, position, expected, tolerance)
+			}
+		}
+	}
+
+	t.Log("Success!")
+}
@@ -0,0 +1,138 @@
+package bread
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"sync"
+)
+
+// Sink receives the batches of an Eat call, see Bread.Sink
+type Sink interface {
+	// Write receives a batch, it may be called concurrently by up to Workers goroutines.
+	// The data is only valid until Write returns.
+	Write(ctx context.Context, meta BatchMeta, data []byte) error
+	// Flush is called exactly once after every Write returned, no matter how the Eat call ended.
+	// The context is not canceled along with the Eat context, see EatError.
+	Flush(ctx context.Context) error
+}
+
+// eatErrorKey is the context key of the error passed to Sink.Flush
+type eatErrorKey struct{}
+
+// EatError returns the error that ended the Eat call, from the context passed to Sink.Flush.
+// A nil error means that the whole stream was written.
+func EatError(ctx context.Context) error {
+	err, _ := ctx.Value(eatErrorKey{}).(error)
+	return err
+}
+
+// flush flushes the Sink, passing the error that ended the Eat call through the context
+func (e *eater) flush(ctx context.Context, err error) error {
+	if err == nil {
+		err = ctx.Err()
+	}
+
+	return e.Sink.Flush(context.WithValue(context.WithoutCancel(ctx), eatErrorKey{}, err))
+}
+
+// OrderedSink is a Sink that writes the batches to an io.Writer in stream order, holding the batches that
+// arrive early until the previous ones were written. Flush syncs the writer if it has a Sync method, like *os.File.
+//
+// Batches held when the Eat call fails are discarded by Flush.
+type OrderedSink struct {
+	w io.Writer
+
+	mu      sync.Mutex
+	next    uint64
+	pending map[uint64][]byte
+}
+
+// NewOrderedSink returns an OrderedSink that writes to w
+func NewOrderedSink(w io.Writer) *OrderedSink {
+	return &OrderedSink{
+		w:       w,
+		pending: make(map[uint64][]byte),
+	}
+}
+
+// Write implements Sink
+func (s *OrderedSink) Write(_ context.Context, meta BatchMeta, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if meta.Index != s.next {
+		s.pending[meta.Index] = bytes.Clone(data)
+		return nil
+	}
+
+	if _, err := s.w.Write(data); err != nil {
+		return err
+	}
+
+	s.next++
+
+	// Batches unblocked by this one
+	for {
+		data, ok := s.pending[s.next]
+		if !ok {
+			return nil
+		}
+
+		delete(s.pending, s.next)
+
+		if _, err := s.w.Write(data); err != nil {
+			return err
+		}
+
+		s.next++
+	}
+}
+
+// Flush implements Sink
+func (s *OrderedSink) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	clear(s.pending)
+
+	if syncer, ok := s.w.(interface{ Sync() error }); ok && EatError(ctx) == nil {
+		return syncer.Sync()
+	}
+
+	return nil
+}
+
+// multiSink is the Sink returned by MultiSink
+type multiSink []Sink
+
+// MultiSink returns a Sink that duplicates the batches to every sink, like io.MultiWriter.
+// Every sink is written and flushed even if others fail, the errors are joined.
+func MultiSink(sinks ...Sink) Sink {
+	return multiSink(sinks)
+}
+
+func (m multiSink) Write(ctx context.Context, meta BatchMeta, data []byte) error {
+	errs := make([]error, 0)
+
+	for _, sink := range m {
+		if err := sink.Write(ctx, meta, data); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (m multiSink) Flush(ctx context.Context) error {
+	errs := make([]error, 0)
+
+	for _, sink := range m {
+		if err := sink.Flush(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
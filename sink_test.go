@@ -0,0 +1,136 @@
+package bread
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// countingSink wraps a Sink counting the calls to Flush and keeping the error passed to it
+type countingSink struct {
+	Sink
+	fail    uint64
+	flushes atomic.Int32
+	eatErr  error
+}
+
+var errSinkWrite = errors.New("sink write failure")
+
+func (s *countingSink) Write(ctx context.Context, meta BatchMeta, data []byte) error {
+	if s.fail > 0 && meta.Index == s.fail {
+		return errSinkWrite
+	}
+
+	return s.Sink.Write(ctx, meta, data)
+}
+
+func (s *countingSink) Flush(ctx context.Context) error {
+	s.flushes.Add(1)
+	s.eatErr = EatError(ctx)
+
+	return s.Sink.Flush(ctx)
+}
+
+func TestBread_Sink(t *testing.T) {
+	builder := strings.Builder{}
+	for i := 0; i < 10_000; i++ {
+		_, _ = fmt.Fprintln(&builder, i)
+	}
+
+	data := builder.String()
+
+	cases := [...]struct {
+		workers int
+		fail    uint64
+		ctx     func() context.Context
+		err     error
+	}{
+		{workers: 1},
+		{workers: 16},
+		{workers: 16, fail: 100, err: errSinkWrite},
+		{
+			workers: 4,
+			ctx: func() context.Context {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+
+				return ctx
+			},
+			err: context.Canceled,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			output := bytes.Buffer{}
+			sink := &countingSink{Sink: NewOrderedSink(&output), fail: c.fail}
+
+			ctx := context.Background()
+			if c.ctx != nil {
+				ctx = c.ctx()
+			}
+
+			bread := Bread{
+				Sink:       sink,
+				Workers:    c.workers,
+				BufferSize: 64,
+			}
+
+			err := bread.Eat(ctx, strings.NewReader(data))
+			if c.err != context.Canceled && !errors.Is(err, c.err) {
+				t.Fatalf("expected error %v, got %v", c.err, err)
+			}
+
+			if flushes := sink.flushes.Load(); flushes != 1 {
+				t.Fatalf("expected one call to Flush, got %d", flushes)
+			}
+
+			if !errors.Is(sink.eatErr, c.err) {
+				t.Fatalf("expected Flush to receive %v, got %v", c.err, sink.eatErr)
+			}
+
+			if c.err == nil && output.String() != data {
+				t.Fatal("the ordered sink did not reproduce the input")
+			}
+
+			if !strings.HasPrefix(data, output.String()) {
+				t.Fatal("the ordered sink wrote the batches out of order")
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+func TestMultiSink(t *testing.T) {
+	data := strings.Repeat("multiplexed record\n", 1_000)
+
+	first, second := bytes.Buffer{}, bytes.Buffer{}
+	failing := &countingSink{Sink: NewOrderedSink(new(bytes.Buffer)), fail: 3}
+
+	bread := Bread{
+		Sink:       MultiSink(NewOrderedSink(&first), failing, NewOrderedSink(&second)),
+		Workers:    8,
+		BufferSize: 32,
+	}
+
+	err := bread.Eat(context.Background(), strings.NewReader(data))
+	if !errors.Is(err, errSinkWrite) {
+		t.Fatalf("expected error %v, got %v", errSinkWrite, err)
+	}
+
+	if failing.flushes.Load() != 1 {
+		t.Fatal("the failing sink was not flushed")
+	}
+
+	if first.Len() == 0 || first.String() != second.String() || !strings.HasPrefix(data, first.String()) {
+		t.Fatal("the sinks did not receive the same batches in order")
+	}
+
+	t.Log("Success!")
+}
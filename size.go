@@ -0,0 +1,42 @@
+package bread
+
+import (
+	"io"
+	"os"
+)
+
+// detectSize returns the number of bytes left in the reader, or zero when it cannot be known, see Bread.DetectSize
+func detectSize(reader io.Reader) int64 {
+	if sized, ok := reader.(interface{ Len() int }); ok {
+		return int64(sized.Len())
+	}
+
+	seeker, ok := reader.(io.Seeker)
+	if !ok {
+		return 0
+	}
+
+	// Pipes and devices are files too, but their size is meaningless
+	if f, ok := reader.(*os.File); ok {
+		info, err := f.Stat()
+		if err != nil || !info.Mode().IsRegular() {
+			return 0
+		}
+	}
+
+	current, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0
+	}
+
+	end, err := seeker.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0
+	}
+
+	if _, err = seeker.Seek(current, io.SeekStart); err != nil {
+		return 0
+	}
+
+	return max(end-current, 0)
+}
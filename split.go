@@ -0,0 +1,95 @@
+package bread
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrMissingOutputs indicates that Split was called without outputs
+var ErrMissingOutputs = errors.New("missing outputs")
+
+// SplitStrategy selects the output of each batch in Split
+type SplitStrategy uint8
+
+// Supported values for SplitStrategy
+const (
+	// SplitRoundRobin writes the batches to the outputs in turns
+	SplitRoundRobin SplitStrategy = iota
+	// SplitBalanced writes each batch to the output that received the fewest bytes so far,
+	// so the outputs end with about the same size even if the batches are uneven
+	SplitBalanced
+)
+
+// Split distributes the batches of the reader across the outputs, so every record is written whole to exactly one
+// output. The batches are assigned in stream order, so the records keep their relative order inside each output.
+// Outputs with a Sync method, like *os.File, are synced after the last batch.
+//
+// A failed write stops the reading, the error is an *OutputError that identifies the output.
+//
+// NOTE: WorkerFunc, Sink and MaxBatchSize are ignored by Split, so records are never split across batches
+func Split(ctx context.Context, b Bread, r io.Reader, outputs []io.Writer, strategy SplitStrategy) error {
+	if len(outputs) == 0 {
+		return ErrMissingOutputs
+	}
+
+	b.MaxBatchSize = 0
+	b.Sink = NewOrderedSink(&splitter{
+		outputs:  outputs,
+		written:  make([]int64, len(outputs)),
+		strategy: strategy,
+	})
+
+	return b.Eat(ctx, r)
+}
+
+// splitter is the io.Writer that distributes the ordered batches
+type splitter struct {
+	outputs  []io.Writer
+	written  []int64
+	strategy SplitStrategy
+	// next is the output of the next batch in the round-robin strategy
+	next int
+}
+
+func (s *splitter) Write(data []byte) (int, error) {
+	i := s.next
+
+	switch s.strategy {
+	case SplitBalanced:
+		for j, written := range s.written {
+			if written < s.written[i] {
+				i = j
+			}
+		}
+	default:
+		s.next = (s.next + 1) % len(s.outputs)
+	}
+
+	n, err := s.outputs[i].Write(data)
+	s.written[i] += int64(n)
+
+	if err != nil {
+		return n, &OutputError{Index: i, Err: err}
+	}
+
+	return n, nil
+}
+
+// Sync syncs the outputs that have a Sync method
+func (s *splitter) Sync() error {
+	var errs []error
+
+	for i, output := range s.outputs {
+		syncer, ok := output.(interface{ Sync() error })
+		if !ok {
+			continue
+		}
+
+		if err := syncer.Sync(); err != nil {
+			errs = append(errs, &OutputError{Index: i, Err: err})
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,136 @@
+package bread
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	errWrite := errors.New("write failed")
+
+	cases := [...]struct {
+		data     string
+		outputs  func() []io.Writer
+		strategy SplitStrategy
+		err      error
+		index    int
+	}{
+		{
+			data:    "a\nb\n",
+			outputs: func() []io.Writer { return nil },
+			err:     ErrMissingOutputs,
+		},
+		{
+			data: "a\nb\nc\n",
+			outputs: func() []io.Writer {
+				return []io.Writer{&bytes.Buffer{}, failingWriter{err: errWrite}}
+			},
+			err:   errWrite,
+			index: 1,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			bread := Bread{BufferSize: 1}
+
+			err := Split(context.TODO(), bread, strings.NewReader(c.data), c.outputs(), c.strategy)
+			if !errors.Is(err, c.err) {
+				t.Fatalf("expected error %v, got %v", c.err, err)
+			}
+
+			var outputErr *OutputError
+			if errors.As(err, &outputErr) && outputErr.Index != c.index {
+				t.Fatalf("expected output %d, got %d", c.index, outputErr.Index)
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+// TestSplit_Records checks over random inputs that every record is written to exactly one output,
+// keeping the stream order inside each output
+func TestSplit_Records(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 200; i++ {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			records := make([]string, random.Intn(100))
+
+			for j := range records {
+				records[j] = strconv.Itoa(j) + ":" + strings.Repeat("x", random.Intn(20)) + "\n"
+			}
+
+			// The last record may not be terminated
+			if len(records) > 0 && random.Intn(2) == 0 {
+				records[len(records)-1] = strings.TrimSuffix(records[len(records)-1], "\n")
+			}
+
+			buffers := make([]*bytes.Buffer, 1+random.Intn(5))
+			outputs := make([]io.Writer, len(buffers))
+
+			for j := range buffers {
+				buffers[j] = &bytes.Buffer{}
+				outputs[j] = buffers[j]
+			}
+
+			bread := Bread{
+				Workers:    1 + random.Intn(8),
+				BufferSize: 1 + random.Intn(64),
+			}
+
+			strategy := SplitStrategy(random.Intn(2))
+
+			err := Split(context.TODO(), bread, strings.NewReader(strings.Join(records, "")), outputs, strategy)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var written []string
+
+			for _, buffer := range buffers {
+				previous := -1
+
+				for _, record := range strings.SplitAfter(buffer.String(), "\n") {
+					if record == "" {
+						continue
+					}
+
+					index, _ := strconv.Atoi(record[:strings.IndexByte(record, ':')])
+					if index <= previous {
+						t.Fatalf("record %d written after record %d", index, previous)
+					}
+
+					previous = index
+					written = append(written, record)
+				}
+			}
+
+			slices.Sort(written)
+			slices.Sort(records)
+
+			if !slices.Equal(written, records) {
+				t.Fatalf("expected records %q, got %q", records, written)
+			}
+		})
+	}
+
+	t.Log("Success!")
+}
+
+// failingWriter fails every write
+type failingWriter struct {
+	err error
+}
+
+func (w failingWriter) Write([]byte) (int, error) {
+	return 0, w.err
+}
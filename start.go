@@ -0,0 +1,79 @@
+package bread
+
+import (
+	"context"
+	"io"
+)
+
+// Run is an Eat call running on its own goroutine, see Bread.Start
+type Run struct {
+	cancel  context.CancelFunc
+	tracker *Tracker
+	done    chan struct{}
+
+	// Result of the Eat call, only read once done is closed
+	err   error
+	stats Stats
+}
+
+// Start works like Eat, but the reader is eaten on a new goroutine and the returned Run stops it or waits for it,
+// so the call can be stopped without owning ctx. Every call starts an independent run.
+//
+// NOTE: the runs started from a Bread with a Tracker share it, by default each run tracks its own progress
+func (b Bread) Start(ctx context.Context, reader io.Reader) *Run {
+	ctx, cancel := context.WithCancel(ctx)
+
+	if b.Tracker == nil {
+		b.Tracker = new(Tracker)
+	}
+
+	run := &Run{
+		cancel:  cancel,
+		tracker: b.Tracker,
+		done:    make(chan struct{}),
+	}
+
+	go func() {
+		defer close(run.done)
+		defer cancel()
+
+		run.stats, run.err = b.EatStats(ctx, reader)
+	}()
+
+	return run
+}
+
+// Stop stops the reading like a cancellation of the context, the batches being processed are drained or aborted
+// according to CancelMode. It does not wait for the run to end, see Wait.
+//
+// Calling Stop more than once, or once the run ended, does nothing.
+func (r *Run) Stop() {
+	r.cancel()
+}
+
+// Wait waits until the run ended and returns the error of Eat, that is context.Canceled when it was stopped
+func (r *Run) Wait() error {
+	<-r.done
+	return r.err
+}
+
+// Done returns a channel that is closed once the run ended
+func (r *Run) Done() <-chan struct{} {
+	return r.done
+}
+
+// Progress returns the progress of the run so far, see Tracker.Progress
+func (r *Run) Progress() Progress {
+	return r.tracker.Progress()
+}
+
+// Stats returns the Stats of the run once it ended, the same ones passed to OnComplete.
+// They are empty while the run is still going on.
+func (r *Run) Stats() Stats {
+	select {
+	case <-r.done:
+		return r.stats
+	default:
+		return Stats{}
+	}
+}
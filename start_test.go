@@ -0,0 +1,139 @@
+package bread
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"runtime"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBread_Start(t *testing.T) {
+	cases := [...]struct {
+		bread Bread
+	}{
+		{
+			bread: Bread{CancelMode: CancelAbort},
+		},
+		{
+			bread: Bread{CancelMode: CancelDrain},
+		},
+		{
+			bread: Bread{CancelMode: CancelDrain, QueueDepth: 8},
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			goroutines := runtime.NumGoroutine()
+
+			c.bread.Workers = 4
+			c.bread.BufferSize = 4 * KB
+			c.bread.WorkerFunc = func(context.Context, *[]byte) {
+				time.Sleep(time.Millisecond)
+			}
+
+			// Independent runs over endless readers
+			runs := make([]*Run, 4)
+			for j := range runs {
+				runs[j] = c.bread.Start(context.Background(), readFunc(func(p []byte) (int, error) {
+					return copy(p, bytes.Repeat([]byte("line\n"), len(p)/5)), nil
+				}))
+			}
+
+			time.Sleep(50 * time.Millisecond)
+
+			for _, run := range runs[1:] {
+				if run.Stats() != (Stats{}) {
+					t.Fatal("the stats of a running call are not empty")
+				}
+
+				if run.Progress().BytesRead == 0 {
+					t.Fatal("the run did not make any progress")
+				}
+			}
+
+			wg := sync.WaitGroup{}
+
+			// Stopping the first run does not stop the others
+			runs[0].Stop()
+			runs[0].Stop()
+
+			select {
+			case <-runs[0].Done():
+			case <-time.After(time.Second):
+				t.Fatal("the run did not end after Stop")
+			}
+
+			for _, run := range runs[1:] {
+				select {
+				case <-run.Done():
+					t.Fatal("a run ended before Stop")
+				default:
+				}
+
+				wg.Add(1)
+
+				go func() {
+					defer wg.Done()
+					run.Stop()
+				}()
+			}
+
+			wg.Wait()
+
+			for _, run := range runs {
+				err := run.Wait()
+				if !errors.Is(err, context.Canceled) {
+					t.Fatalf("expected error %v, got %v", context.Canceled, err)
+				}
+
+				if run.Stats().Batches == 0 {
+					t.Fatal("the stats of the run are empty")
+				}
+
+				// Stop after the end does nothing
+				run.Stop()
+			}
+
+			deadline := time.Now().Add(time.Second)
+			for runtime.NumGoroutine() > goroutines && time.Now().Before(deadline) {
+				time.Sleep(10 * time.Millisecond)
+			}
+
+			if runtime.NumGoroutine() > goroutines {
+				t.Fatalf("%d goroutines leaked", runtime.NumGoroutine()-goroutines)
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+func TestBread_StartCompleted(t *testing.T) {
+	bread := Bread{
+		BufferSize: 16,
+		WorkerFunc: func(context.Context, *[]byte) {},
+	}
+
+	run := bread.Start(context.Background(), bytes.NewReader(memoryData(KB)))
+
+	if err := run.Wait(); err != nil {
+		t.Fatal(err)
+	}
+
+	run.Stop()
+
+	if err := run.Wait(); err != nil {
+		t.Fatalf("unexpected error after Stop %v", err)
+	}
+
+	if run.Stats().BytesRead != KB {
+		t.Fatalf("expected %d bytes read, got %d", KB, run.Stats().BytesRead)
+	}
+
+	t.Log("Success!")
+}
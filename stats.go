@@ -0,0 +1,160 @@
+package bread
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// Stats summarizes the work done by an Eat call
+type Stats struct {
+	// BytesRead is the number of bytes read from the io.Reader
+	BytesRead ByteSize
+	// Batches is the number of batches dispatched to the workers
+	Batches uint64
+	// Records is the number of records in the dispatched batches, see BatchMeta.Records
+	Records uint64
+	// DeliveredRecords is the number of records passed to the workers, it only differs from Records with
+	// SampleEvery or SampleRate
+	DeliveredRecords uint64
+	// Failures is the number of batches that failed
+	Failures uint64
+	// TruncatedRecords is the number of records cut by TruncateRecordsAt
+	TruncatedRecords uint64
+	// ComplementBytes is the number of bytes read past BufferSize to complete the batches
+	ComplementBytes ByteSize
+	// Allocations is the number of buffers allocated by the pool, along with the ones replaced by AdaptiveBuffer.
+	// For a shared Pool it includes the allocations made for concurrent Eat calls.
+	Allocations uint64
+	// Reuses is the number of buffers taken from the pool that were not allocated for them,
+	// the buffers allocated by BufferSeed count as allocations
+	Reuses uint64
+	// Workers is the number of active workers when the processing ended, it only changes with ScaleWorkers
+	Workers int
+	// ScaleUps and ScaleDowns are the number of workers activated and deactivated by ScaleWorkers
+	ScaleUps   uint64
+	ScaleDowns uint64
+	// Retained is the number of buffers retained by the workers and not released yet, see Batch.Retain
+	Retained uint64
+	// BatchSize summarizes the size of the dispatched batches
+	BatchSize BatchSizeStats
+	// BufferSize is the size of the last reads, it only differs from the BufferSize of the Bread
+	// with AdaptiveBuffer
+	BufferSize ByteSize
+	// Duration is the wall time spent eating
+	Duration time.Duration
+	// QueueAge summarizes the time the batches waited since they were read until a worker took them.
+	// Compare it with the time spent by the workers to tell slow workers from a slow dispatch
+	QueueAge QueueAgeStats
+	// Delimiter is the delimiter of the records picked by DetectDelimiter, empty without it
+	Delimiter string
+	// StartOffset is the offset of the stream where the first batch starts, see Bread.StartOffset
+	StartOffset int64
+	// DigestSkipped indicates that ExpectedDigest was not compared because the reading ended early,
+	// by a cancellation, a failed batch, MaxRecords, MaxBatches or LimitBytes
+	DigestSkipped bool
+}
+
+// QueueAgeStats summarizes the queue age of the batches, see Observer.OnBatchStart
+type QueueAgeStats struct {
+	Mean time.Duration
+	// P95 is approximated by the next power of two nanoseconds
+	P95 time.Duration
+	Max time.Duration
+}
+
+// BatchSizeStats summarizes the size of the batches, see BatchMeta.Size
+type BatchSizeStats struct {
+	Min  ByteSize
+	Mean ByteSize
+	Max  ByteSize
+}
+
+// EpochStats summarizes a single pass over the reader, see Bread.OnEpoch
+type EpochStats struct {
+	// BytesRead is the number of bytes read during the epoch
+	BytesRead ByteSize
+	// Batches is the number of batches dispatched during the epoch
+	Batches uint64
+	// Records is the number of records in the batches of the epoch
+	Records uint64
+}
+
+// stats takes a snapshot of the counters of the eater
+func (e *eater) stats() Stats {
+	allocations, gets := e.pool.Allocations()-e.allocations+e.resized, e.gets.Load()
+
+	delivered := e.counters.delivered.Load()
+	if !e.sampling() {
+		delivered = e.counters.records.Load()
+	}
+
+	return Stats{
+		BytesRead:        ByteSize(e.counters.bytesRead.Load()),
+		Batches:          e.counters.batches.Load(),
+		Records:          e.counters.records.Load(),
+		DeliveredRecords: delivered,
+		Failures:         e.counters.failures.Load(),
+		TruncatedRecords: e.counters.truncated.Load(),
+		ComplementBytes:  ByteSize(e.counters.complements.Load()),
+		Allocations:      allocations,
+		Reuses:           gets - min(gets, allocations),
+		Workers:          int(e.counters.workers.Load()),
+		ScaleUps:         e.scaler.ups(),
+		ScaleDowns:       e.scaler.downs(),
+		Retained:         uint64(e.retention.held.Load()),
+		BatchSize:        e.counters.batchSizes(),
+		BufferSize:       ByteSize(e.readSize),
+		Duration:         e.counters.elapsed(),
+		QueueAge:         e.counters.queueAges.stats(),
+		Delimiter:        e.detected,
+		StartOffset:      e.startOffset,
+		DigestSkipped:    e.digestSkipped,
+	}
+}
+
+// EatStats works like Eat and also returns the Stats of the call, the same ones passed to OnComplete.
+// The Stats are empty when the settings are not valid.
+func (b Bread) EatStats(ctx context.Context, reader io.Reader) (Stats, error) {
+	stats := Stats{}
+	onComplete := b.OnComplete
+
+	b.OnComplete = func(ctx context.Context, s Stats, err error) {
+		stats = s
+
+		if onComplete != nil {
+			onComplete(ctx, s, err)
+		}
+	}
+
+	err := b.Eat(ctx, reader)
+
+	return stats, err
+}
+
+// batchSizes summarizes the size of the dispatched batches
+func (t *Tracker) batchSizes() BatchSizeStats {
+	batches := t.batches.Load()
+	if batches == 0 {
+		return BatchSizeStats{}
+	}
+
+	return BatchSizeStats{
+		Min:  ByteSize(t.minBatch.Load()),
+		Mean: ByteSize(t.batchBytes.Load() / int64(batches)),
+		Max:  ByteSize(t.maxBatch.Load()),
+	}
+}
+
+// observeBatch adds the size of a dispatched batch, it is only called by the reading goroutine
+func (t *Tracker) observeBatch(size int) {
+	if t.batches.Load() == 0 || int64(size) < t.minBatch.Load() {
+		t.minBatch.Store(int64(size))
+	}
+
+	if int64(size) > t.maxBatch.Load() {
+		t.maxBatch.Store(int64(size))
+	}
+
+	t.batchBytes.Add(int64(size))
+}
@@ -0,0 +1,99 @@
+package bread
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"testing"
+)
+
+func TestBread_EatStats(t *testing.T) {
+	// 1000 records of 10 bytes
+	data := bytes.Repeat([]byte("012345678\n"), 1000)
+
+	cases := [...]struct {
+		bread    Bread
+		data     []byte
+		expected Stats
+		err      error
+	}{
+		{
+			// Each batch is completed up to the end of the record that crosses BufferSize
+			bread: Bread{Workers: 8, BufferSize: 95},
+			data:  data,
+			expected: Stats{
+				BytesRead: 10_000,
+				Batches:   100,
+				Records:   1000,
+				BatchSize: BatchSizeStats{Min: 100, Mean: 100, Max: 100},
+			},
+		},
+		{
+			// The last record is not terminated
+			bread: Bread{Workers: 4, BufferSize: 95},
+			data:  append(bytes.Clone(data), "0123"...),
+			expected: Stats{
+				BytesRead: 10_004,
+				Batches:   101,
+				Records:   1001,
+				BatchSize: BatchSizeStats{Min: 4, Mean: 99, Max: 100},
+			},
+		},
+		{
+			bread: Bread{Workers: 4, BufferSize: 95, PoolStrategy: PoolFreelist, FreelistSize: 2},
+			data:  data,
+			expected: Stats{
+				BytesRead: 10_000,
+				Batches:   100,
+				Records:   1000,
+				BatchSize: BatchSizeStats{Min: 100, Mean: 100, Max: 100},
+			},
+		},
+		{
+			bread: Bread{Workers: 4, BufferSize: 95},
+		},
+		{
+			bread: Bread{Workers: 4},
+			data:  data,
+			err:   ErrMissingBufferSize,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			completed := atomic.Uint64{}
+
+			c.bread.WorkerFunc = func(context.Context, *[]byte) {
+				completed.Add(1)
+			}
+
+			stats, err := c.bread.EatStats(context.TODO(), bytes.NewReader(c.data))
+			if !errors.Is(err, c.err) {
+				t.Fatalf("expected error %v, got %v", c.err, err)
+			}
+
+			if stats.BytesRead != c.expected.BytesRead || stats.Batches != c.expected.Batches ||
+				stats.Records != c.expected.Records || stats.BatchSize != c.expected.BatchSize {
+				t.Fatalf("expected %+v, got %+v", c.expected, stats)
+			}
+
+			if completed.Load() != stats.Batches {
+				t.Fatalf("%d batches were processed, %d were reported", completed.Load(), stats.Batches)
+			}
+
+			// Every buffer taken from the pool was either allocated or reused
+			if c.err == nil && stats.Allocations+stats.Reuses < stats.Batches {
+				t.Fatalf("%d allocations and %d reuses for %d batches", stats.Allocations, stats.Reuses, stats.Batches)
+			}
+
+			// The freelist allocates up to its size
+			if c.bread.PoolStrategy == PoolFreelist && stats.Allocations > uint64(c.bread.FreelistSize) {
+				t.Fatalf("expected at most %d allocations, got %d", c.bread.FreelistSize, stats.Allocations)
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package bread
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"io"
+
+	"github.com/yael-castro/bread/internal/semaphore"
+)
+
+// tarHeaderKey is the context key of the header of the tar entry being eaten
+type tarHeaderKey struct{}
+
+// TarHeader returns the header of the entry being eaten by EatTar, from the context passed to the workers, the Sink
+// and the hooks like OnComplete. It returns nil outside EatTar.
+func TarHeader(ctx context.Context) *tar.Header {
+	header, _ := ctx.Value(tarHeaderKey{}).(*tar.Header)
+	return header
+}
+
+// EatTar eats every regular file of a tar archive, one entry after another, skipping directories, links and the
+// entries rejected by EntryFilter. The header of each entry is passed to the workers through their context, see
+// TarHeader, and BatchMeta.Source is the position of the entry in the archive. Batch offsets are relative to the
+// entry contents.
+//
+// The Decompressor applies to the whole archive, so a .tar.gz is eaten with SniffCompression. The settings used for
+// each entry are resolved through ConfigFor, while the worker budget defined by the base Workers, the MaxMemory budget
+// and the buffer pools are shared by all the entries like the files of EatFS. The error of an entry is wrapped in a *FileError holding its name.
+func (b Bread) EatTar(ctx context.Context, reader io.Reader) (err error) {
+	if reader == nil {
+		return ErrNilReader
+	}
+
+	if closer, ok := reader.(io.Closer); ok && b.CloseReader {
+		defer func() {
+			err = errors.Join(err, closer.Close())
+		}()
+	}
+
+	// The entries are read through the archive reader
+	b.CloseReader = false
+
+	if err := b.Validate(); err != nil {
+		return err
+	}
+
+	if b.Decompressor != nil {
+		decompressor, err := b.decompress(reader)
+		if err != nil {
+			return err
+		}
+
+		defer func() {
+			err = errors.Join(err, decompressor.Close())
+		}()
+
+		reader = decompressor
+		b.Decompressor = nil
+	}
+
+	if b.Workers == 0 {
+		b.Workers = DefaultWorkers
+	}
+
+	if b.slots == nil {
+		b.slots = make(chan struct{}, b.Workers)
+	}
+
+	if b.memory == nil && b.MaxMemory > 0 {
+		b.memory = semaphore.NewWeighted(b.MaxMemory)
+	}
+
+	pools := make(map[int]BufferPool)
+	archive := tar.NewReader(reader)
+
+	for index := 0; ; index++ {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if !header.FileInfo().Mode().IsRegular() || b.EntryFilter != nil && !b.EntryFilter(header) {
+			continue
+		}
+
+		config, err := b.configFor(header.Name, header.FileInfo())
+		if err != nil {
+			return &FileError{Path: header.Name, Err: err}
+		}
+
+		config = config.share(b, pools)
+		config.sourceIndex = index
+		config.CloseReader = false
+
+		if err = config.Eat(context.WithValue(ctx, tarHeaderKey{}, header), archive); err != nil {
+			return &FileError{Path: header.Name, Err: err}
+		}
+
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+	}
+}
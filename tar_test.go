@@ -0,0 +1,171 @@
+package bread
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// tarEntry is a file of the archives built by archive
+type tarEntry struct {
+	name string
+	data string
+	// typeflag defaults to a regular file
+	typeflag byte
+}
+
+// archive builds a tar archive out of the entries, compressed with gzip when compress is set
+func archive(t *testing.T, compress bool, entries ...tarEntry) []byte {
+	t.Helper()
+
+	buffer := &bytes.Buffer{}
+
+	var gz *gzip.Writer
+	if compress {
+		gz = gzip.NewWriter(buffer)
+	}
+
+	w := tar.NewWriter(buffer)
+	if gz != nil {
+		w = tar.NewWriter(gz)
+	}
+
+	for _, entry := range entries {
+		header := &tar.Header{Name: entry.name, Mode: 0o600, Size: int64(len(entry.data)), Typeflag: entry.typeflag}
+
+		switch entry.typeflag {
+		case 0:
+			header.Typeflag = tar.TypeReg
+		case tar.TypeDir:
+			header.Mode = 0o700
+		case tar.TypeSymlink:
+			header.Linkname = "a.log"
+		}
+
+		if err := w.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := w.Write([]byte(entry.data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return buffer.Bytes()
+}
+
+func TestBread_EatTar(t *testing.T) {
+	entries := []tarEntry{
+		{name: "logs/", typeflag: tar.TypeDir},
+		{name: "logs/a.log", data: strings.Repeat("a\n", 100)},
+		{name: "logs/b.log", data: strings.Repeat("bb\n", 250)},
+		{name: "logs/link.log", typeflag: tar.TypeSymlink},
+		{name: "logs/c.tmp", data: strings.Repeat("c\n", 10)},
+		{name: "logs/empty.log"},
+		{name: "logs/d.log", data: strings.Repeat("dddd\n", 1000) + "no newline"},
+	}
+
+	cases := [...]struct {
+		bread    Bread
+		compress bool
+		// records holds the number of records of each entry
+		records map[string]int
+		// failed is the entry expected to fail
+		failed string
+		err    error
+	}{
+		{
+			bread:   Bread{BufferSize: 64, Workers: 4},
+			records: map[string]int{"logs/a.log": 100, "logs/b.log": 250, "logs/c.tmp": 10, "logs/d.log": 1001},
+		},
+		{
+			bread:    Bread{BufferSize: 64, Workers: 4, Decompressor: SniffCompression},
+			compress: true,
+			records:  map[string]int{"logs/a.log": 100, "logs/b.log": 250, "logs/c.tmp": 10, "logs/d.log": 1001},
+		},
+		{
+			bread: Bread{BufferSize: 64, Workers: 4, EntryFilter: func(header *tar.Header) bool {
+				return strings.HasSuffix(header.Name, ".log")
+			}},
+			records: map[string]int{"logs/a.log": 100, "logs/b.log": 250, "logs/d.log": 1001},
+		},
+		{
+			// The freelist is shared by the entries, its buffers are returned after each one
+			bread:   Bread{BufferSize: 64, Workers: 4, PoolStrategy: PoolFreelist, FreelistSize: 2},
+			records: map[string]int{"logs/a.log": 100, "logs/b.log": 250, "logs/c.tmp": 10, "logs/d.log": 1001},
+		},
+		{
+			bread:   Bread{BufferSize: 64, Workers: 4, MaxMemory: 256},
+			records: map[string]int{"logs/a.log": 100, "logs/b.log": 250, "logs/c.tmp": 10, "logs/d.log": 1001},
+		},
+		{
+			bread:  Bread{BufferSize: 64, Workers: 4, MaxRecordSize: 8},
+			failed: "logs/d.log",
+			err:    ErrRecordTooLarge,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			mu := sync.Mutex{}
+			records := make(map[string]int)
+
+			c.bread.WorkerBatchFunc = func(ctx context.Context, batch Batch) {
+				header := TarHeader(ctx)
+
+				// The position of the entry in the archive
+				if name := entries[batch.Source].name; header.Name != name {
+					t.Errorf("expected source %s, got %s", header.Name, name)
+				}
+
+				mu.Lock()
+				defer mu.Unlock()
+
+				records[header.Name] += int(batch.Records)
+			}
+
+			err := c.bread.EatTar(context.TODO(), bytes.NewReader(archive(t, c.compress, entries...)))
+			if c.err != nil {
+				var fileErr *FileError
+				if !errors.As(err, &fileErr) || fileErr.Path != c.failed || !errors.Is(err, c.err) {
+					t.Fatalf("expected error %v in %s, got %v", c.err, c.failed, err)
+				}
+
+				t.Logf("Success! %v", err)
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(records) != len(c.records) {
+				t.Fatalf("expected records of %d entries, got %v", len(c.records), records)
+			}
+
+			for name, expected := range c.records {
+				if records[name] != expected {
+					t.Fatalf("expected %d records in %s, got %d", expected, name, records[name])
+				}
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
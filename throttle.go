@@ -0,0 +1,51 @@
+package bread
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// throttledReader holds the reads of r to BytesPerSecond on average, see Bread.BytesPerSecond
+type throttledReader struct {
+	ctx  context.Context
+	r    io.Reader
+	rate int64
+	// start is the time of the first read, read the bytes read since then
+	start time.Time
+	read  int64
+}
+
+// throttle wraps the reader when BytesPerSecond is set
+func (e *eater) throttle(ctx context.Context, reader io.Reader) io.Reader {
+	if e.BytesPerSecond <= 0 {
+		return reader
+	}
+
+	return &throttledReader{ctx: ctx, r: reader, rate: e.BytesPerSecond}
+}
+
+func (t *throttledReader) Read(p []byte) (int, error) {
+	if t.start.IsZero() {
+		t.start = time.Now()
+	}
+
+	// A single read never takes more than a second of budget
+	n, err := t.r.Read(p[:min(int64(len(p)), t.rate)])
+	t.read += int64(n)
+
+	// The bytes read are paid before the next read, unless the reading was cancelled
+	due := t.start.Add(time.Duration(float64(t.read) / float64(t.rate) * float64(time.Second)))
+
+	if wait := time.Until(due); wait > 0 && n > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-t.ctx.Done():
+		}
+	}
+
+	return n, err
+}
@@ -0,0 +1,68 @@
+package bread
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBread_BytesPerSecond(t *testing.T) {
+	data := memoryData(10 * MB)
+	size := atomic.Int64{}
+
+	bread := Bread{
+		Workers:        4,
+		BufferSize:     64 * KB,
+		BytesPerSecond: 5 * MB,
+		WorkerFunc: func(_ context.Context, buffer *[]byte) {
+			size.Add(int64(len(*buffer)))
+		},
+	}
+
+	start := time.Now()
+
+	err := bread.Eat(context.TODO(), bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	elapsed := time.Since(start)
+
+	if elapsed < 1500*time.Millisecond || elapsed > 3*time.Second {
+		t.Fatalf("expected about 2s, got %v", elapsed)
+	}
+
+	if size.Load() != int64(len(data)) {
+		t.Fatalf("expected %d bytes, got %d", len(data), size.Load())
+	}
+
+	t.Logf("Success! %v", elapsed)
+}
+
+func TestBread_BytesPerSecondCancel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	bread := Bread{
+		BufferSize:     MB,
+		BytesPerSecond: KB,
+		WorkerFunc:     func(context.Context, *[]byte) {},
+	}
+
+	start := time.Now()
+
+	// A single read takes a second of budget
+	err := bread.Eat(ctx, bytes.NewReader(memoryData(10*MB)))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected error %v, got %v", context.DeadlineExceeded, err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("the cancellation was delayed by %v", elapsed)
+	}
+
+	t.Logf("Success! %v", err)
+}
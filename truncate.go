@@ -0,0 +1,102 @@
+package bread
+
+import (
+	"bufio"
+	"context"
+	"io"
+)
+
+// readTruncated assembles batches of about BufferSize bytes record by record, cutting the records longer
+// than TruncateRecordsAt. The stream offset advances by the discarded bytes too.
+func (e *eater) readTruncated(ctx context.Context, r *bufio.Reader) error {
+	for eof := false; !eof && !e.failed.Load() && !e.stopped; {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+
+		buffer := e.pool.Get()
+		*buffer = (*buffer)[:0]
+
+		// consumed is the number of bytes of the batch read from the stream
+		records, consumed := 0, int64(0)
+
+		for len(*buffer) < e.BufferSize {
+			length, err := e.truncate(r, buffer, e.offset+consumed)
+			if err != nil && err != io.EOF {
+				e.pool.Put(buffer)
+				return &ReadError{Position: e.offset + consumed + length, Err: err}
+			}
+
+			if length > 0 {
+				records++
+				consumed += length
+			}
+
+			if err == io.EOF {
+				eof = true
+				break
+			}
+		}
+
+		if records == 0 {
+			e.pool.Put(buffer)
+			return nil
+		}
+
+		discarded := consumed - int64(len(*buffer))
+
+		e.emit(ctx, buffer, records)
+		e.offset += discarded
+	}
+
+	return nil
+}
+
+// truncate appends the next record to the buffer cutting it at TruncateRecordsAt, offset is the stream offset
+// of the record. It returns the length of the record in the stream.
+func (e *eater) truncate(r *bufio.Reader, buffer *[]byte, offset int64) (length int64, err error) {
+	start, limit := len(*buffer), e.TruncateRecordsAt
+
+	for {
+		var slice []byte
+
+		slice, err = r.ReadSlice(e.Delimiter)
+		length += int64(len(slice))
+
+		terminated := err == nil
+		if terminated {
+			slice = slice[:len(slice)-1]
+		}
+
+		// Only the bytes within the limit are kept, the rest of the slice is discarded by the next ReadSlice
+		if room := limit - (len(*buffer) - start); room > 0 {
+			*buffer = append(*buffer, slice[:min(room, len(slice))]...)
+		}
+
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+
+		if terminated {
+			*buffer = append(*buffer, e.Delimiter)
+		}
+
+		// The delimiter is not part of the record length
+		content := length
+		if terminated {
+			content--
+		}
+
+		if content > int64(limit) {
+			e.counters.truncated.Add(1)
+
+			if e.OnTruncate != nil {
+				e.OnTruncate(offset, length)
+			}
+		}
+
+		return
+	}
+}
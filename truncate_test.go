@@ -0,0 +1,145 @@
+package bread
+
+import (
+	"context"
+	"io"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestBread_TruncateRecordsAt(t *testing.T) {
+	type truncation struct {
+		offset, length int64
+	}
+
+	cases := [...]struct {
+		bread     Bread
+		data      string
+		expected  []string
+		offsets   []int64
+		truncated []truncation
+	}{
+		{
+			bread:    Bread{BufferSize: 4, TruncateRecordsAt: 3},
+			data:     "abc\nde\n",
+			expected: []string{"abc\n", "de\n"},
+			offsets:  []int64{0, 4},
+		},
+		{
+			bread:     Bread{BufferSize: 4, TruncateRecordsAt: 3},
+			data:      "abcdefgh\nij\nklmn",
+			expected:  []string{"abc\n", "ij\nklm"},
+			offsets:   []int64{0, 9},
+			truncated: []truncation{{0, 9}, {12, 4}},
+		},
+		{
+			// Several records per batch, the batch after a truncated record starts at its stream offset
+			bread:     Bread{BufferSize: 8, TruncateRecordsAt: 2},
+			data:      "a\nbbbbbb\nc\nd\ne\nf\n",
+			expected:  []string{"a\nbb\nc\nd\n", "e\nf\n"},
+			offsets:   []int64{0, 13},
+			truncated: []truncation{{2, 7}},
+		},
+		{
+			bread:     Bread{BufferSize: 8, TruncateRecordsAt: 2, MaxRecords: 2},
+			data:      "a\nbbbbbb\nc\nd\n",
+			expected:  []string{"a\nbb\n"},
+			offsets:   []int64{0},
+			truncated: []truncation{{2, 7}},
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			sink := &collectingSink{}
+
+			var (
+				truncated []truncation
+				stats     Stats
+			)
+
+			c.bread.Sink = sink
+			c.bread.OnTruncate = func(offset int64, length int64) {
+				truncated = append(truncated, truncation{offset, length})
+			}
+			c.bread.OnComplete = func(_ context.Context, s Stats, _ error) {
+				stats = s
+			}
+
+			if err := c.bread.Eat(context.TODO(), strings.NewReader(c.data)); err != nil {
+				t.Fatal(err)
+			}
+
+			var (
+				batches []string
+				offsets []int64
+			)
+
+			for _, batch := range sink.batches {
+				batches = append(batches, string(batch.Data))
+				offsets = append(offsets, batch.Offset)
+			}
+
+			if !slices.Equal(batches, c.expected) || !slices.Equal(offsets, c.offsets) {
+				t.Fatalf("expected batches %q at %v, got %q at %v", c.expected, c.offsets, batches, offsets)
+			}
+
+			if !slices.Equal(truncated, c.truncated) || stats.TruncatedRecords != uint64(len(c.truncated)) {
+				t.Fatalf("expected truncations %v, got %v (%d in stats)", c.truncated, truncated, stats.TruncatedRecords)
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+// repeatReader returns the byte b forever
+type repeatReader byte
+
+func (r repeatReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = byte(r)
+	}
+
+	return len(p), nil
+}
+
+// TestBread_TruncateRecordsAt_Stream checks that the discarded part of a huge record is not held in memory
+func TestBread_TruncateRecordsAt_Stream(t *testing.T) {
+	const length = 256 * MB
+
+	reader := io.MultiReader(io.LimitReader(repeatReader('x'), length), strings.NewReader("\nlast\n"))
+
+	var before, after runtime.MemStats
+
+	runtime.ReadMemStats(&before)
+
+	var records []string
+
+	bread := Bread{
+		BufferSize:        KB,
+		TruncateRecordsAt: 64,
+	}
+
+	err := bread.EatBatches(context.TODO(), reader, func(_ context.Context, batch Batch) {
+		records = append(records, string(batch.Data))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	runtime.ReadMemStats(&after)
+
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > MB {
+		t.Fatalf("expected the record to be streamed, %d bytes were allocated", allocated)
+	}
+
+	if expected := strings.Repeat("x", 64) + "\nlast\n"; strings.Join(records, "") != expected {
+		t.Fatalf("expected %q, got %q", expected, records)
+	}
+
+	t.Log("Success!")
+}
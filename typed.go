@@ -0,0 +1,63 @@
+package bread
+
+import (
+	"context"
+	"io"
+)
+
+// EatTyped eats the records of the reader decoded by unmarshal, each value is passed to the worker on the goroutine
+// of the worker that holds its batch. The records are delimited like the ones of RecordFunc, and they are decoded
+// straight from the pooled buffer, so unmarshal must not retain them.
+//
+// A record that fails to decode is passed to OnDecodeError and skipped, without OnDecodeError it fails its batch
+// with a *RecordError, like the errors of the worker. A failed batch stops the reading, see WorkerErrFunc.
+//
+// NOTE: the worker functions and the Sink of b are ignored by EatTyped
+func EatTyped[T any](ctx context.Context, b Bread, reader io.Reader, unmarshal func([]byte) (T, error), worker func(context.Context, T) error) error {
+	if unmarshal == nil || worker == nil {
+		return ErrMissingWorkerFunc
+	}
+
+	if b.Framing == FramingNone {
+		return ErrNoRecords
+	}
+
+	b = b.delimiters()
+
+	return b.eat(ctx, reader, func(ctx context.Context, batch Batch) error {
+		records := recordSlices.Get().(*[][]byte)
+		*records = b.batchRecords((*records)[:0], batch)
+
+		defer func() {
+			clear(*records)
+			recordSlices.Put(records)
+		}()
+
+		for i, data := range *records {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			value, err := unmarshal(data)
+			if err == nil {
+				err = worker(ctx, value)
+			} else if b.OnDecodeError != nil {
+				b.OnDecodeError(recordOffset(batch, data), data, err)
+				continue
+			}
+
+			if err != nil {
+				record := Record{Number: batch.FirstRecord + uint64(i) + 1, Offset: recordOffset(batch, data), Parent: batch.BatchMeta}
+				return &RecordError{Record: record, Err: err}
+			}
+		}
+
+		return nil
+	})
+}
+
+// recordOffset returns the stream offset of a record sliced out of the batch data
+func recordOffset(batch Batch, record []byte) int64 {
+	// The slices of the batch data share its end, so their capacity tells where they start
+	return batch.Offset + int64(cap(batch.Data)-cap(record))
+}
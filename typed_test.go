@@ -0,0 +1,186 @@
+package bread
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestEatTyped(t *testing.T) {
+	lines := make([]string, 1000)
+	for i := range lines {
+		lines[i] = strconv.Itoa(i)
+	}
+
+	// The bad row is the record 501
+	lines[500] = "five hundred"
+	data := strings.Join(lines, "\n") + "\n"
+	offset := int64(strings.Index(data, "five hundred"))
+
+	cases := [...]struct {
+		bread Bread
+		// skipped is the number of records passed to OnDecodeError
+		skipped int
+		sum     int64
+		err     error
+	}{
+		{
+			bread:   Bread{BufferSize: 64, Workers: 4},
+			skipped: 1,
+			sum:     999*1000/2 - 500,
+		},
+		{
+			bread: Bread{BufferSize: 64, Workers: 4},
+			err:   strconv.ErrSyntax,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			sum, skipped := atomic.Int64{}, atomic.Int64{}
+
+			if c.skipped > 0 {
+				c.bread.OnDecodeError = func(position int64, record []byte, err error) {
+					if position != offset || string(record) != "five hundred" {
+						t.Errorf("unexpected record %q at offset %d", record, position)
+					}
+
+					skipped.Add(1)
+				}
+			}
+
+			atoi := func(record []byte) (int, error) {
+				return strconv.Atoi(string(record))
+			}
+
+			err := EatTyped(context.TODO(), c.bread, strings.NewReader(data), atoi, func(_ context.Context, n int) error {
+				sum.Add(int64(n))
+				return nil
+			})
+
+			if c.err != nil {
+				var recordErr *RecordError
+				if !errors.As(err, &recordErr) || !errors.Is(err, c.err) {
+					t.Fatalf("expected a *RecordError wrapping %v, got %v", c.err, err)
+				}
+
+				if recordErr.Record.Number != 501 || recordErr.Record.Offset != offset {
+					t.Fatalf("expected record 501 at offset %d, got %d at %d", offset, recordErr.Record.Number, recordErr.Record.Offset)
+				}
+
+				t.Logf("Success! %v", err)
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if int(skipped.Load()) != c.skipped {
+				t.Fatalf("expected %d skipped records, got %d", c.skipped, skipped.Load())
+			}
+
+			if sum.Load() != c.sum {
+				t.Fatalf("expected sum %d, got %d", c.sum, sum.Load())
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+func TestEatTyped_JSON(t *testing.T) {
+	type event struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+
+	data := `{"id": 1, "name": "a"}
+{"id": 2, "name": "b"}
+{"id": 3, "name": "c"}
+`
+
+	unmarshal := func(record []byte) (e event, err error) {
+		err = json.Unmarshal(record, &e)
+		return
+	}
+
+	mu := sync.Mutex{}
+	events := make(map[int]string)
+
+	workerErr := errors.New("worker failed")
+
+	// A single worker decodes the records in order, so the failure of the last one cannot drop the others
+	err := EatTyped(context.TODO(), Bread{BufferSize: 16, Workers: 1}, strings.NewReader(data), unmarshal, func(_ context.Context, e event) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		events[e.ID] = e.Name
+
+		if e.ID == 3 {
+			return workerErr
+		}
+
+		return nil
+	})
+
+	// The errors of the worker identify the record
+	var recordErr *RecordError
+	if !errors.As(err, &recordErr) || !errors.Is(err, workerErr) || recordErr.Record.Number != 3 {
+		t.Fatalf("expected a *RecordError of the record 3 wrapping %v, got %v", workerErr, err)
+	}
+
+	if len(events) != 3 || events[1] != "a" || events[2] != "b" || events[3] != "c" {
+		t.Fatalf("unexpected events %v", events)
+	}
+
+	t.Logf("Success! %v", err)
+}
+
+// BenchmarkEatTyped compares EatTyped with RecordFunc, the decoding must not add allocations per record
+func BenchmarkEatTyped(b *testing.B) {
+	data := memoryData(64 * MB)
+	bread := Bread{Workers: 16, BufferSize: 64 * KB}
+
+	length := func(record []byte) (int, error) {
+		return len(record), nil
+	}
+
+	b.Run("EatTyped", func(b *testing.B) {
+		b.SetBytes(int64(len(data)))
+		b.ReportAllocs()
+
+		for n := 0; n < b.N; n++ {
+			err := EatTyped(context.TODO(), bread, bytes.NewReader(data), length, func(context.Context, int) error {
+				return nil
+			})
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("RecordFunc", func(b *testing.B) {
+		bread.RecordFunc = func(_ context.Context, records [][]byte) {
+			for _, record := range records {
+				_, _ = length(record)
+			}
+		}
+
+		b.SetBytes(int64(len(data)))
+		b.ReportAllocs()
+
+		for n := 0; n < b.N; n++ {
+			err := bread.Eat(context.TODO(), bytes.NewReader(data))
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,155 @@
+package bread
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+)
+
+// ErrInvalidWARCRecord indicates that the data passed to ParseWARCRecord is not a WARC record
+var ErrInvalidWARCRecord = errors.New("invalid WARC record")
+
+// WARCRecord is a record delivered by FramingWARC, see ParseWARCRecord
+type WARCRecord struct {
+	// Version is the version line of the record, e.g. "WARC/1.0"
+	Version string
+	// Header holds the named fields of the record
+	Header textproto.MIMEHeader
+	// Payload is the content block of the record, it shares the memory of the parsed data
+	Payload []byte
+}
+
+// ParseWARCRecord splits a record delivered by FramingWARC in its header and payload
+func ParseWARCRecord(data []byte) (WARCRecord, error) {
+	end := bytes.Index(data, []byte("\r\n\r\n"))
+	if end < 0 || !bytes.HasPrefix(data, []byte("WARC/")) {
+		return WARCRecord{}, ErrInvalidWARCRecord
+	}
+
+	version, _, _ := bytes.Cut(data[:end+2], []byte("\r\n"))
+
+	header, err := textproto.NewReader(bufio.NewReader(bytes.NewReader(data[len(version)+2 : end+4]))).ReadMIMEHeader()
+	if err != nil {
+		return WARCRecord{}, fmt.Errorf("%w: %v", ErrInvalidWARCRecord, err)
+	}
+
+	return WARCRecord{
+		Version: string(version),
+		Header:  header,
+		Payload: data[end+4:],
+	}, nil
+}
+
+// warcFramer splits the stream in WARC records, decompressing it first when it is gzip compressed
+type warcFramer struct {
+	// r is the decompressed stream, set by the first call to next
+	r *bufio.Reader
+}
+
+func (f *warcFramer) next(r *bufio.Reader, dst *[]byte, offset int64) (before, after int, err error) {
+	if f.r == nil {
+		f.r = r
+
+		if magic, _ := r.Peek(2); bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+			zr, err := gzip.NewReader(r)
+			if err != nil {
+				return 0, 0, &FramingError{Position: offset, Reason: "invalid gzip member", Err: err}
+			}
+
+			f.r = bufio.NewReader(zr)
+		}
+	}
+
+	r = f.r
+
+	// Blank lines left by the previous record
+	for {
+		head, err := r.Peek(1)
+		if err != nil {
+			return before, 0, err
+		}
+
+		if head[0] != '\r' && head[0] != '\n' {
+			break
+		}
+
+		_, _ = r.ReadByte()
+		before++
+	}
+
+	position := offset + int64(before)
+	invalid := func(reason string, err error) *FramingError {
+		return &FramingError{Position: position, Reason: reason, Err: err}
+	}
+
+	// Header block
+	length := int64(-1)
+
+	for line := 0; ; line++ {
+		start := len(*dst)
+
+		_, err = complete(r, dst, '\n')
+		if err == io.EOF {
+			return before, 0, invalid("truncated WARC header", io.ErrUnexpectedEOF)
+		}
+
+		if err != nil {
+			return before, 0, err
+		}
+
+		field := bytes.TrimRight((*dst)[start:], "\r\n")
+
+		if line == 0 {
+			if !bytes.HasPrefix(field, []byte("WARC/")) {
+				return before, 0, invalid("missing WARC version line", nil)
+			}
+
+			continue
+		}
+
+		if len(field) == 0 {
+			break
+		}
+
+		name, value, _ := bytes.Cut(field, []byte(":"))
+		if textproto.CanonicalMIMEHeaderKey(string(bytes.TrimSpace(name))) != "Content-Length" {
+			continue
+		}
+
+		length, err = strconv.ParseInt(string(bytes.TrimSpace(value)), 10, 64)
+		if err != nil || length < 0 {
+			return before, 0, invalid(fmt.Sprintf("invalid Content-Length %q", bytes.TrimSpace(value)), nil)
+		}
+	}
+
+	if length < 0 {
+		return before, 0, invalid("missing Content-Length", nil)
+	}
+
+	// The payload is appended in chunks, so corrupt lengths do not reserve memory that never gets used
+	n, err := io.CopyN(appender{dst}, r, length)
+	if err == io.EOF {
+		return before, 0, invalid(fmt.Sprintf("truncated WARC payload, %d of %d bytes", n, length), io.ErrUnexpectedEOF)
+	}
+
+	if err != nil {
+		return before, 0, err
+	}
+
+	return before, 0, nil
+}
+
+// appender is an io.Writer that appends to a slice
+type appender struct {
+	dst *[]byte
+}
+
+func (a appender) Write(p []byte) (int, error) {
+	*a.dst = append(*a.dst, p...)
+	return len(p), nil
+}
@@ -0,0 +1,209 @@
+package bread
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// warcRecord builds a WARC/1.0 record with the given type and payload
+func warcRecord(kind, payload string) string {
+	return "WARC/1.0\r\n" +
+		"WARC-Type: " + kind + "\r\n" +
+		"WARC-Target-URI: http://example.com/\r\n" +
+		"WARC-Date: 2026-01-01T00:00:00Z\r\n" +
+		"Content-Length: " + strconv.Itoa(len(payload)) + "\r\n" +
+		"\r\n" +
+		payload
+}
+
+// gzipMembers compresses each record in its own gzip member
+func gzipMembers(records ...string) string {
+	buffer := bytes.Buffer{}
+
+	for _, record := range records {
+		w := gzip.NewWriter(&buffer)
+		_, _ = io.WriteString(w, record+"\r\n\r\n")
+		_ = w.Close()
+	}
+
+	return buffer.String()
+}
+
+func TestBread_FramingWARC(t *testing.T) {
+	request := warcRecord("request", "GET / HTTP/1.1\r\nHost: example.com\r\n\r\n")
+	response := warcRecord("response", "HTTP/1.1 200 OK\r\nContent-Type: text/html\r\n\r\n<html>"+strings.Repeat("body", 2_000)+"</html>")
+	metadata := warcRecord("metadata", "fetchTimeMs: 128\r\n")
+	empty := warcRecord("warcinfo", "")
+
+	cases := [...]struct {
+		data     string
+		reader   func(io.Reader) io.Reader
+		expected []string
+		// position of the expected *FramingError, -1 means no error
+		position int64
+		err      error
+	}{
+		{
+			data:     request + "\r\n\r\n" + response + "\r\n\r\n" + metadata + "\r\n\r\n",
+			expected: []string{request, response, metadata},
+			position: -1,
+		},
+		{
+			// Records spanning internal reads
+			data:     empty + "\r\n\r\n" + response + "\r\n\r\n" + request,
+			reader:   iotest.OneByteReader,
+			expected: []string{empty, response, request},
+			position: -1,
+		},
+		{
+			// One gzip member per record
+			data:     gzipMembers(request, response, metadata),
+			expected: []string{request, response, metadata},
+			position: -1,
+		},
+		{
+			data:     request + "\r\n\r\n" + strings.Replace(metadata, "Content-Length: 18", "Content-Length: 1x", 1),
+			expected: []string{request},
+			position: int64(len(request) + 4),
+		},
+		{
+			data:     strings.Replace(metadata, "Content-Length: 18\r\n", "", 1),
+			position: 0,
+		},
+		{
+			data:     "HTTP/1.1 200 OK\r\n\r\n",
+			position: 0,
+		},
+		{
+			// Truncated final record
+			data:     request + "\r\n\r\n" + response[:len(response)-10],
+			expected: []string{request},
+			position: int64(len(request) + 4),
+			err:      io.ErrUnexpectedEOF,
+		},
+		{
+			data:     request + "\r\n\r\n" + "WARC/1.0\r\nWARC-Type: request\r\n",
+			expected: []string{request},
+			position: int64(len(request) + 4),
+			err:      io.ErrUnexpectedEOF,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			bread := Bread{
+				Framing:    FramingWARC,
+				BufferSize: 16,
+				Workers:    4,
+			}
+
+			var reader io.Reader = strings.NewReader(c.data)
+			if c.reader != nil {
+				reader = c.reader(reader)
+			}
+
+			batches, err := collect(t, bread, reader)
+
+			if c.position < 0 && err != nil {
+				t.Fatal(err)
+			}
+
+			if c.position >= 0 {
+				var framingErr *FramingError
+				if !errors.As(err, &framingErr) {
+					t.Fatalf("expected a *FramingError, got %v", err)
+				}
+
+				if framingErr.Position != c.position {
+					t.Fatalf("expected error at offset %d, got %d", c.position, framingErr.Position)
+				}
+
+				if c.err != nil && !errors.Is(err, c.err) {
+					t.Fatalf("expected error wrapping %v, got %v", c.err, err)
+				}
+			}
+
+			if len(batches) != len(c.expected) {
+				t.Fatalf("expected %d records, got %d", len(c.expected), len(batches))
+			}
+
+			for j, batch := range batches {
+				if string(batch.Data) != c.expected[j] {
+					t.Fatalf("expected record %q, got %q", c.expected[j], batch.Data)
+				}
+
+				record, err := ParseWARCRecord(batch.Data)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if record.Version != "WARC/1.0" || record.Header.Get("WARC-Target-URI") != "http://example.com/" {
+					t.Fatalf("unexpected header %q %v", record.Version, record.Header)
+				}
+
+				if length := record.Header.Get("Content-Length"); length != strconv.Itoa(len(record.Payload)) {
+					t.Fatalf("expected a payload of %s bytes, got %d", length, len(record.Payload))
+				}
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+func TestParseWARCRecord(t *testing.T) {
+	cases := [...]struct {
+		data    string
+		kind    string
+		payload string
+		err     error
+	}{
+		{
+			data:    warcRecord("response", "HTTP/1.1 200 OK\r\n\r\n"),
+			kind:    "response",
+			payload: "HTTP/1.1 200 OK\r\n\r\n",
+		},
+		{
+			data: warcRecord("warcinfo", ""),
+			kind: "warcinfo",
+		},
+		{
+			data: "HTTP/1.1 200 OK\r\n\r\n",
+			err:  ErrInvalidWARCRecord,
+		},
+		{
+			data: "WARC/1.0\r\nWARC-Type: request\r\n",
+			err:  ErrInvalidWARCRecord,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			record, err := ParseWARCRecord([]byte(c.data))
+			if !errors.Is(err, c.err) {
+				t.Fatalf("expected error %v, got %v", c.err, err)
+			}
+
+			if c.err != nil {
+				t.Log("Success!")
+				return
+			}
+
+			if kind := record.Header.Get("WARC-Type"); kind != c.kind {
+				t.Fatalf("expected type %q, got %q", c.kind, kind)
+			}
+
+			if string(record.Payload) != c.payload {
+				t.Fatalf("expected payload %q, got %q", c.payload, record.Payload)
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package bread
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/bits"
+	"time"
+)
+
+// Default Heuristics parameters
+const (
+	DefaultHeuristicsWindow          = 100
+	DefaultHeuristicsComplementRatio = 2
+	DefaultHeuristicsAllocationRatio = 0.5
+	DefaultHeuristicsInterval        = time.Minute
+)
+
+// Heuristics tunes the detection of misconfigurations that are only visible at runtime.
+// The warnings are emitted through the Bread Logger.
+type Heuristics struct {
+	// Window is the number of batches observed before evaluating the heuristics
+	//
+	// This member is optional. Default value DefaultHeuristicsWindow
+	Window uint32
+	// ComplementRatio is the average complement size, relative to BufferSize, that triggers a warning
+	//
+	// This member is optional. Default value DefaultHeuristicsComplementRatio
+	ComplementRatio float64
+	// AllocationRatio is the fraction of buffers allocated by the pool, instead of reused, that triggers a warning
+	//
+	// This member is optional. Default value DefaultHeuristicsAllocationRatio
+	AllocationRatio float64
+	// Interval is the minimum time between two warnings of the same kind
+	//
+	// This member is optional. Default value DefaultHeuristicsInterval
+	Interval time.Duration
+}
+
+// withDefaults fills the missing parameters
+func (h Heuristics) withDefaults() Heuristics {
+	if h.Window == 0 {
+		h.Window = DefaultHeuristicsWindow
+	}
+
+	if h.ComplementRatio == 0 {
+		h.ComplementRatio = DefaultHeuristicsComplementRatio
+	}
+
+	if h.AllocationRatio == 0 {
+		h.AllocationRatio = DefaultHeuristicsAllocationRatio
+	}
+
+	if h.Interval == 0 {
+		h.Interval = DefaultHeuristicsInterval
+	}
+
+	return h
+}
+
+// detector looks for misconfiguration patterns in the stream of batches
+type detector struct {
+	Heuristics
+	bufferSize int
+	workers    int
+	warn       func(msg string, attrs ...slog.Attr)
+	now        func() time.Time
+
+	// Current window
+	batches     uint32
+	complements uint64
+	allocations uint32
+
+	// Last warning of each kind
+	lastComplement time.Time
+	lastAllocation time.Time
+}
+
+// observe records a batch, allocated indicates whether the pool had to allocate its buffer
+func (d *detector) observe(complement int, allocated bool) {
+	d.batches++
+	d.complements += uint64(complement)
+
+	if allocated {
+		d.allocations++
+	}
+
+	if d.batches < d.Window {
+		return
+	}
+
+	d.evaluate()
+
+	d.batches, d.complements, d.allocations = 0, 0, 0
+}
+
+// evaluate emits the warnings of the current window
+func (d *detector) evaluate() {
+	now := d.now()
+
+	ratio := float64(d.complements) / float64(d.batches) / float64(d.bufferSize)
+
+	if ratio >= d.ComplementRatio && now.Sub(d.lastComplement) >= d.Interval {
+		d.lastComplement = now
+
+		// The average rounds down to zero with a small ComplementRatio
+		suggested := uint64(1) << bits.Len64(max(d.complements/uint64(d.batches), 1)-1)
+
+		d.warn(
+			fmt.Sprintf(
+				"complement averaged %.1fx BufferSize over the last %d batches; consider BufferSize >= %s",
+				ratio,
+				d.batches,
+				ByteSize(suggested),
+			),
+			slog.Float64("ratio", ratio),
+			slog.Uint64("suggested_buffer_size", suggested),
+		)
+	}
+
+	allocated := float64(d.allocations) / float64(d.batches)
+
+	if allocated >= d.AllocationRatio && now.Sub(d.lastAllocation) >= d.Interval {
+		d.lastAllocation = now
+
+		d.warn(
+			fmt.Sprintf(
+				"the pool allocated %d buffers over the last %d batches; consider BufferSeed >= %d",
+				d.allocations,
+				d.batches,
+				d.workers,
+			),
+			slog.Float64("ratio", allocated),
+			slog.Uint64("suggested_buffer_seed", uint64(d.workers)),
+		)
+	}
+}
+
+// newDetector builds the detector of the eater, it returns nil when there is no Logger
+func (e *eater) newDetector(ctx context.Context) *detector {
+	if e.Logger == nil {
+		return nil
+	}
+
+	return &detector{
+		Heuristics: e.Heuristics.withDefaults(),
+		bufferSize: e.BufferSize,
+		workers:    e.Workers,
+		now:        time.Now,
+		warn: func(msg string, attrs ...slog.Attr) {
+			e.Logger.LogAttrs(ctx, slog.LevelWarn, msg, attrs...)
+		},
+	}
+}
@@ -0,0 +1,289 @@
+package bread
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDetector(t *testing.T) {
+	type observation struct {
+		complement int
+		allocated  bool
+	}
+
+	repeat := func(n int, o observation) []observation {
+		observations := make([]observation, n)
+		for i := range observations {
+			observations[i] = o
+		}
+
+		return observations
+	}
+
+	cases := [...]struct {
+		heuristics   Heuristics
+		observations []observation
+		elapsed      time.Duration
+		expected     []string
+	}{
+		{
+			heuristics:   Heuristics{Window: 10},
+			observations: repeat(100, observation{complement: 10}),
+		},
+		{
+			heuristics:   Heuristics{Window: 10},
+			observations: repeat(10, observation{complement: 8 << 10}),
+			expected: []string{
+				"complement averaged 8.0x BufferSize over the last 10 batches; consider BufferSize >= 8KB",
+			},
+		},
+		{
+			// The average complement is below a byte
+			heuristics:   Heuristics{Window: 10, ComplementRatio: 0.0001},
+			observations: append(repeat(9, observation{}), observation{complement: 5}),
+			expected: []string{
+				"complement averaged 0.0x BufferSize over the last 10 batches; consider BufferSize >= 1B",
+			},
+		},
+		{
+			heuristics:   Heuristics{Window: 10, ComplementRatio: 10},
+			observations: repeat(10, observation{complement: 8 << 10}),
+		},
+		{
+			heuristics:   Heuristics{Window: 10},
+			observations: repeat(9, observation{complement: 8 << 10}),
+		},
+		{
+			// Rate limited
+			heuristics:   Heuristics{Window: 10, Interval: time.Hour},
+			observations: repeat(50, observation{allocated: true}),
+			elapsed:      time.Minute,
+			expected: []string{
+				"the pool allocated 10 buffers over the last 10 batches; consider BufferSeed >= 64",
+			},
+		},
+		{
+			heuristics:   Heuristics{Window: 10, Interval: time.Minute},
+			observations: repeat(20, observation{allocated: true}),
+			elapsed:      time.Minute,
+			expected: []string{
+				"the pool allocated 10 buffers over the last 10 batches; consider BufferSeed >= 64",
+				"the pool allocated 10 buffers over the last 10 batches; consider BufferSeed >= 64",
+			},
+		},
+		{
+			heuristics:   Heuristics{Window: 4, AllocationRatio: 0.75},
+			observations: append(repeat(2, observation{allocated: true}), repeat(2, observation{})...),
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			now := time.Now()
+			warnings := make([]string, 0)
+
+			d := detector{
+				Heuristics: c.heuristics.withDefaults(),
+				bufferSize: 1 << 10,
+				workers:    64,
+				now: func() time.Time {
+					now = now.Add(c.elapsed)
+					return now
+				},
+				warn: func(msg string, _ ...slog.Attr) {
+					warnings = append(warnings, msg)
+				},
+			}
+
+			for _, o := range c.observations {
+				d.observe(o.complement, o.allocated)
+			}
+
+			if strings.Join(warnings, "\n") != strings.Join(c.expected, "\n") {
+				t.Fatalf("expected %q, got %q", c.expected, warnings)
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+func TestBread_Logger(t *testing.T) {
+	logs := bytes.Buffer{}
+
+	bread := Bread{
+		BufferSize: 4,
+		Logger:     slog.New(slog.NewTextHandler(&logs, nil)),
+		Heuristics: Heuristics{
+			Window: 10,
+		},
+	}
+
+	data := bytes.Repeat([]byte(strings.Repeat("O", 100)+"\n"), 100)
+
+	err := bread.EatBatches(context.TODO(), bytes.NewReader(data), func(context.Context, Batch) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(logs.String(), "consider BufferSize >= 128B") {
+		t.Fatalf("missing warning in %q", logs.String())
+	}
+
+	t.Log("Success!")
+}
+
+// recordHandler captures the records logged, along with their attributes
+type recordHandler struct {
+	mu      sync.Mutex
+	level   slog.Level
+	records []slog.Record
+}
+
+func (h *recordHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *recordHandler) Handle(_ context.Context, record slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.records = append(h.records, record.Clone())
+
+	return nil
+}
+
+func (h *recordHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+func (h *recordHandler) WithGroup(string) slog.Handler      { return h }
+
+func TestBread_LoggerDebug(t *testing.T) {
+	data := bytes.Repeat([]byte(strings.Repeat("O", 100)+"\n"), 100)
+
+	cases := [...]struct {
+		bread Bread
+		data  []byte
+		// reason is the expected termination reason
+		reason string
+	}{
+		{
+			bread:  Bread{Workers: 4, BufferSize: 64},
+			data:   data,
+			reason: "end of input",
+		},
+		{
+			bread:  Bread{Workers: 4, BufferSize: 64, MaxRecords: 10},
+			data:   data,
+			reason: "limit reached",
+		},
+		{
+			bread:  Bread{Workers: 4, BufferSize: 64, SyncThreshold: 1 * MB},
+			data:   data,
+			reason: "end of input",
+		},
+		{
+			bread:  Bread{Workers: 4, BufferSize: 64, ShuffleBuffer: 10},
+			data:   data,
+			reason: "end of input",
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			handler := &recordHandler{level: slog.LevelDebug}
+			c.bread.Logger = slog.New(handler)
+
+			err := c.bread.EatBatches(context.TODO(), bytes.NewReader(c.data), func(context.Context, Batch) {})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			dispatched, processed := make(map[uint64]int), make(map[uint64]int)
+			events := make(map[string]int)
+
+			for _, record := range handler.records {
+				events[record.Message]++
+
+				record.Attrs(func(attr slog.Attr) bool {
+					if attr.Key == "index" {
+						switch record.Message {
+						case "batch dispatched":
+							dispatched[attr.Value.Uint64()]++
+						case "batch processed":
+							processed[attr.Value.Uint64()]++
+						}
+					}
+
+					if attr.Key == "reason" && attr.Value.String() != c.reason {
+						t.Errorf("expected reason %q, got %q", c.reason, attr.Value)
+					}
+
+					return true
+				})
+			}
+
+			if len(dispatched) == 0 || len(dispatched) != len(processed) {
+				t.Fatalf("%d batches dispatched, %d batches processed", len(dispatched), len(processed))
+			}
+
+			for index, count := range dispatched {
+				if count != 1 || processed[index] != 1 {
+					t.Fatalf("batch %d was dispatched %d times and processed %d times", index, count, processed[index])
+				}
+			}
+
+			if events["eat ended"] != 1 || events["read"] == 0 {
+				t.Fatalf("unexpected events %v", events)
+			}
+
+			// The inline batches are sliced from the input
+			if c.bread.SyncThreshold == 0 && events["buffer taken"] == 0 {
+				t.Fatalf("unexpected events %v", events)
+			}
+
+			t.Log("Success!")
+		})
+	}
+}
+
+// TestBread_LoggerDisabled checks that the debug events do not allocate when the debug level is disabled
+func TestBread_LoggerDisabled(t *testing.T) {
+	if raceEnabled {
+		t.Skip("the pool drops buffers under the race detector")
+	}
+
+	data := bytes.Repeat([]byte(strings.Repeat("O", 100)+"\n"), 100)
+
+	allocs := func(logger *slog.Logger) float64 {
+		bread := Bread{
+			BufferSize: 256,
+			Logger:     logger,
+			Pool:       NewBufferPool(256, 512),
+			WorkerFunc: func(context.Context, *[]byte) {},
+		}
+
+		return testing.AllocsPerRun(20, func() {
+			_ = bread.Eat(context.TODO(), bytes.NewReader(data))
+		})
+	}
+
+	handler := &recordHandler{level: slog.LevelInfo}
+
+	without, with := allocs(nil), allocs(slog.New(handler))
+
+	// The detector of the warnings is the only difference
+	if with > without+2 {
+		t.Fatalf("%.0f allocations with the debug level disabled, %.0f without a Logger", with, without)
+	}
+
+	if len(handler.records) != 0 {
+		t.Fatalf("unexpected records %v", handler.records)
+	}
+
+	t.Logf("Success! %.0f allocations", with)
+}